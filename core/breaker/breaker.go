@@ -85,6 +85,7 @@ type (
 
 	circuitBreaker struct {
 		name string
+		k    float64
 		throttle
 	}
 
@@ -109,7 +110,11 @@ func NewBreaker(opts ...Option) Breaker {
 	if len(b.name) == 0 {
 		b.name = stringx.Rand()
 	}
-	b.throttle = newLoggedThrottle(b.name, newGoogleBreaker())
+	gb := newGoogleBreaker()
+	if b.k > 0 {
+		gb.k = b.k
+	}
+	b.throttle = newLoggedThrottle(b.name, gb)
 
 	return &b
 }
@@ -139,6 +144,14 @@ func (cb *circuitBreaker) Name() string {
 	return cb.name
 }
 
+// WithK returns a function to set the sensitivity of a Breaker, defaults to 1.5.
+// The smaller k is, the more aggressively the Breaker drops the requests on failures.
+func WithK(k float64) Option {
+	return func(b *circuitBreaker) {
+		b.k = k
+	}
+}
+
 // WithName returns a function to set the name of a Breaker.
 func WithName(name string) Option {
 	return func(b *circuitBreaker) {
@@ -22,6 +22,17 @@ func TestCircuitBreakerALlow(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestCircuitBreakerWithK(t *testing.T) {
+	b := NewBreaker(WithName("foo"), WithK(2))
+	assert.Equal(t, "foo", b.Name())
+	gb := b.(*circuitBreaker).throttle.(loggedThrottle).internalThrottle.(*googleBreaker)
+	assert.Equal(t, float64(2), gb.k)
+
+	b = NewBreaker()
+	gb = b.(*circuitBreaker).throttle.(loggedThrottle).internalThrottle.(*googleBreaker)
+	assert.Equal(t, k, gb.k)
+}
+
 func TestLogReason(t *testing.T) {
 	b := NewBreaker()
 	assert.True(t, len(b.Name()) > 0)
@@ -0,0 +1,71 @@
+package budget
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"time"
+)
+
+// HeaderKey is the http header key to carry the remaining deadline budget in milliseconds.
+const HeaderKey = "X-Deadline-Budget"
+
+// ErrExhausted is an error that indicates the deadline budget is exhausted.
+var ErrExhausted = errors.New("deadline budget exhausted")
+
+// Format formats the budget d into the header value in milliseconds.
+func Format(d time.Duration) string {
+	return strconv.FormatInt(d.Milliseconds(), 10)
+}
+
+// maxMillis is the max milliseconds that time.Duration holds.
+const maxMillis = int64(math.MaxInt64 / time.Millisecond)
+
+// Parse parses the budget from the header value in milliseconds,
+// the values out of the range of time.Duration are clamped.
+func Parse(val string) (time.Duration, bool) {
+	if len(val) == 0 {
+		return 0, false
+	}
+
+	ms, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	ms = min(max(ms, -maxMillis), maxMillis)
+	return time.Duration(ms) * time.Millisecond, true
+}
+
+// Remaining returns the remaining budget of ctx, false if ctx has no deadline.
+func Remaining(ctx context.Context) (time.Duration, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+
+	return time.Until(deadline), true
+}
+
+// Reserve returns a copy of ctx with the deadline brought forward by margin,
+// to leave the caller the time to handle the result of the downstream call.
+// ErrExhausted is returned if the remaining budget is not more than margin.
+// ctx is returned as is if it has no deadline.
+func Reserve(ctx context.Context, margin time.Duration) (context.Context, context.CancelFunc, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return ctx, func() {}, nil
+	}
+
+	if time.Until(deadline) <= margin {
+		return nil, nil, ErrExhausted
+	}
+
+	if margin <= 0 {
+		return ctx, func() {}, nil
+	}
+
+	reserved, cancel := context.WithDeadline(ctx, deadline.Add(-margin))
+	return reserved, cancel, nil
+}
@@ -0,0 +1,71 @@
+package budget
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatAndParse(t *testing.T) {
+	assert.Equal(t, "1500", Format(time.Millisecond*1500))
+
+	d, ok := Parse("1500")
+	assert.True(t, ok)
+	assert.Equal(t, time.Millisecond*1500, d)
+
+	d, ok = Parse("-10")
+	assert.True(t, ok)
+	assert.Equal(t, -time.Millisecond*10, d)
+
+	d, ok = Parse("9223372036854775807")
+	assert.True(t, ok)
+	assert.True(t, d > 0)
+	d, ok = Parse("-9223372036854775808")
+	assert.True(t, ok)
+	assert.True(t, d < 0)
+
+	_, ok = Parse("")
+	assert.False(t, ok)
+	_, ok = Parse("1s")
+	assert.False(t, ok)
+}
+
+func TestRemaining(t *testing.T) {
+	_, ok := Remaining(context.Background())
+	assert.False(t, ok)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	remaining, ok := Remaining(ctx)
+	assert.True(t, ok)
+	assert.True(t, remaining > 0 && remaining <= time.Second)
+}
+
+func TestReserve(t *testing.T) {
+	ctx, cancel, err := Reserve(context.Background(), time.Millisecond)
+	assert.NoError(t, err)
+	cancel()
+	_, ok := ctx.Deadline()
+	assert.False(t, ok)
+
+	parent, parentCancel := context.WithTimeout(context.Background(), time.Second)
+	defer parentCancel()
+	parentDeadline, _ := parent.Deadline()
+
+	ctx, cancel, err = Reserve(parent, time.Millisecond*100)
+	assert.NoError(t, err)
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	assert.True(t, ok)
+	assert.Equal(t, parentDeadline.Add(-time.Millisecond*100), deadline)
+
+	ctx, cancel, err = Reserve(parent, 0)
+	assert.NoError(t, err)
+	cancel()
+	assert.Equal(t, parent, ctx)
+
+	_, _, err = Reserve(parent, time.Second*2)
+	assert.ErrorIs(t, err, ErrExhausted)
+}
@@ -0,0 +1,52 @@
+package canary
+
+import "context"
+
+const (
+	// HeaderKey is the http header key to carry the traffic color.
+	HeaderKey = "X-Canary"
+	// MetadataKey is the grpc metadata key to carry the traffic color.
+	MetadataKey = "x-canary"
+
+	maxLength = 64
+)
+
+type colorKey struct{}
+
+// FromContext returns the traffic color from ctx, empty string if not set.
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	if color, ok := ctx.Value(colorKey{}).(string); ok {
+		return color
+	}
+
+	return ""
+}
+
+// IsValid checks if the given color can be accepted from the peer,
+// only letters, digits, dots, dashes and underscores are allowed.
+func IsValid(color string) bool {
+	if len(color) == 0 || len(color) > maxLength {
+		return false
+	}
+
+	for i := 0; i < len(color); i++ {
+		c := color[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '.', c == '-', c == '_':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// NewContext returns a new context with the given traffic color.
+func NewContext(ctx context.Context, color string) context.Context {
+	return context.WithValue(ctx, colorKey{}, color)
+}
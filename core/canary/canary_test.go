@@ -0,0 +1,27 @@
+package canary
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromContext(t *testing.T) {
+	assert.Empty(t, FromContext(context.Background()))
+	//nolint:staticcheck
+	assert.Empty(t, FromContext(nil))
+
+	ctx := NewContext(context.Background(), "v2")
+	assert.Equal(t, "v2", FromContext(ctx))
+}
+
+func TestIsValid(t *testing.T) {
+	assert.True(t, IsValid("v2"))
+	assert.True(t, IsValid("canary-v1.2_a"))
+	assert.False(t, IsValid(""))
+	assert.False(t, IsValid("a b"))
+	assert.False(t, IsValid("a/b"))
+	assert.False(t, IsValid(strings.Repeat("a", maxLength+1)))
+}
@@ -0,0 +1,47 @@
+package discov
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/jialequ/linux-sdk/core/logx"
+)
+
+// An Instance is a published address with its metadata, like zone, version and weight.
+type Instance struct {
+	Addr     string            `json:"addr"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// encodeInstance encodes the addr with md as a json value,
+// the bare addr is used if no metadata to be compatible with the old subscribers.
+func encodeInstance(addr string, md map[string]string) string {
+	if len(md) == 0 {
+		return addr
+	}
+
+	val, err := json.Marshal(Instance{
+		Addr:     addr,
+		Metadata: md,
+	})
+	if err != nil {
+		logx.Errorf("failed to encode instance %s, error: %v", addr, err)
+		return addr
+	}
+
+	return string(val)
+}
+
+// parseInstance parses the value published by encodeInstance, the bare addr is also accepted.
+func parseInstance(val string) Instance {
+	if !strings.HasPrefix(val, "{") {
+		return Instance{Addr: val}
+	}
+
+	var inst Instance
+	if err := json.Unmarshal([]byte(val), &inst); err != nil || len(inst.Addr) == 0 {
+		return Instance{Addr: val}
+	}
+
+	return inst
+}
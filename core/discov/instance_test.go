@@ -0,0 +1,58 @@
+package discov
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeInstance(t *testing.T) {
+	assert.Equal(t, "localhost:8080", encodeInstance("localhost:8080", nil))
+	assert.Equal(t, `{"addr":"localhost:8080","metadata":{"zone":"a"}}`,
+		encodeInstance("localhost:8080", map[string]string{"zone": "a"}))
+}
+
+func TestParseInstance(t *testing.T) {
+	tests := []struct {
+		name   string
+		val    string
+		expect Instance
+	}{
+		{
+			name:   "bare addr",
+			val:    "localhost:8080",
+			expect: Instance{Addr: "localhost:8080"},
+		},
+		{
+			name: "with metadata",
+			val:  `{"addr":"localhost:8080","metadata":{"zone":"a","weight":"2"}}`,
+			expect: Instance{
+				Addr:     "localhost:8080",
+				Metadata: map[string]string{"zone": "a", "weight": "2"},
+			},
+		},
+		{
+			name:   "bad json",
+			val:    `{"addr":`,
+			expect: Instance{Addr: `{"addr":`},
+		},
+		{
+			name:   "no addr",
+			val:    `{"metadata":{"zone":"a"}}`,
+			expect: Instance{Addr: `{"metadata":{"zone":"a"}}`},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expect, parseInstance(test.val))
+		})
+	}
+}
+
+func TestEncodeParseInstance(t *testing.T) {
+	md := map[string]string{"zone": "a", "version": "v2"}
+	assert.Equal(t, Instance{Addr: "localhost:8080", Metadata: md},
+		parseInstance(encodeInstance("localhost:8080", md)))
+}
@@ -58,6 +58,17 @@ func (r *Registry) Monitor(endpoints []string, key string, l UpdateListener) err
 	return c.monitor(key, l)
 }
 
+// Unmonitor stops notifying l of the key on given etcd endpoints,
+// the key is not watched anymore after all its listeners are removed.
+func (r *Registry) Unmonitor(endpoints []string, key string, l UpdateListener) {
+	r.lock.Lock()
+	c, ok := r.clusters[getClusterKey(endpoints)]
+	r.lock.Unlock()
+	if ok {
+		c.unmonitor(key, l)
+	}
+}
+
 func (r *Registry) getCluster(endpoints []string) (c *cluster, exists bool) {
 	clusterKey := getClusterKey(endpoints)
 	r.lock.Lock()
@@ -76,17 +87,24 @@ type cluster struct {
 	key        string
 	values     map[string]map[string]string
 	listeners  map[string][]UpdateListener
+	watchers   map[string]keyWatcher
 	watchGroup *threading.RoutineGroup
 	done       chan lang.PlaceholderType
 	lock       sync.Mutex
 }
 
+type keyWatcher struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
 func newCluster(endpoints []string) *cluster {
 	return &cluster{
 		endpoints:  endpoints,
 		key:        getClusterKey(endpoints),
 		values:     make(map[string]map[string]string),
 		listeners:  make(map[string][]UpdateListener),
+		watchers:   make(map[string]keyWatcher),
 		watchGroup: threading.NewRoutineGroup(),
 		done:       make(chan lang.PlaceholderType),
 	}
@@ -230,6 +248,7 @@ func (c *cluster) load(cli EtcdClient, key string) int64 {
 func (c *cluster) monitor(key string, l UpdateListener) error {
 	c.lock.Lock()
 	c.listeners[key] = append(c.listeners[key], l)
+	ctx := c.watchContext(key)
 	c.lock.Unlock()
 
 	cli, err := c.getClient()
@@ -239,7 +258,7 @@ func (c *cluster) monitor(key string, l UpdateListener) error {
 
 	rev := c.load(cli, key)
 	c.watchGroup.Run(func() {
-		c.watch(cli, key, rev)
+		c.watch(ctx, cli, key, rev)
 	})
 
 	return nil
@@ -262,24 +281,48 @@ func (c *cluster) reload(cli EtcdClient) {
 	c.watchGroup.Wait()
 	c.done = make(chan lang.PlaceholderType)
 	c.watchGroup = threading.NewRoutineGroup()
-	var keys []string
+	watchers := make(map[string]context.Context)
 	for k := range c.listeners {
-		keys = append(keys, k)
+		watchers[k] = c.watchContext(k)
 	}
 	c.lock.Unlock()
 
-	for _, key := range keys {
-		k := key
+	for key, ctx := range watchers {
+		k, ctx := key, ctx
 		c.watchGroup.Run(func() {
 			rev := c.load(cli, k)
-			c.watch(cli, k, rev)
+			c.watch(ctx, cli, k, rev)
 		})
 	}
 }
 
-func (c *cluster) watch(cli EtcdClient, key string, rev int64) {
+func (c *cluster) unmonitor(key string, l UpdateListener) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	listeners := c.listeners[key]
+	for i, listener := range listeners {
+		if listener == l {
+			listeners = append(listeners[:i:i], listeners[i+1:]...)
+			break
+		}
+	}
+	if len(listeners) > 0 {
+		c.listeners[key] = listeners
+		return
+	}
+
+	delete(c.listeners, key)
+	delete(c.values, key)
+	if w, ok := c.watchers[key]; ok {
+		w.cancel()
+		delete(c.watchers, key)
+	}
+}
+
+func (c *cluster) watch(ctx context.Context, cli EtcdClient, key string, rev int64) {
 	for {
-		err := c.watchStream(cli, key, rev)
+		err := c.watchStream(ctx, cli, key, rev)
 		if err == nil {
 			return
 		}
@@ -294,13 +337,32 @@ func (c *cluster) watch(cli EtcdClient, key string, rev int64) {
 	}
 }
 
-func (c *cluster) watchStream(cli EtcdClient, key string, rev int64) error {
+// watchContext returns the context of watching key, c.lock must be held.
+func (c *cluster) watchContext(key string) context.Context {
+	if w, ok := c.watchers[key]; ok {
+		return w.ctx
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.watchers[key] = keyWatcher{
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	return ctx
+}
+
+func (c *cluster) watchStream(ctx context.Context, cli EtcdClient, key string, rev int64) error {
+	// cancel the etcd watch on returning, otherwise it's kept until the client closed.
+	wctx, cancel := context.WithCancel(c.context(cli))
+	defer cancel()
+
 	var rch clientv3.WatchChan
 	if rev != 0 {
-		rch = cli.Watch(clientv3.WithRequireLeader(c.context(cli)), makeKeyPrefix(key),
+		rch = cli.Watch(clientv3.WithRequireLeader(wctx), makeKeyPrefix(key),
 			clientv3.WithPrefix(), clientv3.WithRev(rev+1))
 	} else {
-		rch = cli.Watch(clientv3.WithRequireLeader(c.context(cli)), makeKeyPrefix(key),
+		rch = cli.Watch(clientv3.WithRequireLeader(wctx), makeKeyPrefix(key),
 			clientv3.WithPrefix())
 	}
 
@@ -320,6 +382,8 @@ func (c *cluster) watchStream(cli EtcdClient, key string, rev int64) error {
 			c.handleWatchEvents(key, wresp.Events)
 		case <-c.done:
 			return nil
+		case <-ctx.Done():
+			return nil
 		}
 	}
 }
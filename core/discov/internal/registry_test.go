@@ -158,7 +158,7 @@ func TestClusterWatchRespFailures(t *testing.T) {
 				ch <- resp
 				close(c.done)
 			}()
-			c.watch(cli, "any", 0)
+			c.watch(context.Background(), cli, "any", 0)
 		})
 	}
 }
@@ -178,7 +178,60 @@ func TestCloseChan(t *testing.T) {
 		close(ch)
 		close(c.done)
 	}()
-	c.watch(cli, "any", 0)
+	c.watch(context.Background(), cli, "any", 0)
+}
+
+func TestClusterUnmonitor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	cli := NewMockEtcdClient(ctrl)
+	restore := setMockClient(cli)
+	defer restore()
+	ch := make(chan clientv3.WatchResponse)
+	cli.EXPECT().Watch(gomock.Any(), "any/", gomock.Any()).Return(ch).AnyTimes()
+	cli.EXPECT().Ctx().Return(context.Background()).AnyTimes()
+
+	first := new(mockListener)
+	second := new(mockListener)
+	c := newCluster([]string{"any"})
+	c.listeners["any"] = []UpdateListener{first, second}
+	c.values["any"] = map[string]string{"foo": "bar"}
+	ctx := c.watchContext("any")
+	done := make(chan lang.PlaceholderType)
+	go func() {
+		c.watch(ctx, cli, "any", 0)
+		close(done)
+	}()
+
+	c.unmonitor("any", first)
+	assert.Equal(t, []UpdateListener{second}, c.listeners["any"])
+	assert.NoError(t, ctx.Err())
+	c.unmonitor("any", second)
+	assert.Empty(t, c.listeners)
+	assert.Empty(t, c.values)
+	assert.Empty(t, c.watchers)
+	assert.Error(t, ctx.Err())
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("watch not stopped after unmonitored")
+	}
+}
+
+func TestRegistryUnmonitor(t *testing.T) {
+	endpoints := []string{"unmonitor"}
+	l := new(mockListener)
+	c := newCluster(endpoints)
+	c.listeners["foo"] = []UpdateListener{l}
+	GetRegistry().lock.Lock()
+	GetRegistry().clusters[getClusterKey(endpoints)] = c
+	GetRegistry().lock.Unlock()
+
+	GetRegistry().Unmonitor([]string{"not-exist"}, "foo", l)
+	assert.Len(t, c.listeners["foo"], 1)
+	GetRegistry().Unmonitor(endpoints, "foo", l)
+	assert.Empty(t, c.listeners)
 }
 
 func TestValueOnlyContext(t *testing.T) {
@@ -222,6 +275,7 @@ func TestRegistryMonitor(t *testing.T) {
 	GetRegistry().clusters = map[string]*cluster{
 		getClusterKey(endpoints): {
 			listeners: map[string][]UpdateListener{},
+			watchers:  map[string]keyWatcher{},
 			values: map[string]map[string]string{
 				"foo": {
 					"bar": "baz",
@@ -23,6 +23,7 @@ type (
 		fullKey    string
 		id         int64
 		value      string
+		metadata   map[string]string
 		lease      clientv3.LeaseID
 		quit       *syncx.DoneChan
 		pauseChan  chan lang.PlaceholderType
@@ -47,6 +48,7 @@ func NewPublisher(endpoints []string, key, value string, opts ...PubOption) *Pub
 	for _, opt := range opts {
 		opt(publisher)
 	}
+	publisher.value = encodeInstance(value, publisher.metadata)
 
 	return publisher
 }
@@ -186,6 +188,15 @@ func WithId(id int64) PubOption {
 	}
 }
 
+// WithPubMetadata customizes a Publisher with the metadata of the instance, like zone and version.
+// The value is published as json with the metadata, which requires the subscribers to read
+// it by Subscriber.Instances, so upgrade the subscribers before publishing metadata.
+func WithPubMetadata(md map[string]string) PubOption {
+	return func(publisher *Publisher) {
+		publisher.metadata = md
+	}
+}
+
 // WithPubEtcdAccount provides the etcd username/password.
 func WithPubEtcdAccount(user, pass string) PubOption {
 	return func(pub *Publisher) {
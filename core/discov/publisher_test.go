@@ -142,6 +142,25 @@ func TestPublisherregisterWithOptions(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestPublisherregisterWithMetadata(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	const id = 3
+	cli := internal.NewMockEtcdClient(ctrl)
+	restore := setMockClient(cli)
+	defer restore()
+	cli.EXPECT().Ctx().AnyTimes()
+	cli.EXPECT().Grant(gomock.Any(), timeToLive).Return(&clientv3.LeaseGrantResponse{
+		ID: id,
+	}, nil)
+	cli.EXPECT().Put(gomock.Any(), makeEtcdKey("thekey", id),
+		`{"addr":"thevalue","metadata":{"zone":"a"}}`, gomock.Any())
+	pub := NewPublisher(nil, "thekey", "thevalue",
+		WithPubMetadata(map[string]string{"zone": "a"}))
+	_, err := pub.register(cli)
+	assert.Nil(t, err)
+}
+
 func TestPublisherregisterError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
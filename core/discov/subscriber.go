@@ -16,6 +16,7 @@ type (
 	// A Subscriber is used to subscribe the given key on an etcd cluster.
 	Subscriber struct {
 		endpoints []string
+		key       string
 		exclusive bool
 		items     *container
 	}
@@ -28,6 +29,7 @@ type (
 func NewSubscriber(endpoints []string, key string, opts ...SubOption) (*Subscriber, error) {
 	sub := &Subscriber{
 		endpoints: endpoints,
+		key:       key,
 	}
 	for _, opt := range opts {
 		opt(sub)
@@ -46,11 +48,28 @@ func (s *Subscriber) AddListener(listener func()) {
 	s.items.addListener(listener)
 }
 
+// Close closes s, the values are not updated anymore,
+// and the key is not watched after all its subscribers closed.
+func (s *Subscriber) Close() {
+	internal.GetRegistry().Unmonitor(s.endpoints, s.key, s.items)
+}
+
 // Values returns all the subscription values.
 func (s *Subscriber) Values() []string {
 	return s.items.getValues()
 }
 
+// Instances returns all the subscription values as instances, with the metadata if published.
+func (s *Subscriber) Instances() []Instance {
+	vals := s.items.getValues()
+	instances := make([]Instance, 0, len(vals))
+	for _, val := range vals {
+		instances = append(instances, parseInstance(val))
+	}
+
+	return instances
+}
+
 // Exclusive means that key value can only be 1:1,
 // which means later added value will remove the keys associated with the same value previously.
 func Exclusive() SubOption {
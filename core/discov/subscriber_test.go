@@ -214,6 +214,28 @@ func TestSubscriber(t *testing.T) {
 	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
 }
 
+func TestSubscriberClose(t *testing.T) {
+	sub := &Subscriber{
+		endpoints: []string{"localhost:2379"},
+		key:       "foo",
+		items:     newContainer(false),
+	}
+	sub.items.addKv("first", "localhost:8080")
+	sub.Close()
+	assert.Equal(t, []string{"localhost:8080"}, sub.Values())
+}
+
+func TestSubscriberInstances(t *testing.T) {
+	sub := new(Subscriber)
+	sub.items = newContainer(false)
+	sub.items.addKv("first", "localhost:8080")
+	sub.items.addKv("second", `{"addr":"localhost:8081","metadata":{"zone":"a"}}`)
+	assert.ElementsMatch(t, []Instance{
+		{Addr: "localhost:8080"},
+		{Addr: "localhost:8081", Metadata: map[string]string{"zone": "a"}},
+	}, sub.Instances())
+}
+
 func TestWithSubEtcdAccount(t *testing.T) {
 	endpoints := []string{"localhost:2379"}
 	user := stringx.Rand()
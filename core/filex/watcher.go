@@ -0,0 +1,104 @@
+package filex
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	threading "github.com/jialequ/linux-sdk/core/dist"
+	"github.com/jialequ/linux-sdk/core/syncx"
+)
+
+const defaultWatchInterval = time.Second * 5
+
+type (
+	// WatchOption defines the method to customize a Watcher.
+	WatchOption func(w *Watcher)
+
+	// A Watcher watches a file by polling, and notifies the listener on changes.
+	// Polling is used to make it work on all platforms and volume mounts,
+	// like kubernetes configmaps, which are updated by symlink swapping.
+	Watcher struct {
+		filename string
+		interval time.Duration
+		listener func()
+		modTime  time.Time
+		size     int64
+		done     *syncx.DoneChan
+		lock     sync.Mutex
+	}
+)
+
+// NewWatcher returns a Watcher that watches the given file.
+// listener is called in a separated goroutine when the file changes.
+func NewWatcher(filename string, listener func(), opts ...WatchOption) (*Watcher, error) {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Watcher{
+		filename: filename,
+		interval: defaultWatchInterval,
+		listener: listener,
+		modTime:  info.ModTime(),
+		size:     info.Size(),
+		done:     syncx.NewDoneChan(),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	threading.GoSafe(w.watch)
+
+	return w, nil
+}
+
+// Stop stops watching the file.
+func (w *Watcher) Stop() {
+	w.done.Close()
+}
+
+func (w *Watcher) changed() bool {
+	info, err := os.Stat(w.filename)
+	if err != nil {
+		// the file might be in the middle of replacing, check it next time
+		return false
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if info.ModTime().Equal(w.modTime) && info.Size() == w.size {
+		return false
+	}
+
+	w.modTime = info.ModTime()
+	w.size = info.Size()
+	return true
+}
+
+func (w *Watcher) watch() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if w.changed() {
+				threading.RunSafe(w.listener)
+			}
+		case <-w.done.Done():
+			return
+		}
+	}
+}
+
+// WithWatchInterval customizes a Watcher with the given polling interval.
+func WithWatchInterval(interval time.Duration) WatchOption {
+	return func(w *Watcher) {
+		if interval > 0 {
+			w.interval = interval
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package filex
+
+import (
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jialequ/linux-sdk/core/fs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatcher(t *testing.T) {
+	filename, err := fs.TempFilenameWithText("foo")
+	assert.Nil(t, err)
+	defer os.Remove(filename)
+
+	var count int32
+	changed := make(chan struct{}, 1)
+	w, err := NewWatcher(filename, func() {
+		atomic.AddInt32(&count, 1)
+		changed <- struct{}{}
+	}, WithWatchInterval(time.Millisecond*10))
+	assert.Nil(t, err)
+	defer w.Stop()
+
+	assert.Nil(t, os.WriteFile(filename, []byte("foobar"), os.ModePerm))
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatal("file change not notified")
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+}
+
+func TestWatcherNotExists(t *testing.T) {
+	_, err := NewWatcher("not-exists-file", func() {})
+	assert.NotNil(t, err)
+}
+
+func TestWatcherStop(t *testing.T) {
+	filename, err := fs.TempFilenameWithText("foo")
+	assert.Nil(t, err)
+	defer os.Remove(filename)
+
+	w, err := NewWatcher(filename, func() {
+		t.Fatal("should not be notified")
+	}, WithWatchInterval(time.Millisecond*10))
+	assert.Nil(t, err)
+	w.Stop()
+	time.Sleep(time.Millisecond * 20)
+	assert.Nil(t, os.WriteFile(filename, []byte("foobar"), os.ModePerm))
+	time.Sleep(time.Millisecond * 30)
+}
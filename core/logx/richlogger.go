@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/jialequ/linux-sdk/core/requestid"
 	"github.com/jialequ/linux-sdk/core/timex"
 	"github.com/jialequ/linux-sdk/internal/trace"
 )
@@ -178,6 +179,11 @@ func (l *richLogger) buildFields(fields ...LogField) []LogField {
 		fields = append(fields, Field(spanKey, spanID))
 	}
 
+	requestID := requestid.FromContext(l.ctx)
+	if len(requestID) > 0 {
+		fields = append(fields, Field(requestKey, requestID))
+	}
+
 	val := l.ctx.Value(fieldsContextKey)
 	if val != nil {
 		if arr, ok := val.([]LogField); ok {
@@ -10,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/jialequ/linux-sdk/core/requestid"
 	"github.com/stretchr/testify/assert"
 	"go.opentelemetry.io/otel"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
@@ -37,6 +38,26 @@ func TestTraceLog(t *testing.T) {
 	validate(t, w.String(), true, true)
 }
 
+func TestRequestIdLog(t *testing.T) {
+	SetLevel(InfoLevel)
+	w := new(mockWriter)
+	old := writer.Swap(w)
+	writer.lock.RLock()
+	defer func() {
+		writer.lock.RUnlock()
+		writer.Store(old)
+	}()
+
+	ctx := requestid.NewContext(context.Background(), "foo-request")
+	WithContext(ctx).Info(testlog)
+	assert.True(t, strings.Contains(w.String(), requestKey))
+	assert.True(t, strings.Contains(w.String(), "foo-request"))
+
+	w.Reset()
+	WithContext(context.Background()).Info(testlog)
+	assert.False(t, strings.Contains(w.String(), requestKey))
+}
+
 func TestTraceDebug(t *testing.T) {
 	w := new(mockWriter)
 	old := writer.Swap(w)
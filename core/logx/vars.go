@@ -56,6 +56,7 @@ const (
 	contentKey   = "content"
 	durationKey  = "duration"
 	levelKey     = "level"
+	requestKey   = "request_id"
 	spanKey      = "span"
 	timestampKey = "@timestamp"
 	traceKey     = "trace"
@@ -0,0 +1,58 @@
+package requestid
+
+import (
+	"context"
+
+	"github.com/jialequ/linux-sdk/core/utils"
+)
+
+const (
+	// HeaderKey is the http header key to carry the request id.
+	HeaderKey = "X-Request-Id"
+	// MetadataKey is the grpc metadata key to carry the request id.
+	MetadataKey = "x-request-id"
+
+	maxLength = 128
+)
+
+type requestIdKey struct{}
+
+// FromContext returns the request id from ctx, empty string if not set.
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	if id, ok := ctx.Value(requestIdKey{}).(string); ok {
+		return id
+	}
+
+	return ""
+}
+
+// IsValid checks if the given id can be accepted as a request id from the peer.
+// It prevents unbounded or unprintable ids from being written into logs.
+func IsValid(id string) bool {
+	if len(id) == 0 || len(id) > maxLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		// only visible ascii characters are allowed
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// New generates a new request id.
+func New() string {
+	return utils.NewUuid()
+}
+
+// NewContext returns a new context with the given request id.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, id)
+}
@@ -0,0 +1,31 @@
+package requestid
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromContext(t *testing.T) {
+	assert.Empty(t, FromContext(context.Background()))
+	//nolint:staticcheck
+	assert.Empty(t, FromContext(nil))
+
+	ctx := NewContext(context.Background(), "foo")
+	assert.Equal(t, "foo", FromContext(ctx))
+}
+
+func TestIsValid(t *testing.T) {
+	assert.True(t, IsValid("abc-123"))
+	assert.True(t, IsValid(New()))
+	assert.False(t, IsValid(""))
+	assert.False(t, IsValid("a b"))
+	assert.False(t, IsValid("a\nb"))
+	assert.False(t, IsValid(strings.Repeat("a", maxLength+1)))
+}
+
+func TestNew(t *testing.T) {
+	assert.NotEqual(t, New(), New())
+}
@@ -1,6 +1,9 @@
 package gateway
 
 import (
+	"time"
+
+	"github.com/jialequ/linux-sdk/core/discov"
 	"github.com/jialequ/linux-sdk/rest"
 	"github.com/jialequ/linux-sdk/zrpc"
 )
@@ -10,6 +13,31 @@ type (
 	GatewayConf struct {
 		rest.RestConf
 		Upstreams []Upstream
+		// Reload watches the upstreams and reloads them without restart.
+		Reload ReloadConf `json:",optional"`
+		// Response is the format of the responses of the grpc upstreams.
+		Response ResponseConf `json:",optional"`
+	}
+
+	// ResponseConf is the configuration of the response format.
+	ResponseConf struct {
+		// ErrorEnvelope writes the errors as the json of google.rpc.Status,
+		// like {"code": 5, "message": "not found", "details": []}.
+		ErrorEnvelope bool `json:",optional"`
+		// WrapData wraps the unary responses as {"code": 0, "msg": "ok", "data": {...}}.
+		WrapData bool `json:",optional"`
+	}
+
+	// ReloadConf is the configuration to reload the upstreams.
+	ReloadConf struct {
+		// File is the config file to watch, usually the file that the gateway is loaded from,
+		// only the Upstreams in it are reloaded.
+		File string `json:",optional"`
+		// Interval is the interval to check the changes of File.
+		Interval time.Duration `json:",default=10s"`
+		// Etcd is the etcd key to watch, the value under the key is the config in yaml or json,
+		// only the Upstreams in it are reloaded.
+		Etcd discov.EtcdConf `json:",optional"`
 	}
 
 	// RouteMapping is a mapping between a gateway route and an upstream rpc method.
@@ -18,8 +46,50 @@ type (
 		Method string
 		// Path is the HTTP path.
 		Path string
-		// RpcPath is the gRPC rpc method, with format of package.service/method
-		RpcPath string
+		// RpcPath is the gRPC rpc method, with format of package.service/method,
+		// only for the grpc upstreams.
+		RpcPath string `json:",optional"`
+		// Rewrite is the path of the requests to the http upstreams, like /users/:id,
+		// the path variables of Path are replaced, keep it blank to use the request path.
+		Rewrite string `json:",optional"`
+		// Timeout is the timeout of the route in milliseconds to the http upstreams,
+		// 0 means the Timeout of the upstream, it can't exceed the Timeout of the server.
+		Timeout int64 `json:",optional"`
+		// Retries is the retry times of the route to the http upstreams,
+		// 0 means the Retries of the upstream.
+		Retries int `json:",optional"`
+	}
+
+	// HeaderRules are the rules to modify the headers.
+	HeaderRules struct {
+		// Add sets the headers, like X-Gateway: edge.
+		Add map[string]string `json:",optional"`
+		// Remove removes the headers, like Cookie.
+		Remove []string `json:",optional"`
+	}
+
+	// HttpClientConf is the configuration for an http upstream.
+	HttpClientConf struct {
+		// Targets are the base urls of the upstream, like http://localhost:8080,
+		// the requests are balanced across the targets in round-robin.
+		Targets []string `json:",optional"`
+		// Etcd discovers the targets of the upstream, the values of the key are host:port.
+		Etcd discov.EtcdConf `json:",optional,inherit"`
+		// Scheme is the scheme of the targets discovered by Etcd.
+		Scheme string `json:",default=http,options=http|https"`
+		// Timeout is the timeout of the requests in milliseconds,
+		// it can't exceed the Timeout of the server.
+		Timeout int64 `json:",default=3000"`
+		// Retries is the retry times of the idempotent requests
+		// on the connection errors and the 502, 503 and 504 responses, with a jittered interval.
+		Retries int `json:",optional,range=[0:10]"`
+		// MaxRetryBytes is the max bytes of the request bodies buffered to retry,
+		// the larger requests are forwarded without retries.
+		MaxRetryBytes int64 `json:",default=1048576"`
+		// RequestHeaders are the rules to modify the headers of the requests to the upstream.
+		RequestHeaders HeaderRules `json:",optional"`
+		// ResponseHeaders are the rules to modify the headers of the responses from the upstream.
+		ResponseHeaders HeaderRules `json:",optional"`
 	}
 
 	// Upstream is the configuration for an upstream.
@@ -27,12 +97,18 @@ type (
 		// Name is the name of the upstream.
 		Name string `json:",optional"`
 		// Grpc is the target of the upstream.
-		Grpc zrpc.RpcClientConf
+		Grpc zrpc.RpcClientConf `json:",optional"`
+		// Http is the target of the http upstream, the requests are proxied to the upstream
+		// with the Mappings if set, otherwise the upstream is a grpc upstream.
+		Http *HttpClientConf `json:",optional"`
 		// ProtoSets is the file list of proto set, like [hello.pb].
 		// if your proto file import another proto file, you need to write multi-file slice,
 		// like [hello.pb, common.pb].
 		ProtoSets []string `json:",optional"`
-		// Mappings is the mapping between gateway routes and Upstream rpc methods.
+		// StatusCodes overrides the http status codes of the grpc codes, like NOT_FOUND: 200,
+		// the keys are the names or the numbers of the grpc codes, only for the grpc upstreams.
+		StatusCodes map[string]int `json:",optional"`
+		// Mappings is the mapping between gateway routes and Upstream rpc methods or http paths.
 		// Keep it blank if annotations are added in rpc methods.
 		Mappings []RouteMapping `json:",optional"`
 	}
@@ -0,0 +1,358 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jialequ/linux-sdk/core/budget"
+	"github.com/jialequ/linux-sdk/core/discov"
+	"github.com/jialequ/linux-sdk/core/logx"
+	"github.com/jialequ/linux-sdk/core/mathx"
+	"github.com/jialequ/linux-sdk/gateway/internal"
+	"github.com/jialequ/linux-sdk/rest"
+	"github.com/jialequ/linux-sdk/rest/httpc"
+	"github.com/jialequ/linux-sdk/rest/pathvar"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	xForwardedFor   = "X-Forwarded-For"
+	xForwardedHost  = "X-Forwarded-Host"
+	xForwardedProto = "X-Forwarded-Proto"
+	// retryInterval is the mean interval between the retries, with the jitter of retryDeviation.
+	retryInterval  = time.Millisecond * 50
+	retryDeviation = 0.5
+)
+
+var (
+	errNoHttpTargets = errors.New("no targets or etcd of http upstream")
+	retryJitter      = mathx.NewUnstable(retryDeviation)
+	// hopHeaders are the hop-by-hop headers, which are not forwarded to upstreams or clients.
+	hopHeaders = []string{
+		"Connection",
+		"Keep-Alive",
+		"Proxy-Authenticate",
+		"Proxy-Authorization",
+		"Proxy-Connection",
+		"Te",
+		"Trailer",
+		"Transfer-Encoding",
+		"Upgrade",
+	}
+)
+
+// httpUpstream proxies the requests to an http upstream.
+type httpUpstream struct {
+	name    string
+	conf    HttpClientConf
+	picker  *internal.TargetPicker
+	service httpc.Service
+	// sub is the etcd subscription of the targets, nil if the targets are static.
+	sub *discov.Subscriber
+	// errorEnvelope writes the errors of the gateway as the json of google.rpc.Status,
+	// the responses of the upstream are written as is.
+	errorEnvelope bool
+}
+
+func newHttpUpstream(name string, c HttpClientConf, errorEnvelope bool) (*httpUpstream, error) {
+	var targets func() []string
+	var sub *discov.Subscriber
+	switch {
+	case len(c.Targets) > 0:
+		static := make([]string, 0, len(c.Targets))
+		for _, target := range c.Targets {
+			static = append(static, strings.TrimSuffix(target, "/"))
+		}
+		targets = func() []string {
+			return static
+		}
+	case len(c.Etcd.Hosts) > 0:
+		if err := c.Etcd.Validate(); err != nil {
+			return nil, err
+		}
+
+		var opts []discov.SubOption
+		if c.Etcd.HasAccount() {
+			opts = append(opts, discov.WithSubEtcdAccount(c.Etcd.User, c.Etcd.Pass))
+		}
+		if c.Etcd.HasTLS() {
+			opts = append(opts, discov.WithSubEtcdTLS(c.Etcd.CertFile, c.Etcd.CertKeyFile,
+				c.Etcd.CACertFile, c.Etcd.InsecureSkipVerify))
+		}
+		var err error
+		sub, err = discov.NewSubscriber(c.Etcd.Hosts, c.Etcd.Key, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		targets = func() []string {
+			// the values might be published with the metadata, like zone and version.
+			instances := sub.Instances()
+			urls := make([]string, 0, len(instances))
+			for _, inst := range instances {
+				urls = append(urls, fmt.Sprintf("%s://%s", c.Scheme, inst.Addr))
+			}
+			return urls
+		}
+	default:
+		return nil, errNoHttpTargets
+	}
+
+	return &httpUpstream{
+		name:          name,
+		conf:          c,
+		picker:        internal.NewTargetPicker(targets),
+		service:       httpc.NewService(name, httpc.WithBudget()),
+		sub:           sub,
+		errorEnvelope: errorEnvelope,
+	}, nil
+}
+
+// close releases the etcd subscription of u, the targets are not updated anymore.
+func (u *httpUpstream) close() {
+	if u.sub != nil {
+		u.sub.Close()
+	}
+}
+
+// routes returns the routes of the mappings, which share the middlewares of the rest server.
+// The timeouts of the mappings can't exceed maxTimeout in milliseconds if it's positive,
+// because the requests are cut off by the rest server at maxTimeout.
+func (u *httpUpstream) routes(mappings []RouteMapping, maxTimeout int64) ([]rest.Route, error) {
+	routes := make([]rest.Route, 0, len(mappings))
+	for _, m := range mappings {
+		if len(m.Method) == 0 || len(m.Path) == 0 {
+			return nil, fmt.Errorf("%s: method and path are required in mappings", u.name)
+		}
+		if timeout := u.timeoutOf(m); maxTimeout > 0 && timeout > maxTimeout {
+			return nil, fmt.Errorf("%s: timeout %dms of %s exceeds the server timeout %dms",
+				u.name, timeout, m.Path, maxTimeout)
+		}
+
+		routes = append(routes, rest.Route{
+			Method:  strings.ToUpper(m.Method),
+			Path:    m.Path,
+			Handler: u.buildHandler(m),
+		})
+	}
+
+	return routes, nil
+}
+
+func (u *httpUpstream) buildHandler(m RouteMapping) http.HandlerFunc {
+	timeout := u.timeoutOf(m)
+	retries := u.conf.Retries
+	if m.Retries > 0 {
+		retries = m.Retries
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Millisecond)
+			defer cancel()
+		}
+
+		// the escaped path, to not turn the escaped ? and # into the query and fragment.
+		path := r.URL.EscapedPath()
+		if len(m.Rewrite) > 0 {
+			path = internal.RewritePath(m.Rewrite, pathvar.Vars(r))
+		}
+
+		attempts := retries
+		if !isIdempotent(r.Method) {
+			attempts = 0
+		}
+
+		var body []byte
+		if attempts > 0 && r.Body != nil && r.Body != http.NoBody {
+			if u.conf.MaxRetryBytes <= 0 || r.ContentLength > u.conf.MaxRetryBytes {
+				// too large to buffer, forwarded without retries.
+				attempts = 0
+			} else {
+				// the chunked bodies are limited while reading.
+				var err error
+				body, err = io.ReadAll(http.MaxBytesReader(w, r.Body, u.conf.MaxRetryBytes))
+				if err != nil {
+					logx.WithContext(ctx).Errorf("read request body of %s failed, error: %v",
+						r.URL.Path, err)
+					var maxErr *http.MaxBytesError
+					if errors.As(err, &maxErr) {
+						u.writeError(w, http.StatusRequestEntityTooLarge, codes.ResourceExhausted)
+					} else {
+						u.writeError(w, http.StatusBadRequest, codes.InvalidArgument)
+					}
+					return
+				}
+			}
+		}
+
+		var resp *http.Response
+		var err error
+		for i := 0; i <= attempts; i++ {
+			if i > 0 && !waitRetry(ctx) {
+				break
+			}
+			if resp != nil {
+				resp.Body.Close()
+			}
+
+			var req *http.Request
+			req, err = u.buildRequest(ctx, r, path, body, attempts > 0)
+			if err != nil {
+				break
+			}
+
+			resp, err = u.service.DoRequest(req)
+			if (err == nil && !isRetryableStatus(resp.StatusCode)) || ctx.Err() != nil {
+				break
+			}
+		}
+		if err != nil {
+			logx.WithContext(ctx).Errorf("proxy %s to upstream %s failed, error: %v", r.URL.Path, u.name, err)
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				u.writeError(w, http.StatusGatewayTimeout, codes.DeadlineExceeded)
+			} else {
+				u.writeError(w, http.StatusBadGateway, codes.Unavailable)
+			}
+			return
+		}
+		defer resp.Body.Close()
+
+		header := w.Header()
+		for k, v := range resp.Header {
+			header[k] = v
+		}
+		removeHopHeaders(header)
+		applyHeaderRules(header, u.conf.ResponseHeaders)
+		w.WriteHeader(resp.StatusCode)
+		if _, err = io.Copy(w, resp.Body); err != nil {
+			logx.WithContext(ctx).Errorf("copy response of %s from upstream %s failed, error: %v",
+				r.URL.Path, u.name, err)
+		}
+	}
+}
+
+func (u *httpUpstream) buildRequest(ctx context.Context, r *http.Request, path string, body []byte,
+	buffered bool) (*http.Request, error) {
+	target, err := u.picker.Pick()
+	if err != nil {
+		return nil, err
+	}
+
+	reqBody := r.Body
+	if buffered {
+		reqBody = io.NopCloser(bytes.NewReader(body))
+	} else if r.ContentLength == 0 {
+		reqBody = http.NoBody
+	}
+	// path is escaped, which can't carry the query or fragment into the url.
+	req, err := http.NewRequestWithContext(ctx, r.Method, target+path, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	req.URL.RawQuery = r.URL.RawQuery
+
+	if buffered {
+		req.ContentLength = int64(len(body))
+	} else {
+		req.ContentLength = r.ContentLength
+	}
+	req.Header = r.Header.Clone()
+	removeHopHeaders(req.Header)
+	// the gateway is the edge, the forwarded headers from the clients are not trusted.
+	req.Header.Del(xForwardedFor)
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		req.Header.Set(xForwardedFor, host)
+	}
+	req.Header.Set(xForwardedHost, r.Host)
+	if r.TLS != nil {
+		req.Header.Set(xForwardedProto, "https")
+	} else {
+		req.Header.Set(xForwardedProto, "http")
+	}
+	// the deadline budget of the client has been applied into ctx,
+	// the service propagates the remaining one of ctx instead.
+	req.Header.Del(budget.HeaderKey)
+	applyHeaderRules(req.Header, u.conf.RequestHeaders)
+
+	return req, nil
+}
+
+// writeError writes the error with the status code, the details of the error are only logged.
+func (u *httpUpstream) writeError(w http.ResponseWriter, statusCode int, code codes.Code) {
+	if u.errorEnvelope {
+		internal.WriteErrorEnvelope(w, statusCode, status.New(code, http.StatusText(statusCode)), nil)
+	} else {
+		w.WriteHeader(statusCode)
+	}
+}
+
+func applyHeaderRules(header http.Header, rules HeaderRules) {
+	for _, key := range rules.Remove {
+		header.Del(key)
+	}
+	for k, v := range rules.Add {
+		header.Set(k, v)
+	}
+}
+
+// timeoutOf returns the timeout of m in milliseconds, 0 means no timeout.
+func (u *httpUpstream) timeoutOf(m RouteMapping) int64 {
+	if m.Timeout > 0 {
+		return m.Timeout
+	}
+
+	return u.conf.Timeout
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func isRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// waitRetry waits a jittered interval before retrying, returns false if ctx is done.
+func waitRetry(ctx context.Context) bool {
+	timer := time.NewTimer(retryJitter.AroundDuration(retryInterval))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func removeHopHeaders(header http.Header) {
+	for _, key := range header.Values("Connection") {
+		for _, each := range strings.Split(key, ",") {
+			header.Del(strings.TrimSpace(each))
+		}
+	}
+	for _, key := range hopHeaders {
+		header.Del(key)
+	}
+}
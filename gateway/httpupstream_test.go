@@ -0,0 +1,240 @@
+package gateway
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jialequ/linux-sdk/core/budget"
+	"github.com/jialequ/linux-sdk/rest/pathvar"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHttpUpstream(t *testing.T) {
+	_, err := newHttpUpstream("foo", HttpClientConf{}, false)
+	assert.Equal(t, errNoHttpTargets, err)
+
+	up, err := newHttpUpstream("foo", HttpClientConf{Targets: []string{"http://localhost/"}}, false)
+	assert.NoError(t, err)
+	_, err = up.routes([]RouteMapping{{Path: "/foo"}}, 0)
+	assert.Error(t, err)
+	routes, err := up.routes([]RouteMapping{{Method: "get", Path: "/foo"}}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, http.MethodGet, routes[0].Method)
+
+	// the timeouts exceeding the server timeout are rejected.
+	up.conf.Timeout = 3000
+	_, err = up.routes([]RouteMapping{{Method: "get", Path: "/foo"}}, 3000)
+	assert.NoError(t, err)
+	_, err = up.routes([]RouteMapping{{Method: "get", Path: "/foo", Timeout: 5000}}, 3000)
+	assert.Error(t, err)
+	up.conf.Timeout = 5000
+	_, err = up.routes([]RouteMapping{{Method: "get", Path: "/foo"}}, 3000)
+	assert.Error(t, err)
+	_, err = up.routes([]RouteMapping{{Method: "get", Path: "/foo", Timeout: 1000}}, 3000)
+	assert.NoError(t, err)
+}
+
+func TestHttpUpstreamEscapedPath(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.EscapedPath() + "|" + r.URL.RawQuery))
+	}))
+	defer backend.Close()
+
+	up, err := newHttpUpstream(backend.URL, HttpClientConf{
+		Targets: []string{backend.URL},
+		Timeout: 1000,
+	}, false)
+	assert.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	up.buildHandler(RouteMapping{})(w, httptest.NewRequest(http.MethodGet, "/api/a%3Fx=1%23b?c=d",
+		http.NoBody))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "/api/a%3Fx=1%23b|c=d", w.Body.String())
+
+	r := httptest.NewRequest(http.MethodGet, "/users/a%3Fx=1", http.NoBody)
+	r = pathvar.WithVars(r, map[string]string{"id": "a?x=1#b"})
+	w = httptest.NewRecorder()
+	up.buildHandler(RouteMapping{Rewrite: "/v2/users/:id"})(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "/v2/users/a%3Fx=1%23b|", w.Body.String())
+}
+
+func TestHttpUpstreamProxy(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Path", r.URL.RequestURI())
+		w.Header().Set("X-Added", r.Header.Get("X-Gateway"))
+		w.Header().Set("X-Cookie", r.Header.Get("Cookie"))
+		w.Header().Set("X-Forwarded", r.Header.Get("X-Forwarded-For"))
+		w.Header().Set("X-Forwarded-Host-Got", r.Header.Get("X-Forwarded-Host"))
+		w.Header().Set("X-Budget", r.Header.Get(budget.HeaderKey))
+		w.Header().Set("X-Keep", r.Header.Get("Keep-Alive"))
+		w.Header().Set("Server", "backend")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(body)
+	}))
+	defer backend.Close()
+
+	up, err := newHttpUpstream(backend.URL, HttpClientConf{
+		Targets: []string{backend.URL},
+		Timeout: 1000,
+		RequestHeaders: HeaderRules{
+			Add:    map[string]string{"X-Gateway": "edge"},
+			Remove: []string{"Cookie"},
+		},
+		ResponseHeaders: HeaderRules{
+			Remove: []string{"Server"},
+		},
+	}, false)
+	assert.NoError(t, err)
+
+	handler := up.buildHandler(RouteMapping{
+		Method:  "post",
+		Path:    "/users/:id",
+		Rewrite: "/v2/users/:id",
+	})
+	r := httptest.NewRequest(http.MethodPost, "/users/1?a=b", strings.NewReader("hello"))
+	r = pathvar.WithVars(r, map[string]string{"id": "1"})
+	r.Header.Set("Cookie", "c=d")
+	r.Header.Set("Keep-Alive", "timeout=5")
+	r.Header.Set("X-Forwarded-For", "10.0.0.1")
+	r.Header.Set("X-Forwarded-Host", "evil.com")
+	r.Header.Set(budget.HeaderKey, "100000")
+	w := httptest.NewRecorder()
+	handler(w, r)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "hello", w.Body.String())
+	assert.Equal(t, "/v2/users/1?a=b", w.Header().Get("X-Path"))
+	assert.Equal(t, "edge", w.Header().Get("X-Added"))
+	assert.Empty(t, w.Header().Get("X-Cookie"))
+	assert.Empty(t, w.Header().Get("X-Keep"))
+	assert.Equal(t, "192.0.2.1", w.Header().Get("X-Forwarded"))
+	assert.Equal(t, "example.com", w.Header().Get("X-Forwarded-Host-Got"))
+	remaining, ok := budget.Parse(w.Header().Get("X-Budget"))
+	assert.True(t, ok)
+	assert.True(t, remaining <= time.Second)
+	assert.Empty(t, w.Header().Get("Server"))
+}
+
+func TestHttpUpstreamRetries(t *testing.T) {
+	var calls int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	defer backend.Close()
+
+	up, err := newHttpUpstream(backend.URL, HttpClientConf{
+		Targets:       []string{backend.URL},
+		Timeout:       1000,
+		Retries:       1,
+		MaxRetryBytes: 2,
+	}, false)
+	assert.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	up.buildHandler(RouteMapping{})(w, httptest.NewRequest(http.MethodPut, "/", strings.NewReader("a")))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	atomic.StoreInt32(&calls, 0)
+	w = httptest.NewRecorder()
+	up.buildHandler(RouteMapping{Retries: 2})(w, httptest.NewRequest(http.MethodPut, "/",
+		strings.NewReader("a")))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "a", w.Body.String())
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	// the bodies larger than MaxRetryBytes are not retried.
+	atomic.StoreInt32(&calls, 0)
+	w = httptest.NewRecorder()
+	up.buildHandler(RouteMapping{Retries: 2})(w, httptest.NewRequest(http.MethodPut, "/",
+		strings.NewReader("abc")))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// the chunked bodies larger than MaxRetryBytes are rejected.
+	atomic.StoreInt32(&calls, 0)
+	r := httptest.NewRequest(http.MethodPut, "/", strings.NewReader("abc"))
+	r.ContentLength = -1
+	w = httptest.NewRecorder()
+	up.buildHandler(RouteMapping{Retries: 2})(w, r)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&calls))
+
+	// non-idempotent requests are not retried.
+	atomic.StoreInt32(&calls, 0)
+	w = httptest.NewRecorder()
+	up.buildHandler(RouteMapping{Retries: 2})(w, httptest.NewRequest(http.MethodPost, "/",
+		strings.NewReader("a")))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestHttpUpstreamErrors(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer backend.Close()
+
+	up, err := newHttpUpstream(backend.URL, HttpClientConf{
+		Targets: []string{backend.URL},
+		Timeout: 1000,
+	}, false)
+	assert.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	up.buildHandler(RouteMapping{Timeout: 10})(w, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	up, err = newHttpUpstream(closed.URL, HttpClientConf{
+		Targets: []string{closed.URL},
+		Timeout: 1000,
+	}, false)
+	assert.NoError(t, err)
+
+	w = httptest.NewRecorder()
+	up.buildHandler(RouteMapping{})(w, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+}
+
+func TestHttpUpstreamErrorEnvelope(t *testing.T) {
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	up, err := newHttpUpstream(closed.URL, HttpClientConf{
+		Targets: []string{closed.URL},
+		Timeout: 1000,
+	}, true)
+	assert.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	up.buildHandler(RouteMapping{})(w, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	assert.JSONEq(t, `{"code":14,"message":"Bad Gateway","details":[]}`, w.Body.String())
+}
+
+func TestWaitRetry(t *testing.T) {
+	start := time.Now()
+	assert.True(t, waitRetry(context.Background()))
+	assert.True(t, time.Since(start) >= time.Duration(float64(retryInterval)*(1-retryDeviation)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.False(t, waitRetry(ctx))
+}
@@ -15,10 +15,13 @@ type Method struct {
 	HttpMethod string
 	HttpPath   string
 	RpcPath    string
+	// ClientStreaming and ServerStreaming tell whether the rpc method streams the requests or responses.
+	ClientStreaming bool
+	ServerStreaming bool
 }
 
 // GetMethods returns all methods of the given grpcurl.DescriptorSource.
-func GetMethods(source grpcurl.DescriptorSource) ([]Method, error) { //NOSONAR
+func GetMethods(source grpcurl.DescriptorSource) ([]Method, error) {
 	svcs, err := source.ListServices()
 	if err != nil {
 		return nil, err
@@ -31,63 +34,38 @@ func GetMethods(source grpcurl.DescriptorSource) ([]Method, error) { //NOSONAR
 			return nil, err
 		}
 
-		switch val := d.(type) {
-		case *desc.ServiceDescriptor:
-			svcMethods := val.GetMethods()
-			for _, method := range svcMethods {
-				rpcPath := fmt.Sprintf("%s/%s", svc, method.GetName())
-				ext := proto.GetExtension(method.GetMethodOptions(), annotations.E_Http)
-				switch rule := ext.(type) {
-				case *annotations.HttpRule:
-					if rule == nil {
-						methods = append(methods, Method{
-							RpcPath: rpcPath,
-						})
-						continue
-					}
+		val, ok := d.(*desc.ServiceDescriptor)
+		if !ok {
+			continue
+		}
 
-					switch httpRule := rule.GetPattern().(type) {
-					case *annotations.HttpRule_Get:
-						methods = append(methods, Method{
-							HttpMethod: http.MethodGet,
-							HttpPath:   adjustHttpPath(httpRule.Get),
-							RpcPath:    rpcPath,
-						})
-					case *annotations.HttpRule_Post:
-						methods = append(methods, Method{
-							HttpMethod: http.MethodPost,
-							HttpPath:   adjustHttpPath(httpRule.Post),
-							RpcPath:    rpcPath,
-						})
-					case *annotations.HttpRule_Put:
-						methods = append(methods, Method{
-							HttpMethod: http.MethodPut,
-							HttpPath:   adjustHttpPath(httpRule.Put),
-							RpcPath:    rpcPath,
-						})
-					case *annotations.HttpRule_Delete:
-						methods = append(methods, Method{
-							HttpMethod: http.MethodDelete,
-							HttpPath:   adjustHttpPath(httpRule.Delete),
-							RpcPath:    rpcPath,
-						})
-					case *annotations.HttpRule_Patch:
-						methods = append(methods, Method{
-							HttpMethod: http.MethodPatch,
-							HttpPath:   adjustHttpPath(httpRule.Patch),
-							RpcPath:    rpcPath,
-						})
-					default:
-						methods = append(methods, Method{
-							RpcPath: rpcPath,
-						})
-					}
-				default:
-					methods = append(methods, Method{
-						RpcPath: rpcPath,
-					})
+		for _, method := range val.GetMethods() {
+			m := Method{
+				RpcPath:         fmt.Sprintf("%s/%s", svc, method.GetName()),
+				ClientStreaming: method.IsClientStreaming(),
+				ServerStreaming: method.IsServerStreaming(),
+			}
+			ext := proto.GetExtension(method.GetMethodOptions(), annotations.E_Http)
+			if rule, ok := ext.(*annotations.HttpRule); ok && rule != nil {
+				switch httpRule := rule.GetPattern().(type) {
+				case *annotations.HttpRule_Get:
+					m.HttpMethod = http.MethodGet
+					m.HttpPath = adjustHttpPath(httpRule.Get)
+				case *annotations.HttpRule_Post:
+					m.HttpMethod = http.MethodPost
+					m.HttpPath = adjustHttpPath(httpRule.Post)
+				case *annotations.HttpRule_Put:
+					m.HttpMethod = http.MethodPut
+					m.HttpPath = adjustHttpPath(httpRule.Put)
+				case *annotations.HttpRule_Delete:
+					m.HttpMethod = http.MethodDelete
+					m.HttpPath = adjustHttpPath(httpRule.Delete)
+				case *annotations.HttpRule_Patch:
+					m.HttpMethod = http.MethodPatch
+					m.HttpPath = adjustHttpPath(httpRule.Patch)
 				}
 			}
+			methods = append(methods, m)
 		}
 	}
 
@@ -0,0 +1,155 @@
+package internal
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jialequ/linux-sdk/rest/httpx"
+	"github.com/jialequ/linux-sdk/rest/router"
+)
+
+type (
+	// A DynamicRouter is a httpx.Router whose route tables can be swapped atomically,
+	// the routes handled by Handle are kept in all the route tables.
+	DynamicRouter struct {
+		table      atomic.Pointer[RouteTable]
+		lock       sync.Mutex
+		routes     []staticRoute
+		notFound   http.Handler
+		notAllowed http.Handler
+	}
+
+	// A RouteTable is a generation of the routes of a DynamicRouter,
+	// which tracks its in-flight requests to be drained after swapped out.
+	RouteTable struct {
+		httpx.Router
+		lock   sync.Mutex
+		cond   *sync.Cond
+		active int
+	}
+
+	staticRoute struct {
+		method  string
+		path    string
+		handler http.Handler
+	}
+)
+
+// NewDynamicRouter returns a DynamicRouter with an empty route table.
+func NewDynamicRouter() *DynamicRouter {
+	r := new(DynamicRouter)
+	r.table.Store(newRouteTable())
+	return r
+}
+
+// Handle handles the route in all the route tables.
+func (r *DynamicRouter) Handle(method, path string, handler http.Handler) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if err := r.table.Load().Handle(method, path, handler); err != nil {
+		return err
+	}
+
+	r.routes = append(r.routes, staticRoute{
+		method:  method,
+		path:    path,
+		handler: handler,
+	})
+	return nil
+}
+
+// NewTable returns a new RouteTable with the routes and handlers set on r.
+func (r *DynamicRouter) NewTable() (*RouteTable, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	table := newRouteTable()
+	for _, route := range r.routes {
+		if err := table.Handle(route.method, route.path, route.handler); err != nil {
+			return nil, err
+		}
+	}
+	if r.notFound != nil {
+		table.SetNotFoundHandler(r.notFound)
+	}
+	if r.notAllowed != nil {
+		table.SetNotAllowedHandler(r.notAllowed)
+	}
+
+	return table, nil
+}
+
+// ServeHTTP serves the request with the current route table.
+func (r *DynamicRouter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	for {
+		table := r.table.Load()
+		table.acquire()
+		// the table might be swapped out and being drained before acquired.
+		if r.table.Load() == table {
+			defer table.release()
+			table.ServeHTTP(w, req)
+			return
+		}
+
+		table.release()
+	}
+}
+
+// SetNotAllowedHandler sets the handler for the not allowed requests in all the route tables.
+func (r *DynamicRouter) SetNotAllowedHandler(handler http.Handler) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.notAllowed = handler
+	r.table.Load().SetNotAllowedHandler(handler)
+}
+
+// SetNotFoundHandler sets the handler for the not found requests in all the route tables.
+func (r *DynamicRouter) SetNotFoundHandler(handler http.Handler) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.notFound = handler
+	r.table.Load().SetNotFoundHandler(handler)
+}
+
+// Swap swaps table in, and returns the previous route table.
+func (r *DynamicRouter) Swap(table *RouteTable) *RouteTable {
+	return r.table.Swap(table)
+}
+
+func newRouteTable() *RouteTable {
+	t := &RouteTable{
+		Router: router.NewRouter(),
+	}
+	t.cond = sync.NewCond(&t.lock)
+	return t
+}
+
+// Drain waits for the in-flight requests of t to finish.
+func (t *RouteTable) Drain() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for t.active > 0 {
+		t.cond.Wait()
+	}
+}
+
+func (t *RouteTable) acquire() {
+	t.lock.Lock()
+	t.active++
+	t.lock.Unlock()
+}
+
+func (t *RouteTable) release() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.active--
+	if t.active == 0 {
+		t.cond.Broadcast()
+	}
+}
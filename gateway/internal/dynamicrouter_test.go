@@ -0,0 +1,81 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDynamicRouter(t *testing.T) {
+	r := NewDynamicRouter()
+	assert.NoError(t, r.Handle(http.MethodGet, "/static", writeString("static")))
+	assert.Error(t, r.Handle(http.MethodGet, "/static", writeString("static")))
+	r.SetNotFoundHandler(writeString("not found"))
+	r.SetNotAllowedHandler(writeString("not allowed"))
+	assert.Equal(t, "static", serve(r, http.MethodGet, "/static"))
+	assert.Equal(t, "not found", serve(r, http.MethodGet, "/foo"))
+
+	table, err := r.NewTable()
+	assert.NoError(t, err)
+	assert.NoError(t, table.Handle(http.MethodGet, "/foo", writeString("foo")))
+	assert.Error(t, table.Handle(http.MethodGet, "/static", writeString("foo")))
+	previous := r.Swap(table)
+	previous.Drain()
+
+	assert.Equal(t, "static", serve(r, http.MethodGet, "/static"))
+	assert.Equal(t, "foo", serve(r, http.MethodGet, "/foo"))
+	assert.Equal(t, "not allowed", serve(r, http.MethodPost, "/foo"))
+	assert.Equal(t, "not found", serve(r, http.MethodGet, "/bar"))
+}
+
+func TestDynamicRouterDrain(t *testing.T) {
+	r := NewDynamicRouter()
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	assert.NoError(t, r.Handle(http.MethodGet, "/slow", http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			close(started)
+			<-finish
+		})))
+
+	go serve(r, http.MethodGet, "/slow")
+	<-started
+
+	table, err := r.NewTable()
+	assert.NoError(t, err)
+	previous := r.Swap(table)
+
+	drained := make(chan struct{})
+	go func() {
+		previous.Drain()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		t.Fatal("drained with in-flight requests")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	close(finish)
+	select {
+	case <-drained:
+	case <-time.After(time.Second):
+		t.Fatal("not drained")
+	}
+}
+
+func serve(h http.Handler, method, path string) string {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, path, http.NoBody))
+	return w.Body.String()
+}
+
+func writeString(s string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(s))
+	})
+}
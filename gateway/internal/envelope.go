@@ -0,0 +1,63 @@
+package internal
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/jialequ/linux-sdk/core/logx"
+	"github.com/jialequ/linux-sdk/rest/httpx"
+	"google.golang.org/grpc/status"
+)
+
+const okMsg = "ok"
+
+type (
+	// dataEnvelope is the envelope of the successful responses.
+	dataEnvelope struct {
+		Code int             `json:"code"`
+		Msg  string          `json:"msg"`
+		Data json.RawMessage `json:"data"`
+	}
+
+	// errorEnvelope is the envelope of the errors, used if st can't be marshaled with details.
+	errorEnvelope struct {
+		Code    int32             `json:"code"`
+		Message string            `json:"message"`
+		Details []json.RawMessage `json:"details"`
+	}
+)
+
+// WriteDataEnvelope writes the json data as {"code": 0, "msg": "ok", "data": data}.
+func WriteDataEnvelope(w http.ResponseWriter, data []byte) {
+	if len(data) == 0 {
+		data = []byte("null")
+	}
+
+	httpx.WriteJson(w, http.StatusOK, dataEnvelope{
+		Code: 0,
+		Msg:  okMsg,
+		Data: data,
+	})
+}
+
+// WriteErrorEnvelope writes st as the json of google.rpc.Status with the http status code,
+// like {"code": 5, "message": "not found", "details": []}, the details are resolved by resolver.
+func WriteErrorEnvelope(w http.ResponseWriter, code int, st *status.Status, resolver jsonpb.AnyResolver) {
+	marshaler := jsonpb.Marshaler{
+		EmitDefaults: true,
+		AnyResolver:  resolver,
+	}
+	content, err := marshaler.MarshalToString(st.Proto())
+	if err != nil {
+		logx.Errorf("marshal status with details failed, error: %v", err)
+		httpx.WriteJson(w, code, errorEnvelope{
+			Code:    int32(st.Code()),
+			Message: st.Message(),
+			Details: []json.RawMessage{},
+		})
+		return
+	}
+
+	httpx.WriteJson(w, code, json.RawMessage(content))
+}
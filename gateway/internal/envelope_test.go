@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestWriteDataEnvelope(t *testing.T) {
+	w := httptest.NewRecorder()
+	WriteDataEnvelope(w, []byte(`{"name":"kevin"}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"code":0,"msg":"ok","data":{"name":"kevin"}}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	WriteDataEnvelope(w, nil)
+	assert.JSONEq(t, `{"code":0,"msg":"ok","data":null}`, w.Body.String())
+}
+
+func TestWriteErrorEnvelope(t *testing.T) {
+	st, err := status.New(codes.InvalidArgument, "bad name").WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: "name", Description: "is required"},
+		},
+	})
+	assert.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	WriteErrorEnvelope(w, http.StatusBadRequest, st, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"code":3,"message":"bad name","details":[{
+"@type":"type.googleapis.com/google.rpc.BadRequest",
+"fieldViolations":[{"field":"name","description":"is required"}]}]}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	WriteErrorEnvelope(w, http.StatusNotFound, status.New(codes.NotFound, "not found"), nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.JSONEq(t, `{"code":5,"message":"not found","details":[]}`, w.Body.String())
+}
+
+func TestWriteErrorEnvelopeUnknownDetails(t *testing.T) {
+	st := status.FromProto(status.New(codes.Internal, "internal").Proto())
+	proto := st.Proto()
+	proto.Details = append(proto.Details, &anypb.Any{
+		TypeUrl: "type.googleapis.com/unknown.Detail",
+		Value:   []byte("abc"),
+	})
+
+	w := httptest.NewRecorder()
+	WriteErrorEnvelope(w, http.StatusInternalServerError, status.FromProto(proto), nil)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, `{"code":13,"message":"internal","details":[]}`, w.Body.String())
+}
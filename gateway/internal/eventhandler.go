@@ -1,7 +1,11 @@
 package internal
 
 import (
+	"fmt"
 	"io"
+	"mime"
+	"net/http"
+	"strings"
 
 	"github.com/golang/protobuf/jsonpb"
 	"github.com/golang/protobuf/proto"
@@ -11,10 +15,19 @@ import (
 	"google.golang.org/grpc/status"
 )
 
+const (
+	// NdjsonContentType is the content type of the newline delimited json streams.
+	NdjsonContentType = "application/x-ndjson"
+	// SseContentType is the content type of the server-sent events.
+	SseContentType = "text/event-stream"
+)
+
 type EventHandler struct {
-	Status    *status.Status
-	writer    io.Writer
-	marshaler jsonpb.Marshaler
+	Status      *status.Status
+	writer      io.Writer
+	marshaler   jsonpb.Marshaler
+	contentType string
+	messages    int
 }
 
 func NewEventHandler(writer io.Writer, resolver jsonpb.AnyResolver) *EventHandler {
@@ -27,10 +40,50 @@ func NewEventHandler(writer io.Writer, resolver jsonpb.AnyResolver) *EventHandle
 	}
 }
 
+// NewStreamEventHandler returns an EventHandler that writes the messages of server streams
+// one by one in contentType, which is NdjsonContentType or SseContentType,
+// and flushes each message if writer is a http.Flusher.
+func NewStreamEventHandler(writer io.Writer, resolver jsonpb.AnyResolver, contentType string) *EventHandler {
+	h := NewEventHandler(writer, resolver)
+	h.contentType = contentType
+	return h
+}
+
+// GetStreamContentType returns the content type of the server streams accepted by the header,
+// NdjsonContentType if neither NdjsonContentType nor SseContentType is accepted.
+func GetStreamContentType(header http.Header) string {
+	for _, accept := range header.Values("Accept") {
+		for _, val := range strings.Split(accept, ",") {
+			mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(val))
+			if err != nil {
+				continue
+			}
+
+			switch mediaType {
+			case SseContentType, NdjsonContentType:
+				return mediaType
+			}
+		}
+	}
+
+	return NdjsonContentType
+}
+
 func (h *EventHandler) OnReceiveResponse(message proto.Message) {
-	if err := h.marshaler.Marshal(h.writer, message); err != nil {
+	if len(h.contentType) == 0 {
+		if err := h.marshaler.Marshal(h.writer, message); err != nil {
+			logx.Error(err)
+		}
+		return
+	}
+
+	content, err := h.marshaler.MarshalToString(message)
+	if err != nil {
 		logx.Error(err)
+		return
 	}
+
+	h.writeEvent("", content)
 }
 
 func (h *EventHandler) OnReceiveTrailers(status *status.Status, _ metadata.MD) {
@@ -51,3 +104,47 @@ func (h *EventHandler) OnReceiveHeaders(_ metadata.MD) {
 
 	//func (h *EventHandler) OnReceiveHeaders(_ metadata.MD)
 }
+
+// Written returns whether any messages are written to the stream,
+// after that the errors can only be written by WriteStatus.
+func (h *EventHandler) Written() bool {
+	return h.messages > 0
+}
+
+// WriteStatus writes st as the last message of the stream, an error event of server-sent events,
+// or a line of {"error": st} of newline delimited json.
+func (h *EventHandler) WriteStatus(st *status.Status) {
+	content, err := h.marshaler.MarshalToString(st.Proto())
+	if err != nil {
+		logx.Error(err)
+		return
+	}
+
+	if h.contentType == SseContentType {
+		h.writeEvent("error", content)
+	} else {
+		h.writeEvent("", fmt.Sprintf(`{"error":%s}`, content))
+	}
+}
+
+func (h *EventHandler) writeEvent(event, content string) {
+	var err error
+	if h.contentType == SseContentType {
+		if len(event) > 0 {
+			_, err = fmt.Fprintf(h.writer, "event: %s\ndata: %s\n\n", event, content)
+		} else {
+			_, err = fmt.Fprintf(h.writer, "data: %s\n\n", content)
+		}
+	} else {
+		_, err = fmt.Fprintln(h.writer, content)
+	}
+	if err != nil {
+		logx.Error(err)
+		return
+	}
+
+	h.messages++
+	if flusher, ok := h.writer.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
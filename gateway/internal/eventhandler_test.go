@@ -2,11 +2,14 @@ package internal
 
 import (
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 func TestEventHandler(t *testing.T) {
@@ -18,3 +21,61 @@ func TestEventHandler(t *testing.T) {
 	assert.Equal(t, codes.OK, h.Status.Code())
 	h.OnReceiveResponse(nil)
 }
+
+func TestStreamEventHandler(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		expect      string
+	}{
+		{
+			name:        "ndjson",
+			contentType: NdjsonContentType,
+			expect: "\"a\"\n\"b\"\n" +
+				"{\"error\":{\"code\":5,\"message\":\"not found\",\"details\":[]}}\n",
+		},
+		{
+			name:        "sse",
+			contentType: SseContentType,
+			expect: "data: \"a\"\n\ndata: \"b\"\n\n" +
+				"event: error\ndata: {\"code\":5,\"message\":\"not found\",\"details\":[]}\n\n",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h := NewStreamEventHandler(w, nil, test.contentType)
+			assert.False(t, h.Written())
+			h.OnReceiveResponse(wrapperspb.String("a"))
+			h.OnReceiveResponse(wrapperspb.String("b"))
+			assert.True(t, h.Written())
+			assert.True(t, w.Flushed)
+			h.WriteStatus(status.New(codes.NotFound, "not found"))
+			assert.Equal(t, test.expect, w.Body.String())
+		})
+	}
+}
+
+func TestGetStreamContentType(t *testing.T) {
+	tests := []struct {
+		accept string
+		expect string
+	}{
+		{accept: "", expect: NdjsonContentType},
+		{accept: "application/json", expect: NdjsonContentType},
+		{accept: "text/event-stream", expect: SseContentType},
+		{accept: "text/html, text/event-stream;q=0.9", expect: SseContentType},
+		{accept: "application/x-ndjson, text/event-stream", expect: NdjsonContentType},
+		{accept: ";;, text/event-stream", expect: SseContentType},
+	}
+
+	for _, test := range tests {
+		header := make(http.Header)
+		if len(test.accept) > 0 {
+			header.Set("Accept", test.accept)
+		}
+		assert.Equal(t, test.expect, GetStreamContentType(header), test.accept)
+	}
+}
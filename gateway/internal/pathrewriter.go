@@ -0,0 +1,25 @@
+package internal
+
+import (
+	"net/url"
+	"strings"
+)
+
+const slash = "/"
+
+// RewritePath rewrites the path with the pattern, like /users/:id,
+// the :name segments are replaced by the path variables with the same names.
+func RewritePath(pattern string, vars map[string]string) string {
+	segments := strings.Split(pattern, slash)
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") {
+			continue
+		}
+
+		if val, ok := vars[segment[1:]]; ok {
+			segments[i] = url.PathEscape(val)
+		}
+	}
+
+	return strings.Join(segments, slash)
+}
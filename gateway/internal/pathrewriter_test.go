@@ -0,0 +1,24 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRewritePath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		vars    map[string]string
+		expect  string
+	}{
+		{pattern: "/users", expect: "/users"},
+		{pattern: "/v2/users/:id", vars: map[string]string{"id": "1"}, expect: "/v2/users/1"},
+		{pattern: "/:name/:id/info", vars: map[string]string{"id": "1", "name": "a b"}, expect: "/a%20b/1/info"},
+		{pattern: "/users/:id", expect: "/users/:id"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expect, RewritePath(test.pattern, test.vars), test.pattern)
+	}
+}
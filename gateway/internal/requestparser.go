@@ -8,6 +8,7 @@ import (
 
 	"github.com/fullstorydev/grpcurl"
 	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
 	"github.com/jialequ/linux-sdk/rest/httpx"
 	"github.com/jialequ/linux-sdk/rest/pathvar"
 )
@@ -45,6 +46,40 @@ func NewRequestParser(r *http.Request, resolver jsonpb.AnyResolver) (grpcurl.Req
 	return buildJsonRequestParser(m, resolver)
 }
 
+// NewStreamRequestParser creates a new request parser for client streams from the given http.Request
+// and resolver, the body is read as a stream of json messages, like newline delimited json,
+// and the path and form values are set in each message.
+func NewStreamRequestParser(r *http.Request, resolver jsonpb.AnyResolver) (grpcurl.RequestParser, error) {
+	vars := pathvar.Vars(r)
+	params, err := httpx.GetFormValues(r)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range vars {
+		params[k] = v
+	}
+
+	var body io.Reader = http.NoBody
+	if r.Body != nil {
+		body = r.Body
+	}
+
+	if len(params) == 0 {
+		return grpcurl.NewJSONRequestParser(body, resolver), nil
+	}
+
+	decoder := json.NewDecoder(body)
+	decoder.UseNumber()
+	return &streamRequestParser{
+		decoder: decoder,
+		params:  params,
+		unmarshaler: jsonpb.Unmarshaler{
+			AnyResolver: resolver,
+		},
+	}, nil
+}
+
 func buildJsonRequestParser(m map[string]any, resolver jsonpb.AnyResolver) (
 	grpcurl.RequestParser, error) {
 	var buf bytes.Buffer
@@ -79,3 +114,33 @@ func getBody(r *http.Request) (io.Reader, bool) {
 
 	return nil, false
 }
+
+type streamRequestParser struct {
+	decoder     *json.Decoder
+	params      map[string]any
+	unmarshaler jsonpb.Unmarshaler
+	requests    int
+}
+
+func (p *streamRequestParser) Next(msg proto.Message) error {
+	m := make(map[string]any)
+	if err := p.decoder.Decode(&m); err != nil {
+		return err
+	}
+
+	for k, v := range p.params {
+		m[k] = v
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(m); err != nil {
+		return err
+	}
+
+	p.requests++
+	return p.unmarshaler.Unmarshal(&buf, msg)
+}
+
+func (p *streamRequestParser) NumRequests() int {
+	return p.requests
+}
@@ -2,6 +2,7 @@ package internal
 
 import (
 	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/jialequ/linux-sdk/rest/pathvar"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
 func TestNewRequestParserNoVar(t *testing.T) {
@@ -99,3 +101,53 @@ func (badBody) Read([]byte) (int, error) { return 0, errors.New("something bad")
 func (badBody) Close() error             { return nil }
 
 const literal_6490 = "/val?a=b"
+
+func TestNewStreamRequestParser(t *testing.T) {
+	req := httptest.NewRequest("POST", "/", strings.NewReader("{\"a\": \"b\"}\n{\"a\": \"c\"}\n"))
+	parser, err := NewStreamRequestParser(req, nil)
+	assert.Nil(t, err)
+
+	var msg structpb.Struct
+	assert.Nil(t, parser.Next(&msg))
+	assert.Equal(t, "b", msg.Fields["a"].GetStringValue())
+	assert.Nil(t, parser.Next(&msg))
+	assert.Equal(t, "c", msg.Fields["a"].GetStringValue())
+	assert.Equal(t, io.EOF, parser.Next(&msg))
+	assert.Equal(t, 2, parser.NumRequests())
+}
+
+func TestNewStreamRequestParserWithVars(t *testing.T) {
+	req := httptest.NewRequest("POST", "/?e=f", strings.NewReader("{\"a\": 1}\n{\"a\": 2, \"c\": \"x\"}\n"))
+	req = pathvar.WithVars(req, map[string]string{"c": "d"})
+	parser, err := NewStreamRequestParser(req, nil)
+	assert.Nil(t, err)
+
+	var msg structpb.Struct
+	assert.Nil(t, parser.Next(&msg))
+	assert.Equal(t, float64(1), msg.Fields["a"].GetNumberValue())
+	assert.Equal(t, "d", msg.Fields["c"].GetStringValue())
+	assert.Equal(t, "f", msg.Fields["e"].GetStringValue())
+	msg.Reset()
+	assert.Nil(t, parser.Next(&msg))
+	assert.Equal(t, float64(2), msg.Fields["a"].GetNumberValue())
+	assert.Equal(t, "d", msg.Fields["c"].GetStringValue())
+	assert.Equal(t, io.EOF, parser.Next(&msg))
+	assert.Equal(t, 2, parser.NumRequests())
+}
+
+func TestNewStreamRequestParserWithWrongBody(t *testing.T) {
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{"a": "b"`))
+	req = pathvar.WithVars(req, map[string]string{"c": "d"})
+	parser, err := NewStreamRequestParser(req, nil)
+	assert.Nil(t, err)
+
+	var msg structpb.Struct
+	assert.NotNil(t, parser.Next(&msg))
+}
+
+func TestNewStreamRequestParserWithBadForm(t *testing.T) {
+	req := httptest.NewRequest("GET", "/val?a%1=b", http.NoBody)
+	parser, err := NewStreamRequestParser(req, nil)
+	assert.NotNil(t, err)
+	assert.Nil(t, parser)
+}
@@ -0,0 +1,88 @@
+package internal
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+)
+
+// httpStatuses are the standard http status codes of the grpc codes.
+// See: https://github.com/googleapis/googleapis/blob/master/google/rpc/code.proto
+var httpStatuses = map[codes.Code]int{
+	codes.OK:                 http.StatusOK,
+	codes.Canceled:           http.StatusRequestTimeout,
+	codes.Unknown:            http.StatusInternalServerError,
+	codes.InvalidArgument:    http.StatusBadRequest,
+	codes.DeadlineExceeded:   http.StatusGatewayTimeout,
+	codes.NotFound:           http.StatusNotFound,
+	codes.AlreadyExists:      http.StatusConflict,
+	codes.PermissionDenied:   http.StatusForbidden,
+	codes.ResourceExhausted:  http.StatusTooManyRequests,
+	codes.FailedPrecondition: http.StatusBadRequest,
+	codes.Aborted:            http.StatusConflict,
+	codes.OutOfRange:         http.StatusBadRequest,
+	codes.Unimplemented:      http.StatusNotImplemented,
+	codes.Internal:           http.StatusInternalServerError,
+	codes.Unavailable:        http.StatusServiceUnavailable,
+	codes.DataLoss:           http.StatusInternalServerError,
+	codes.Unauthenticated:    http.StatusUnauthorized,
+}
+
+// A StatusMapper maps the grpc codes to the http status codes.
+type StatusMapper struct {
+	overrides map[codes.Code]int
+}
+
+// NewStatusMapper returns a StatusMapper with the standard mapping overridden by overrides,
+// the keys of overrides are the names or the numbers of the grpc codes, like NOT_FOUND or 5.
+func NewStatusMapper(overrides map[string]int) (*StatusMapper, error) {
+	m := &StatusMapper{
+		overrides: make(map[codes.Code]int, len(overrides)),
+	}
+	for name, status := range overrides {
+		code, err := parseCode(name)
+		if err != nil {
+			return nil, err
+		}
+		if status < http.StatusContinue || status > 599 {
+			return nil, fmt.Errorf("invalid http status %d of grpc code %s", status, name)
+		}
+
+		m.overrides[code] = status
+	}
+
+	return m, nil
+}
+
+// HttpStatus returns the http status code of the grpc code.
+func (m *StatusMapper) HttpStatus(code codes.Code) int {
+	if m != nil {
+		if status, ok := m.overrides[code]; ok {
+			return status
+		}
+	}
+
+	if status, ok := httpStatuses[code]; ok {
+		return status
+	}
+
+	return http.StatusInternalServerError
+}
+
+func parseCode(name string) (codes.Code, error) {
+	var code codes.Code
+	if n, err := strconv.ParseUint(name, 10, 32); err == nil {
+		code = codes.Code(n)
+	} else if err := code.UnmarshalJSON([]byte(strconv.Quote(strings.ToUpper(name)))); err != nil {
+		return 0, fmt.Errorf("invalid grpc code %s", name)
+	}
+
+	if _, ok := httpStatuses[code]; !ok {
+		return 0, fmt.Errorf("invalid grpc code %s", name)
+	}
+
+	return code, nil
+}
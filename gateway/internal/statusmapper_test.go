@@ -0,0 +1,35 @@
+package internal
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+)
+
+func TestStatusMapper(t *testing.T) {
+	var m *StatusMapper
+	assert.Equal(t, http.StatusNotFound, m.HttpStatus(codes.NotFound))
+	assert.Equal(t, http.StatusInternalServerError, m.HttpStatus(codes.Code(100)))
+
+	m, err := NewStatusMapper(map[string]int{
+		"NOT_FOUND":      http.StatusOK,
+		"already_exists": http.StatusUnprocessableEntity,
+		"14":             http.StatusBadGateway,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, m.HttpStatus(codes.NotFound))
+	assert.Equal(t, http.StatusUnprocessableEntity, m.HttpStatus(codes.AlreadyExists))
+	assert.Equal(t, http.StatusBadGateway, m.HttpStatus(codes.Unavailable))
+	assert.Equal(t, http.StatusBadRequest, m.HttpStatus(codes.InvalidArgument))
+}
+
+func TestNewStatusMapperError(t *testing.T) {
+	_, err := NewStatusMapper(map[string]int{"NOT_EXIST": http.StatusOK})
+	assert.Error(t, err)
+	_, err = NewStatusMapper(map[string]int{"100": http.StatusOK})
+	assert.Error(t, err)
+	_, err = NewStatusMapper(map[string]int{"NOT_FOUND": 1000})
+	assert.Error(t, err)
+}
@@ -0,0 +1,34 @@
+package internal
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// ErrNoTargets indicates there are no targets to pick.
+var ErrNoTargets = errors.New("no available targets")
+
+// A TargetPicker picks the targets in round-robin.
+type TargetPicker struct {
+	targets func() []string
+	index   uint64
+}
+
+// NewTargetPicker returns a TargetPicker that picks from the targets returned by fn,
+// which may change, like the targets discovered by etcd.
+func NewTargetPicker(fn func() []string) *TargetPicker {
+	return &TargetPicker{
+		targets: fn,
+	}
+}
+
+// Pick picks the next target.
+func (p *TargetPicker) Pick() (string, error) {
+	targets := p.targets()
+	if len(targets) == 0 {
+		return "", ErrNoTargets
+	}
+
+	index := atomic.AddUint64(&p.index, 1) - 1
+	return targets[index%uint64(len(targets))], nil
+}
@@ -0,0 +1,26 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTargetPicker(t *testing.T) {
+	targets := []string{"a", "b"}
+	picker := NewTargetPicker(func() []string {
+		return targets
+	})
+
+	var picked []string
+	for i := 0; i < 4; i++ {
+		target, err := picker.Pick()
+		assert.NoError(t, err)
+		picked = append(picked, target)
+	}
+	assert.Equal(t, []string{"a", "b", "a", "b"}, picked)
+
+	targets = nil
+	_, err := picker.Pick()
+	assert.Equal(t, ErrNoTargets, err)
+}
@@ -0,0 +1,212 @@
+package gateway
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/jialequ/linux-sdk/core/conf"
+	"github.com/jialequ/linux-sdk/core/discov"
+	"github.com/jialequ/linux-sdk/core/dist"
+	"github.com/jialequ/linux-sdk/core/logx"
+	"github.com/jialequ/linux-sdk/core/metric"
+)
+
+const (
+	reloadNamespace = "gateway"
+	reloadOk        = "ok"
+	reloadFail      = "fail"
+)
+
+var (
+	errMultipleConfigs = errors.New("multiple configs under the etcd key")
+
+	metricReloadTotal = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: reloadNamespace,
+		Subsystem: "upstreams",
+		Name:      "reload_total",
+		Help:      "gateway upstreams reload count.",
+		Labels:    []string{"result"},
+	})
+)
+
+// reloadableConf is the reloadable part of GatewayConf.
+type reloadableConf struct {
+	Upstreams []Upstream `json:",optional"`
+}
+
+// watch watches the config file or the etcd key of the upstreams if configured.
+func (s *Server) watch() error {
+	if len(s.reloadConf.File) > 0 {
+		content, err := os.ReadFile(s.reloadConf.File)
+		if err != nil {
+			return err
+		}
+
+		interval := s.reloadConf.Interval
+		if interval <= 0 {
+			interval = time.Second * 10
+		}
+		dist.GoSafe(func() {
+			s.watchFile(s.reloadConf.File, content, interval)
+		})
+	}
+
+	if len(s.reloadConf.Etcd.Hosts) > 0 {
+		return s.watchEtcd(s.reloadConf.Etcd)
+	}
+
+	return nil
+}
+
+func (s *Server) watchEtcd(c discov.EtcdConf) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	var opts []discov.SubOption
+	if c.HasAccount() {
+		opts = append(opts, discov.WithSubEtcdAccount(c.User, c.Pass))
+	}
+	if c.HasTLS() {
+		opts = append(opts, discov.WithSubEtcdTLS(c.CertFile, c.CertKeyFile, c.CACertFile,
+			c.InsecureSkipVerify))
+	}
+	sub, err := discov.NewSubscriber(c.Hosts, c.Key, opts...)
+	if err != nil {
+		return err
+	}
+
+	update := func() {
+		s.reloadValues(c.Key, sub.Values())
+	}
+	sub.AddListener(update)
+	// the values loaded before the listener added are not notified.
+	update()
+
+	return nil
+}
+
+func (s *Server) watchFile(file string, content []byte, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done.Done():
+			return
+		case <-ticker.C:
+			data, err := os.ReadFile(file)
+			if err != nil {
+				logx.Errorf("gateway failed to read config file %s, error: %v", file, err)
+				continue
+			}
+			if bytes.Equal(data, content) {
+				continue
+			}
+
+			content = data
+			s.reloadContent(file, data, path.Ext(file))
+		}
+	}
+}
+
+// reloadContent reloads the upstreams in content of the given type, like .yaml, .json or .toml.
+// The previous upstreams are kept on any errors.
+func (s *Server) reloadContent(source string, content []byte, ext string) {
+	var c reloadableConf
+	var err error
+	switch strings.ToLower(ext) {
+	case ".json":
+		err = conf.LoadFromJsonBytes(content, &c)
+	case ".toml":
+		err = conf.LoadFromTomlBytes(content, &c)
+	default:
+		err = conf.LoadFromYamlBytes(content, &c)
+	}
+	if err != nil {
+		s.reportReload(source, fmt.Errorf("bad config: %w", err))
+		return
+	}
+
+	changed, err := s.reload(c.Upstreams)
+	if changed || err != nil {
+		s.reportReload(source, err)
+	}
+}
+
+func (s *Server) reloadValues(key string, values []string) {
+	switch len(values) {
+	case 0:
+		logx.Errorf("gateway config under etcd key %s is removed, upstreams are kept", key)
+	case 1:
+		s.reloadContent(key, []byte(values[0]), "")
+	default:
+		s.reportReload(key, errMultipleConfigs)
+	}
+}
+
+// reload reloads upstreams if changed, returns whether they are changed.
+func (s *Server) reload(upstreams []Upstream) (bool, error) {
+	s.reloadLock.Lock()
+	defer s.reloadLock.Unlock()
+
+	normalized, err := normalizeUpstreams(upstreams)
+	if err != nil {
+		return true, err
+	}
+	if reflect.DeepEqual(normalized, s.upstreams) {
+		return false, nil
+	}
+
+	added, removed, changed := diffUpstreams(s.upstreams, normalized)
+	if err := s.apply(normalized); err != nil {
+		return true, err
+	}
+
+	logx.Infof("gateway upstreams reloaded, added: %v, removed: %v, changed: %v", added, removed, changed)
+	return true, nil
+}
+
+func (s *Server) reportReload(source string, err error) {
+	if err != nil {
+		metricReloadTotal.Inc(reloadFail)
+		logx.Errorf("gateway failed to reload upstreams from %s, previous upstreams are kept, error: %v",
+			source, err)
+		return
+	}
+
+	metricReloadTotal.Inc(reloadOk)
+}
+
+// diffUpstreams returns the names of the added, removed and changed upstreams.
+func diffUpstreams(previous, current []Upstream) (added, removed, changed []string) {
+	olds := make(map[string]Upstream, len(previous))
+	for _, up := range previous {
+		olds[up.Name] = up
+	}
+
+	for _, up := range current {
+		old, ok := olds[up.Name]
+		switch {
+		case !ok:
+			added = append(added, up.Name)
+		case !reflect.DeepEqual(old, up):
+			changed = append(changed, up.Name)
+		}
+		delete(olds, up.Name)
+	}
+
+	for _, up := range previous {
+		if _, ok := olds[up.Name]; ok {
+			removed = append(removed, up.Name)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,225 @@
+package gateway
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jialequ/linux-sdk/rest"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/connectivity"
+)
+
+func TestServerReload(t *testing.T) {
+	svr := newTestServer(t, RouteMapping{
+		Method:  "post",
+		Path:    "/unary",
+		RpcPath: "grpc.testing.TestService/UnaryCall",
+	})
+	target := svr.upstreams[0].Name
+	cli := svr.clients[target].cli
+	reflection := svr.clients[target].reflection
+	assert.NotNil(t, reflection)
+	assert.Equal(t, http.StatusNotFound, serveGateway(svr, "/output", `{}`))
+
+	// the grpc client of the unchanged upstream is reused.
+	changed, err := svr.reload([]Upstream{
+		withMappings(svr.upstreams[0], RouteMapping{
+			Method:  "post",
+			Path:    "/unary",
+			RpcPath: "grpc.testing.TestService/UnaryCall",
+		}, RouteMapping{
+			Method:  "post",
+			Path:    "/output",
+			RpcPath: "grpc.testing.TestService/StreamingOutputCall",
+		}),
+	})
+	assert.True(t, changed)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, serveGateway(svr, "/output", `{}`))
+	assert.Equal(t, http.StatusOK, serveGateway(svr, "/unary", `{}`))
+	assert.True(t, cli == svr.clients[target].cli)
+	assert.True(t, reflection == svr.clients[target].reflection)
+
+	changed, err = svr.reload(svr.upstreams)
+	assert.False(t, changed)
+	assert.NoError(t, err)
+
+	// the previous routes are kept on errors.
+	changed, err = svr.reload([]Upstream{
+		withMappings(svr.upstreams[0], RouteMapping{
+			Method:  "get",
+			Path:    "/missing",
+			RpcPath: "grpc.testing.TestService/Missing",
+		}),
+	})
+	assert.True(t, changed)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusOK, serveGateway(svr, "/output", `{}`))
+
+	// the grpc client of the changed upstream is closed after drained.
+	upstream := withMappings(svr.upstreams[0], RouteMapping{
+		Method:  "post",
+		Path:    "/input",
+		RpcPath: "grpc.testing.TestService/StreamingInputCall",
+	})
+	upstream.Grpc.Timeout = 1000
+	changed, err = svr.reload([]Upstream{upstream})
+	assert.True(t, changed)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, serveGateway(svr, "/output", `{}`))
+	assert.Equal(t, http.StatusOK, serveGateway(svr, "/input", `{}`))
+	assert.False(t, cli == svr.clients[target].cli)
+	assert.False(t, reflection == svr.clients[target].reflection)
+	<-svr.drained
+	assert.Equal(t, connectivity.Shutdown, cli.Conn().GetState())
+}
+
+func TestServerReloadHttpUpstream(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+	defer backend.Close()
+
+	svr := MustNewServer(GatewayConf{
+		RestConf: rest.RestConf{
+			Host: "localhost",
+		},
+		Upstreams: []Upstream{
+			{
+				Http: &HttpClientConf{
+					Targets: []string{backend.URL},
+					Timeout: 1000,
+				},
+				Mappings: []RouteMapping{
+					{
+						Method: "get",
+						Path:   "/users",
+					},
+				},
+			},
+		},
+	})
+	assert.NoError(t, svr.build())
+	name := svr.upstreams[0].Name
+	upstream := svr.httpUpstreams[name]
+	assert.NotNil(t, upstream)
+
+	// the http upstream of the unchanged conf is reused.
+	changed, err := svr.reload([]Upstream{
+		withMappings(svr.upstreams[0], RouteMapping{
+			Method: "get",
+			Path:   "/orders",
+		}),
+	})
+	assert.True(t, changed)
+	assert.NoError(t, err)
+	assert.True(t, upstream == svr.httpUpstreams[name])
+	w := httptest.NewRecorder()
+	svr.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders", http.NoBody))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "/orders", w.Body.String())
+
+	// the http upstream of the changed conf is rebuilt.
+	up := svr.upstreams[0]
+	c := *up.Http
+	c.Timeout = 2000
+	up.Http = &c
+	changed, err = svr.reload([]Upstream{up})
+	assert.True(t, changed)
+	assert.NoError(t, err)
+	assert.False(t, upstream == svr.httpUpstreams[name])
+}
+
+func TestServerReloadContent(t *testing.T) {
+	svr := newTestServer(t)
+	target := svr.upstreams[0].Grpc.Target
+
+	svr.reloadContent("test", []byte(fmt.Sprintf(`{"Upstreams": [{"Grpc": {"Target": %q},
+"Mappings": [{"Method": "post", "Path": "/unary", "RpcPath": "grpc.testing.TestService/UnaryCall"}]}]}`,
+		target)), ".json")
+	assert.Equal(t, http.StatusOK, serveGateway(svr, "/unary", `{}`))
+
+	svr.reloadContent("test", []byte("Upstreams: [bad"), ".yaml")
+	assert.Equal(t, http.StatusOK, serveGateway(svr, "/unary", `{}`))
+
+	svr.reloadValues("test", nil)
+	svr.reloadValues("test", []string{"a", "b"})
+	assert.Equal(t, http.StatusOK, serveGateway(svr, "/unary", `{}`))
+
+	svr.reloadValues("test", []string{fmt.Sprintf(`
+Upstreams:
+  - Grpc:
+      Target: %s
+    Mappings:
+      - Method: post
+        Path: /output
+        RpcPath: grpc.testing.TestService/StreamingOutputCall
+`, target)})
+	assert.Equal(t, http.StatusNotFound, serveGateway(svr, "/unary", `{}`))
+	assert.Equal(t, http.StatusOK, serveGateway(svr, "/output", `{}`))
+}
+
+func TestServerWatchFile(t *testing.T) {
+	svr := newTestServer(t)
+	target := svr.upstreams[0].Grpc.Target
+	file := filepath.Join(t.TempDir(), "gateway.yaml")
+	content := `
+Name: gateway
+Host: localhost
+Port: 8888
+Upstreams:
+  - Grpc:
+      Target: %s
+    Mappings:
+      - Method: post
+        Path: %s
+        RpcPath: grpc.testing.TestService/UnaryCall
+`
+	assert.NoError(t, os.WriteFile(file, []byte(fmt.Sprintf(content, target, "/unary")), 0o644))
+	svr.reloadConf = ReloadConf{
+		File:     file,
+		Interval: time.Millisecond * 10,
+	}
+	assert.NoError(t, svr.watch())
+	defer svr.done.Close()
+
+	assert.NoError(t, os.WriteFile(file, []byte(fmt.Sprintf(content, target, "/unary2")), 0o644))
+	assert.Eventually(t, func() bool {
+		return serveGateway(svr, "/unary2", `{}`) == http.StatusOK
+	}, time.Second*5, time.Millisecond*10)
+
+	svr.reloadConf.File = filepath.Join(t.TempDir(), "missing.yaml")
+	assert.Error(t, svr.watch())
+}
+
+func TestDiffUpstreams(t *testing.T) {
+	added, removed, changed := diffUpstreams([]Upstream{
+		{Name: "a"},
+		{Name: "b"},
+		{Name: "c", ProtoSets: []string{"c.pb"}},
+	}, []Upstream{
+		{Name: "b"},
+		{Name: "c", ProtoSets: []string{"c.pb", "d.pb"}},
+		{Name: "d"},
+	})
+	assert.Equal(t, []string{"d"}, added)
+	assert.Equal(t, []string{"a"}, removed)
+	assert.Equal(t, []string{"c"}, changed)
+}
+
+func serveGateway(svr *Server, path, body string) int {
+	w := httptest.NewRecorder()
+	svr.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+	return w.Code
+}
+
+func withMappings(up Upstream, mappings ...RouteMapping) Upstream {
+	up.Mappings = mappings
+	return up
+}
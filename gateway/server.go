@@ -0,0 +1,540 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fullstorydev/grpcurl"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/jhump/protoreflect/grpcreflect"
+	"github.com/jialequ/linux-sdk/core/dist"
+	"github.com/jialequ/linux-sdk/core/lang"
+	"github.com/jialequ/linux-sdk/core/logx"
+	"github.com/jialequ/linux-sdk/core/syncx"
+	"github.com/jialequ/linux-sdk/core/templet"
+	"github.com/jialequ/linux-sdk/gateway/internal"
+	"github.com/jialequ/linux-sdk/rest"
+	"github.com/jialequ/linux-sdk/rest/httpx"
+	"github.com/jialequ/linux-sdk/zrpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type (
+	// Server is a gateway server.
+	Server struct {
+		*rest.Server
+		upstreams     []Upstream
+		timeout       time.Duration
+		processHeader func(http.Header) []string
+		dialer        func(conf zrpc.RpcClientConf) zrpc.Client
+		router        *internal.DynamicRouter
+		// clients are the grpc clients of the current route table, keyed by upstream names.
+		clients map[string]grpcClient
+		// httpUpstreams are the http upstreams of the current route table, keyed by upstream names.
+		httpUpstreams map[string]*httpUpstream
+		reloadConf    ReloadConf
+		response      ResponseConf
+		reloadLock    sync.Mutex
+		// drained is closed after the previous route tables are drained.
+		drained chan lang.PlaceholderType
+		done    *syncx.DoneChan
+	}
+
+	// Option defines the method to customize Server.
+	Option func(svr *Server)
+
+	grpcClient struct {
+		conf zrpc.RpcClientConf
+		cli  zrpc.Client
+		// source is cached with cli, which is rebuilt only if the protoSets changed.
+		protoSets []string
+		source    grpcurl.DescriptorSource
+		// reflection is the reflection client of source, nil if source is from the protoSets.
+		reflection *grpcreflect.Client
+	}
+
+	// resources are the grpc clients, the reflection clients and the http upstreams
+	// that are released together, like the ones retired from a route table.
+	resources struct {
+		clients       []zrpc.Client
+		reflections   []*grpcreflect.Client
+		httpUpstreams []*httpUpstream
+	}
+
+	// grpcTarget is the grpc upstream that the routes invoke.
+	grpcTarget struct {
+		source   grpcurl.DescriptorSource
+		resolver jsonpb.AnyResolver
+		cli      zrpc.Client
+		mapper   *internal.StatusMapper
+	}
+
+	// upstreamRoutes are the routes built from an upstream.
+	upstreamRoutes struct {
+		name   string
+		client *grpcClient
+		http   *httpUpstream
+		routes []rest.Route
+		// streams are the routes of the streaming methods, which ignore the timeout.
+		streams []rest.Route
+	}
+)
+
+// MustNewServer creates a new gateway server.
+func MustNewServer(c GatewayConf, opts ...Option) *Server {
+	router := internal.NewDynamicRouter()
+	svr := &Server{
+		// the not found handler is set again, because it's set on the default router.
+		Server:     rest.MustNewServer(c.RestConf, rest.WithRouter(router), rest.WithNotFoundHandler(nil)),
+		upstreams:  c.Upstreams,
+		timeout:    time.Duration(c.Timeout) * time.Millisecond,
+		router:     router,
+		reloadConf: c.Reload,
+		response:   c.Response,
+		done:       syncx.NewDoneChan(),
+	}
+	for _, opt := range opts {
+		opt(svr)
+	}
+
+	return svr
+}
+
+// Start starts the gateway server.
+func (s *Server) Start() {
+	logx.Must(s.build())
+	logx.Must(s.watch())
+	s.Server.Start()
+}
+
+// Stop stops the gateway server.
+func (s *Server) Stop() {
+	s.done.Close()
+	s.Server.Stop()
+}
+
+func (s *Server) build() error {
+	s.reloadLock.Lock()
+	defer s.reloadLock.Unlock()
+
+	upstreams, err := normalizeUpstreams(s.upstreams)
+	if err != nil {
+		return err
+	}
+
+	return s.apply(upstreams)
+}
+
+// apply builds a route table from upstreams and swaps it in, the grpc clients and the http
+// upstreams of the unchanged upstreams are reused, the retired ones are released after
+// the in-flight requests drained.
+func (s *Server) apply(upstreams []Upstream) error {
+	table, err := s.router.NewTable()
+	if err != nil {
+		return err
+	}
+
+	var lock sync.Mutex
+	// created are the resources created for the table, released if the table is not applied.
+	var created resources
+	var routes, streams []rest.Route
+	clients := make(map[string]grpcClient)
+	httpUpstreams := make(map[string]*httpUpstream)
+	err = templet.MapReduceVoid(func(source chan<- Upstream) {
+		for _, up := range upstreams {
+			source <- up
+		}
+	}, func(up Upstream, writer templet.Writer[upstreamRoutes], cancel func(error)) {
+		if up.Http != nil {
+			upstream, routes, err := s.buildHttpRoutes(up)
+			if err != nil {
+				cancel(err)
+				return
+			}
+			if upstream != s.httpUpstreams[up.Name] {
+				lock.Lock()
+				created.httpUpstreams = append(created.httpUpstreams, upstream)
+				lock.Unlock()
+			}
+
+			writer.Write(upstreamRoutes{
+				name:   up.Name,
+				http:   upstream,
+				routes: routes,
+			})
+			return
+		}
+
+		client, ok := s.clients[up.Name]
+		if !ok || !reflect.DeepEqual(client.conf, up.Grpc) {
+			cli, err := s.dial(up.Grpc)
+			if err != nil {
+				cancel(fmt.Errorf("%s: %w", up.Name, err))
+				return
+			}
+
+			lock.Lock()
+			created.clients = append(created.clients, cli)
+			lock.Unlock()
+			client = grpcClient{
+				conf: up.Grpc,
+				cli:  cli,
+			}
+		}
+
+		// the reflection clients cache the fetched descriptors, so they are reused with the clients.
+		if client.source == nil || !reflect.DeepEqual(client.protoSets, up.ProtoSets) {
+			source, reflection, err := s.createDescriptorSource(client.cli, up)
+			if err != nil {
+				cancel(fmt.Errorf("%s: %w", up.Name, err))
+				return
+			}
+
+			if reflection != nil {
+				lock.Lock()
+				created.reflections = append(created.reflections, reflection)
+				lock.Unlock()
+			}
+			client.protoSets = up.ProtoSets
+			client.source = source
+			client.reflection = reflection
+		}
+
+		routes, streams, err := s.buildGrpcRoutes(up, client)
+		if err != nil {
+			cancel(err)
+			return
+		}
+
+		writer.Write(upstreamRoutes{
+			name:    up.Name,
+			client:  &client,
+			routes:  routes,
+			streams: streams,
+		})
+	}, func(pipe <-chan upstreamRoutes, cancel func(error)) {
+		for item := range pipe {
+			routes = append(routes, item.routes...)
+			streams = append(streams, item.streams...)
+			if item.client != nil {
+				clients[item.name] = *item.client
+			}
+			if item.http != nil {
+				httpUpstreams[item.name] = item.http
+			}
+		}
+	})
+	if err == nil {
+		err = s.Server.BindRoutes(table, routes)
+	}
+	if err == nil && len(streams) > 0 {
+		err = s.Server.BindRoutes(table, streams, rest.WithStreaming())
+	}
+	if err != nil {
+		created.release()
+		return err
+	}
+
+	previous := s.router.Swap(table)
+	var retired resources
+	for name, client := range s.clients {
+		current, ok := clients[name]
+		if !ok || current.cli != client.cli {
+			retired.clients = append(retired.clients, client.cli)
+		}
+		if client.reflection != nil && (!ok || current.reflection != client.reflection) {
+			retired.reflections = append(retired.reflections, client.reflection)
+		}
+	}
+	for name, upstream := range s.httpUpstreams {
+		if current, ok := httpUpstreams[name]; !ok || current != upstream {
+			retired.httpUpstreams = append(retired.httpUpstreams, upstream)
+		}
+	}
+	s.clients = clients
+	s.httpUpstreams = httpUpstreams
+	s.upstreams = upstreams
+
+	// the retired resources might be used by the older route tables,
+	// so they are released after all the previous route tables are drained.
+	lastDrained := s.drained
+	drained := make(chan lang.PlaceholderType)
+	s.drained = drained
+	dist.GoSafe(func() {
+		defer close(drained)
+
+		previous.Drain()
+		if lastDrained != nil {
+			<-lastDrained
+		}
+		retired.release()
+	})
+
+	return nil
+}
+
+// buildGrpcRoutes builds the routes of the rpc methods of up, the routes of the streaming
+// methods are returned as streams.
+func (s *Server) buildGrpcRoutes(up Upstream, client grpcClient) (routes, streams []rest.Route,
+	err error) {
+	mapper, err := internal.NewStatusMapper(up.StatusCodes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", up.Name, err)
+	}
+
+	source := client.source
+	methods, err := internal.GetMethods(source)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", up.Name, err)
+	}
+
+	target := grpcTarget{
+		source:   source,
+		resolver: grpcurl.AnyResolverFromDescriptorSource(source),
+		cli:      client.cli,
+		mapper:   mapper,
+	}
+	add := func(method, path string, m internal.Method) {
+		route := rest.Route{
+			Method:  method,
+			Path:    path,
+			Handler: s.buildHandler(target, m),
+		}
+		if m.ClientStreaming || m.ServerStreaming {
+			streams = append(streams, route)
+		} else {
+			routes = append(routes, route)
+		}
+	}
+	methodSet := make(map[string]internal.Method)
+	for _, m := range methods {
+		methodSet[m.RpcPath] = m
+		if len(m.HttpMethod) > 0 && len(m.HttpPath) > 0 {
+			add(m.HttpMethod, m.HttpPath, m)
+		}
+	}
+
+	for _, m := range up.Mappings {
+		method, ok := methodSet[m.RpcPath]
+		if !ok {
+			return nil, nil, fmt.Errorf("%s: rpc method %s not found", up.Name, m.RpcPath)
+		}
+
+		add(strings.ToUpper(m.Method), m.Path, method)
+	}
+
+	return routes, streams, nil
+}
+
+// buildHttpRoutes builds the routes of the mappings of up, the http upstream is reused
+// if its conf is unchanged.
+func (s *Server) buildHttpRoutes(up Upstream) (*httpUpstream, []rest.Route, error) {
+	upstream, ok := s.httpUpstreams[up.Name]
+	reused := ok && reflect.DeepEqual(upstream.conf, *up.Http)
+	if !reused {
+		var err error
+		upstream, err = newHttpUpstream(up.Name, *up.Http, s.response.ErrorEnvelope)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", up.Name, err)
+		}
+	}
+
+	routes, err := upstream.routes(up.Mappings, s.timeout.Milliseconds())
+	if err != nil {
+		if !reused {
+			upstream.close()
+		}
+		return nil, nil, err
+	}
+
+	return upstream, routes, nil
+}
+
+// buildHandler builds the handler of the rpc method. The client streams are read from
+// the request body as newline delimited json, and the server streams are written as
+// newline delimited json or server-sent events by the Accept header, one message at a time.
+func (s *Server) buildHandler(target grpcTarget, method internal.Method) func(http.ResponseWriter,
+	*http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var parser grpcurl.RequestParser
+		var err error
+		if method.ClientStreaming {
+			parser, err = internal.NewStreamRequestParser(r, target.resolver)
+		} else {
+			parser, err = internal.NewRequestParser(r, target.resolver)
+		}
+		if err != nil {
+			s.writeError(w, r, target, err)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), internal.GetTimeout(r.Header, s.timeout))
+		defer cancel()
+
+		// the unary responses are buffered to be wrapped.
+		var buf bytes.Buffer
+		var handler *internal.EventHandler
+		switch {
+		case method.ServerStreaming:
+			contentType := internal.GetStreamContentType(r.Header)
+			w.Header().Set(httpx.ContentType, contentType)
+			handler = internal.NewStreamEventHandler(w, target.resolver, contentType)
+		case s.response.WrapData:
+			handler = internal.NewEventHandler(&buf, target.resolver)
+		default:
+			w.Header().Set(httpx.ContentType, httpx.JsonContentType)
+			handler = internal.NewEventHandler(w, target.resolver)
+		}
+
+		if err := grpcurl.InvokeRPC(ctx, target.source, target.cli.Conn(), method.RpcPath,
+			s.prepareMetadata(r.Header), handler, parser.Next); err != nil {
+			// the stream might break after the messages written, like on the bad client messages,
+			// the headers can't be written again.
+			if handler.Written() {
+				handler.WriteStatus(toStatus(err))
+			} else {
+				s.writeError(w, r, target, err)
+			}
+			return
+		}
+
+		st := handler.Status
+		if st.Code() == codes.OK {
+			if s.response.WrapData && !method.ServerStreaming {
+				internal.WriteDataEnvelope(w, buf.Bytes())
+			}
+			return
+		}
+
+		// the status code has been sent with the messages of the stream,
+		// so the error is written as the last message.
+		if handler.Written() {
+			handler.WriteStatus(st)
+		} else {
+			s.writeError(w, r, target, st.Err())
+		}
+	}
+}
+
+// writeError writes err with the http status code mapped from its grpc code,
+// the errors that are not grpc errors are treated as InvalidArgument.
+func (s *Server) writeError(w http.ResponseWriter, r *http.Request, target grpcTarget, err error) {
+	st := toStatus(err)
+	code := target.mapper.HttpStatus(st.Code())
+	if s.response.ErrorEnvelope {
+		internal.WriteErrorEnvelope(w, code, st, target.resolver)
+		return
+	}
+
+	httpx.ErrorCtx(r.Context(), w, err, func(w http.ResponseWriter, err error) {
+		http.Error(w, err.Error(), code)
+	})
+}
+
+// createDescriptorSource creates the descriptor source of up, the reflection client is
+// returned if the source is from the server reflection, which should be reset after use.
+func (s *Server) createDescriptorSource(cli zrpc.Client, up Upstream) (grpcurl.DescriptorSource,
+	*grpcreflect.Client, error) {
+	if len(up.ProtoSets) > 0 {
+		source, err := grpcurl.DescriptorSourceFromProtoSets(up.ProtoSets...)
+		return source, nil, err
+	}
+
+	client := grpcreflect.NewClientAuto(context.Background(), cli.Conn())
+	return grpcurl.DescriptorSourceFromServer(context.Background(), client), client, nil
+}
+
+func (s *Server) dial(conf zrpc.RpcClientConf) (zrpc.Client, error) {
+	if s.dialer != nil {
+		return s.dialer(conf), nil
+	}
+
+	return zrpc.NewClient(conf)
+}
+
+func (s *Server) prepareMetadata(header http.Header) []string {
+	vals := internal.ProcessHeaders(header)
+	if s.processHeader != nil {
+		vals = append(vals, s.processHeader(header)...)
+	}
+
+	return vals
+}
+
+// WithHeaderProcessor sets a processor to process request headers.
+// The returned headers are used as metadata to invoke the RPC.
+func WithHeaderProcessor(processHeader func(http.Header) []string) func(*Server) {
+	return func(s *Server) {
+		s.processHeader = processHeader
+	}
+}
+
+// withDialer sets a dialer to create a gRPC client.
+func withDialer(dialer func(conf zrpc.RpcClientConf) zrpc.Client) func(*Server) {
+	return func(s *Server) {
+		s.dialer = dialer
+	}
+}
+
+// release resets the reflection clients before closing the grpc clients that they use,
+// and closes the http upstreams.
+func (r resources) release() {
+	for _, reflection := range r.reflections {
+		reflection.Reset()
+	}
+	for _, cli := range r.clients {
+		if err := cli.Conn().Close(); err != nil {
+			logx.Error(err)
+		}
+	}
+	for _, upstream := range r.httpUpstreams {
+		upstream.close()
+	}
+}
+
+// normalizeUpstreams returns a copy of upstreams with the names ensured.
+func normalizeUpstreams(upstreams []Upstream) ([]Upstream, error) {
+	normalized := make([]Upstream, len(upstreams))
+	copy(normalized, upstreams)
+	for i, up := range normalized {
+		if len(up.Name) > 0 {
+			continue
+		}
+
+		if up.Http != nil {
+			if len(up.Http.Targets) > 0 {
+				normalized[i].Name = up.Http.Targets[0]
+			} else {
+				normalized[i].Name = up.Http.Etcd.Key
+			}
+			continue
+		}
+
+		target, err := up.Grpc.BuildTarget()
+		if err != nil {
+			return nil, err
+		}
+
+		normalized[i].Name = target
+	}
+
+	return normalized, nil
+}
+
+// toStatus converts err to the grpc status, the errors that are not grpc errors
+// are treated as InvalidArgument.
+func toStatus(err error) *status.Status {
+	st, ok := status.FromError(err)
+	if !ok {
+		st = status.New(codes.InvalidArgument, err.Error())
+	}
+
+	return st
+}
@@ -0,0 +1,484 @@
+package gateway
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jialequ/linux-sdk/core/logx"
+	"github.com/jialequ/linux-sdk/rest"
+	"github.com/jialequ/linux-sdk/zrpc"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/interop/grpc_testing"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+func init() {
+	logx.Disable()
+}
+
+func TestServerUnary(t *testing.T) {
+	svr := newTestServer(t, RouteMapping{
+		Method:  "post",
+		Path:    "/unary",
+		RpcPath: "grpc.testing.TestService/UnaryCall",
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/unary", strings.NewReader(`{"responseSize": 2}`))
+	w := httptest.NewRecorder()
+	svr.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `"body":"AAA="`)
+
+	req = httptest.NewRequest(http.MethodPost, "/unary", strings.NewReader(`{"responseSize": -1}`))
+	w = httptest.NewRecorder()
+	svr.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestServerServerStreaming(t *testing.T) {
+	svr := newTestServer(t, RouteMapping{
+		Method:  "post",
+		Path:    "/output",
+		RpcPath: "grpc.testing.TestService/StreamingOutputCall",
+	})
+	body := `{"responseParameters": [{"size": 1}, {"size": 2}]}`
+
+	t.Run("ndjson", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/output", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		svr.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+		assert.True(t, w.Flushed)
+		lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+		if assert.Len(t, lines, 2) {
+			assert.Contains(t, lines[0], `"body":"AA=="`)
+			assert.Contains(t, lines[1], `"body":"AAA="`)
+		}
+	})
+
+	t.Run("sse", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/output", strings.NewReader(body))
+		req.Header.Set("Accept", "text/event-stream")
+		w := httptest.NewRecorder()
+		svr.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+		events := strings.Split(strings.TrimSpace(w.Body.String()), "\n\n")
+		if assert.Len(t, events, 2) {
+			assert.True(t, strings.HasPrefix(events[0], "data: "))
+			assert.Contains(t, events[1], `"body":"AAA="`)
+		}
+	})
+
+	t.Run("error after messages", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/output",
+			strings.NewReader(`{"responseParameters": [{"size": 1}, {"size": -1}]}`))
+		req.Header.Set("Accept", "text/event-stream")
+		w := httptest.NewRecorder()
+		svr.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "event: error\ndata: {\"code\":3,")
+	})
+
+	t.Run("error before messages", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/output",
+			strings.NewReader(`{"responseParameters": [{"size": -1}]}`))
+		w := httptest.NewRecorder()
+		svr.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/output",
+			strings.NewReader(`{"responseParameters": [{"size": 1}, {"size": 1, "intervalUs": 1000000}]}`))
+		req.Header.Set("Accept", "application/x-ndjson")
+		req.Header.Set("Grpc-Timeout", "100ms")
+		w := httptest.NewRecorder()
+		start := time.Now()
+		svr.ServeHTTP(w, req)
+		assert.True(t, time.Since(start) < time.Second)
+		lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+		if assert.Len(t, lines, 2) {
+			assert.Contains(t, lines[1], `{"error":{"code":4,`)
+		}
+	})
+}
+
+func TestServerStreamingIgnoreRestTimeout(t *testing.T) {
+	c := newTestConf(startTestService(t), RouteMapping{
+		Method:  "post",
+		Path:    "/output",
+		RpcPath: "grpc.testing.TestService/StreamingOutputCall",
+	})
+	c.Timeout = 100
+	c.Middlewares.Timeout = true
+	svr := newTestGatewayWithConf(t, c)
+	assert.NoError(t, svr.build())
+
+	req := httptest.NewRequest(http.MethodPost, "/output",
+		strings.NewReader(`{"responseParameters": [{"size": 1}, {"size": 1, "intervalUs": 300000}]}`))
+	req.Header.Set("Grpc-Timeout", "2s")
+	w := httptest.NewRecorder()
+	svr.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if assert.Len(t, lines, 2) {
+		assert.Contains(t, lines[1], `"body":"AA=="`)
+	}
+}
+
+func TestServerClientStreaming(t *testing.T) {
+	svr := newTestServer(t, RouteMapping{
+		Method:  "post",
+		Path:    "/input",
+		RpcPath: "grpc.testing.TestService/StreamingInputCall",
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/input",
+		strings.NewReader("{\"payload\": {\"body\": \"YWJj\"}}\n{\"payload\": {\"body\": \"ZA==\"}}\n"))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	w := httptest.NewRecorder()
+	svr.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"aggregatedPayloadSize":4}`, w.Body.String())
+}
+
+func TestServerBidiStreamingBadMessage(t *testing.T) {
+	svr := newTestServer(t, RouteMapping{
+		Method:  "post",
+		Path:    "/duplex",
+		RpcPath: "grpc.testing.TestService/FullDuplexCall",
+	})
+
+	reader, writer := io.Pipe()
+	go func() {
+		_, _ = writer.Write([]byte("{\"payload\": {\"body\": \"YWJj\"}}\n"))
+		// wait for the response of the first message to be written.
+		time.Sleep(time.Millisecond * 300)
+		_, _ = writer.Write([]byte("bad\n"))
+		_ = writer.Close()
+	}()
+	req := httptest.NewRequest(http.MethodPost, "/duplex", reader)
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	w := httptest.NewRecorder()
+	svr.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if assert.Len(t, lines, 2) {
+		assert.Contains(t, lines[0], `"body":"YWJj"`)
+		assert.Contains(t, lines[1], `{"error":{"code":3,`)
+	}
+}
+
+func TestServerBuildMissingMethod(t *testing.T) {
+	svr := newTestGateway(t, startTestService(t), RouteMapping{
+		Method:  "get",
+		Path:    "/missing",
+		RpcPath: "grpc.testing.TestService/Missing",
+	})
+	assert.Error(t, svr.build())
+}
+
+func TestServerStatusCodes(t *testing.T) {
+	c := newTestConf(startTestService(t), RouteMapping{
+		Method:  "post",
+		Path:    "/unary",
+		RpcPath: "grpc.testing.TestService/UnaryCall",
+	})
+	c.Upstreams[0].StatusCodes = map[string]int{
+		"INVALID_ARGUMENT": http.StatusUnprocessableEntity,
+	}
+	svr := newTestGatewayWithConf(t, c)
+	assert.NoError(t, svr.build())
+
+	req := httptest.NewRequest(http.MethodPost, "/unary", strings.NewReader(`{"responseSize": -1}`))
+	w := httptest.NewRecorder()
+	svr.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "negative size")
+
+	c.Upstreams[0].StatusCodes = map[string]int{
+		"NOT_EXIST": http.StatusOK,
+	}
+	assert.Error(t, newTestGatewayWithConf(t, c).build())
+}
+
+func TestServerResponseEnvelopes(t *testing.T) {
+	c := newTestConf(startTestService(t), RouteMapping{
+		Method:  "post",
+		Path:    "/unary",
+		RpcPath: "grpc.testing.TestService/UnaryCall",
+	}, RouteMapping{
+		Method:  "post",
+		Path:    "/output",
+		RpcPath: "grpc.testing.TestService/StreamingOutputCall",
+	})
+	c.Response = ResponseConf{
+		ErrorEnvelope: true,
+		WrapData:      true,
+	}
+	svr := newTestGatewayWithConf(t, c)
+	assert.NoError(t, svr.build())
+
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		code   int
+		expect []string
+	}{
+		{
+			name:   "data",
+			path:   "/unary",
+			body:   `{"responseSize": 2}`,
+			code:   http.StatusOK,
+			expect: []string{`{"code":0,"msg":"ok","data":{`, `"body":"AAA="`},
+		},
+		{
+			name:   "rpc error",
+			path:   "/unary",
+			body:   `{"responseSize": -1}`,
+			code:   http.StatusBadRequest,
+			expect: []string{`{"code":3,"message":"negative size","details":[]}`},
+		},
+		{
+			name:   "bad request",
+			path:   "/unary",
+			body:   `{"responseSize": "a"}`,
+			code:   http.StatusBadRequest,
+			expect: []string{`{"code":3,"message":"`},
+		},
+		{
+			name:   "stream error",
+			path:   "/output",
+			body:   `{"responseParameters": [{"size": -1}]}`,
+			code:   http.StatusBadRequest,
+			expect: []string{`{"code":3,"message":"negative size","details":[]}`},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, test.path, strings.NewReader(test.body))
+			w := httptest.NewRecorder()
+			svr.ServeHTTP(w, req)
+			assert.Equal(t, test.code, w.Code)
+			assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+			for _, expect := range test.expect {
+				assert.Contains(t, w.Body.String(), expect)
+			}
+		})
+	}
+
+	// the server streams are not wrapped.
+	req := httptest.NewRequest(http.MethodPost, "/output",
+		strings.NewReader(`{"responseParameters": [{"size": 1}]}`))
+	w := httptest.NewRecorder()
+	svr.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.HasPrefix(w.Body.String(), `{"payload":`))
+}
+
+func TestWithHeaderProcessor(t *testing.T) {
+	svr := &Server{}
+	WithHeaderProcessor(func(header http.Header) []string {
+		return []string{"foo:" + header.Get("Foo")}
+	})(svr)
+
+	header := make(http.Header)
+	header.Set("Foo", "bar")
+	header.Set("Grpc-Metadata-A", "b")
+	assert.ElementsMatch(t, []string{"foo:bar", "gateway-A:b"}, svr.prepareMetadata(header))
+}
+
+type testService struct {
+	grpc_testing.UnimplementedTestServiceServer
+}
+
+func (s testService) UnaryCall(_ context.Context, req *grpc_testing.SimpleRequest) (
+	*grpc_testing.SimpleResponse, error) {
+	if req.ResponseSize < 0 {
+		return nil, status.Error(codes.InvalidArgument, "negative size")
+	}
+
+	return &grpc_testing.SimpleResponse{
+		Payload: &grpc_testing.Payload{Body: make([]byte, req.ResponseSize)},
+	}, nil
+}
+
+func (s testService) StreamingOutputCall(req *grpc_testing.StreamingOutputCallRequest,
+	stream grpc_testing.TestService_StreamingOutputCallServer) error {
+	for _, param := range req.ResponseParameters {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-time.After(time.Duration(param.IntervalUs) * time.Microsecond):
+		}
+
+		if param.Size < 0 {
+			return status.Error(codes.InvalidArgument, "negative size")
+		}
+
+		if err := stream.Send(&grpc_testing.StreamingOutputCallResponse{
+			Payload: &grpc_testing.Payload{Body: make([]byte, param.Size)},
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s testService) StreamingInputCall(stream grpc_testing.TestService_StreamingInputCallServer) error {
+	var size int32
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&grpc_testing.StreamingInputCallResponse{
+				AggregatedPayloadSize: size,
+			})
+		}
+		if err != nil {
+			return err
+		}
+
+		size += int32(len(req.GetPayload().GetBody()))
+	}
+}
+
+func (s testService) FullDuplexCall(stream grpc_testing.TestService_FullDuplexCallServer) error {
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err = stream.Send(&grpc_testing.StreamingOutputCallResponse{
+			Payload: req.GetPayload(),
+		}); err != nil {
+			return err
+		}
+	}
+}
+
+func startTestService(t *testing.T) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := grpc.NewServer()
+	grpc_testing.RegisterTestServiceServer(server, testService{})
+	reflection.Register(server)
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	t.Cleanup(server.Stop)
+
+	return lis.Addr().String()
+}
+
+func newTestGateway(t *testing.T, target string, mappings ...RouteMapping) *Server {
+	return newTestGatewayWithConf(t, newTestConf(target, mappings...))
+}
+
+func newTestGatewayWithConf(t *testing.T, c GatewayConf) *Server {
+	return MustNewServer(c, withDialer(func(conf zrpc.RpcClientConf) zrpc.Client {
+		cli, err := zrpc.NewClientWithTarget(conf.Target)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return cli
+	}))
+}
+
+func newTestConf(target string, mappings ...RouteMapping) GatewayConf {
+	return GatewayConf{
+		RestConf: rest.RestConf{
+			Host:    "localhost",
+			Port:    0,
+			Timeout: 3000,
+		},
+		Upstreams: []Upstream{
+			{
+				Grpc: zrpc.RpcClientConf{
+					Target: target,
+				},
+				Mappings: mappings,
+			},
+		},
+	}
+}
+
+func newTestServer(t *testing.T, mappings ...RouteMapping) *Server {
+	svr := newTestGateway(t, startTestService(t), mappings...)
+	if err := svr.build(); err != nil {
+		t.Fatal(err)
+	}
+
+	return svr
+}
+
+func TestServerHttpUpstream(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+	defer backend.Close()
+
+	svr := MustNewServer(GatewayConf{
+		RestConf: rest.RestConf{
+			Host:    "localhost",
+			Timeout: 3000,
+		},
+		Upstreams: []Upstream{
+			{
+				Http: &HttpClientConf{
+					Targets: []string{backend.URL},
+					Timeout: 1000,
+				},
+				Mappings: []RouteMapping{
+					{
+						Method:  "get",
+						Path:    "/users/:id",
+						Rewrite: "/v2/users/:id",
+					},
+				},
+			},
+		},
+	})
+	assert.NoError(t, svr.build())
+	assert.Equal(t, backend.URL, svr.upstreams[0].Name)
+
+	w := httptest.NewRecorder()
+	svr.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/1", http.NoBody))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "/v2/users/1", w.Body.String())
+
+	svr = MustNewServer(GatewayConf{
+		RestConf: rest.RestConf{
+			Host: "localhost",
+		},
+		Upstreams: []Upstream{
+			{
+				Http: &HttpClientConf{},
+			},
+		},
+	})
+	assert.Error(t, svr.build())
+}
@@ -0,0 +1,276 @@
+package fault
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jialequ/linux-sdk/core/conf"
+	"github.com/jialequ/linux-sdk/core/filex"
+	"github.com/jialequ/linux-sdk/core/logx"
+	"github.com/jialequ/linux-sdk/core/mathx"
+)
+
+const (
+	wildcard   = "*"
+	percentage = 100
+)
+
+type (
+	// A Conf is the fault injection config.
+	Conf struct {
+		// Header is the http header or grpc metadata key to turn on fault injection,
+		// only the requests with a true value on it are injected.
+		Header string `json:",default=X-Fault-Inject"`
+		// File is the json or yaml file that contains the rules,
+		// the rules are reloaded on changes, and take precedence over Rules.
+		File  string     `json:",optional"`
+		Rules []RuleConf `json:",optional"`
+	}
+
+	// A RuleConf is the config of a fault injection rule.
+	RuleConf struct {
+		// Routes are the http paths or grpc full methods to match, empty means all.
+		// A trailing * matches by prefix, like /api/* or /pkg.Service/*.
+		Routes []string `json:",optional"`
+		// Percent is the percentage of the matched requests to inject, 100 by default.
+		Percent float64 `json:",default=100,range=[0:100]"`
+		// Delay is the delay before handling the requests.
+		Delay time.Duration `json:",optional"`
+		// MaxDelay makes the delay a random duration in [Delay, MaxDelay).
+		MaxDelay time.Duration `json:",optional"`
+		// Abort is the http status or grpc code to abort the requests with,
+		// the http statuses are mapped to grpc codes on grpc calls, and vice versa.
+		Abort int `json:",optional"`
+		// Drop drops the responses after the requests are handled.
+		Drop bool `json:",optional"`
+	}
+
+	// A Fault is the fault to inject into a request.
+	Fault struct {
+		Delay time.Duration
+		Abort int
+		Drop  bool
+	}
+
+	// An Injector decides which faults to inject into the requests.
+	Injector struct {
+		header string
+		rules  *atomic.Value
+		source *fileSource
+		proba  *mathx.Proba
+		r      *rand.Rand
+		lock   sync.Mutex
+		once   sync.Once
+	}
+
+	// fileSource loads the rules from a file and reloads them on changes,
+	// it's shared by the injectors on the same file to watch the file only once.
+	fileSource struct {
+		file    string
+		rules   atomic.Value
+		watcher *filex.Watcher
+		refs    int
+	}
+
+	rulesFile struct {
+		Rules []RuleConf `json:",optional"`
+	}
+)
+
+var (
+	sources     = make(map[string]*fileSource)
+	sourcesLock sync.Mutex
+)
+
+// NewInjector returns an Injector with the given config.
+func NewInjector(c Conf) (*Injector, error) {
+	injector := &Injector{
+		header: c.Header,
+		proba:  mathx.NewProba(),
+		r:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+
+	if len(c.File) == 0 {
+		if err := validateRules(c.Rules); err != nil {
+			return nil, err
+		}
+
+		injector.rules = new(atomic.Value)
+		injector.Update(c.Rules)
+		return injector, nil
+	}
+
+	source, err := acquireSource(c.File)
+	if err != nil {
+		return nil, err
+	}
+
+	injector.rules = &source.rules
+	injector.source = source
+	return injector, nil
+}
+
+// Enabled checks if the given flag value turns on fault injection.
+func Enabled(flag string) bool {
+	ok, err := strconv.ParseBool(flag)
+	return err == nil && ok
+}
+
+// Wait waits for the given delay, returns the ctx error if ctx is done before that.
+func Wait(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Header returns the header or metadata key that turns on fault injection.
+func (i *Injector) Header() string {
+	return i.header
+}
+
+// Inject returns the fault of the first hit rule to inject into the request on given route,
+// returns false if nothing to inject.
+func (i *Injector) Inject(route string) (Fault, bool) {
+	rules, _ := i.rules.Load().([]RuleConf)
+	for _, rule := range rules {
+		if !matchRoutes(rule.Routes, route) {
+			continue
+		}
+
+		if !i.proba.TrueOnProba(rule.Percent / percentage) {
+			continue
+		}
+
+		return Fault{
+			Delay: i.delay(rule),
+			Abort: rule.Abort,
+			Drop:  rule.Drop,
+		}, true
+	}
+
+	return Fault{}, false
+}
+
+// Stop stops watching the rules file if no other injectors are on it.
+func (i *Injector) Stop() {
+	if i.source != nil {
+		i.once.Do(func() {
+			releaseSource(i.source)
+		})
+	}
+}
+
+// Update replaces the rules with the given ones.
+// The rules are shared by the injectors on the same file, if the rules are from a file.
+func (i *Injector) Update(rules []RuleConf) {
+	i.rules.Store(append([]RuleConf(nil), rules...))
+}
+
+func (i *Injector) delay(rule RuleConf) time.Duration {
+	if rule.MaxDelay <= rule.Delay {
+		return rule.Delay
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	return rule.Delay + time.Duration(i.r.Int63n(int64(rule.MaxDelay-rule.Delay)))
+}
+
+func (s *fileSource) load() error {
+	var rf rulesFile
+	if err := conf.Load(s.file, &rf); err != nil {
+		return err
+	}
+	if err := validateRules(rf.Rules); err != nil {
+		return err
+	}
+
+	s.rules.Store(rf.Rules)
+	logx.Infof("fault injection: loaded %d rules from %s", len(rf.Rules), s.file)
+
+	return nil
+}
+
+func acquireSource(file string) (*fileSource, error) {
+	sourcesLock.Lock()
+	defer sourcesLock.Unlock()
+
+	if source, ok := sources[file]; ok {
+		source.refs++
+		return source, nil
+	}
+
+	source := &fileSource{
+		file: file,
+		refs: 1,
+	}
+	if err := source.load(); err != nil {
+		return nil, err
+	}
+
+	watcher, err := filex.NewWatcher(file, func() {
+		if err := source.load(); err != nil {
+			logx.Errorf("fault injection: failed to reload rules from %s, error: %v", file, err)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	source.watcher = watcher
+	sources[file] = source
+	return source, nil
+}
+
+func releaseSource(source *fileSource) {
+	sourcesLock.Lock()
+	defer sourcesLock.Unlock()
+
+	source.refs--
+	if source.refs == 0 {
+		source.watcher.Stop()
+		delete(sources, source.file)
+	}
+}
+
+func validateRules(rules []RuleConf) error {
+	for _, rule := range rules {
+		if rule.Abort != 0 && !isGrpcCode(rule.Abort) && !isHttpStatus(rule.Abort) {
+			return fmt.Errorf("fault injection: abort %d is neither a grpc code nor an http status",
+				rule.Abort)
+		}
+	}
+
+	return nil
+}
+
+func matchRoutes(routes []string, route string) bool {
+	if len(routes) == 0 {
+		return true
+	}
+
+	for _, each := range routes {
+		if strings.HasSuffix(each, wildcard) {
+			if strings.HasPrefix(route, strings.TrimSuffix(each, wildcard)) {
+				return true
+			}
+		} else if each == route {
+			return true
+		}
+	}
+
+	return false
+}
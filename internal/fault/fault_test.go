@@ -0,0 +1,195 @@
+package fault
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jialequ/linux-sdk/core/fs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnabled(t *testing.T) {
+	assert.True(t, Enabled("true"))
+	assert.True(t, Enabled("1"))
+	assert.False(t, Enabled(""))
+	assert.False(t, Enabled("false"))
+	assert.False(t, Enabled("foo"))
+}
+
+func TestWait(t *testing.T) {
+	assert.Nil(t, Wait(context.Background(), time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, Wait(ctx, time.Second), context.Canceled)
+}
+
+func TestInjectorInject(t *testing.T) {
+	injector, err := NewInjector(Conf{
+		Header: "X-Fault",
+		Rules: []RuleConf{
+			{
+				Routes:  []string{"/api/never"},
+				Percent: 0,
+				Abort:   500,
+			},
+			{
+				Routes:  []string{"/api/abort", "/pkg.Service/*"},
+				Percent: 100,
+				Abort:   503,
+			},
+			{
+				Routes:   []string{"/api/delay"},
+				Percent:  100,
+				Delay:    time.Millisecond,
+				MaxDelay: time.Millisecond * 10,
+				Drop:     true,
+			},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "X-Fault", injector.Header())
+
+	_, ok := injector.Inject("/api/never")
+	assert.False(t, ok)
+	_, ok = injector.Inject("/api/other")
+	assert.False(t, ok)
+
+	f, ok := injector.Inject("/api/abort")
+	assert.True(t, ok)
+	assert.Equal(t, 503, f.Abort)
+
+	f, ok = injector.Inject("/pkg.Service/Method")
+	assert.True(t, ok)
+	assert.Equal(t, 503, f.Abort)
+
+	f, ok = injector.Inject("/api/delay")
+	assert.True(t, ok)
+	assert.True(t, f.Drop)
+	assert.True(t, f.Delay >= time.Millisecond && f.Delay < time.Millisecond*10)
+}
+
+func TestInjectorMatchAll(t *testing.T) {
+	injector, err := NewInjector(Conf{
+		Rules: []RuleConf{
+			{
+				Percent: 100,
+				Delay:   time.Second,
+			},
+		},
+	})
+	assert.Nil(t, err)
+
+	f, ok := injector.Inject("/any")
+	assert.True(t, ok)
+	assert.Equal(t, time.Second, f.Delay)
+
+	injector.Update(nil)
+	_, ok = injector.Inject("/any")
+	assert.False(t, ok)
+}
+
+func TestInjectorWithFile(t *testing.T) {
+	file, err := fs.TempFilenameWithText(`{"Rules": [{"Routes": ["/foo"], "Percent": 100, "Abort": 500}]}`)
+	assert.Nil(t, err)
+	defer os.Remove(file)
+
+	jsonFile := file + ".json"
+	assert.Nil(t, os.Rename(file, jsonFile))
+	defer os.Remove(jsonFile)
+
+	injector, err := NewInjector(Conf{
+		File: jsonFile,
+	})
+	assert.Nil(t, err)
+
+	f, ok := injector.Inject("/foo")
+	assert.True(t, ok)
+	assert.Equal(t, 500, f.Abort)
+}
+
+func TestInjectorDefaultPercent(t *testing.T) {
+	file, err := fs.TempFilenameWithText(`{"Rules": [{"Routes": ["/foo"], "Abort": 500}]}`)
+	assert.Nil(t, err)
+	defer os.Remove(file)
+
+	jsonFile := file + ".json"
+	assert.Nil(t, os.Rename(file, jsonFile))
+	defer os.Remove(jsonFile)
+
+	injector, err := NewInjector(Conf{
+		File: jsonFile,
+	})
+	assert.Nil(t, err)
+	defer injector.Stop()
+
+	for i := 0; i < 10; i++ {
+		_, ok := injector.Inject("/foo")
+		assert.True(t, ok)
+	}
+}
+
+func TestInjectorShareFile(t *testing.T) {
+	file, err := fs.TempFilenameWithText(`{"Rules": [{"Routes": ["/foo"], "Percent": 100, "Abort": 500}]}`)
+	assert.Nil(t, err)
+	defer os.Remove(file)
+
+	jsonFile := file + ".json"
+	assert.Nil(t, os.Rename(file, jsonFile))
+	defer os.Remove(jsonFile)
+
+	first, err := NewInjector(Conf{
+		File: jsonFile,
+	})
+	assert.Nil(t, err)
+	second, err := NewInjector(Conf{
+		File: jsonFile,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, first.source, second.source)
+	assert.Equal(t, 2, first.source.refs)
+
+	first.Stop()
+	first.Stop()
+	assert.Equal(t, 1, second.source.refs)
+	f, ok := second.Inject("/foo")
+	assert.True(t, ok)
+	assert.Equal(t, 500, f.Abort)
+
+	second.Stop()
+	sourcesLock.Lock()
+	_, ok = sources[jsonFile]
+	sourcesLock.Unlock()
+	assert.False(t, ok)
+}
+
+func TestInjectorBadAbort(t *testing.T) {
+	_, err := NewInjector(Conf{
+		Rules: []RuleConf{
+			{
+				Percent: 100,
+				Abort:   20,
+			},
+		},
+	})
+	assert.NotNil(t, err)
+
+	_, err = NewInjector(Conf{
+		Rules: []RuleConf{
+			{
+				Percent: 100,
+				Abort:   600,
+			},
+		},
+	})
+	assert.NotNil(t, err)
+}
+
+func TestInjectorWithBadFile(t *testing.T) {
+	_, err := NewInjector(Conf{
+		File: "not-exists.json",
+	})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,104 @@
+package fault
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/jialequ/linux-sdk/core/logx"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	faultInjectedMessage = "fault injected"
+	maxGrpcCode          = int(codes.Unauthenticated)
+	minHttpStatus        = 100
+	maxHttpStatus        = 599
+)
+
+// maxDropTime is the max time to hold the dropped responses,
+// to not hold them forever on the servers without deadlines.
+var maxDropTime = time.Minute
+
+// DelayOrAbort delays the grpc call of method if f has a delay,
+// then returns the status error to abort with if f aborts.
+func DelayOrAbort(ctx context.Context, f Fault, method string) error {
+	if f.Delay > 0 {
+		logx.WithContext(ctx).Infof("fault injected, %s delayed %s", method, f.Delay)
+		if err := Wait(ctx, f.Delay); err != nil {
+			return status.FromContextError(err).Err()
+		}
+	}
+
+	if f.Abort > 0 {
+		code := GrpcCode(f.Abort)
+		logx.WithContext(ctx).Infof("fault injected, %s aborted with code %s", method, code)
+		return status.Error(code, faultInjectedMessage)
+	}
+
+	return nil
+}
+
+// DropResponse holds the response of the grpc call of method until ctx is done,
+// at most maxDropTime, to act like the response is lost.
+func DropResponse(ctx context.Context, method string) error {
+	logx.WithContext(ctx).Infof("fault injected, %s response dropped", method)
+	if err := Wait(ctx, maxDropTime); err != nil {
+		return status.FromContextError(err).Err()
+	}
+
+	return status.Error(codes.DeadlineExceeded, faultInjectedMessage)
+}
+
+// GrpcCode returns the grpc code of abort, the http statuses are mapped to the grpc codes.
+func GrpcCode(abort int) codes.Code {
+	if isGrpcCode(abort) {
+		return codes.Code(abort)
+	}
+
+	switch abort {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusRequestTimeout:
+		return codes.Canceled
+	case http.StatusConflict:
+		return codes.Aborted
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable, http.StatusBadGateway:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	default:
+		return codes.Unknown
+	}
+}
+
+// InjectMetadata returns the fault to inject into the grpc call of method,
+// if the fault injection is turned on in md.
+func (i *Injector) InjectMetadata(md metadata.MD, method string) (Fault, bool) {
+	vals := md.Get(i.header)
+	if len(vals) == 0 || !Enabled(vals[0]) {
+		return Fault{}, false
+	}
+
+	return i.Inject(method)
+}
+
+func isGrpcCode(abort int) bool {
+	return abort > 0 && abort <= maxGrpcCode
+}
+
+func isHttpStatus(abort int) bool {
+	return abort >= minHttpStatus && abort <= maxHttpStatus
+}
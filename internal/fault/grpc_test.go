@@ -0,0 +1,86 @@
+package fault
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestDelayOrAbort(t *testing.T) {
+	assert.Nil(t, DelayOrAbort(context.Background(), Fault{Delay: time.Millisecond}, "/foo"))
+
+	err := DelayOrAbort(context.Background(), Fault{Abort: int(codes.Unavailable)}, "/foo")
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	err = DelayOrAbort(context.Background(), Fault{Abort: http.StatusServiceUnavailable}, "/foo")
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = DelayOrAbort(ctx, Fault{Delay: time.Second}, "/foo")
+	assert.Equal(t, codes.Canceled, status.Code(err))
+}
+
+func TestDropResponse(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(DropResponse(ctx, "/foo")))
+}
+
+func TestDropResponseWithoutDeadline(t *testing.T) {
+	old := maxDropTime
+	maxDropTime = time.Millisecond
+	defer func() {
+		maxDropTime = old
+	}()
+
+	err := DropResponse(context.Background(), "/foo")
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	assert.Equal(t, faultInjectedMessage, status.Convert(err).Message())
+}
+
+func TestGrpcCode(t *testing.T) {
+	tests := []struct {
+		abort int
+		code  codes.Code
+	}{
+		{abort: int(codes.NotFound), code: codes.NotFound},
+		{abort: int(codes.Unauthenticated), code: codes.Unauthenticated},
+		{abort: http.StatusBadRequest, code: codes.InvalidArgument},
+		{abort: http.StatusTooManyRequests, code: codes.ResourceExhausted},
+		{abort: http.StatusServiceUnavailable, code: codes.Unavailable},
+		{abort: http.StatusGatewayTimeout, code: codes.DeadlineExceeded},
+		{abort: http.StatusInternalServerError, code: codes.Unknown},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.code, GrpcCode(test.abort))
+	}
+}
+
+func TestInjectorInjectMetadata(t *testing.T) {
+	injector, err := NewInjector(Conf{
+		Header: "X-Fault-Inject",
+		Rules: []RuleConf{
+			{
+				Percent: 100,
+				Abort:   int(codes.Internal),
+			},
+		},
+	})
+	assert.Nil(t, err)
+
+	_, ok := injector.InjectMetadata(metadata.MD{}, "/foo")
+	assert.False(t, ok)
+	_, ok = injector.InjectMetadata(metadata.Pairs("x-fault-inject", "false"), "/foo")
+	assert.False(t, ok)
+	f, ok := injector.InjectMetadata(metadata.Pairs("x-fault-inject", "true"), "/foo")
+	assert.True(t, ok)
+	assert.Equal(t, int(codes.Internal), f.Abort)
+}
@@ -4,9 +4,15 @@ import (
 	"time"
 
 	"github.com/jialequ/linux-sdk/core/service"
+	"github.com/jialequ/linux-sdk/internal/fault"
 )
 
 type (
+	// FaultConf is the config of fault injection.
+	FaultConf = fault.Conf
+	// FaultRuleConf is the config of a fault injection rule.
+	FaultRuleConf = fault.RuleConf
+
 	// MiddlewaresConf is the config of middlewares.
 	MiddlewaresConf struct {
 		Trace      bool `json:",default=true"`
@@ -20,6 +26,16 @@ type (
 		Metrics    bool `json:",default=true"`
 		MaxBytes   bool `json:",default=true"`
 		Gunzip     bool `json:",default=true"`
+		RequestId  bool `json:",default=true"`
+		// Canary routes the downstream calls by the traffic color in the X-Canary header,
+		// turn it on only if the header is set or stripped by the trusted proxies in front,
+		// otherwise anyone can send the requests to the canary instances.
+		Canary bool `json:",optional"`
+		// Budget bounds the requests with the deadline budget in the header from the callers.
+		Budget bool `json:",default=true"`
+		// Fault turns on the fault injection, only for resilience drills.
+		Fault     bool      `json:",optional"`
+		FaultConf FaultConf `json:",optional"`
 	}
 
 	// A PrivateKeyConf is a private key config.
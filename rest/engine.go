@@ -6,11 +6,13 @@ import (
 	"fmt"
 	"net/http"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/jialequ/linux-sdk/core/codec"
 	"github.com/jialequ/linux-sdk/core/load"
 	"github.com/jialequ/linux-sdk/core/stat"
+	"github.com/jialequ/linux-sdk/internal/fault"
 	"github.com/jialequ/linux-sdk/rest/chain"
 	"github.com/jialequ/linux-sdk/rest/handler"
 	"github.com/jialequ/linux-sdk/rest/httpx"
@@ -36,6 +38,10 @@ type engine struct {
 	shedder              load.Shedder
 	priorityShedder      load.Shedder
 	tlsConfig            *tls.Config
+	faultInjector        *fault.Injector
+	// bindMetrics is the metrics of the routes bound by bindExtraRoutes.
+	bindMetrics     *stat.Metrics
+	bindMetricsOnce sync.Once
 }
 
 func newEngine(c RestConf) *engine {
@@ -97,11 +103,17 @@ func (ng *engine) bindFeaturedRoutes(router httpx.Router, fr featuredRoutes, met
 func (ng *engine) bindRoute(fr featuredRoutes, router httpx.Router, metrics *stat.Metrics,
 	route Route, verifier func(chain.Chain) chain.Chain) error {
 	chn := ng.chain
-	if chn == nil {
+	native := chn == nil
+	if native {
 		chn = ng.buildChainWithNativeMiddlewares(fr, route, metrics)
 	}
 
 	chn = ng.appendAuthHandler(fr, chn, verifier)
+	// fault injection is placed after breaker, shedding and timeout to exercise them,
+	// and after auth to not let the unauthenticated requests inject faults.
+	if native && ng.faultInjector != nil {
+		chn = chn.Append(handler.FaultHandler(ng.faultInjector, route.Path))
+	}
 
 	for _, middleware := range ng.middlewares {
 		chn = chn.Append(convertMiddleware(middleware))
@@ -111,6 +123,16 @@ func (ng *engine) bindRoute(fr featuredRoutes, router httpx.Router, metrics *sta
 	return router.Handle(route.Method, route.Path, handle)
 }
 
+// bindExtraRoutes binds the routes that are not added to ng into router,
+// the metrics are shared across the calls.
+func (ng *engine) bindExtraRoutes(router httpx.Router, fr featuredRoutes) error {
+	ng.bindMetricsOnce.Do(func() {
+		ng.bindMetrics = ng.createMetrics()
+	})
+
+	return ng.bindFeaturedRoutes(router, fr, ng.bindMetrics)
+}
+
 func (ng *engine) bindRoutes(router httpx.Router) error {
 	metrics := ng.createMetrics()
 
@@ -127,11 +149,21 @@ func (ng *engine) buildChainWithNativeMiddlewares(fr featuredRoutes, route Route
 	metrics *stat.Metrics) chain.Chain {
 	chn := chain.New()
 
+	// placed first to get the writer of the server, not wrapped by the other middlewares.
+	if fr.streaming {
+		chn = chn.Append(handler.StreamingHandler)
+	}
 	if ng.conf.Middlewares.Trace {
 		chn = chn.Append(handler.TraceHandler(ng.conf.Name,
 			route.Path,
 			handler.WithTraceIgnorePaths(ng.conf.TraceIgnorePaths)))
 	}
+	if ng.conf.Middlewares.RequestId {
+		chn = chn.Append(handler.RequestIdHandler)
+	}
+	if ng.conf.Middlewares.Canary {
+		chn = chn.Append(handler.CanaryHandler)
+	}
 	if ng.conf.Middlewares.Log {
 		chn = chn.Append(ng.getLogHandler())
 	}
@@ -147,7 +179,10 @@ func (ng *engine) buildChainWithNativeMiddlewares(fr featuredRoutes, route Route
 	if ng.conf.Middlewares.Shedding {
 		chn = chn.Append(handler.SheddingHandler(ng.getShedder(fr.priority), metrics))
 	}
-	if ng.conf.Middlewares.Timeout {
+	if ng.conf.Middlewares.Budget {
+		chn = chn.Append(handler.BudgetHandler)
+	}
+	if ng.conf.Middlewares.Timeout && !fr.streaming {
 		chn = chn.Append(handler.TimeoutHandler(ng.checkedTimeout(fr.timeout)))
 	}
 	if ng.conf.Middlewares.Recover {
@@ -219,6 +254,9 @@ func (ng *engine) notFoundHandler(next http.Handler) http.Handler {
 				handler.WithTraceIgnorePaths(ng.conf.TraceIgnorePaths)),
 		)
 
+		if ng.conf.Middlewares.RequestId {
+			chn = chn.Append(handler.RequestIdHandler)
+		}
 		if ng.conf.Middlewares.Log {
 			chn = chn.Append(ng.getLogHandler())
 		}
@@ -253,6 +291,10 @@ func (ng *engine) print() {
 	}
 }
 
+func (ng *engine) setFaultInjector(injector *fault.Injector) {
+	ng.faultInjector = injector
+}
+
 func (ng *engine) setTlsConfig(cfg *tls.Config) {
 	ng.tlsConfig = cfg
 }
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/jialequ/linux-sdk/core/budget"
+	"github.com/jialequ/linux-sdk/core/logx"
+)
+
+// BudgetHandler returns a middleware that bounds the request context with the deadline budget
+// in the request header, and refuses the requests with the exhausted budget.
+func BudgetHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remaining, ok := budget.Parse(r.Header.Get(budget.HeaderKey))
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if remaining <= 0 {
+			logx.WithContext(r.Context()).Errorf("%s, path: %s, remaining: %s",
+				budget.ErrExhausted.Error(), r.URL.Path, remaining)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), remaining)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jialequ/linux-sdk/core/budget"
+	"github.com/jialequ/linux-sdk/core/logx/logtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBudgetHandler(t *testing.T) {
+	handler := BudgetHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline, ok := r.Context().Deadline()
+		assert.True(t, ok)
+		assert.True(t, time.Until(deadline) <= time.Second)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "http://localhost", http.NoBody)
+	req.Header.Set(budget.HeaderKey, "1000")
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestBudgetHandlerWithoutBudget(t *testing.T) {
+	handler := BudgetHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, ok := r.Context().Deadline()
+		assert.False(t, ok)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, val := range []string{"", "bad"} {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost", http.NoBody)
+		req.Header.Set(budget.HeaderKey, val)
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusOK, resp.Code)
+	}
+}
+
+func TestBudgetHandlerExhausted(t *testing.T) {
+	c := logtest.NewCollector(t)
+	handler := BudgetHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("should not be called")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "http://localhost/foo", http.NoBody)
+	req.Header.Set(budget.HeaderKey, "0")
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	assert.Contains(t, c.String(), "deadline budget exhausted")
+	assert.Contains(t, c.String(), "/foo")
+}
@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/jialequ/linux-sdk/core/canary"
+)
+
+// CanaryHandler returns a middleware that puts the traffic color in the request header
+// into the request context, to route the downstream calls to the instances of the color.
+// The header is trusted as is, so it should be set or stripped by the trusted proxies in front.
+func CanaryHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		color := r.Header.Get(canary.HeaderKey)
+		if !canary.IsValid(color) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(canary.NewContext(r.Context(), color)))
+	})
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jialequ/linux-sdk/core/canary"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanaryHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		expect string
+	}{
+		{
+			name:   "colored",
+			header: "v2",
+			expect: "v2",
+		},
+		{
+			name: "missing",
+		},
+		{
+			name:   "invalid",
+			header: "v 2",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var color string
+			handler := CanaryHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				color = canary.FromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "http://localhost", http.NoBody)
+			if len(test.header) > 0 {
+				req.Header.Set(canary.HeaderKey, test.header)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+			assert.Equal(t, test.expect, color)
+		})
+	}
+}
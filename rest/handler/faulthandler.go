@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/jialequ/linux-sdk/internal/fault"
+	"github.com/jialequ/linux-sdk/rest/internal"
+	"github.com/jialequ/linux-sdk/rest/internal/errcode"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// FaultHandler returns a middleware that injects faults into the requests on given path.
+// Only the requests with the injector header turned on are injected.
+func FaultHandler(injector *fault.Injector, path string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !fault.Enabled(r.Header.Get(injector.Header())) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			f, ok := injector.Inject(path)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if f.Delay > 0 {
+				internal.Infof(r, "fault injected, delayed %s", f.Delay)
+				if err := fault.Wait(r.Context(), f.Delay); err != nil {
+					return
+				}
+			}
+
+			if f.Abort > 0 {
+				code := httpStatus(f.Abort)
+				internal.Infof(r, "fault injected, aborted with code %d", code)
+				w.WriteHeader(code)
+				return
+			}
+
+			if f.Drop {
+				next.ServeHTTP(&droppedResponseWriter{header: make(http.Header)}, r)
+				internal.Info(r, "fault injected, response dropped")
+				// let the http server close the connection without a response
+				panic(http.ErrAbortHandler)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// httpStatus returns the http status of abort, the grpc codes are mapped to the http statuses.
+func httpStatus(abort int) int {
+	if abort < http.StatusContinue {
+		return errcode.CodeFromGrpcError(status.Error(codes.Code(abort), ""))
+	}
+
+	return abort
+}
+
+type droppedResponseWriter struct {
+	header http.Header
+}
+
+func (w *droppedResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *droppedResponseWriter) Write(bs []byte) (int, error) {
+	return len(bs), nil
+}
+
+func (w *droppedResponseWriter) WriteHeader(_ int) {
+}
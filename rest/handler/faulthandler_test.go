@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jialequ/linux-sdk/internal/fault"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+)
+
+const faultHeader = "X-Fault-Inject"
+
+func TestFaultHandler(t *testing.T) {
+	injector, err := fault.NewInjector(fault.Conf{
+		Header: faultHeader,
+		Rules: []fault.RuleConf{
+			{
+				Routes:  []string{"/abort"},
+				Percent: 100,
+				Delay:   time.Millisecond,
+				Abort:   http.StatusServiceUnavailable,
+			},
+			{
+				Routes:  []string{"/grpc"},
+				Percent: 100,
+				Abort:   int(codes.NotFound),
+			},
+			{
+				Routes:  []string{"/drop"},
+				Percent: 100,
+				Drop:    true,
+			},
+		},
+	})
+	assert.Nil(t, err)
+
+	tests := []struct {
+		name    string
+		path    string
+		flag    string
+		code    int
+		handled bool
+		panic   bool
+	}{
+		{
+			name:    "not enabled",
+			path:    "/abort",
+			code:    http.StatusOK,
+			handled: true,
+		},
+		{
+			name:    "not matched",
+			path:    "/foo",
+			flag:    "true",
+			code:    http.StatusOK,
+			handled: true,
+		},
+		{
+			name: "abort",
+			path: "/abort",
+			flag: "true",
+			code: http.StatusServiceUnavailable,
+		},
+		{
+			name: "grpc code",
+			path: "/grpc",
+			flag: "true",
+			code: http.StatusNotFound,
+		},
+		{
+			name:    "drop",
+			path:    "/drop",
+			flag:    "true",
+			handled: true,
+			panic:   true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var handled bool
+			handler := FaultHandler(injector, test.path)(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					handled = true
+					w.WriteHeader(http.StatusOK)
+				}))
+
+			req := httptest.NewRequest(http.MethodGet, "http://localhost"+test.path, http.NoBody)
+			if len(test.flag) > 0 {
+				req.Header.Set(faultHeader, test.flag)
+			}
+			resp := httptest.NewRecorder()
+			if test.panic {
+				assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+					handler.ServeHTTP(resp, req)
+				})
+			} else {
+				handler.ServeHTTP(resp, req)
+				assert.Equal(t, test.code, resp.Code)
+			}
+			assert.Equal(t, test.handled, handled)
+		})
+	}
+}
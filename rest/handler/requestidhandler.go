@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/jialequ/linux-sdk/core/requestid"
+)
+
+// RequestIdHandler returns a middleware that accepts or generates the request id,
+// puts it into the request context and writes it back in the response header.
+func RequestIdHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.HeaderKey)
+		if !requestid.IsValid(id) {
+			id = requestid.New()
+		}
+
+		w.Header().Set(requestid.HeaderKey, id)
+		next.ServeHTTP(w, r.WithContext(requestid.NewContext(r.Context(), id)))
+	})
+}
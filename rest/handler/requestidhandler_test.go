@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jialequ/linux-sdk/core/requestid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestIdHandler(t *testing.T) {
+	var id string
+	handler := RequestIdHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id = requestid.FromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "http://localhost", http.NoBody)
+	req.Header.Set(requestid.HeaderKey, "foo")
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	assert.Equal(t, "foo", id)
+	assert.Equal(t, "foo", resp.Header().Get(requestid.HeaderKey))
+}
+
+func TestRequestIdHandlerGenerate(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+	}{
+		{
+			name: "missing",
+		},
+		{
+			name:   "invalid",
+			header: "foo bar",
+		},
+		{
+			name:   "too long",
+			header: strings.Repeat("a", 1024),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var id string
+			handler := RequestIdHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				id = requestid.FromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "http://localhost", http.NoBody)
+			if len(test.header) > 0 {
+				req.Header.Set(requestid.HeaderKey, test.header)
+			}
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			assert.True(t, requestid.IsValid(id))
+			assert.NotEqual(t, test.header, id)
+			assert.Equal(t, id, resp.Header().Get(requestid.HeaderKey))
+		})
+	}
+}
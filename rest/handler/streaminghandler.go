@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/jialequ/linux-sdk/core/logx"
+)
+
+// StreamingHandler returns a middleware that clears the read and write deadlines of the
+// connection, which are set by the timeout of the server, for the long-lived streaming
+// requests and responses. It should be placed before the middlewares wrapping the writer.
+func StreamingHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rc := http.NewResponseController(w)
+		if err := rc.SetReadDeadline(time.Time{}); err != nil {
+			logx.WithContext(r.Context()).Errorf("clear read deadline of %s failed, error: %v",
+				r.URL.Path, err)
+		}
+		if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+			logx.WithContext(r.Context()).Errorf("clear write deadline of %s failed, error: %v",
+				r.URL.Path, err)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
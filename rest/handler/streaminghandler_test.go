@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamingHandler(t *testing.T) {
+	timeout := time.Millisecond * 100
+	streaming := func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 3; i++ {
+			time.Sleep(timeout)
+			_, _ = w.Write([]byte("a"))
+			http.NewResponseController(w).Flush()
+		}
+	}
+
+	serve := func(handler http.Handler) string {
+		svr := httptest.NewUnstartedServer(handler)
+		svr.Config.ReadTimeout = timeout
+		svr.Config.WriteTimeout = timeout
+		svr.Start()
+		defer svr.Close()
+
+		resp, err := http.Get(svr.URL)
+		if err != nil {
+			return ""
+		}
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	// the write timeout of the server cuts off the streams.
+	assert.NotEqual(t, "aaa", serve(http.HandlerFunc(streaming)))
+	assert.Equal(t, "aaa", serve(StreamingHandler(http.HandlerFunc(streaming))))
+}
+
+func TestStreamingHandlerNotSupported(t *testing.T) {
+	handler := StreamingHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "http://localhost", http.NoBody))
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
@@ -148,6 +148,21 @@ func TestTimeoutSSE(t *testing.T) {
 	assert.Equal(t, http.StatusOK, resp.Code)
 }
 
+func TestTimeoutNdjson(t *testing.T) {
+	timeoutHandler := TimeoutHandler(time.Millisecond)
+	handler := timeoutHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Millisecond * 10)
+		_, _ = w.Write([]byte("{}\n"))
+	}))
+
+	// the clients can't skip the timeout by the Accept header, use rest.WithStreaming instead.
+	req := httptest.NewRequest(http.MethodGet, literal_6347, http.NoBody)
+	req.Header.Set(headerAccept, "application/x-ndjson")
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+}
+
 func TestTimeoutWebsocket(t *testing.T) {
 	timeoutHandler := TimeoutHandler(time.Millisecond)
 	handler := timeoutHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,18 @@
+package internal
+
+import (
+	"net/http"
+
+	"github.com/jialequ/linux-sdk/core/budget"
+)
+
+// BudgetInterceptor propagates the remaining deadline budget in context through the http header.
+func BudgetInterceptor(r *http.Request) (*http.Request, ResponseHandler) {
+	if len(r.Header.Get(budget.HeaderKey)) == 0 {
+		if remaining, ok := budget.Remaining(r.Context()); ok {
+			r.Header.Set(budget.HeaderKey, budget.Format(remaining))
+		}
+	}
+
+	return r, func(*http.Response, error) {}
+}
@@ -0,0 +1,39 @@
+package internal
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jialequ/linux-sdk/core/budget"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBudgetInterceptor(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodGet, "http://localhost", http.NoBody)
+	req = req.WithContext(ctx)
+	req, _ = BudgetInterceptor(req)
+	remaining, ok := budget.Parse(req.Header.Get(budget.HeaderKey))
+	assert.True(t, ok)
+	assert.True(t, remaining > 0 && remaining <= time.Second)
+}
+
+func TestBudgetInterceptorKeepHeader(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodGet, "http://localhost", http.NoBody)
+	req = req.WithContext(ctx)
+	req.Header.Set(budget.HeaderKey, "100")
+	req, _ = BudgetInterceptor(req)
+	assert.Equal(t, "100", req.Header.Get(budget.HeaderKey))
+}
+
+func TestBudgetInterceptorWithoutDeadline(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://localhost", http.NoBody)
+	req, _ = BudgetInterceptor(req)
+	assert.Empty(t, req.Header.Get(budget.HeaderKey))
+}
@@ -0,0 +1,18 @@
+package internal
+
+import (
+	"net/http"
+
+	"github.com/jialequ/linux-sdk/core/canary"
+)
+
+// CanaryInterceptor propagates the traffic color in context through the http header.
+func CanaryInterceptor(r *http.Request) (*http.Request, ResponseHandler) {
+	if len(r.Header.Get(canary.HeaderKey)) == 0 {
+		if color := canary.FromContext(r.Context()); len(color) > 0 {
+			r.Header.Set(canary.HeaderKey, color)
+		}
+	}
+
+	return r, func(*http.Response, error) {}
+}
@@ -0,0 +1,31 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jialequ/linux-sdk/core/canary"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanaryInterceptor(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://localhost", http.NoBody)
+	req = req.WithContext(canary.NewContext(req.Context(), "v2"))
+	req, _ = CanaryInterceptor(req)
+	assert.Equal(t, "v2", req.Header.Get(canary.HeaderKey))
+}
+
+func TestCanaryInterceptorKeepHeader(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://localhost", http.NoBody)
+	req = req.WithContext(canary.NewContext(req.Context(), "v2"))
+	req.Header.Set(canary.HeaderKey, "v3")
+	req, _ = CanaryInterceptor(req)
+	assert.Equal(t, "v3", req.Header.Get(canary.HeaderKey))
+}
+
+func TestCanaryInterceptorWithoutColor(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://localhost", http.NoBody)
+	req, _ = CanaryInterceptor(req)
+	assert.Empty(t, req.Header.Get(canary.HeaderKey))
+}
@@ -0,0 +1,18 @@
+package internal
+
+import (
+	"net/http"
+
+	"github.com/jialequ/linux-sdk/core/requestid"
+)
+
+// RequestIdInterceptor propagates the request id in context through the http header.
+func RequestIdInterceptor(r *http.Request) (*http.Request, ResponseHandler) {
+	if len(r.Header.Get(requestid.HeaderKey)) == 0 {
+		if id := requestid.FromContext(r.Context()); len(id) > 0 {
+			r.Header.Set(requestid.HeaderKey, id)
+		}
+	}
+
+	return r, func(*http.Response, error) {}
+}
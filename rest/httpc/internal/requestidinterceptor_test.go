@@ -0,0 +1,40 @@
+package internal
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jialequ/linux-sdk/core/requestid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestIdInterceptor(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "foo", r.Header.Get(requestid.HeaderKey))
+	}))
+	defer svr.Close()
+	req, err := http.NewRequestWithContext(requestid.NewContext(context.Background(), "foo"),
+		http.MethodGet, svr.URL, nil)
+	assert.Nil(t, err)
+	req, handler := RequestIdInterceptor(req)
+	resp, err := http.DefaultClient.Do(req)
+	handler(resp, err)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestRequestIdInterceptorKeepHeader(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://localhost", http.NoBody)
+	req = req.WithContext(requestid.NewContext(req.Context(), "foo"))
+	req.Header.Set(requestid.HeaderKey, "bar")
+	req, _ = RequestIdInterceptor(req)
+	assert.Equal(t, "bar", req.Header.Get(requestid.HeaderKey))
+}
+
+func TestRequestIdInterceptorWithoutId(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://localhost", http.NoBody)
+	req, _ = RequestIdInterceptor(req)
+	assert.Empty(t, req.Header.Get(requestid.HeaderKey))
+}
@@ -24,6 +24,8 @@ import (
 
 var interceptors = []internal.Interceptor{
 	internal.LogInterceptor,
+	internal.RequestIdInterceptor,
+	internal.CanaryInterceptor,
 }
 
 // Do sends an HTTP request with the given arguments and returns an HTTP response.
@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/jialequ/linux-sdk/core/breaker"
+	"github.com/jialequ/linux-sdk/rest/httpc/internal"
 )
 
 type (
@@ -26,6 +27,16 @@ type (
 	}
 )
 
+// WithBudget returns an Option that propagates the remaining deadline budget of
+// the request context through the http header. Use it only with the internal services,
+// not to reveal the deadlines to the external services.
+func WithBudget() Option {
+	return func(r *http.Request) *http.Request {
+		r, _ = internal.BudgetInterceptor(r)
+		return r
+	}
+}
+
 // NewService returns a remote service with the given name.
 // opts are used to customize the *http.Client.
 func NewService(name string, opts ...Option) Service {
@@ -2,10 +2,13 @@ package httpc
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/jialequ/linux-sdk/core/budget"
 	"github.com/jialequ/linux-sdk/rest/internal/header"
 	"github.com/stretchr/testify/assert"
 )
@@ -38,6 +41,42 @@ func TestNamedServiceDoRequestGet(t *testing.T) {
 	assert.Equal(t, "bar", resp.Header.Get("foo"))
 }
 
+func TestNamedServiceWithBudget(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get(budget.HeaderKey)))
+	}))
+	defer svr.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for _, test := range []struct {
+		name    string
+		service Service
+		expect  bool
+	}{
+		{
+			name:    "without budget",
+			service: NewService("foo"),
+		},
+		{
+			name:    "with budget",
+			service: NewService("foo", WithBudget()),
+			expect:  true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			resp, err := test.service.Do(ctx, http.MethodGet, svr.URL, nil)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			remaining, ok := budget.Parse(string(body))
+			assert.Equal(t, test.expect, ok)
+			assert.True(t, remaining >= 0 && remaining <= time.Second)
+		})
+	}
+}
+
 func TestNamedServiceDoRequestPost(t *testing.T) {
 	svr := httptest.NewServer(http.NotFoundHandler())
 	defer svr.Close()
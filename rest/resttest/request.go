@@ -0,0 +1,129 @@
+package resttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	// TestSecret is the default secret to sign jwt tokens in tests.
+	TestSecret = "resttest-secret"
+
+	authorizationHeader = "Authorization"
+	bearerPrefix        = "Bearer "
+	contentTypeHeader   = "Content-Type"
+	jsonContentType     = "application/json; charset=utf-8"
+	jwtExpire           = "exp"
+	jwtIssueAt          = "iat"
+	tokenDuration       = time.Hour
+)
+
+// A Request is a fluent builder of a http request to send to a Server.
+type Request struct {
+	server *Server
+	method string
+	path   string
+	header http.Header
+	query  url.Values
+	body   []byte
+}
+
+func newRequest(server *Server, method, path string) *Request {
+	return &Request{
+		server: server,
+		method: method,
+		path:   path,
+		header: make(http.Header),
+		query:  make(url.Values),
+	}
+}
+
+// Do sends the request to the Server, returns the Response.
+func (r *Request) Do() *Response {
+	u, err := url.Parse(r.path)
+	if err != nil {
+		r.server.t.Fatal(err)
+	}
+
+	if len(r.query) > 0 {
+		q := u.Query()
+		for k, vals := range r.query {
+			for _, v := range vals {
+				q.Add(k, v)
+			}
+		}
+		u.RawQuery = q.Encode()
+	}
+
+	var body io.Reader = http.NoBody
+	if r.body != nil {
+		body = bytes.NewReader(r.body)
+	}
+
+	req := httptest.NewRequest(r.method, u.String(), body)
+	for k, vals := range r.header {
+		for _, v := range vals {
+			req.Header.Add(k, v)
+		}
+	}
+
+	return r.server.Serve(req)
+}
+
+// WithBody sets the raw body of the request.
+func (r *Request) WithBody(body []byte) *Request {
+	r.body = body
+	return r
+}
+
+// WithHeader sets the header of the request.
+func (r *Request) WithHeader(key, value string) *Request {
+	r.header.Set(key, value)
+	return r
+}
+
+// WithJson sets v as the json body of the request.
+func (r *Request) WithJson(v any) *Request {
+	body, err := json.Marshal(v)
+	if err != nil {
+		r.server.t.Fatal(err)
+	}
+
+	r.body = body
+	r.header.Set(contentTypeHeader, jsonContentType)
+	return r
+}
+
+// WithJwt signs a jwt token with given secret and claims, and sets it into the request.
+// Use TestSecret as secret if the routes are registered with rest.WithJwt(resttest.TestSecret).
+func (r *Request) WithJwt(secret string, claims map[string]any) *Request {
+	now := time.Now()
+	mapClaims := jwt.MapClaims{
+		jwtExpire:  now.Add(tokenDuration).Unix(),
+		jwtIssueAt: now.Unix(),
+	}
+	for k, v := range claims {
+		mapClaims[k] = v
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, mapClaims).SignedString([]byte(secret))
+	if err != nil {
+		r.server.t.Fatal(err)
+	}
+
+	r.header.Set(authorizationHeader, bearerPrefix+token)
+	return r
+}
+
+// WithQuery adds the query parameter to the request.
+func (r *Request) WithQuery(key, value string) *Request {
+	r.query.Add(key, value)
+	return r
+}
@@ -0,0 +1,104 @@
+package resttest
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const pathSeparator = "."
+
+// A Response is the response of a Request, with assertion helpers.
+type Response struct {
+	*httptest.ResponseRecorder
+	t *testing.T
+}
+
+func newResponse(t *testing.T, recorder *httptest.ResponseRecorder) *Response {
+	return &Response{
+		ResponseRecorder: recorder,
+		t:                t,
+	}
+}
+
+// AssertHeader asserts the response header of key equals to value.
+func (r *Response) AssertHeader(key, value string) *Response {
+	r.t.Helper()
+	assert.Equal(r.t, value, r.Header().Get(key), "header %q", key)
+	return r
+}
+
+// AssertJson asserts the value on given json path equals to expected.
+// The path is separated by dots, and array elements are indexed by numbers,
+// like data.items.0.name, empty path means the whole body.
+func (r *Response) AssertJson(path string, expected any) *Response {
+	r.t.Helper()
+
+	actual, ok := r.JsonPath(path)
+	if !assert.True(r.t, ok, "json path %q not found in %s", path, r.Body.String()) {
+		return r
+	}
+
+	// normalize expected to the types that json unmarshals into, like float64 for numbers.
+	bs, err := json.Marshal(expected)
+	if !assert.NoError(r.t, err) {
+		return r
+	}
+
+	var want any
+	if !assert.NoError(r.t, json.Unmarshal(bs, &want)) {
+		return r
+	}
+
+	assert.Equal(r.t, want, actual, "json path %q", path)
+	return r
+}
+
+// AssertStatus asserts the response status code equals to code.
+func (r *Response) AssertStatus(code int) *Response {
+	r.t.Helper()
+	assert.Equal(r.t, code, r.Code, "status code, body: %s", r.Body.String())
+	return r
+}
+
+// Json unmarshals the response body into v.
+func (r *Response) Json(v any) error {
+	return json.Unmarshal(r.Body.Bytes(), v)
+}
+
+// JsonPath returns the value on given json path, false if not found.
+func (r *Response) JsonPath(path string) (any, bool) {
+	var val any
+	if err := r.Json(&val); err != nil {
+		return nil, false
+	}
+
+	if len(path) == 0 {
+		return val, true
+	}
+
+	for _, key := range strings.Split(path, pathSeparator) {
+		switch v := val.(type) {
+		case map[string]any:
+			child, ok := v[key]
+			if !ok {
+				return nil, false
+			}
+			val = child
+		case []any:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, false
+			}
+			val = v[index]
+		default:
+			return nil, false
+		}
+	}
+
+	return val, true
+}
@@ -0,0 +1,112 @@
+package resttest
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jialequ/linux-sdk/core/requestid"
+	"github.com/jialequ/linux-sdk/rest"
+	"github.com/jialequ/linux-sdk/rest/httpx"
+	"github.com/stretchr/testify/assert"
+)
+
+type greetReq struct {
+	Name string `json:"name"`
+	Lang string `form:"lang,optional"`
+}
+
+func greet(w http.ResponseWriter, r *http.Request) {
+	var req greetReq
+	if err := httpx.Parse(r, &req); err != nil {
+		httpx.ErrorCtx(r.Context(), w, err)
+		return
+	}
+
+	httpx.OkJsonCtx(r.Context(), w, map[string]any{
+		"data": map[string]any{
+			"message": "hello " + req.Name,
+			"lang":    req.Lang,
+			"tags":    []string{"a", "b"},
+			"count":   2,
+		},
+	})
+}
+
+func TestServer(t *testing.T) {
+	svr := NewServer(t, MustNewConf(t), []rest.Route{
+		{
+			Method:  http.MethodPost,
+			Path:    "/greet",
+			Handler: greet,
+		},
+	})
+
+	resp := svr.Post("/greet").
+		WithJson(map[string]string{"name": "kevin"}).
+		WithQuery("lang", "en").
+		WithHeader(requestid.HeaderKey, "foo").
+		Do()
+	resp.AssertStatus(http.StatusOK).
+		AssertHeader(requestid.HeaderKey, "foo").
+		AssertJson("data.message", "hello kevin").
+		AssertJson("data.lang", "en").
+		AssertJson("data.tags.1", "b").
+		AssertJson("data.count", 2)
+
+	_, ok := resp.JsonPath("data.tags.2")
+	assert.False(t, ok)
+	_, ok = resp.JsonPath("data.message.foo")
+	assert.False(t, ok)
+	_, ok = resp.JsonPath("data.missing")
+	assert.False(t, ok)
+
+	svr.Get("/missing").Do().AssertStatus(http.StatusNotFound)
+	assert.True(t, strings.Contains(svr.Logs().String(), "/greet"))
+}
+
+func TestServerWithJwt(t *testing.T) {
+	svr := NewServer(t, MustNewConf(t), nil)
+	svr.AddRoutes([]rest.Route{
+		{
+			Method: http.MethodGet,
+			Path:   "/user",
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				httpx.OkJsonCtx(r.Context(), w, map[string]any{
+					"uid": r.Context().Value("uid"),
+				})
+			},
+		},
+	}, rest.WithJwt(TestSecret))
+
+	svr.Get("/user").Do().AssertStatus(http.StatusUnauthorized)
+	svr.Get("/user").WithJwt("wrong-secret", nil).Do().AssertStatus(http.StatusUnauthorized)
+	svr.Get("/user").
+		WithJwt(TestSecret, map[string]any{"uid": "123"}).
+		Do().
+		AssertStatus(http.StatusOK).
+		AssertJson("uid", "123")
+}
+
+func TestServerUse(t *testing.T) {
+	svr := NewServer(t, MustNewConf(t), []rest.Route{
+		{
+			Method: http.MethodPut,
+			Path:   "/foo",
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			},
+		},
+	}).Use(func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Middleware", "yes")
+			next(w, r)
+		}
+	})
+
+	svr.Put("/foo").WithBody([]byte("bar")).Do().
+		AssertStatus(http.StatusNoContent).
+		AssertHeader("X-Middleware", "yes")
+	svr.Delete("/foo").Do().AssertStatus(http.StatusMethodNotAllowed)
+	svr.Patch("/foo").Do().AssertStatus(http.StatusMethodNotAllowed)
+}
@@ -0,0 +1,105 @@
+package resttest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jialequ/linux-sdk/core/conf"
+	"github.com/jialequ/linux-sdk/core/logx/logtest"
+	"github.com/jialequ/linux-sdk/rest"
+)
+
+const defaultName = "resttest"
+
+// A Server is a rest server that serves requests in memory for testing,
+// all the requests go through the full middleware chain of rest.Server.
+type Server struct {
+	t      *testing.T
+	server *rest.Server
+	logs   *logtest.Buffer
+}
+
+// MustNewConf returns a rest.RestConf with all the default values filled.
+func MustNewConf(t *testing.T) rest.RestConf {
+	var c rest.RestConf
+	if err := conf.FillDefault(&c); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Name = defaultName
+	return c
+}
+
+// NewServer returns a Server with given config and routes.
+// The logs are captured during the test, and can be retrieved by Logs.
+func NewServer(t *testing.T, c rest.RestConf, routes []rest.Route, opts ...rest.RunOption) *Server {
+	server, err := rest.NewServer(c, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(routes) > 0 {
+		server.AddRoutes(routes)
+	}
+
+	return &Server{
+		t:      t,
+		server: server,
+		logs:   logtest.NewCollector(t),
+	}
+}
+
+// AddRoutes adds given routes into the Server.
+func (s *Server) AddRoutes(rs []rest.Route, opts ...rest.RouteOption) *Server {
+	s.server.AddRoutes(rs, opts...)
+	return s
+}
+
+// Delete returns a Request with DELETE method on given path.
+func (s *Server) Delete(path string) *Request {
+	return s.NewRequest(http.MethodDelete, path)
+}
+
+// Get returns a Request with GET method on given path.
+func (s *Server) Get(path string) *Request {
+	return s.NewRequest(http.MethodGet, path)
+}
+
+// Logs returns the logs captured in the Server.
+func (s *Server) Logs() *logtest.Buffer {
+	return s.logs
+}
+
+// NewRequest returns a Request with given method and path.
+func (s *Server) NewRequest(method, path string) *Request {
+	return newRequest(s, method, path)
+}
+
+// Patch returns a Request with PATCH method on given path.
+func (s *Server) Patch(path string) *Request {
+	return s.NewRequest(http.MethodPatch, path)
+}
+
+// Post returns a Request with POST method on given path.
+func (s *Server) Post(path string) *Request {
+	return s.NewRequest(http.MethodPost, path)
+}
+
+// Put returns a Request with PUT method on given path.
+func (s *Server) Put(path string) *Request {
+	return s.NewRequest(http.MethodPut, path)
+}
+
+// Serve serves the given request in memory, returns the Response.
+func (s *Server) Serve(r *http.Request) *Response {
+	w := httptest.NewRecorder()
+	s.server.ServeHTTP(w, r)
+	return newResponse(s.t, w)
+}
+
+// Use adds the given middleware in the Server.
+func (s *Server) Use(middleware rest.Middleware) *Server {
+	s.server.Use(middleware)
+	return s
+}
@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/jialequ/linux-sdk/core/logx"
+	"github.com/jialequ/linux-sdk/core/proc"
+	"github.com/jialequ/linux-sdk/internal/fault"
 	"github.com/jialequ/linux-sdk/rest/chain"
 	"github.com/jialequ/linux-sdk/rest/handler"
 	"github.com/jialequ/linux-sdk/rest/httpx"
@@ -54,6 +56,16 @@ func NewServer(c RestConf, opts ...RunOption) (*Server, error) {
 		router: router.NewRouter(),
 	}
 
+	if c.Middlewares.Fault {
+		injector, err := fault.NewInjector(c.Middlewares.FaultConf)
+		if err != nil {
+			return nil, err
+		}
+
+		proc.AddShutdownListener(injector.Stop)
+		server.ngin.setFaultInjector(injector)
+	}
+
 	opts = append([]RunOption{WithNotFoundHandler(nil)}, opts...)
 	for _, opt := range opts {
 		opt(server)
@@ -78,6 +90,22 @@ func (s *Server) AddRoute(r Route, opts ...RouteOption) {
 	s.AddRoutes([]Route{r}, opts...)
 }
 
+// BindRoutes binds given routes with the middlewares of the Server into router,
+// without adding them into the Server. It's used to build the route tables that are
+// swapped into a running Server with a custom router, like the reloaded routes of the gateway.
+// Notice: the timeouts of the routes don't extend the read and write timeouts of the Server,
+// except the streaming routes, which clear the deadlines per request.
+func (s *Server) BindRoutes(router httpx.Router, rs []Route, opts ...RouteOption) error {
+	r := featuredRoutes{
+		routes: rs,
+	}
+	for _, opt := range opts {
+		opt(&r)
+	}
+
+	return s.ngin.bindExtraRoutes(router, r)
+}
+
 // PrintRoutes prints the added routes to stdout.
 func (s *Server) PrintRoutes() {
 	s.ngin.print()
@@ -278,6 +306,15 @@ func WithSignature(signature SignatureConf) RouteOption {
 	}
 }
 
+// WithStreaming returns a RouteOption to make the routes ignore the timeout, including the
+// read and write timeouts of the connections, for the long-lived streaming requests and
+// responses, like newline delimited json streams.
+func WithStreaming() RouteOption {
+	return func(r *featuredRoutes) {
+		r.streaming = true
+	}
+}
+
 // WithTimeout returns a RouteOption to set timeout with given value.
 func WithTimeout(timeout time.Duration) RouteOption {
 	return func(r *featuredRoutes) {
@@ -638,3 +638,170 @@ Port: 54321
 		})
 	}
 }
+
+func TestServerWithFault(t *testing.T) {
+	logtest.Discard(t)
+
+	const configYaml = `
+Name: foo
+Port: 54321
+Middlewares:
+  Fault: true
+  FaultConf:
+    Rules:
+      - Routes: [/foo, /auth/foo]
+        Percent: 100
+        Abort: 503
+`
+
+	var cnf RestConf
+	assert.Nil(t, conf.LoadFromYamlBytes([]byte(configYaml), &cnf))
+
+	svr, err := NewServer(cnf)
+	assert.Nil(t, err)
+	svr.AddRoute(Route{
+		Method: http.MethodGet,
+		Path:   "/foo",
+		Handler: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	svr.AddRoute(Route{
+		Method: http.MethodGet,
+		Path:   "/foo",
+		Handler: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		},
+	}, WithJwt("jwt-secret"), WithPrefix("/auth"))
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/foo", http.NoBody)
+	svr.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/foo", http.NoBody)
+	req.Header.Set("X-Fault-Inject", "true")
+	svr.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	// the unauthenticated requests can't inject faults.
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/auth/foo", http.NoBody)
+	req.Header.Set("X-Fault-Inject", "true")
+	svr.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	cnf.Middlewares.FaultConf.File = "not-exists.yaml"
+	_, err = NewServer(cnf)
+	assert.NotNil(t, err)
+}
+
+func TestServerBindStreamingRoutes(t *testing.T) {
+	svr := MustNewServer(RestConf{
+		Host:    "localhost",
+		Timeout: 1,
+		Middlewares: MiddlewaresConf{
+			Timeout: true,
+		},
+	})
+
+	slow := func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Millisecond * 20)
+		_, _ = w.Write([]byte("{}\n"))
+	}
+	rt := router.NewRouter()
+	assert.NoError(t, svr.BindRoutes(rt, []Route{
+		{Method: http.MethodGet, Path: "/unary", Handler: slow},
+	}))
+	assert.NoError(t, svr.BindRoutes(rt, []Route{
+		{Method: http.MethodGet, Path: "/stream", Handler: slow},
+	}, WithStreaming()))
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/unary", http.NoBody))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	w = httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stream", http.NoBody))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "{}\n", w.Body.String())
+}
+
+func TestServerStreamingRoutesClearDeadlines(t *testing.T) {
+	svr := MustNewServer(RestConf{
+		Host: "localhost",
+		Middlewares: MiddlewaresConf{
+			Log:     true,
+			Metrics: true,
+			Recover: true,
+		},
+	})
+
+	rt := router.NewRouter()
+	assert.NoError(t, svr.BindRoutes(rt, []Route{
+		{
+			Method: http.MethodGet,
+			Path:   "/stream",
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				for i := 0; i < 3; i++ {
+					time.Sleep(time.Millisecond * 50)
+					_, _ = w.Write([]byte("{}\n"))
+					http.NewResponseController(w).Flush()
+				}
+			},
+		},
+	}, WithStreaming()))
+
+	backend := httptest.NewUnstartedServer(rt)
+	backend.Config.WriteTimeout = time.Millisecond * 50
+	backend.Start()
+	defer backend.Close()
+
+	resp, err := http.Get(backend.URL + "/stream")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "{}\n{}\n{}\n", string(body))
+}
+
+func TestServerBindRoutes(t *testing.T) {
+	svr := MustNewServer(RestConf{
+		Host: "localhost",
+	})
+	svr.Use(func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Middleware", "yes")
+			next(w, r)
+		}
+	})
+
+	rt := router.NewRouter()
+	assert.NoError(t, svr.BindRoutes(rt, []Route{
+		{
+			Method: http.MethodGet,
+			Path:   "/foo",
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("foo"))
+			},
+		},
+	}, WithPrefix("/api")))
+	assert.Empty(t, svr.Routes())
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/foo", http.NoBody))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "foo", w.Body.String())
+	assert.Equal(t, "yes", w.Header().Get("X-Middleware"))
+
+	assert.Error(t, svr.BindRoutes(rt, []Route{
+		{
+			Method:  http.MethodGet,
+			Path:    "/api/foo",
+			Handler: http.NotFound,
+		},
+	}))
+}
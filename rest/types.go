@@ -37,5 +37,6 @@ type (
 		signature signatureSetting
 		routes    []Route
 		maxBytes  int64
+		streaming bool
 	}
 )
@@ -97,6 +97,7 @@ func ZRPC(_ *cobra.Command, args []string) error {
 	ctx.Output = zrpcOut
 	ctx.ProtocCmd = strings.Join(protocArgs, " ")
 	ctx.IsGenClient = VarBoolClient
+	ctx.IsGenValidator = VarBoolValidate
 	g := generator.NewGenerator(style, verbose)
 	return g.Generate(&ctx)
 }
@@ -30,6 +30,8 @@ type ZRpcContext struct {
 	Multiple bool
 	// Whether to generate rpc client
 	IsGenClient bool
+	// Whether to generate the validators of the messages
+	IsGenValidator bool
 }
 
 // Generate generates a rpc service, through the proto file,
@@ -77,6 +79,13 @@ func (g *Generator) Generate(zctx *ZRpcContext) error {
 		return err
 	}
 
+	if zctx.IsGenValidator {
+		err = g.GenValidate(dirCtx, proto)
+		if err != nil {
+			return err
+		}
+	}
+
 	err = g.GenConfig(dirCtx, proto, g.cfg)
 	if err != nil {
 		return err
@@ -0,0 +1,400 @@
+package generator
+
+import (
+	_ "embed"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/emicklei/proto"
+	"github.com/jialequ/linux-sdk/tools/goctl/rpc/parser"
+	"github.com/jialequ/linux-sdk/tools/goctl/util"
+	"github.com/jialequ/linux-sdk/tools/goctl/util/pathx"
+)
+
+const (
+	validateImport = `"github.com/jialequ/linux-sdk/zrpc/validate"`
+	rulesOption    = "(zrpc.validate.rules)"
+
+	kindBool    = "bool"
+	kindBytes   = "bytes"
+	kindEnum    = "enum"
+	kindMessage = "message"
+	kindNumber  = "number"
+	kindString  = "string"
+	kindUnknown = "unknown"
+)
+
+//go:embed validate.tpl
+var validateTemplate string
+
+var numberTypes = map[string]struct{}{
+	"double":   {},
+	"float":    {},
+	"int32":    {},
+	"int64":    {},
+	"uint32":   {},
+	"uint64":   {},
+	"sint32":   {},
+	"sint64":   {},
+	"fixed32":  {},
+	"fixed64":  {},
+	"sfixed32": {},
+	"sfixed64": {},
+}
+
+type (
+	validateMessage struct {
+		Name   string
+		Checks []string
+	}
+
+	validateRules struct {
+		required bool
+		minLen   uint64
+		maxLen   uint64
+		minItems uint64
+		maxItems uint64
+		pattern  *string
+		bounds   []string
+	}
+
+	// typeResolver resolves the types of the fields in the messages and enums of the proto file.
+	typeResolver struct {
+		pkg   string
+		kinds map[string]string
+		// useFmt is set if the generated code formats the paths of the items.
+		useFmt bool
+	}
+)
+
+// GenValidate generates the Validate methods of the messages into the pb dir,
+// which check the fields with the rules annotated by (zrpc.validate.rules).
+func (g *Generator) GenValidate(ctx DirContext, proto parser.Proto) error {
+	resolver := newTypeResolver(proto)
+	var messages []validateMessage
+	for _, msg := range proto.Message {
+		if msg.IsExtend {
+			continue
+		}
+
+		path := pathOf(msg.Message)
+		checks, err := resolver.checksOf(msg.Message, path)
+		if err != nil {
+			return err
+		}
+		if len(checks) > 0 {
+			messages = append(messages, validateMessage{
+				Name:   goTypeName(path),
+				Checks: checks,
+			})
+		}
+	}
+
+	imports := validateImport
+	if resolver.useFmt {
+		imports = "\"fmt\"\n\n\t" + imports
+	}
+
+	text, err := pathx.LoadTemplate(category, validateTemplateFile, validateTemplate)
+	if err != nil {
+		return err
+	}
+
+	name := strings.TrimSuffix(proto.Name, filepath.Ext(proto.Name)) + "_validate.go"
+	return util.With("validate").GoFmt(true).Parse(text).SaveTo(map[string]any{
+		"source":   proto.Name,
+		"package":  proto.PbPackage,
+		"imports":  imports,
+		"messages": messages,
+	}, filepath.Join(ctx.GetPb().Filename, name), true)
+}
+
+func newTypeResolver(p parser.Proto) *typeResolver {
+	r := &typeResolver{
+		kinds: make(map[string]string),
+	}
+	if p.Package.Package != nil {
+		r.pkg = p.Package.Name
+	}
+	for _, msg := range p.Message {
+		if !msg.IsExtend {
+			r.kinds[strings.Join(pathOf(msg.Message), ".")] = kindMessage
+		}
+	}
+	for _, enum := range p.Enum {
+		r.kinds[strings.Join(append(pathOf(enum.Parent), enum.Name), ".")] = kindEnum
+	}
+
+	return r
+}
+
+func (r *typeResolver) checksOf(msg *proto.Message, scope []string) ([]string, error) {
+	var checks []string
+	for _, element := range msg.Elements {
+		switch field := element.(type) {
+		case *proto.NormalField:
+			rules, err := rulesOf(field.Field)
+			if err != nil {
+				return nil, err
+			}
+			checks = append(checks, r.normalChecks(field, rules, scope)...)
+		case *proto.MapField:
+			rules, err := rulesOf(field.Field)
+			if err != nil {
+				return nil, err
+			}
+			checks = append(checks, r.mapChecks(field, rules, scope)...)
+		case *proto.Oneof:
+			for _, each := range field.Elements {
+				oneOf, ok := each.(*proto.OneOfField)
+				if !ok {
+					continue
+				}
+				rules, err := rulesOf(oneOf.Field)
+				if err != nil {
+					return nil, err
+				}
+				// the oneof members are checked only if set, which is easier by reflection.
+				kind := r.kindOf(oneOf.Type, scope)
+				if rules != nil || kind == kindMessage || kind == kindUnknown {
+					checks = append(checks, fieldCheck(oneOf.Name))
+				}
+			}
+		}
+	}
+
+	return checks, nil
+}
+
+func (r *typeResolver) kindOf(typ string, scope []string) string {
+	switch typ {
+	case kindBool, kindBytes, kindString:
+		return typ
+	}
+	if _, ok := numberTypes[typ]; ok {
+		return kindNumber
+	}
+
+	typ = strings.TrimPrefix(typ, ".")
+	if len(r.pkg) > 0 {
+		typ = strings.TrimPrefix(typ, r.pkg+".")
+	}
+	for i := len(scope); i >= 0; i-- {
+		name := strings.Join(append(append([]string{}, scope[:i]...), typ), ".")
+		if kind, ok := r.kinds[name]; ok {
+			return kind
+		}
+	}
+
+	return kindUnknown
+}
+
+func (r *typeResolver) mapChecks(field *proto.MapField, rules *validateRules, scope []string) []string {
+	getter := getterOf(field.Name)
+	var checks []string
+	if r.kindOf(field.Type, scope) == kindMessage {
+		r.useFmt = true
+		checks = append(checks, fmt.Sprintf("for key, item := range %s {\nv.Nested(fmt.Sprintf(%q, key), item)\n}",
+			getter, field.Name+"[%v]"))
+	}
+	if rules == nil {
+		return checks
+	}
+
+	if rules.minItems > 0 || rules.maxItems > 0 {
+		checks = append([]string{fmt.Sprintf("v.Items(%q, len(%s), %d, %d)",
+			field.Name, getter, rules.minItems, rules.maxItems)}, checks...)
+	}
+
+	return requiredChecks(field.Name, rules, fmt.Sprintf("len(%s) > 0", getter), checks)
+}
+
+func (r *typeResolver) normalChecks(field *proto.NormalField, rules *validateRules, scope []string) []string {
+	kind := r.kindOf(field.Type, scope)
+	if kind == kindUnknown {
+		return []string{fieldCheck(field.Name)}
+	}
+
+	getter := getterOf(field.Name)
+	if field.Repeated {
+		var checks []string
+		if rules != nil && (rules.minItems > 0 || rules.maxItems > 0) {
+			checks = append(checks, fmt.Sprintf("v.Items(%q, len(%s), %d, %d)",
+				field.Name, getter, rules.minItems, rules.maxItems))
+		}
+		if items := valueChecks(kind, rules, "path", "item"); len(items) > 0 {
+			r.useFmt = true
+			checks = append(checks, fmt.Sprintf("for i, item := range %s {\npath := fmt.Sprintf(%q, i)\n%s\n}",
+				getter, field.Name+"[%d]", strings.Join(items, "\n")))
+		}
+
+		return requiredChecks(field.Name, rules, fmt.Sprintf("len(%s) > 0", getter), checks)
+	}
+
+	checks := valueChecks(kind, rules, strconv.Quote(field.Name), getter)
+	// the optional scalars are checked only if set.
+	if field.Optional && kind != kindMessage {
+		set := fmt.Sprintf("m.%s != nil", parser.CamelCase(field.Name))
+		if rules != nil && rules.required {
+			return requiredChecks(field.Name, rules, set, checks)
+		}
+		if len(checks) == 0 {
+			return nil
+		}
+
+		return []string{fmt.Sprintf("if %s {\n%s\n}", set, strings.Join(checks, "\n"))}
+	}
+
+	var set string
+	switch kind {
+	case kindBool:
+		set = getter
+	case kindBytes, kindString:
+		set = fmt.Sprintf("len(%s) > 0", getter)
+	case kindEnum, kindNumber:
+		set = getter + " != 0"
+	default:
+		set = getter + " != nil"
+	}
+
+	return requiredChecks(field.Name, rules, set, checks)
+}
+
+func (r *validateRules) set(name string, lit *proto.Literal) error {
+	var err error
+	switch name {
+	case "required":
+		r.required, err = strconv.ParseBool(lit.Source)
+	case "min_len":
+		r.minLen, err = strconv.ParseUint(lit.Source, 10, 64)
+	case "max_len":
+		r.maxLen, err = strconv.ParseUint(lit.Source, 10, 64)
+	case "min_items":
+		r.minItems, err = strconv.ParseUint(lit.Source, 10, 64)
+	case "max_items":
+		r.maxItems, err = strconv.ParseUint(lit.Source, 10, 64)
+	case "pattern":
+		var pattern string
+		if pattern, err = strconv.Unquote(`"` + lit.Source + `"`); err == nil {
+			r.pattern = &pattern
+		}
+	case "gt", "gte", "lt", "lte":
+		var bound float64
+		if bound, err = strconv.ParseFloat(lit.Source, 64); err == nil {
+			r.bounds = append(r.bounds, fmt.Sprintf("validate.%s(%s)", parser.CamelCase(name),
+				strconv.FormatFloat(bound, 'g', -1, 64)))
+		}
+	default:
+		return fmt.Errorf("unknown rule %q", name)
+	}
+
+	return err
+}
+
+func fieldCheck(name string) string {
+	return fmt.Sprintf("v.Field(m, %q)", name)
+}
+
+func getterOf(name string) string {
+	return fmt.Sprintf("m.Get%s()", parser.CamelCase(name))
+}
+
+// goTypeName returns the go type name of the message with the path, like protoc-gen-go.
+func goTypeName(path []string) string {
+	names := make([]string, 0, len(path))
+	for _, name := range path {
+		names = append(names, parser.CamelCase(name))
+	}
+
+	return strings.Join(names, "_")
+}
+
+// pathOf returns the names of v and its enclosing messages.
+func pathOf(v proto.Visitee) []string {
+	var path []string
+	for {
+		msg, ok := v.(*proto.Message)
+		if !ok {
+			return path
+		}
+
+		path = append([]string{msg.Name}, path...)
+		v = msg.Parent
+	}
+}
+
+// requiredChecks returns checks run only if the field is set, when the field is required.
+func requiredChecks(name string, rules *validateRules, set string, checks []string) []string {
+	if rules == nil || !rules.required {
+		return checks
+	}
+
+	required := fmt.Sprintf("v.Required(%q, %s)", name, set)
+	if len(checks) == 0 {
+		return []string{required}
+	}
+
+	return []string{fmt.Sprintf("if %s {\n%s\n}", required, strings.Join(checks, "\n"))}
+}
+
+func rulesOf(field *proto.Field) (*validateRules, error) {
+	var rules *validateRules
+	for _, option := range field.Options {
+		switch {
+		case option.Name == rulesOption:
+			if rules == nil {
+				rules = new(validateRules)
+			}
+			for _, each := range option.Constant.OrderedMap {
+				if err := rules.set(each.Name, each.Literal); err != nil {
+					return nil, fmt.Errorf("field %s: %w", field.Name, err)
+				}
+			}
+		case strings.HasPrefix(option.Name, rulesOption+"."):
+			if rules == nil {
+				rules = new(validateRules)
+			}
+			if err := rules.set(strings.TrimPrefix(option.Name, rulesOption+"."),
+				&option.Constant); err != nil {
+				return nil, fmt.Errorf("field %s: %w", field.Name, err)
+			}
+		}
+	}
+
+	return rules, nil
+}
+
+// valueChecks returns the checks of the value expr of the field with the path expr.
+func valueChecks(kind string, rules *validateRules, path, expr string) []string {
+	if kind == kindMessage {
+		return []string{fmt.Sprintf("v.Nested(%s, %s)", path, expr)}
+	}
+	if rules == nil {
+		return nil
+	}
+
+	var checks []string
+	switch kind {
+	case kindString:
+		if rules.minLen > 0 || rules.maxLen > 0 {
+			checks = append(checks, fmt.Sprintf("v.Len(%s, %s, %d, %d)", path, expr, rules.minLen, rules.maxLen))
+		}
+		if rules.pattern != nil {
+			checks = append(checks, fmt.Sprintf("v.Match(%s, %s, %s)", path, expr, strconv.Quote(*rules.pattern)))
+		}
+	case kindBytes:
+		if rules.minLen > 0 || rules.maxLen > 0 {
+			checks = append(checks, fmt.Sprintf("v.BytesLen(%s, %s, %d, %d)", path, expr,
+				rules.minLen, rules.maxLen))
+		}
+	case kindEnum, kindNumber:
+		if len(rules.bounds) > 0 {
+			checks = append(checks, fmt.Sprintf("v.Range(%s, float64(%s), %s)", path, expr,
+				strings.Join(rules.bounds, ", ")))
+		}
+	}
+
+	return checks
+}
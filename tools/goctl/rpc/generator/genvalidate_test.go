@@ -0,0 +1,83 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jialequ/linux-sdk/tools/goctl/rpc/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenValidate(t *testing.T) {
+	p, err := parser.NewDefaultProtoParser().Parse("./test_validate.proto")
+	assert.NoError(t, err)
+
+	dir := t.TempDir()
+	g := NewGenerator("gozero", false)
+	assert.NoError(t, g.GenValidate(pbDirContext{dir: dir}, p))
+
+	content, err := os.ReadFile(filepath.Join(dir, "test_validate_validate.go"))
+	assert.NoError(t, err)
+	code := string(content)
+	for _, expected := range []string{
+		"package pb",
+		`"github.com/jialequ/linux-sdk/zrpc/validate"`,
+		"func (m *Profile) Validate() error {",
+		`v.Len("nick", m.GetNick(), 1, 16)`,
+		`v.Match("nick", m.GetNick(), "^[a-z]+\\d*$")`,
+		"func (m *CreateReq) Validate() error {",
+		`if v.Required("name", len(m.GetName()) > 0) {`,
+		`v.Range("age", float64(m.GetAge()), validate.Gte(0), validate.Lt(150))`,
+		`v.Range("status", float64(m.GetStatus()), validate.Lte(1))`,
+		`v.Items("tags", len(m.GetTags()), 0, 8)`,
+		`path := fmt.Sprintf("tags[%d]", i)`,
+		`v.Len(path, item, 0, 16)`,
+		`if v.Required("profile", m.GetProfile() != nil) {`,
+		`v.Nested("profile", m.GetProfile())`,
+		`v.Nested(path, item)`,
+		`v.Items("named", len(m.GetNamed()), 0, 4)`,
+		`v.Nested(fmt.Sprintf("named[%v]", key), item)`,
+		"if m.Level != nil {",
+		`v.Range("level", float64(m.GetLevel()), validate.Gt(0))`,
+		`v.Nested("extra", m.GetExtra())`,
+		`v.Field(m, "created")`,
+		`v.Field(m, "email")`,
+		`v.Required("agreed", m.GetAgreed())`,
+		"func (m *CreateReq_Extra) Validate() error {",
+		`v.BytesLen("data", m.GetData(), 0, 64)`,
+	} {
+		assert.Contains(t, code, expected)
+	}
+	assert.NotContains(t, code, "CreateResp")
+	assert.NotContains(t, code, `"phone"`)
+}
+
+func TestGenValidateUnknownRule(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "bad.proto")
+	assert.NoError(t, os.WriteFile(src, []byte(`syntax = "proto3";
+package bad;
+option go_package = "bad";
+message Req {
+  string name = 1 [(zrpc.validate.rules).max_size = 1];
+}
+service Bad {
+  rpc Do(Req) returns (Req);
+}
+`), 0o644))
+	p, err := parser.NewDefaultProtoParser().Parse(src)
+	assert.NoError(t, err)
+
+	g := NewGenerator("gozero", false)
+	assert.Error(t, g.GenValidate(pbDirContext{dir: dir}, p))
+}
+
+type pbDirContext struct {
+	DirContext
+	dir string
+}
+
+func (c pbDirContext) GetPb() Dir {
+	return Dir{Filename: c.dir}
+}
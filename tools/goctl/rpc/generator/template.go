@@ -20,6 +20,7 @@ const (
 	serverFuncTemplateFile            = "server-func.tpl"
 	svcTemplateFile                   = "svc.tpl"
 	rpcTemplateFile                   = "template.tpl"
+	validateTemplateFile              = "validate.tpl"
 )
 
 var templates = map[string]string{
@@ -33,6 +34,7 @@ var templates = map[string]string{
 	serverFuncTemplateFile:    functionTemplate,
 	svcTemplateFile:           svcTemplate,
 	rpcTemplateFile:           rpcTemplateText,
+	validateTemplateFile:      validateTemplate,
 }
 
 // GenTemplates is the entry for command goctl template,
@@ -55,6 +55,9 @@ func (p *DefaultProtoParser) Parse(src string, multiple ...bool) (Proto, error)
 		proto.WithMessage(func(message *proto.Message) {
 			ret.Message = append(ret.Message, Message{Message: message})
 		}),
+		proto.WithEnum(func(enum *proto.Enum) {
+			ret.Enum = append(ret.Enum, Enum{Enum: enum})
+		}),
 		proto.WithPackage(func(p *proto.Package) {
 			ret.Package = Package{Package: p}
 		}),
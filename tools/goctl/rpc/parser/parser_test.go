@@ -28,6 +28,8 @@ func TestDefaultProtoParse(t *testing.T) {
 			sort.Strings(list)
 			return list
 		}())
+	assert.Len(t, data.Enum, 1)
+	assert.Equal(t, "TestEnum", data.Enum[0].Name)
 
 	assert.Equal(t, true, func() bool {
 		if len(data.Service) != 1 {
@@ -9,5 +9,6 @@ type Proto struct {
 	GoPackage string
 	Import    []Import
 	Message   []Message
+	Enum      []Enum
 	Service   Services
 }
@@ -8,11 +8,15 @@ import (
 	"github.com/jialequ/linux-sdk/zrpc/internal"
 	"github.com/jialequ/linux-sdk/zrpc/internal/auth"
 	"github.com/jialequ/linux-sdk/zrpc/internal/clientinterceptors"
+	"github.com/jialequ/linux-sdk/zrpc/internal/message"
+	"github.com/jialequ/linux-sdk/zrpc/internal/tlsx"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/keepalive"
 )
 
 var (
+	// WithBalancer is an alias of internal.WithBalancer.
+	WithBalancer = internal.WithBalancer
 	// WithDialOption is an alias of internal.WithDialOption.
 	WithDialOption = internal.WithDialOption
 	// WithNonBlock sets the dialing to be nonblock.
@@ -55,12 +59,42 @@ func NewClient(c RpcClientConf, options ...ClientOption) (Client, error) {
 			Token: c.Token,
 		})))
 	}
+	if c.TLS {
+		creds, err := tlsx.NewClientCredentials(c.TLSConf)
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, WithTransportCredentials(creds))
+	}
 	if c.NonBlock {
 		opts = append(opts, WithNonBlock())
 	}
 	if c.Timeout > 0 {
 		opts = append(opts, WithTimeout(time.Duration(c.Timeout)*time.Millisecond))
 	}
+	if len(c.Balancer) > 0 {
+		opts = append(opts, WithBalancer(c.Balancer))
+	}
+	if c.OutlierDetection {
+		odc := c.OutlierDetectionConf
+		// the conf built in code doesn't have the defaults, like NewDirectClientConf.
+		if odc == (OutlierDetectionConf{}) {
+			if err := conf.FillDefault(&odc); err != nil {
+				return nil, err
+			}
+		}
+
+		opts = append(opts, internal.WithOutlierDetection(odc))
+	}
+	if !c.Message.IsZero() {
+		m, err := message.NewMatcher(c.Message)
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, internal.WithMessage(m))
+	}
 	if c.KeepaliveTime > 0 {
 		opts = append(opts, WithDialOption(grpc.WithKeepaliveParams(keepalive.ClientParameters{
 			Time: c.KeepaliveTime,
@@ -111,7 +145,30 @@ func SetClientSlowThreshold(threshold time.Duration) {
 	clientinterceptors.SetSlowThreshold(threshold)
 }
 
+// WithHashKey returns a call option with given key to route the call with consistent hash balancer.
+func WithHashKey(key string) grpc.CallOption {
+	return clientinterceptors.WithHashKey(key)
+}
+
 // WithCallTimeout return a call option with given timeout to make a method call.
 func WithCallTimeout(timeout time.Duration) grpc.CallOption {
 	return clientinterceptors.WithCallTimeout(timeout)
 }
+
+// WithTokenCredential returns a func to send the bearer token with the calls.
+func WithTokenCredential(token string) ClientOption {
+	return WithDialOption(grpc.WithPerRPCCredentials(&auth.TokenCredential{
+		Token: token,
+	}))
+}
+
+// WithForwardedTokenCredential returns a func to forward the bearer token of the incoming
+// request with the calls, the given token is sent if no incoming token.
+// Notice: use it only to call the trusted services, because the token is forwarded even
+// without TLS, which can be used to call the other services on behalf of the caller.
+func WithForwardedTokenCredential(token string) ClientOption {
+	return WithDialOption(grpc.WithPerRPCCredentials(&auth.TokenCredential{
+		Token:   token,
+		Forward: true,
+	}))
+}
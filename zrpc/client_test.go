@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/jialequ/linux-sdk/core/conf"
 	"github.com/jialequ/linux-sdk/core/discov"
 	"github.com/jialequ/linux-sdk/core/logx"
 	"github.com/jialequ/linux-sdk/internal/mock"
@@ -183,6 +184,146 @@ func TestDepositServerDeposit(t *testing.T) {
 	}
 }
 
+func TestNewClientWithBalancer(t *testing.T) {
+	balancers := []string{"p2c_ewma", "consistent_hash", "wrr", "least_request"}
+	for _, name := range balancers {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			client, err := NewClient(
+				RpcClientConf{
+					Endpoints: []string{"foo", "bar"},
+					Timeout:   1000,
+					Balancer:  name,
+				},
+				WithDialOption(grpc.WithContextDialer(dialer())),
+			)
+			assert.Nil(t, err)
+
+			cli := mock.NewDepositServiceClient(client.Conn())
+			resp, err := cli.Deposit(context.Background(), &mock.DepositRequest{Amount: 1},
+				WithHashKey("foo"))
+			assert.Nil(t, err)
+			assert.True(t, resp.Ok)
+		})
+	}
+}
+
+func TestNewClientWithOutlierDetection(t *testing.T) {
+	var c OutlierDetectionConf
+	assert.NoError(t, conf.FillDefault(&c))
+	client, err := NewClient(
+		RpcClientConf{
+			Endpoints:            []string{"foo", "bar"},
+			Timeout:              1000,
+			Balancer:             "p2c_ewma",
+			OutlierDetection:     true,
+			OutlierDetectionConf: c,
+		},
+		WithDialOption(grpc.WithContextDialer(dialer())),
+	)
+	assert.Nil(t, err)
+
+	cli := mock.NewDepositServiceClient(client.Conn())
+	resp, err := cli.Deposit(context.Background(), &mock.DepositRequest{Amount: 1})
+	assert.Nil(t, err)
+	assert.True(t, resp.Ok)
+}
+
+func TestNewClientWithOutlierDetectionDefaults(t *testing.T) {
+	client, err := NewClient(
+		RpcClientConf{
+			Endpoints:        []string{"foo"},
+			Timeout:          1000,
+			Balancer:         "p2c_ewma",
+			OutlierDetection: true,
+		},
+		WithDialOption(grpc.WithContextDialer(dialer())),
+	)
+	assert.Nil(t, err)
+
+	cli := mock.NewDepositServiceClient(client.Conn())
+	resp, err := cli.Deposit(context.Background(), &mock.DepositRequest{Amount: 1})
+	assert.Nil(t, err)
+	assert.True(t, resp.Ok)
+}
+
+func TestNewClientWithMessage(t *testing.T) {
+	client, err := NewClient(
+		RpcClientConf{
+			Endpoints: []string{"foo"},
+			Timeout:   1000,
+			Message: MessageConf{
+				MaxSendSize: 1024,
+				Compressor:  "gzip",
+				Methods: []MessageMethodConf{
+					{
+						FullMethod: "/mock.DepositService/Deposit",
+						Compressor: "zstd",
+					},
+				},
+			},
+		},
+		WithDialOption(grpc.WithContextDialer(dialer())),
+	)
+	assert.Nil(t, err)
+
+	cli := mock.NewDepositServiceClient(client.Conn())
+	resp, err := cli.Deposit(context.Background(), &mock.DepositRequest{Amount: 1})
+	assert.Nil(t, err)
+	assert.True(t, resp.Ok)
+
+	_, err = NewClient(RpcClientConf{
+		Endpoints: []string{"foo"},
+		Message: MessageConf{
+			Compressor: "foo",
+		},
+	})
+	assert.Error(t, err)
+}
+
+func TestNewClientWithTokenCredential(t *testing.T) {
+	client, err := NewClient(
+		RpcClientConf{
+			Endpoints: []string{"foo"},
+			Timeout:   1000,
+		},
+		WithDialOption(grpc.WithContextDialer(dialer())),
+		WithTokenCredential("token"),
+	)
+	assert.Nil(t, err)
+
+	cli := mock.NewDepositServiceClient(client.Conn())
+	resp, err := cli.Deposit(context.Background(), &mock.DepositRequest{Amount: 1})
+	assert.Nil(t, err)
+	assert.True(t, resp.Ok)
+
+	client, err = NewClient(
+		RpcClientConf{
+			Endpoints: []string{"foo"},
+			Timeout:   1000,
+		},
+		WithDialOption(grpc.WithContextDialer(dialer())),
+		WithForwardedTokenCredential("token"),
+	)
+	assert.Nil(t, err)
+
+	cli = mock.NewDepositServiceClient(client.Conn())
+	resp, err = cli.Deposit(context.Background(), &mock.DepositRequest{Amount: 1})
+	assert.Nil(t, err)
+	assert.True(t, resp.Ok)
+}
+
+func TestNewClientWithTLS(t *testing.T) {
+	_, err := NewClient(RpcClientConf{
+		Endpoints: []string{"foo"},
+		TLS:       true,
+		TLSConf: TLSConf{
+			CACertFile: "not-exist",
+		},
+	})
+	assert.Error(t, err)
+}
+
 func TestNewClientWithError(t *testing.T) {
 	_, err := NewClient(
 		RpcClientConf{
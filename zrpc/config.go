@@ -19,6 +19,40 @@ type (
 	StatConf = internal.StatConf
 	// MethodTimeoutConf defines specified timeout for gRPC method.
 	MethodTimeoutConf = internal.MethodTimeoutConf
+	// RateLimitConf defines the rate limit config.
+	RateLimitConf = internal.RateLimitConf
+	// RateLimitRuleConf defines the rate limit rule config of a method.
+	RateLimitRuleConf = internal.RateLimitRuleConf
+	// RetryConf defines the retry config.
+	RetryConf = internal.RetryConf
+	// RetryPolicyConf defines the retry policy config of a method.
+	RetryPolicyConf = internal.RetryPolicyConf
+	// BreakerConf defines the breaker config of the methods.
+	BreakerConf = internal.BreakerConf
+	// BreakerRuleConf defines the breaker rule of the methods.
+	BreakerRuleConf = internal.BreakerRuleConf
+	// MessageConf defines the size limits and the compression of the messages.
+	MessageConf = internal.MessageConf
+	// MessageMethodConf defines the size limits and the compression of the messages of the methods.
+	MessageMethodConf = internal.MessageMethodConf
+	// JwtAuthConf defines the jwt authentication and authorization config.
+	JwtAuthConf = internal.JwtAuthConf
+	// AclRuleConf defines the required scopes or roles of the methods.
+	AclRuleConf = internal.AclRuleConf
+	// TLSConf defines the TLS config.
+	TLSConf = internal.TLSConf
+	// WebConf defines the config to serve gRPC-Web and Connect requests.
+	WebConf = internal.WebConf
+	// MetadataConf defines the metadata of a server instance to publish.
+	MetadataConf = internal.MetadataConf
+	// RoutingConf defines how a client routes the calls by the instance metadata.
+	RoutingConf = internal.RoutingConf
+	// OutlierDetectionConf defines the config to eject the failing servers from the p2c balancer.
+	OutlierDetectionConf = internal.OutlierDetectionConf
+	// FaultConf defines the fault injection config.
+	FaultConf = internal.FaultConf
+	// FaultRuleConf defines the fault injection rule config.
+	FaultRuleConf = internal.FaultRuleConf
 
 	// A RpcClientConf is a rpc client config.
 	RpcClientConf struct {
@@ -30,7 +64,20 @@ type (
 		NonBlock      bool            `json:",optional"`
 		Timeout       int64           `json:",default=2000"`
 		KeepaliveTime time.Duration   `json:",optional"`
-		Middlewares   ClientMiddlewaresConf
+		// TLS turns on the TLS with TLSConf, set CertFile and KeyFile for mutual TLS.
+		TLS     bool    `json:",optional"`
+		TLSConf TLSConf `json:",optional"`
+		// Routing prefers the servers in the same zone, or restricts the calls to a version.
+		Routing RoutingConf `json:",optional"`
+		// Balancer is the load balancer to pick the servers.
+		Balancer string `json:",default=p2c_ewma,options=p2c_ewma|consistent_hash|wrr|least_request"`
+		// OutlierDetection turns on ejecting the failing servers with OutlierDetectionConf,
+		// only for p2c_ewma balancer. The defaults of OutlierDetectionConf are used if not set.
+		OutlierDetection     bool `json:",optional"`
+		OutlierDetectionConf OutlierDetectionConf
+		// Message sets the size limits and the compression of the messages.
+		Message     MessageConf `json:",optional"`
+		Middlewares ClientMiddlewaresConf
 	}
 
 	// A RpcServerConf is a rpc server config.
@@ -41,15 +88,59 @@ type (
 		Auth          bool               `json:",optional"`
 		Redis         redis.RedisKeyConf `json:",optional"`
 		StrictControl bool               `json:",optional"`
+		// Metadata is published with the address to etcd, like zone, version, weight and color.
+		Metadata MetadataConf `json:",optional"`
+		// JwtAuth turns on the bearer token authentication and the acl in JwtAuthConf.
+		JwtAuth     bool        `json:",optional"`
+		JwtAuthConf JwtAuthConf `json:",optional"`
+		// TLS turns on the TLS with TLSConf, set RequireClientCert for mutual TLS.
+		TLS     bool    `json:",optional"`
+		TLSConf TLSConf `json:",optional"`
+		// Web turns on serving gRPC-Web and Connect requests over HTTP/1.1 with WebConf,
+		// WebConf.AllowedOrigins is required if Web is on.
+		// Without WebConf.ListenOn, the native gRPC requests share the address and are served by
+		// the experimental http handler transport of grpc-go, see WebConf.ListenOn.
+		Web     bool    `json:",optional"`
+		WebConf WebConf `json:",optional"`
+		// Message sets the size limits and the compression of the messages.
+		Message MessageConf `json:",optional"`
 		// setting 0 means no timeout
 		Timeout      int64 `json:",default=2000"`
 		CpuThreshold int64 `json:",default=900,range=[0:1000)"`
 		// grpc health check switch
-		Health      bool `json:",default=true"`
+		Health bool `json:",default=true"`
+		// HealthCheckInterval is the interval to run the health checks added by AddHealthCheck.
+		HealthCheckInterval time.Duration `json:",default=5s"`
+		// Reflection turns on the grpc server reflection, used by grpcurl and the gateway.
+		Reflection  bool `json:",optional"`
 		Middlewares ServerMiddlewaresConf
 		// setting specified timeout for gRPC method
 		MethodTimeouts []MethodTimeoutConf `json:",optional"`
 	}
+
+	// A ProxyRouteConf is a route of the proxy server.
+	ProxyRouteConf struct {
+		// Service is the full name of the service to forward, like pkg.Service,
+		// * matches all the services without routes.
+		Service string
+		// Target is the backend to forward the calls of the service.
+		Target RpcClientConf
+		// Public, Scopes and Roles authorize the calls of the service with JwtAuth,
+		// like AclRuleConf.
+		Public bool     `json:",optional"`
+		Scopes []string `json:",optional"`
+		Roles  []string `json:",optional"`
+	}
+
+	// A RpcProxyConf is a transparent rpc proxy server config.
+	RpcProxyConf struct {
+		RpcServerConf
+		Routes []ProxyRouteConf
+		// AllowedMetadata are the only metadata to forward if not empty.
+		AllowedMetadata []string `json:",optional"`
+		// DeniedMetadata are the metadata not to forward.
+		DeniedMetadata []string `json:",optional"`
+	}
 )
 
 // NewDirectClientConf returns a RpcClientConf.
@@ -80,6 +171,11 @@ func (sc RpcServerConf) HasEtcd() bool {
 
 // Validate validates the config.
 func (sc RpcServerConf) Validate() error {
+	if sc.Web {
+		if err := sc.WebConf.Validate(); err != nil {
+			return err
+		}
+	}
 	if !sc.Auth {
 		return nil
 	}
@@ -89,6 +185,15 @@ func (sc RpcServerConf) Validate() error {
 
 // BuildTarget builds the rpc target from the given config.
 func (cc RpcClientConf) BuildTarget() (string, error) {
+	target, err := cc.buildTarget()
+	if err != nil {
+		return "", err
+	}
+
+	return resolver.BuildRoutingTarget(target, cc.Routing)
+}
+
+func (cc RpcClientConf) buildTarget() (string, error) {
 	if len(cc.Endpoints) > 0 {
 		return resolver.BuildDirectTarget(cc.Endpoints), nil
 	} else if len(cc.Target) > 0 {
@@ -3,6 +3,7 @@ package zrpc
 import (
 	"testing"
 
+	"github.com/jialequ/linux-sdk/core/conf"
 	"github.com/jialequ/linux-sdk/core/discov"
 	"github.com/jialequ/linux-sdk/core/service"
 	"github.com/jialequ/linux-sdk/core/stores/redis"
@@ -39,6 +40,37 @@ func TestRpcClientConf(t *testing.T) {
 		_, err := conf.BuildTarget()
 		assert.Error(t, err)
 	})
+
+	t.Run("etcd with routing", func(t *testing.T) {
+		conf := NewEtcdClientConf([]string{literal_8406}, "key", "foo", "bar")
+		conf.Routing = RoutingConf{
+			Zone:            "a",
+			MinHealthyRatio: 0.5,
+		}
+		target, err := conf.BuildTarget()
+		assert.NoError(t, err)
+		assert.Equal(t, "etcd://"+literal_8406+"/key?minHealthyRatio=0.5&zone=a", target)
+	})
+}
+
+func TestRpcClientConfOutlierDetectionDefaults(t *testing.T) {
+	var c RpcClientConf
+	assert.NoError(t, conf.LoadFromJsonBytes([]byte(`{"Target":"foo","OutlierDetection":true}`), &c))
+	assert.True(t, c.OutlierDetection)
+	assert.Equal(t, 5, c.OutlierDetectionConf.ConsecutiveErrors)
+	assert.Equal(t, 0.5, c.OutlierDetectionConf.ErrorRate)
+	assert.Equal(t, 50, c.OutlierDetectionConf.MaxEjectionPercent)
+}
+
+func TestRpcServerConfCanaryDefaults(t *testing.T) {
+	var c RpcServerConf
+	assert.NoError(t, conf.LoadFromJsonBytes([]byte(`{"Name":"foo","ListenOn":":8080"}`), &c))
+	// the incoming colors are not trusted by default, but propagated on the clients.
+	assert.False(t, c.Middlewares.Canary)
+
+	var cc RpcClientConf
+	assert.NoError(t, conf.LoadFromJsonBytes([]byte(`{"Target":"foo"}`), &cc))
+	assert.True(t, cc.Middlewares.Canary)
 }
 
 func TestRpcServerConf(t *testing.T) {
@@ -64,6 +96,10 @@ func TestRpcServerConf(t *testing.T) {
 	assert.NotNil(t, conf.Validate())
 	conf.Redis.Host = literal_0462
 	assert.Nil(t, conf.Validate())
+	conf.Web = true
+	assert.NotNil(t, conf.Validate())
+	conf.WebConf.AllowedOrigins = []string{"https://example.com"}
+	assert.Nil(t, conf.Validate())
 }
 
 const literal_8406 = "localhost:1234"
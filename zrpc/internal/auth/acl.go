@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"strings"
+)
+
+const (
+	scopeClaim  = "scope"
+	scpClaim    = "scp"
+	rolesClaim  = "roles"
+	wildcardAll = "*"
+)
+
+type (
+	// AclRuleConf defines the required scopes or roles of the methods.
+	AclRuleConf struct {
+		// FullMethod is the method to authorize, like /pkg.Service/Method,
+		// /pkg.Service/* matches all the methods of the service, empty matches all methods.
+		FullMethod string `json:",optional"`
+		// Scopes are required all, read from the scope or scp claim.
+		Scopes []string `json:",optional"`
+		// Roles are required any, read from the roles claim.
+		Roles []string `json:",optional"`
+		// Public allows the requests without a valid token.
+		Public bool `json:",optional"`
+	}
+
+	acl struct {
+		methods  map[string]*AclRuleConf
+		services map[string]*AclRuleConf
+		fallback *AclRuleConf
+	}
+)
+
+func newAcl(rules []AclRuleConf) *acl {
+	a := &acl{
+		methods:  make(map[string]*AclRuleConf),
+		services: make(map[string]*AclRuleConf),
+	}
+
+	for i := range rules {
+		rule := &rules[i]
+		switch {
+		case len(rule.FullMethod) == 0 || rule.FullMethod == wildcardAll:
+			a.fallback = rule
+		case strings.HasSuffix(rule.FullMethod, "/"+wildcardAll):
+			a.services[strings.TrimSuffix(rule.FullMethod, wildcardAll)] = rule
+		default:
+			a.methods[rule.FullMethod] = rule
+		}
+	}
+
+	return a
+}
+
+func (a *acl) match(method string) *AclRuleConf {
+	if rule, ok := a.methods[method]; ok {
+		return rule
+	}
+
+	if index := strings.LastIndexByte(method, '/'); index >= 0 {
+		if rule, ok := a.services[method[:index+1]]; ok {
+			return rule
+		}
+	}
+
+	return a.fallback
+}
+
+func (r *AclRuleConf) allow(claims Claims) bool {
+	if len(r.Scopes) > 0 {
+		scopes := toSet(claimStrings(claims, scopeClaim), claimStrings(claims, scpClaim))
+		for _, scope := range r.Scopes {
+			if _, ok := scopes[scope]; !ok {
+				return false
+			}
+		}
+	}
+
+	if len(r.Roles) > 0 {
+		roles := toSet(claimStrings(claims, rolesClaim))
+		for _, role := range r.Roles {
+			if _, ok := roles[role]; ok {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return true
+}
+
+// claimStrings reads the claim as a space separated string or a string array.
+func claimStrings(claims Claims, name string) []string {
+	switch val := claims[name].(type) {
+	case string:
+		return strings.Fields(val)
+	case []string:
+		return val
+	case []any:
+		vals := make([]string, 0, len(val))
+		for _, each := range val {
+			if s, ok := each.(string); ok {
+				vals = append(vals, s)
+			}
+		}
+		return vals
+	default:
+		return nil
+	}
+}
+
+func toSet(lists ...[]string) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, list := range lists {
+		for _, each := range list {
+			set[each] = struct{}{}
+		}
+	}
+
+	return set
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAclMatch(t *testing.T) {
+	a := newAcl([]AclRuleConf{
+		{
+			Scopes: []string{"default"},
+		},
+		{
+			FullMethod: "/pkg.Service/*",
+			Scopes:     []string{"service"},
+		},
+		{
+			FullMethod: "/pkg.Service/Method",
+			Scopes:     []string{"method"},
+		},
+	})
+
+	assert.Equal(t, []string{"method"}, a.match("/pkg.Service/Method").Scopes)
+	assert.Equal(t, []string{"service"}, a.match("/pkg.Service/Other").Scopes)
+	assert.Equal(t, []string{"default"}, a.match("/pkg.Other/Method").Scopes)
+	assert.Nil(t, newAcl(nil).match("/pkg.Service/Method"))
+}
+
+func TestAclRuleAllow(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   AclRuleConf
+		claims Claims
+		allow  bool
+	}{
+		{
+			name:   "no requirements",
+			claims: Claims{},
+			allow:  true,
+		},
+		{
+			name:   "scope string",
+			rule:   AclRuleConf{Scopes: []string{"read", "write"}},
+			claims: Claims{"scope": "read write"},
+			allow:  true,
+		},
+		{
+			name:   "scp array",
+			rule:   AclRuleConf{Scopes: []string{"read", "write"}},
+			claims: Claims{"scp": []any{"read", "write"}},
+			allow:  true,
+		},
+		{
+			name:   "missing scope",
+			rule:   AclRuleConf{Scopes: []string{"read", "write"}},
+			claims: Claims{"scope": "read"},
+		},
+		{
+			name:   "any role",
+			rule:   AclRuleConf{Roles: []string{"admin", "ops"}},
+			claims: Claims{"roles": []string{"ops"}},
+			allow:  true,
+		},
+		{
+			name:   "missing role",
+			rule:   AclRuleConf{Roles: []string{"admin"}},
+			claims: Claims{"roles": "user"},
+		},
+		{
+			name:   "bad roles",
+			rule:   AclRuleConf{Roles: []string{"admin"}},
+			claims: Claims{"roles": 1},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.allow, test.rule.allow(test.claims))
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	threading "github.com/jialequ/linux-sdk/core/dist"
+	"github.com/jialequ/linux-sdk/core/logx"
+	"github.com/jialequ/linux-sdk/core/syncx"
+	"github.com/jialequ/linux-sdk/core/timex"
+)
+
+const (
+	jwksFetchTimeout = 5 * time.Second
+	// jwksMinRefreshInterval avoids fetching the jwks on every token with an unknown kid,
+	// and on every token while the jwks endpoint is unavailable.
+	jwksMinRefreshInterval = time.Minute
+	jwksFlightKey          = "jwks"
+)
+
+var errUnknownKid = errors.New("unknown kid")
+
+type (
+	jwks struct {
+		url             string
+		refreshInterval time.Duration
+		client          *http.Client
+		flight          syncx.SingleFlight
+		keys            map[string]any
+		// lastAttempt is the time of the last fetch, no matter it succeeded or not.
+		lastAttempt time.Duration
+		attempted   bool
+		lock        sync.RWMutex
+	}
+
+	jsonWebKey struct {
+		Kid string `json:"kid"`
+		Kty string `json:"kty"`
+		Use string `json:"use"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+		Crv string `json:"crv"`
+		X   string `json:"x"`
+		Y   string `json:"y"`
+	}
+
+	jsonWebKeySet struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+)
+
+func newJwks(url string, refreshInterval time.Duration) *jwks {
+	return &jwks{
+		url:             url,
+		refreshInterval: refreshInterval,
+		client:          &http.Client{Timeout: jwksFetchTimeout},
+		flight:          syncx.NewSingleFlight(),
+	}
+}
+
+// key returns the key of kid. The keys are fetched on the first use, then refreshed
+// in the background, the cached keys are served meanwhile, even if the refreshing failed,
+// to avoid blocking the calls on fetching. The tokens of the unknown kids, like the ones
+// signed by the rotated keys, are rejected until the keys are refreshed.
+func (j *jwks) key(kid string) (any, error) {
+	j.lock.RLock()
+	key, ok := j.keys[kid]
+	loaded := j.keys != nil
+	elapsed := timex.Since(j.lastAttempt)
+	attempted := j.attempted
+	j.lock.RUnlock()
+
+	if !loaded {
+		if attempted && elapsed < jwksMinRefreshInterval {
+			return nil, errUnknownKid
+		}
+
+		return j.load(kid)
+	}
+
+	if ok {
+		j.refreshAsync(j.refreshInterval)
+		return key, nil
+	}
+
+	j.refreshAsync(jwksMinRefreshInterval)
+	return nil, errUnknownKid
+}
+
+// load fetches the keys and returns the key of kid, the concurrent calls share the fetch.
+func (j *jwks) load(kid string) (any, error) {
+	if _, err := j.flight.Do(jwksFlightKey, func() (any, error) {
+		j.lock.Lock()
+		j.lastAttempt = timex.Now()
+		j.attempted = true
+		j.lock.Unlock()
+		return nil, j.fetch()
+	}); err != nil {
+		logx.Errorf("failed to fetch jwks from %s, error: %v", j.url, err)
+		return nil, err
+	}
+
+	j.lock.RLock()
+	defer j.lock.RUnlock()
+	if key, ok := j.keys[kid]; ok {
+		return key, nil
+	}
+
+	return nil, errUnknownKid
+}
+
+// refreshAsync refreshes the keys in the background if the last attempt is interval ago.
+func (j *jwks) refreshAsync(interval time.Duration) {
+	j.lock.Lock()
+	due := timex.Since(j.lastAttempt) >= interval
+	if due {
+		j.lastAttempt = timex.Now()
+		j.attempted = true
+	}
+	j.lock.Unlock()
+
+	if due {
+		threading.GoSafe(func() {
+			if err := j.fetch(); err != nil {
+				logx.Errorf("failed to refresh jwks from %s, error: %v", j.url, err)
+			}
+		})
+	}
+}
+
+func (j *jwks) fetch() error {
+	resp, err := j.client.Get(j.url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var set jsonWebKeySet
+	if err = json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return err
+	}
+
+	keys := make(map[string]any, len(set.Keys))
+	for _, k := range set.Keys {
+		if len(k.Use) > 0 && k.Use != "sig" {
+			continue
+		}
+
+		key, err := k.publicKey()
+		if err != nil {
+			logx.Errorf("ignored jwk %q, error: %v", k.Kid, err)
+			continue
+		}
+
+		keys[k.Kid] = key
+	}
+
+	j.lock.Lock()
+	j.keys = keys
+	j.lock.Unlock()
+
+	return nil
+}
+
+func (k jsonWebKey) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve: %s", k.Crv)
+		}
+
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type: %s", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
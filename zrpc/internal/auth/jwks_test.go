@@ -0,0 +1,219 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestJwtAuthenticatorJwks(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	var fetches int32
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		_ = json.NewEncoder(w).Encode(jsonWebKeySet{
+			Keys: []jsonWebKey{
+				{
+					Kid: "rsa",
+					Kty: "RSA",
+					Use: "sig",
+					N:   encodeBigInt(rsaKey.N),
+					E:   encodeBigInt(big.NewInt(int64(rsaKey.E))),
+				},
+				{
+					Kid: "ec",
+					Kty: "EC",
+					Crv: "P-256",
+					X:   encodeBigInt(ecKey.X),
+					Y:   encodeBigInt(ecKey.Y),
+				},
+				{
+					Kid: "enc",
+					Kty: "RSA",
+					Use: "enc",
+				},
+				{
+					Kid: "oct",
+					Kty: "oct",
+				},
+			},
+		})
+	}))
+	defer svr.Close()
+
+	a, err := NewJwtAuthenticator(JwtConf{
+		JwksUrl:             svr.URL,
+		JwksRefreshInterval: time.Minute,
+	})
+	assert.NoError(t, err)
+
+	token := signToken(t, jwt.SigningMethodRS256, rsaKey, "rsa", jwt.MapClaims{"sub": "foo"})
+	_, err = a.Authenticate(incomingToken(token), testMethod)
+	assert.NoError(t, err)
+
+	token = signToken(t, jwt.SigningMethodES256, ecKey, "ec", jwt.MapClaims{"sub": "foo"})
+	_, err = a.Authenticate(incomingToken(token), testMethod)
+	assert.NoError(t, err)
+
+	// unknown kid doesn't refetch the jwks within the min refresh interval.
+	token = signToken(t, jwt.SigningMethodRS256, rsaKey, "unknown", jwt.MapClaims{"sub": "foo"})
+	_, err = a.Authenticate(incomingToken(token), testMethod)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+}
+
+func TestJwksUnavailable(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer svr.Close()
+
+	j := newJwks(svr.URL, time.Minute)
+	_, err := j.key("foo")
+	assert.Error(t, err)
+
+	j = newJwks("http://127.0.0.1:0", time.Minute)
+	_, err = j.key("foo")
+	assert.Error(t, err)
+}
+
+func TestJwksStaleKey(t *testing.T) {
+	var healthy atomic.Bool
+	healthy.Store(true)
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"keys":[{"kid":"foo","kty":"RSA","n":"AQAB","e":"AQAB"}]}`))
+	}))
+	defer svr.Close()
+
+	j := newJwks(svr.URL, time.Nanosecond)
+	key, err := j.key("foo")
+	assert.NoError(t, err)
+
+	healthy.Store(false)
+	stale, err := j.key("foo")
+	assert.NoError(t, err)
+	assert.Equal(t, key, stale)
+}
+
+func TestJwksUnavailableBackoff(t *testing.T) {
+	var healthy atomic.Bool
+	var fetches int32
+	healthy.Store(true)
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"keys":[{"kid":"foo","kty":"RSA","n":"AQAB","e":"AQAB"}]}`))
+	}))
+	defer svr.Close()
+
+	j := newJwks(svr.URL, time.Millisecond*10)
+	key, err := j.key("foo")
+	assert.NoError(t, err)
+
+	healthy.Store(false)
+	time.Sleep(time.Millisecond * 20)
+	for i := 0; i < 3; i++ {
+		stale, err := j.key("foo")
+		assert.NoError(t, err)
+		assert.Equal(t, key, stale)
+	}
+	// only one attempt within the refresh interval after the failure.
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&fetches) == 2
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&fetches))
+
+	// the unknown kids don't fetch either within the min refresh interval.
+	_, err = j.key("bar")
+	assert.ErrorIs(t, err, errUnknownKid)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&fetches))
+}
+
+func TestJwksRefreshInBackground(t *testing.T) {
+	var fetches int32
+	release := make(chan struct{})
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the refreshing blocks until released, the first fetch doesn't.
+		if atomic.AddInt32(&fetches, 1) > 1 {
+			<-release
+		}
+		_, _ = w.Write([]byte(`{"keys":[{"kid":"foo","kty":"RSA","n":"AQAB","e":"AQAB"}]}`))
+	}))
+	defer svr.Close()
+	defer close(release)
+
+	j := newJwks(svr.URL, time.Millisecond)
+	key, err := j.key("foo")
+	assert.NoError(t, err)
+
+	time.Sleep(time.Millisecond * 5)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cached, err := j.key("foo")
+		assert.NoError(t, err)
+		assert.Equal(t, key, cached)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("blocked on refreshing the jwks")
+	}
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&fetches) == 2
+	}, time.Second, time.Millisecond)
+
+	// the unknown kids trigger the refreshing in the background too.
+	j = newJwks(svr.URL, time.Minute)
+	j.keys = map[string]any{}
+	_, err = j.key("bar")
+	assert.ErrorIs(t, err, errUnknownKid)
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&fetches) == 3
+	}, time.Second, time.Millisecond)
+}
+
+func TestJsonWebKeyPublicKey(t *testing.T) {
+	_, err := jsonWebKey{Kty: "RSA", N: "!"}.publicKey()
+	assert.Error(t, err)
+	_, err = jsonWebKey{Kty: "RSA", N: "AQAB", E: "!"}.publicKey()
+	assert.Error(t, err)
+	_, err = jsonWebKey{Kty: "EC", Crv: "P-1"}.publicKey()
+	assert.Error(t, err)
+	_, err = jsonWebKey{Kty: "EC", Crv: "P-384", X: "!"}.publicKey()
+	assert.Error(t, err)
+	_, err = jsonWebKey{Kty: "EC", Crv: "P-521", X: "AQAB", Y: "!"}.publicKey()
+	assert.Error(t, err)
+}
+
+func encodeBigInt(n *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(n.Bytes())
+}
@@ -0,0 +1,242 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	authorizationKey = "authorization"
+	bearerPrefix     = "bearer "
+
+	missingToken = "bearer token required"
+	invalidToken = "invalid token"
+)
+
+var (
+	// ErrNoJwtKey is an error that indicates no key configured to verify the tokens.
+	ErrNoJwtKey = errors.New("one of Secret, PublicKeyFile or JwksUrl is required")
+
+	hmacMethods = []string{"HS256", "HS384", "HS512"}
+	pkMethods   = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+)
+
+type (
+	// Claims is the claims of a verified token.
+	Claims = jwt.MapClaims
+
+	// JwtConf defines the config to verify the bearer tokens and authorize the methods.
+	JwtConf struct {
+		// Secret is the HMAC secret, PrevSecret is used to rotate the secret.
+		Secret     string `json:",optional"`
+		PrevSecret string `json:",optional"`
+		// PublicKeyFile is the PEM encoded RSA or ECDSA public key file.
+		PublicKeyFile string `json:",optional"`
+		// JwksUrl is the url of the JSON Web Key Set, the keys are picked by the kid.
+		JwksUrl             string        `json:",optional"`
+		JwksRefreshInterval time.Duration `json:",default=5m"`
+		Issuer              string        `json:",optional"`
+		Audience            string        `json:",optional"`
+		Rules               []AclRuleConf `json:",optional"`
+	}
+
+	// A JwtAuthenticator is used to authenticate the rpc requests with bearer tokens.
+	JwtAuthenticator struct {
+		secrets   [][]byte
+		publicKey any
+		jwks      *jwks
+		issuer    string
+		audience  string
+		acl       *acl
+		parser    *jwt.Parser
+	}
+
+	claimsKey struct{}
+)
+
+// NewJwtAuthenticator returns a JwtAuthenticator.
+func NewJwtAuthenticator(c JwtConf) (*JwtAuthenticator, error) {
+	a := &JwtAuthenticator{
+		issuer:   c.Issuer,
+		audience: c.Audience,
+		acl:      newAcl(c.Rules),
+		parser:   jwt.NewParser(jwt.WithJSONNumber()),
+	}
+
+	if len(c.Secret) > 0 {
+		a.secrets = append(a.secrets, []byte(c.Secret))
+		if len(c.PrevSecret) > 0 {
+			a.secrets = append(a.secrets, []byte(c.PrevSecret))
+		}
+	}
+
+	if len(c.PublicKeyFile) > 0 {
+		key, err := loadPublicKey(c.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		a.publicKey = key
+	}
+
+	if len(c.JwksUrl) > 0 {
+		a.jwks = newJwks(c.JwksUrl, c.JwksRefreshInterval)
+	}
+
+	if len(a.secrets) == 0 && a.publicKey == nil && a.jwks == nil {
+		return nil, ErrNoJwtKey
+	}
+
+	return a, nil
+}
+
+// Authenticate authenticates the given ctx and authorizes the method with the acl,
+// returns a new context that carries the claims.
+func (a *JwtAuthenticator) Authenticate(ctx context.Context, method string) (context.Context, error) {
+	rule := a.acl.match(method)
+	tokenString, ok := parseBearerToken(ctx)
+	if !ok {
+		if rule != nil && rule.Public {
+			return ctx, nil
+		}
+
+		return nil, status.Error(codes.Unauthenticated, missingToken)
+	}
+
+	claims, err := a.verify(tokenString)
+	if err != nil {
+		if rule != nil && rule.Public {
+			return ctx, nil
+		}
+
+		return nil, status.Error(codes.Unauthenticated, invalidToken)
+	}
+
+	if rule != nil && !rule.allow(claims) {
+		return nil, status.Error(codes.PermissionDenied, accessDenied)
+	}
+
+	return context.WithValue(ctx, claimsKey{}, claims), nil
+}
+
+func (a *JwtAuthenticator) keyfuncs(token *jwt.Token) ([]jwt.Keyfunc, error) {
+	var keyfuncs []jwt.Keyfunc
+	if strings.HasPrefix(token.Method.Alg(), "HS") {
+		for _, secret := range a.secrets {
+			secret := secret
+			keyfuncs = append(keyfuncs, func(*jwt.Token) (any, error) {
+				return secret, nil
+			})
+		}
+		return keyfuncs, nil
+	}
+
+	if kid, ok := token.Header["kid"].(string); ok && a.jwks != nil {
+		key, err := a.jwks.key(kid)
+		if err != nil {
+			return nil, err
+		}
+
+		return append(keyfuncs, func(*jwt.Token) (any, error) {
+			return key, nil
+		}), nil
+	}
+
+	if a.publicKey != nil {
+		keyfuncs = append(keyfuncs, func(*jwt.Token) (any, error) {
+			return a.publicKey, nil
+		})
+	}
+
+	return keyfuncs, nil
+}
+
+func (a *JwtAuthenticator) verify(tokenString string) (Claims, error) {
+	token, _, err := a.parser.ParseUnverified(tokenString, Claims{})
+	if err != nil {
+		return nil, err
+	}
+
+	keyfuncs, err := a.keyfuncs(token)
+	if err != nil {
+		return nil, err
+	}
+	if len(keyfuncs) == 0 {
+		return nil, jwt.ErrTokenUnverifiable
+	}
+
+	methods := hmacMethods
+	if !strings.HasPrefix(token.Method.Alg(), "HS") {
+		methods = pkMethods
+	}
+	parser := jwt.NewParser(jwt.WithJSONNumber(), jwt.WithValidMethods(methods))
+
+	for _, keyfunc := range keyfuncs {
+		claims := Claims{}
+		token, err = parser.ParseWithClaims(tokenString, claims, keyfunc)
+		if err != nil {
+			continue
+		}
+		if !token.Valid {
+			err = jwt.ErrTokenSignatureInvalid
+			continue
+		}
+
+		if len(a.issuer) > 0 && !claims.VerifyIssuer(a.issuer, true) {
+			return nil, jwt.ErrTokenInvalidIssuer
+		}
+		if len(a.audience) > 0 && !claims.VerifyAudience(a.audience, true) {
+			return nil, jwt.ErrTokenInvalidAudience
+		}
+
+		return claims, nil
+	}
+
+	return nil, err
+}
+
+// ClaimsFromContext returns the claims of the verified token in ctx.
+func ClaimsFromContext(ctx context.Context) (Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(Claims)
+	return claims, ok
+}
+
+func loadPublicKey(file string) (any, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, err := jwt.ParseRSAPublicKeyFromPEM(content); err == nil {
+		return key, nil
+	}
+
+	return jwt.ParseECPublicKeyFromPEM(content)
+}
+
+func parseBearerToken(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	vals := md.Get(authorizationKey)
+	if len(vals) == 0 {
+		return "", false
+	}
+
+	val := vals[0]
+	if len(val) <= len(bearerPrefix) || !strings.EqualFold(val[:len(bearerPrefix)], bearerPrefix) {
+		return "", false
+	}
+
+	return strings.TrimSpace(val[len(bearerPrefix):]), true
+}
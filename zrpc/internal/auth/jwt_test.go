@@ -0,0 +1,235 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const testMethod = "/pkg.Service/Method"
+
+func TestNewJwtAuthenticator(t *testing.T) {
+	_, err := NewJwtAuthenticator(JwtConf{})
+	assert.ErrorIs(t, err, ErrNoJwtKey)
+
+	_, err = NewJwtAuthenticator(JwtConf{PublicKeyFile: "not-exist"})
+	assert.Error(t, err)
+}
+
+func TestJwtAuthenticatorHmac(t *testing.T) {
+	a, err := NewJwtAuthenticator(JwtConf{
+		Secret:     "secret",
+		PrevSecret: "prev",
+		Issuer:     "issuer",
+		Audience:   "audience",
+	})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		token  string
+		code   codes.Code
+		claims bool
+	}{
+		{
+			name: "no token",
+			code: codes.Unauthenticated,
+		},
+		{
+			name:  "invalid token",
+			token: "Bearer bad",
+			code:  codes.Unauthenticated,
+		},
+		{
+			name:  "not bearer",
+			token: "Basic " + signHmac(t, "secret", jwt.MapClaims{"iss": "issuer", "aud": "audience"}),
+			code:  codes.Unauthenticated,
+		},
+		{
+			name:   "secret",
+			token:  "Bearer " + signHmac(t, "secret", jwt.MapClaims{"iss": "issuer", "aud": "audience", "uid": "1"}),
+			code:   codes.OK,
+			claims: true,
+		},
+		{
+			name:   "prev secret",
+			token:  "bearer " + signHmac(t, "prev", jwt.MapClaims{"iss": "issuer", "aud": "audience", "uid": "1"}),
+			code:   codes.OK,
+			claims: true,
+		},
+		{
+			name:  "wrong secret",
+			token: "Bearer " + signHmac(t, "wrong", jwt.MapClaims{"iss": "issuer", "aud": "audience"}),
+			code:  codes.Unauthenticated,
+		},
+		{
+			name:  "wrong issuer",
+			token: "Bearer " + signHmac(t, "secret", jwt.MapClaims{"iss": "other", "aud": "audience"}),
+			code:  codes.Unauthenticated,
+		},
+		{
+			name:  "wrong audience",
+			token: "Bearer " + signHmac(t, "secret", jwt.MapClaims{"iss": "issuer", "aud": "other"}),
+			code:  codes.Unauthenticated,
+		},
+		{
+			name: "expired",
+			token: "Bearer " + signHmac(t, "secret", jwt.MapClaims{
+				"iss": "issuer",
+				"aud": "audience",
+				"exp": time.Now().Add(-time.Minute).Unix(),
+			}),
+			code: codes.Unauthenticated,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			if len(test.token) > 0 {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(authorizationKey, test.token))
+			}
+
+			ctx, err := a.Authenticate(ctx, testMethod)
+			assert.Equal(t, test.code, status.Code(err))
+			if test.claims {
+				claims, ok := ClaimsFromContext(ctx)
+				assert.True(t, ok)
+				assert.Equal(t, "1", claims["uid"])
+			}
+		})
+	}
+}
+
+func TestJwtAuthenticatorPublicKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	t.Run("rsa", func(t *testing.T) {
+		a, err := NewJwtAuthenticator(JwtConf{
+			PublicKeyFile: writePublicKey(t, &rsaKey.PublicKey),
+		})
+		assert.NoError(t, err)
+
+		token := signToken(t, jwt.SigningMethodRS256, rsaKey, "", jwt.MapClaims{"sub": "foo"})
+		_, err = a.Authenticate(incomingToken(token), testMethod)
+		assert.NoError(t, err)
+
+		token = signToken(t, jwt.SigningMethodES256, ecKey, "", jwt.MapClaims{"sub": "foo"})
+		_, err = a.Authenticate(incomingToken(token), testMethod)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		// hmac tokens must not be verified with the public key.
+		token = signHmac(t, "secret", jwt.MapClaims{"sub": "foo"})
+		_, err = a.Authenticate(incomingToken(token), testMethod)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("ecdsa", func(t *testing.T) {
+		a, err := NewJwtAuthenticator(JwtConf{
+			PublicKeyFile: writePublicKey(t, &ecKey.PublicKey),
+		})
+		assert.NoError(t, err)
+
+		token := signToken(t, jwt.SigningMethodES256, ecKey, "", jwt.MapClaims{"sub": "foo"})
+		_, err = a.Authenticate(incomingToken(token), testMethod)
+		assert.NoError(t, err)
+	})
+}
+
+func TestJwtAuthenticatorAcl(t *testing.T) {
+	a, err := NewJwtAuthenticator(JwtConf{
+		Secret: "secret",
+		Rules: []AclRuleConf{
+			{
+				FullMethod: "/pkg.Service/Public",
+				Public:     true,
+			},
+			{
+				FullMethod: "/pkg.Service/Write",
+				Scopes:     []string{"write"},
+			},
+			{
+				FullMethod: "/pkg.Admin/*",
+				Roles:      []string{"admin"},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	_, err = a.Authenticate(context.Background(), "/pkg.Service/Public")
+	assert.NoError(t, err)
+	_, err = a.Authenticate(incomingToken("bad"), "/pkg.Service/Public")
+	assert.NoError(t, err)
+	_, err = a.Authenticate(context.Background(), "/pkg.Service/Write")
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	reader := signHmac(t, "secret", jwt.MapClaims{"scope": "read", "roles": []string{"user"}})
+	writer := signHmac(t, "secret", jwt.MapClaims{"scope": "read write"})
+	admin := signHmac(t, "secret", jwt.MapClaims{"roles": []string{"admin"}})
+
+	_, err = a.Authenticate(incomingToken(reader), "/pkg.Service/Write")
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = a.Authenticate(incomingToken(writer), "/pkg.Service/Write")
+	assert.NoError(t, err)
+	_, err = a.Authenticate(incomingToken(reader), "/pkg.Admin/Delete")
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = a.Authenticate(incomingToken(admin), "/pkg.Admin/Delete")
+	assert.NoError(t, err)
+	_, err = a.Authenticate(incomingToken(reader), "/pkg.Service/Read")
+	assert.NoError(t, err)
+}
+
+func TestClaimsFromContext(t *testing.T) {
+	_, ok := ClaimsFromContext(context.Background())
+	assert.False(t, ok)
+}
+
+func incomingToken(token string) context.Context {
+	return metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs(authorizationKey, "Bearer "+token))
+}
+
+func signHmac(t *testing.T, secret string, claims jwt.MapClaims) string {
+	return signToken(t, jwt.SigningMethodHS256, []byte(secret), "", claims)
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, key any, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if len(kid) > 0 {
+		token.Header["kid"] = kid
+	}
+
+	signed, err := token.SignedString(key)
+	assert.NoError(t, err)
+	return signed
+}
+
+func writePublicKey(t *testing.T, key any) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	assert.NoError(t, err)
+
+	file := filepath.Join(t.TempDir(), "public.pem")
+	assert.NoError(t, os.WriteFile(file, pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: der,
+	}), 0o600))
+	return file
+}
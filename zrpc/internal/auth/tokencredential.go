@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"context"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// A TokenCredential is used to send the bearer token Token. If Forward is true, the token
+// of the incoming request is forwarded instead if any, which should be used only to call
+// the trusted services, because the token is sent without requiring the transport security.
+type TokenCredential struct {
+	Token   string
+	Forward bool
+}
+
+// GetRequestMetadata gets the request metadata.
+func (c *TokenCredential) GetRequestMetadata(ctx context.Context, _ ...string) (map[string]string, error) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok && c.Forward {
+		if vals := md.Get(authorizationKey); len(vals) > 0 && len(vals[0]) > 0 {
+			return map[string]string{
+				authorizationKey: vals[0],
+			}, nil
+		}
+	}
+
+	if len(c.Token) == 0 {
+		return map[string]string{}, nil
+	}
+
+	return map[string]string{
+		authorizationKey: "Bearer " + c.Token,
+	}, nil
+}
+
+// RequireTransportSecurity always returns false.
+func (c *TokenCredential) RequireTransportSecurity() bool {
+	return false
+}
@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestTokenCredential(t *testing.T) {
+	c := &TokenCredential{}
+	assert.False(t, c.RequireTransportSecurity())
+
+	md, err := c.GetRequestMetadata(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, md)
+
+	c.Token = "foo"
+	md, err = c.GetRequestMetadata(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer foo", md[authorizationKey])
+
+	// the incoming token is not forwarded by default.
+	ctx := metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs(authorizationKey, "Bearer bar"))
+	md, err = c.GetRequestMetadata(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer foo", md[authorizationKey])
+
+	c.Forward = true
+	md, err = c.GetRequestMetadata(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer bar", md[authorizationKey])
+}
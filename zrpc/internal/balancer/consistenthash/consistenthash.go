@@ -0,0 +1,215 @@
+package consistenthash
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jialequ/linux-sdk/core/hash"
+	"github.com/jialequ/linux-sdk/core/logx"
+	"github.com/jialequ/linux-sdk/core/syncx"
+	"github.com/jialequ/linux-sdk/core/timex"
+	"github.com/jialequ/linux-sdk/zrpc/internal/instance"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/resolver"
+)
+
+const (
+	// Name is the name of consistent hash balancer.
+	Name = "consistent_hash"
+	// MetadataKey is the key in the outgoing metadata to carry the hash key.
+	MetadataKey = "x-hash-key"
+
+	// loadFactor bounds the inflight requests of a conn to 1.25 times of the average,
+	// the requests are moved to the next conn on the ring if exceeded.
+	loadFactor  = 1.25
+	logInterval = time.Minute
+)
+
+var emptyPickResult balancer.PickResult
+
+type hashKey struct{}
+
+func init() {
+	balancer.Register(newBuilder())
+}
+
+// NewContext returns a new context that carries the hash key.
+func NewContext(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, hashKey{}, key)
+}
+
+type consistentHashPickerBuilder struct{}
+
+func (b *consistentHashPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	return instance.NewColorPicker(info.ReadySCs, b.build)
+}
+
+func (b *consistentHashPickerBuilder) build(readySCs map[balancer.SubConn]base.SubConnInfo) balancer.Picker {
+	readySCs = instance.PreferLocal(readySCs)
+	if len(readySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+
+	ring := hash.NewConsistentHash()
+	conns := make(map[string]*subConn, len(readySCs))
+	for conn, connInfo := range readySCs {
+		// use the address as the node, to be consistent across the rebuilds and the clients.
+		conns[connInfo.Address.Addr] = &subConn{
+			addr: connInfo.Address,
+			conn: conn,
+		}
+		ring.Add(connInfo.Address.Addr)
+	}
+
+	return &consistentHashPicker{
+		ring:  ring,
+		conns: conns,
+		r:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		stamp: syncx.NewAtomicDuration(),
+	}
+}
+
+func newBuilder() balancer.Builder {
+	return base.NewBalancerBuilder(Name, new(consistentHashPickerBuilder), base.Config{HealthCheck: true})
+}
+
+type consistentHashPicker struct {
+	ring     *hash.ConsistentHash
+	conns    map[string]*subConn
+	inflight int64
+	r        *rand.Rand
+	stamp    *syncx.AtomicDuration
+	lock     sync.Mutex
+}
+
+func (p *consistentHashPicker) Pick(info balancer.PickInfo) (balancer.PickResult, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if len(p.conns) == 0 {
+		return emptyPickResult, balancer.ErrNoSubConnAvailable
+	}
+
+	var chosen *subConn
+	if key := keyFromContext(info.Ctx); len(key) > 0 {
+		chosen = p.choose(key)
+	} else {
+		chosen = p.random()
+	}
+
+	atomic.AddInt64(&p.inflight, 1)
+	atomic.AddInt64(&chosen.inflight, 1)
+	atomic.AddInt64(&chosen.requests, 1)
+
+	return balancer.PickResult{
+		SubConn: chosen.conn,
+		Done:    p.buildDoneFunc(chosen),
+	}, nil
+}
+
+func (p *consistentHashPicker) buildDoneFunc(c *subConn) func(info balancer.DoneInfo) {
+	return func(info balancer.DoneInfo) {
+		atomic.AddInt64(&p.inflight, -1)
+		atomic.AddInt64(&c.inflight, -1)
+
+		now := timex.Now()
+		stamp := p.stamp.Load()
+		if now-stamp >= logInterval {
+			if p.stamp.CompareAndSwap(stamp, now) {
+				p.logStats()
+			}
+		}
+	}
+}
+
+// choose returns the conn of the key on the ring, if the conn is overloaded,
+// the key is rehashed to find another conn, the least loaded one is used if all failed.
+func (p *consistentHashPicker) choose(key string) *subConn {
+	maxLoad := int64(math.Ceil(float64(atomic.LoadInt64(&p.inflight)+1) *
+		loadFactor / float64(len(p.conns))))
+
+	for i := 0; i < len(p.conns); i++ {
+		k := key
+		if i > 0 {
+			k = key + "#" + strconv.Itoa(i)
+		}
+		node, ok := p.ring.Get(k)
+		if !ok {
+			continue
+		}
+
+		conn := p.conns[node.(string)]
+		if atomic.LoadInt64(&conn.inflight) < maxLoad {
+			return conn
+		}
+	}
+
+	var chosen *subConn
+	for _, conn := range p.conns {
+		if chosen == nil || atomic.LoadInt64(&conn.inflight) < atomic.LoadInt64(&chosen.inflight) {
+			chosen = conn
+		}
+	}
+
+	return chosen
+}
+
+func (p *consistentHashPicker) logStats() {
+	var stats []string
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, conn := range p.conns {
+		stats = append(stats, fmt.Sprintf("conn: %s, inflight: %d, reqs: %d",
+			conn.addr.Addr, atomic.LoadInt64(&conn.inflight), atomic.SwapInt64(&conn.requests, 0)))
+	}
+
+	logx.Statf("consistent_hash - %s", strings.Join(stats, "; "))
+}
+
+func (p *consistentHashPicker) random() *subConn {
+	index := p.r.Intn(len(p.conns))
+	for _, conn := range p.conns {
+		if index == 0 {
+			return conn
+		}
+		index--
+	}
+
+	return nil
+}
+
+type subConn struct {
+	inflight int64
+	requests int64
+	addr     resolver.Address
+	conn     balancer.SubConn
+}
+
+func keyFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	if key, ok := ctx.Value(hashKey{}).(string); ok && len(key) > 0 {
+		return key
+	}
+
+	if md, ok := metadata.FromOutgoingContext(ctx); ok {
+		if vals := md.Get(MetadataKey); len(vals) > 0 {
+			return vals[0]
+		}
+	}
+
+	return ""
+}
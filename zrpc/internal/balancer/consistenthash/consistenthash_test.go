@@ -0,0 +1,125 @@
+package consistenthash
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/jialequ/linux-sdk/core/logx"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/resolver"
+)
+
+func init() {
+	logx.Disable()
+}
+
+func TestConsistentHashPickerPickNil(t *testing.T) {
+	builder := new(consistentHashPickerBuilder)
+	picker := builder.Build(base.PickerBuildInfo{})
+	_, err := picker.Pick(balancer.PickInfo{
+		FullMethodName: "/",
+		Ctx:            context.Background(),
+	})
+	assert.NotNil(t, err)
+}
+
+func TestConsistentHashPickerPick(t *testing.T) {
+	picker := buildPicker(10)
+
+	pick := func(ctx context.Context) balancer.PickResult {
+		result, err := picker.Pick(balancer.PickInfo{
+			FullMethodName: "/",
+			Ctx:            ctx,
+		})
+		assert.NoError(t, err)
+		result.Done(balancer.DoneInfo{})
+		return result
+	}
+
+	for i := 0; i < 100; i++ {
+		key := strconv.Itoa(i)
+		first := pick(NewContext(context.Background(), key))
+		assert.Equal(t, first.SubConn, pick(NewContext(context.Background(), key)).SubConn)
+		assert.Equal(t, first.SubConn, pick(metadata.AppendToOutgoingContext(context.Background(),
+			MetadataKey, key)).SubConn)
+		// same result on rebuilt picker, like the addresses refreshed.
+		rebuilt := buildPicker(10)
+		result, err := rebuilt.Pick(balancer.PickInfo{
+			FullMethodName: "/",
+			Ctx:            NewContext(context.Background(), key),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, first.SubConn, result.SubConn)
+	}
+
+	// picks randomly without hash key
+	assert.NotNil(t, pick(context.Background()).SubConn)
+	assert.NotNil(t, pick(nil).SubConn)
+	picker.(*consistentHashPicker).logStats()
+}
+
+func TestConsistentHashPickerBoundedLoad(t *testing.T) {
+	picker := buildPicker(4)
+	dist := make(map[balancer.SubConn]int)
+	for i := 0; i < 100; i++ {
+		result, err := picker.Pick(balancer.PickInfo{
+			FullMethodName: "/",
+			Ctx:            NewContext(context.Background(), "foo"),
+		})
+		assert.NoError(t, err)
+		dist[result.SubConn]++
+	}
+
+	// the hot key is spread, no conn takes more than 1.25 times of the average.
+	assert.Equal(t, 4, len(dist))
+	for _, count := range dist {
+		assert.True(t, count <= 32)
+	}
+}
+
+func TestPickerWithEmptyConns(t *testing.T) {
+	var picker consistentHashPicker
+	_, err := picker.Pick(balancer.PickInfo{
+		FullMethodName: "/",
+		Ctx:            context.Background(),
+	})
+	assert.ErrorIs(t, err, balancer.ErrNoSubConnAvailable)
+}
+
+func buildPicker(candidates int) balancer.Picker {
+	ready := make(map[balancer.SubConn]base.SubConnInfo)
+	for i := 0; i < candidates; i++ {
+		ready[mockClientConn{id: strconv.Itoa(i)}] = base.SubConnInfo{
+			Address: resolver.Address{
+				Addr: strconv.Itoa(i),
+			},
+		}
+	}
+
+	return new(consistentHashPickerBuilder).Build(base.PickerBuildInfo{
+		ReadySCs: ready,
+	})
+}
+
+type mockClientConn struct {
+	// add random string member to avoid map key equality.
+	id string
+}
+
+func (m mockClientConn) GetOrBuildProducer(builder balancer.ProducerBuilder) (
+	p balancer.Producer, close func()) {
+	return builder.Build(m)
+}
+
+func (m mockClientConn) UpdateAddresses(_ []resolver.Address) {
+}
+
+func (m mockClientConn) Connect() {
+}
+
+func (m mockClientConn) Shutdown() {
+}
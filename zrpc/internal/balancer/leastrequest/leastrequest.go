@@ -0,0 +1,136 @@
+package leastrequest
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jialequ/linux-sdk/core/logx"
+	"github.com/jialequ/linux-sdk/core/syncx"
+	"github.com/jialequ/linux-sdk/core/timex"
+	"github.com/jialequ/linux-sdk/zrpc/internal/instance"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/resolver"
+)
+
+const (
+	// Name is the name of least request balancer.
+	Name = "least_request"
+
+	logInterval = time.Minute
+)
+
+var emptyPickResult balancer.PickResult
+
+func init() {
+	balancer.Register(newBuilder())
+}
+
+type leastRequestPickerBuilder struct{}
+
+func (b *leastRequestPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	return instance.NewColorPicker(info.ReadySCs, b.build)
+}
+
+func (b *leastRequestPickerBuilder) build(readySCs map[balancer.SubConn]base.SubConnInfo) balancer.Picker {
+	readySCs = instance.PreferLocal(readySCs)
+	if len(readySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+
+	var conns []*subConn
+	for conn, connInfo := range readySCs {
+		conns = append(conns, &subConn{
+			addr: connInfo.Address,
+			conn: conn,
+		})
+	}
+
+	return &leastRequestPicker{
+		conns: conns,
+		r:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		stamp: syncx.NewAtomicDuration(),
+	}
+}
+
+func newBuilder() balancer.Builder {
+	return base.NewBalancerBuilder(Name, new(leastRequestPickerBuilder), base.Config{HealthCheck: true})
+}
+
+// leastRequestPicker picks the conn with less inflight requests from two random ones.
+type leastRequestPicker struct {
+	conns []*subConn
+	r     *rand.Rand
+	stamp *syncx.AtomicDuration
+	lock  sync.Mutex
+}
+
+func (p *leastRequestPicker) Pick(_ balancer.PickInfo) (balancer.PickResult, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var chosen *subConn
+	switch len(p.conns) {
+	case 0:
+		return emptyPickResult, balancer.ErrNoSubConnAvailable
+	case 1:
+		chosen = p.conns[0]
+	default:
+		a := p.r.Intn(len(p.conns))
+		b := p.r.Intn(len(p.conns) - 1)
+		if b >= a {
+			b++
+		}
+		chosen = p.conns[a]
+		if atomic.LoadInt64(&p.conns[b].inflight) < atomic.LoadInt64(&chosen.inflight) {
+			chosen = p.conns[b]
+		}
+	}
+
+	atomic.AddInt64(&chosen.inflight, 1)
+	atomic.AddInt64(&chosen.requests, 1)
+
+	return balancer.PickResult{
+		SubConn: chosen.conn,
+		Done:    p.buildDoneFunc(chosen),
+	}, nil
+}
+
+func (p *leastRequestPicker) buildDoneFunc(c *subConn) func(info balancer.DoneInfo) {
+	return func(info balancer.DoneInfo) {
+		atomic.AddInt64(&c.inflight, -1)
+
+		now := timex.Now()
+		stamp := p.stamp.Load()
+		if now-stamp >= logInterval {
+			if p.stamp.CompareAndSwap(stamp, now) {
+				p.logStats()
+			}
+		}
+	}
+}
+
+func (p *leastRequestPicker) logStats() {
+	var stats []string
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, conn := range p.conns {
+		stats = append(stats, fmt.Sprintf("conn: %s, inflight: %d, reqs: %d",
+			conn.addr.Addr, atomic.LoadInt64(&conn.inflight), atomic.SwapInt64(&conn.requests, 0)))
+	}
+
+	logx.Statf("least_request - %s", strings.Join(stats, "; "))
+}
+
+type subConn struct {
+	inflight int64
+	requests int64
+	addr     resolver.Address
+	conn     balancer.SubConn
+}
@@ -0,0 +1,115 @@
+package leastrequest
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/jialequ/linux-sdk/core/logx"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/resolver"
+)
+
+func init() {
+	logx.Disable()
+}
+
+func TestLeastRequestPickerPickNil(t *testing.T) {
+	builder := new(leastRequestPickerBuilder)
+	picker := builder.Build(base.PickerBuildInfo{})
+	_, err := picker.Pick(balancer.PickInfo{
+		FullMethodName: "/",
+		Ctx:            context.Background(),
+	})
+	assert.NotNil(t, err)
+}
+
+func TestLeastRequestPickerPick(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates int
+	}{
+		{
+			name:       "single",
+			candidates: 1,
+		},
+		{
+			name:       "two",
+			candidates: 2,
+		},
+		{
+			name:       "multiple",
+			candidates: 10,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			ready := make(map[balancer.SubConn]base.SubConnInfo)
+			for i := 0; i < test.candidates; i++ {
+				ready[mockClientConn{id: strconv.Itoa(i)}] = base.SubConnInfo{
+					Address: resolver.Address{
+						Addr: strconv.Itoa(i),
+					},
+				}
+			}
+
+			picker := new(leastRequestPickerBuilder).Build(base.PickerBuildInfo{
+				ReadySCs: ready,
+			})
+			var results []balancer.PickResult
+			for i := 0; i < test.candidates*10; i++ {
+				result, err := picker.Pick(balancer.PickInfo{
+					FullMethodName: "/",
+					Ctx:            context.Background(),
+				})
+				assert.NoError(t, err)
+				results = append(results, result)
+			}
+
+			conns := picker.(*leastRequestPicker).conns
+			for _, conn := range conns {
+				assert.True(t, conn.inflight > 0)
+			}
+
+			for _, result := range results {
+				result.Done(balancer.DoneInfo{})
+			}
+			for _, conn := range conns {
+				assert.Equal(t, int64(0), conn.inflight)
+			}
+			picker.(*leastRequestPicker).logStats()
+		})
+	}
+}
+
+func TestPickerWithEmptyConns(t *testing.T) {
+	var picker leastRequestPicker
+	_, err := picker.Pick(balancer.PickInfo{
+		FullMethodName: "/",
+		Ctx:            context.Background(),
+	})
+	assert.ErrorIs(t, err, balancer.ErrNoSubConnAvailable)
+}
+
+type mockClientConn struct {
+	// add random string member to avoid map key equality.
+	id string
+}
+
+func (m mockClientConn) GetOrBuildProducer(builder balancer.ProducerBuilder) (
+	p balancer.Producer, close func()) {
+	return builder.Build(m)
+}
+
+func (m mockClientConn) UpdateAddresses(_ []resolver.Address) {
+}
+
+func (m mockClientConn) Connect() {
+}
+
+func (m mockClientConn) Shutdown() {
+}
@@ -0,0 +1,188 @@
+package p2c
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jialequ/linux-sdk/core/collection"
+	"github.com/jialequ/linux-sdk/core/metric"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	outlierBuckets         = 10
+	reasonConsecutiveError = "consecutive_errors"
+	reasonErrorRate        = "error_rate"
+	// maxEjectionShift avoids overflowing on doubling the ejection time.
+	maxEjectionShift = 30
+)
+
+var metricEjections = metric.NewCounterVec(&metric.CounterVecOpts{
+	Namespace: "rpc_client",
+	Subsystem: "balancer",
+	Name:      "ejections_total",
+	Help:      "rpc client balancer outlier ejections count.",
+	Labels:    []string{"addr", "reason"},
+})
+
+type (
+	// OutlierDetectionConf defines the config to eject the failing servers from the p2c balancer.
+	// The server errors are Unknown, DeadlineExceeded, Internal, Unavailable and DataLoss.
+	OutlierDetectionConf struct {
+		// ConsecutiveErrors ejects a server after the consecutive server errors, 0 to disable.
+		ConsecutiveErrors int `json:",default=5"`
+		// ErrorRate ejects a server if the rate of server errors in Window is over it,
+		// with MinRequests in Window at least, 0 to disable.
+		ErrorRate   float64       `json:",default=0.5,range=[0:1]"`
+		Window      time.Duration `json:",default=10s"`
+		MinRequests int           `json:",default=20"`
+		// BaseEjectionTime is the first ejection time of a server,
+		// doubled on each consecutive ejection up to MaxEjectionTime.
+		BaseEjectionTime time.Duration `json:",default=30s"`
+		MaxEjectionTime  time.Duration `json:",default=5m"`
+		// MaxEjectionPercent is the max percentage of the ejected servers.
+		MaxEjectionPercent int `json:",default=50,range=[0:100]"`
+	}
+
+	// outlierDetector keeps the stats of the servers across the pickers,
+	// because the pickers are rebuilt on any connectivity change.
+	outlierDetector struct {
+		conf  OutlierDetectionConf
+		stats map[string]*outlierStat
+		lock  sync.Mutex
+	}
+
+	outlierStat struct {
+		conf        *OutlierDetectionConf
+		consecutive int
+		window      *collection.RollingWindow
+		ejections   int
+		// ejectedUntil is the time returned by timex.Now, accessed atomically.
+		ejectedUntil int64
+		lock         sync.Mutex
+	}
+)
+
+func newOutlierDetector(c OutlierDetectionConf) *outlierDetector {
+	return &outlierDetector{
+		conf:  c,
+		stats: make(map[string]*outlierStat),
+	}
+}
+
+// prune removes the stats of the servers not in addrs, unless they are still ejected.
+func (d *outlierDetector) prune(addrs map[string]struct{}, now time.Duration) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	for addr, stat := range d.stats {
+		if _, ok := addrs[addr]; !ok && !stat.ejected(now) {
+			delete(d.stats, addr)
+		}
+	}
+}
+
+// stat returns the stat of the server on addr, created if not exists.
+func (d *outlierDetector) stat(addr string) *outlierStat {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	stat, ok := d.stats[addr]
+	if !ok {
+		stat = &outlierStat{
+			conf:   &d.conf,
+			window: newOutlierWindow(d.conf.Window),
+		}
+		d.stats[addr] = stat
+	}
+
+	return stat
+}
+
+// eject ejects the server, the ejection time is doubled if the server is ejected again
+// within MaxEjectionTime after it returns.
+func (s *outlierStat) eject(now time.Duration) time.Duration {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.ejections > 0 && now-time.Duration(atomic.LoadInt64(&s.ejectedUntil)) > s.conf.MaxEjectionTime {
+		s.ejections = 0
+	}
+	if s.ejections < maxEjectionShift {
+		s.ejections++
+	}
+
+	duration := s.conf.BaseEjectionTime << (s.ejections - 1)
+	if duration > s.conf.MaxEjectionTime || duration <= 0 {
+		duration = s.conf.MaxEjectionTime
+	}
+	atomic.StoreInt64(&s.ejectedUntil, int64(now+duration))
+	// start over after returning, to not be ejected again by the errors before the ejection.
+	s.consecutive = 0
+	s.window = newOutlierWindow(s.conf.Window)
+
+	return duration
+}
+
+func (s *outlierStat) ejected(now time.Duration) bool {
+	return int64(now) < atomic.LoadInt64(&s.ejectedUntil)
+}
+
+// record records the result of a call, returns the reason if the server should be ejected.
+func (s *outlierStat) record(err error) (string, bool) {
+	failed := isServerError(err)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if failed {
+		s.consecutive++
+		s.window.Add(1)
+	} else {
+		s.consecutive = 0
+		s.window.Add(0)
+		return "", false
+	}
+
+	if s.conf.ConsecutiveErrors > 0 && s.consecutive >= s.conf.ConsecutiveErrors {
+		return reasonConsecutiveError, true
+	}
+
+	if s.conf.ErrorRate > 0 {
+		var failures float64
+		var total int64
+		s.window.Reduce(func(b *collection.Bucket) {
+			failures += b.Sum
+			total += b.Count
+		})
+		if total > 0 && total >= int64(s.conf.MinRequests) && failures/float64(total) > s.conf.ErrorRate {
+			return reasonErrorRate, true
+		}
+	}
+
+	return "", false
+}
+
+func isServerError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	switch status.Code(err) {
+	case codes.Unknown, codes.DeadlineExceeded, codes.Internal, codes.Unavailable, codes.DataLoss:
+		return true
+	default:
+		return false
+	}
+}
+
+func newOutlierWindow(window time.Duration) *collection.RollingWindow {
+	interval := window / outlierBuckets
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	return collection.NewRollingWindow(outlierBuckets, interval)
+}
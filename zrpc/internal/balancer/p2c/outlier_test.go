@@ -0,0 +1,120 @@
+package p2c
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestOutlierConf() OutlierDetectionConf {
+	return OutlierDetectionConf{
+		ConsecutiveErrors:  3,
+		ErrorRate:          0.5,
+		Window:             time.Minute,
+		MinRequests:        10,
+		BaseEjectionTime:   time.Second,
+		MaxEjectionTime:    5 * time.Second,
+		MaxEjectionPercent: 50,
+	}
+}
+
+func TestOutlierStatRecordConsecutive(t *testing.T) {
+	stat := newOutlierDetector(newTestOutlierConf()).stat("a")
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+
+	for i := 0; i < 2; i++ {
+		_, ok := stat.record(unavailable)
+		assert.False(t, ok)
+	}
+	_, ok := stat.record(nil)
+	assert.False(t, ok)
+
+	for i := 0; i < 2; i++ {
+		_, ok = stat.record(unavailable)
+		assert.False(t, ok)
+	}
+	reason, ok := stat.record(unavailable)
+	assert.True(t, ok)
+	assert.Equal(t, reasonConsecutiveError, reason)
+}
+
+func TestOutlierStatRecordErrorRate(t *testing.T) {
+	c := newTestOutlierConf()
+	c.ConsecutiveErrors = 0
+	stat := newOutlierDetector(c).stat("a")
+	internal := status.Error(codes.Internal, "internal")
+
+	for i := 0; i < 4; i++ {
+		_, ok := stat.record(internal)
+		assert.False(t, ok)
+		_, ok = stat.record(nil)
+		assert.False(t, ok)
+	}
+
+	// 5 errors in 9 requests, less than MinRequests.
+	_, ok := stat.record(internal)
+	assert.False(t, ok)
+	reason, ok := stat.record(internal)
+	assert.True(t, ok)
+	assert.Equal(t, reasonErrorRate, reason)
+}
+
+func TestOutlierStatRecordClientErrors(t *testing.T) {
+	stat := newOutlierDetector(newTestOutlierConf()).stat("a")
+	for i := 0; i < 10; i++ {
+		_, ok := stat.record(status.Error(codes.InvalidArgument, "bad"))
+		assert.False(t, ok)
+	}
+}
+
+func TestOutlierStatEject(t *testing.T) {
+	stat := newOutlierDetector(newTestOutlierConf()).stat("a")
+	now := time.Hour
+
+	assert.False(t, stat.ejected(now))
+	assert.Equal(t, time.Second, stat.eject(now))
+	assert.True(t, stat.ejected(now))
+	assert.False(t, stat.ejected(now+time.Second))
+
+	// ejected again soon after returning, doubled.
+	now += 2 * time.Second
+	assert.Equal(t, 2*time.Second, stat.eject(now))
+	now += 3 * time.Second
+	assert.Equal(t, 4*time.Second, stat.eject(now))
+	now += 5 * time.Second
+	assert.Equal(t, 5*time.Second, stat.eject(now))
+
+	// healthy for longer than MaxEjectionTime, reset.
+	now += time.Minute
+	assert.Equal(t, time.Second, stat.eject(now))
+}
+
+func TestOutlierDetectorPrune(t *testing.T) {
+	d := newOutlierDetector(newTestOutlierConf())
+	a := d.stat("a")
+	assert.Equal(t, a, d.stat("a"))
+	b := d.stat("b")
+	b.eject(time.Hour)
+	d.stat("c")
+
+	d.prune(map[string]struct{}{"a": {}}, time.Hour)
+	assert.Len(t, d.stats, 2)
+	assert.Equal(t, a, d.stats["a"])
+	assert.Equal(t, b, d.stats["b"])
+
+	d.prune(map[string]struct{}{"a": {}}, 2*time.Hour)
+	assert.Len(t, d.stats, 1)
+}
+
+func TestIsServerError(t *testing.T) {
+	assert.False(t, isServerError(nil))
+	assert.True(t, isServerError(errors.New("any")))
+	assert.True(t, isServerError(status.Error(codes.Unavailable, "")))
+	assert.True(t, isServerError(status.Error(codes.DeadlineExceeded, "")))
+	assert.False(t, isServerError(status.Error(codes.NotFound, "")))
+	assert.False(t, isServerError(status.Error(codes.ResourceExhausted, "")))
+}
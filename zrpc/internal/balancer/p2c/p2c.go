@@ -1,6 +1,7 @@
 package p2c
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
 	"math/rand"
@@ -13,9 +14,11 @@ import (
 	"github.com/jialequ/linux-sdk/core/syncx"
 	"github.com/jialequ/linux-sdk/core/timex"
 	"github.com/jialequ/linux-sdk/zrpc/internal/codes"
+	"github.com/jialequ/linux-sdk/zrpc/internal/instance"
 	"google.golang.org/grpc/balancer"
 	"google.golang.org/grpc/balancer/base"
 	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/serviceconfig"
 )
 
 const (
@@ -34,66 +37,151 @@ const (
 var emptyPickResult balancer.PickResult
 
 func init() {
-	balancer.Register(newBuilder())
+	balancer.Register(new(p2cBuilder))
 }
 
-type p2cPickerBuilder struct{}
+type (
+	p2cBuilder struct{}
+
+	// p2cBalancer captures the config of the balancer for its picker builder.
+	p2cBalancer struct {
+		balancer.Balancer
+		builder *p2cPickerBuilder
+	}
+
+	p2cPickerBuilder struct {
+		detector *outlierDetector
+	}
+
+	lbConfig struct {
+		serviceconfig.LoadBalancingConfig `json:"-"`
+		OutlierDetection                  *OutlierDetectionConf `json:"outlierDetection,omitempty"`
+	}
+)
+
+// BuildServiceConfig returns the service config to use p2c balancer with the outlier detection.
+func BuildServiceConfig(c OutlierDetectionConf) string {
+	cfg, err := json.Marshal(map[string]any{
+		"loadBalancingConfig": []map[string]lbConfig{
+			{
+				Name: {
+					OutlierDetection: &c,
+				},
+			},
+		},
+	})
+	if err != nil {
+		logx.Errorf("p2c - failed to build service config, error: %v", err)
+		return fmt.Sprintf(`{"loadBalancingPolicy":"%s"}`, Name)
+	}
+
+	return string(cfg)
+}
+
+func (b *p2cBuilder) Build(cc balancer.ClientConn, opts balancer.BuildOptions) balancer.Balancer {
+	builder := new(p2cPickerBuilder)
+	return &p2cBalancer{
+		Balancer: base.NewBalancerBuilder(Name, builder, base.Config{HealthCheck: true}).Build(cc, opts),
+		builder:  builder,
+	}
+}
+
+func (b *p2cBuilder) Name() string {
+	return Name
+}
+
+func (b *p2cBuilder) ParseConfig(js json.RawMessage) (serviceconfig.LoadBalancingConfig, error) {
+	var cfg lbConfig
+	if err := json.Unmarshal(js, &cfg); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+func (b *p2cBalancer) UpdateClientConnState(state balancer.ClientConnState) error {
+	// called on the same goroutine with the picker building, no need to lock.
+	if cfg, ok := state.BalancerConfig.(*lbConfig); ok && cfg.OutlierDetection != nil {
+		if b.builder.detector == nil || b.builder.detector.conf != *cfg.OutlierDetection {
+			b.builder.detector = newOutlierDetector(*cfg.OutlierDetection)
+		}
+	} else {
+		b.builder.detector = nil
+	}
+
+	return b.Balancer.UpdateClientConnState(state)
+}
 
 func (b *p2cPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
-	readySCs := info.ReadySCs
+	if b.detector != nil {
+		addrs := make(map[string]struct{}, len(info.ReadySCs))
+		for _, connInfo := range info.ReadySCs {
+			addrs[connInfo.Address.Addr] = struct{}{}
+		}
+		b.detector.prune(addrs, timex.Now())
+	}
+
+	return instance.NewColorPicker(info.ReadySCs, b.build)
+}
+
+func (b *p2cPickerBuilder) build(readySCs map[balancer.SubConn]base.SubConnInfo) balancer.Picker {
+	readySCs = instance.PreferLocal(readySCs)
 	if len(readySCs) == 0 {
 		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
 	}
 
 	var conns []*subConn
 	for conn, connInfo := range readySCs {
-		conns = append(conns, &subConn{
+		sc := &subConn{
 			addr:    connInfo.Address,
 			conn:    conn,
 			success: initSuccess,
-		})
+		}
+		if b.detector != nil {
+			sc.outlier = b.detector.stat(connInfo.Address.Addr)
+		}
+		conns = append(conns, sc)
 	}
 
 	return &p2cPicker{
-		conns: conns,
-		r:     rand.New(rand.NewSource(time.Now().UnixNano())),
-		stamp: syncx.NewAtomicDuration(),
+		conns:    conns,
+		detector: b.detector,
+		r:        rand.New(rand.NewSource(time.Now().UnixNano())),
+		stamp:    syncx.NewAtomicDuration(),
 	}
 }
 
-func newBuilder() balancer.Builder {
-	return base.NewBalancerBuilder(Name, new(p2cPickerBuilder), base.Config{HealthCheck: true})
-}
-
 type p2cPicker struct {
-	conns []*subConn
-	r     *rand.Rand
-	stamp *syncx.AtomicDuration
-	lock  sync.Mutex
+	conns    []*subConn
+	detector *outlierDetector
+	r        *rand.Rand
+	stamp    *syncx.AtomicDuration
+	lock     sync.Mutex
 }
 
 func (p *p2cPicker) Pick(_ balancer.PickInfo) (balancer.PickResult, error) {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
+	conns := p.available()
 	var chosen *subConn
-	switch len(p.conns) {
+	switch len(conns) {
 	case 0:
 		return emptyPickResult, balancer.ErrNoSubConnAvailable
 	case 1:
-		chosen = p.choose(p.conns[0], nil)
+		chosen = p.choose(conns[0], nil)
 	case 2:
-		chosen = p.choose(p.conns[0], p.conns[1])
+		chosen = p.choose(conns[0], conns[1])
 	default:
 		var node1, node2 *subConn
 		for i := 0; i < pickTimes; i++ {
-			a := p.r.Intn(len(p.conns))
-			b := p.r.Intn(len(p.conns) - 1)
+			a := p.r.Intn(len(conns))
+			b := p.r.Intn(len(conns) - 1)
 			if b >= a {
 				b++
 			}
-			node1 = p.conns[a]
-			node2 = p.conns[b]
+			node1 = conns[a]
+			node2 = conns[b]
 			if node1.healthy() && node2.healthy() {
 				break
 			}
@@ -138,6 +226,12 @@ func (p *p2cPicker) buildDoneFunc(c *subConn) func(info balancer.DoneInfo) {
 		osucc := atomic.LoadUint64(&c.success)
 		atomic.StoreUint64(&c.success, uint64(float64(osucc)*w+float64(success)*(1-w)))
 
+		if c.outlier != nil {
+			if reason, ok := c.outlier.record(info.Err); ok {
+				p.eject(c, reason)
+			}
+		}
+
 		stamp := p.stamp.Load()
 		if now-stamp >= logInterval {
 			if p.stamp.CompareAndSwap(stamp, now) {
@@ -147,6 +241,26 @@ func (p *p2cPicker) buildDoneFunc(c *subConn) func(info balancer.DoneInfo) {
 	}
 }
 
+// available returns the conns that are not ejected, or all the conns if all ejected.
+func (p *p2cPicker) available() []*subConn {
+	if p.detector == nil {
+		return p.conns
+	}
+
+	now := timex.Now()
+	var conns []*subConn
+	for _, conn := range p.conns {
+		if !conn.outlier.ejected(now) {
+			conns = append(conns, conn)
+		}
+	}
+	if len(conns) == 0 {
+		return p.conns
+	}
+
+	return conns
+}
+
 func (p *p2cPicker) choose(c1, c2 *subConn) *subConn {
 	start := int64(timex.Now())
 	if c2 == nil {
@@ -167,6 +281,32 @@ func (p *p2cPicker) choose(c1, c2 *subConn) *subConn {
 	return c1
 }
 
+func (p *p2cPicker) eject(c *subConn, reason string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	now := timex.Now()
+	if c.outlier.ejected(now) {
+		return
+	}
+
+	var ejected int
+	for _, conn := range p.conns {
+		if conn.outlier.ejected(now) {
+			ejected++
+		}
+	}
+	if (ejected+1)*100 > len(p.conns)*p.detector.conf.MaxEjectionPercent {
+		logx.Errorf("p2c - skipped ejecting conn: %s, reason: %s, ejected: %d/%d",
+			c.addr.Addr, reason, ejected, len(p.conns))
+		return
+	}
+
+	duration := c.outlier.eject(now)
+	metricEjections.Inc(c.addr.Addr, reason)
+	logx.Errorf("p2c - ejected conn: %s, reason: %s, duration: %s", c.addr.Addr, reason, duration)
+}
+
 func (p *p2cPicker) logStats() {
 	var stats []string
 
@@ -190,6 +330,7 @@ type subConn struct {
 	pick     int64
 	addr     resolver.Address
 	conn     balancer.SubConn
+	outlier  *outlierStat
 }
 
 func (c *subConn) healthy() bool {
@@ -2,13 +2,18 @@ package p2c
 
 import (
 	"context"
+	"encoding/json"
+	"strconv"
 	"testing"
 
 	"github.com/jialequ/linux-sdk/core/logx"
+	"github.com/jialequ/linux-sdk/core/timex"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc/balancer"
 	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/status"
 )
 
 func init() {
@@ -115,6 +120,122 @@ func TestP2cPickerPickNil(t *testing.T) {
 // 	}
 // }
 
+func TestP2cPickerOutlierDetection(t *testing.T) {
+	ready := make(map[balancer.SubConn]base.SubConnInfo)
+	for i := 0; i < 4; i++ {
+		ready[mockClientConn{id: strconv.Itoa(i)}] = base.SubConnInfo{
+			Address: resolver.Address{
+				Addr: strconv.Itoa(i),
+			},
+		}
+	}
+
+	builder := &p2cPickerBuilder{
+		detector: newOutlierDetector(newTestOutlierConf()),
+	}
+	picker := builder.Build(base.PickerBuildInfo{
+		ReadySCs: ready,
+	}).(*p2cPicker)
+	fail := func(conn *subConn) {
+		for i := 0; i < 3; i++ {
+			picker.buildDoneFunc(conn)(balancer.DoneInfo{
+				Err: status.Error(codes.Unavailable, "unavailable"),
+			})
+		}
+	}
+
+	fail(picker.conns[0])
+	fail(picker.conns[1])
+	// at most 50% of the conns can be ejected.
+	fail(picker.conns[2])
+	now := timex.Now()
+	assert.True(t, picker.conns[0].outlier.ejected(now))
+	assert.True(t, picker.conns[1].outlier.ejected(now))
+	assert.False(t, picker.conns[2].outlier.ejected(now))
+
+	for i := 0; i < 100; i++ {
+		result, err := picker.Pick(balancer.PickInfo{
+			FullMethodName: "/",
+			Ctx:            context.Background(),
+		})
+		assert.NoError(t, err)
+		assert.NotEqual(t, picker.conns[0].conn, result.SubConn)
+		assert.NotEqual(t, picker.conns[1].conn, result.SubConn)
+		result.Done(balancer.DoneInfo{})
+	}
+
+	// the ejection state is kept across the rebuilds.
+	rebuilt := builder.Build(base.PickerBuildInfo{
+		ReadySCs: ready,
+	}).(*p2cPicker)
+	var ejected int
+	for _, conn := range rebuilt.conns {
+		if conn.outlier.ejected(now) {
+			ejected++
+		}
+	}
+	assert.Equal(t, 2, ejected)
+}
+
+func TestP2cPickerAllEjected(t *testing.T) {
+	c := newTestOutlierConf()
+	c.MaxEjectionPercent = 100
+	builder := &p2cPickerBuilder{
+		detector: newOutlierDetector(c),
+	}
+	picker := builder.Build(base.PickerBuildInfo{
+		ReadySCs: map[balancer.SubConn]base.SubConnInfo{
+			mockClientConn{id: "a"}: {
+				Address: resolver.Address{Addr: "a"},
+			},
+		},
+	}).(*p2cPicker)
+	picker.conns[0].outlier.eject(timex.Now())
+
+	// fall back to the ejected conns instead of failing the calls.
+	_, err := picker.Pick(balancer.PickInfo{
+		FullMethodName: "/",
+		Ctx:            context.Background(),
+	})
+	assert.NoError(t, err)
+}
+
+func TestP2cBalancerConfig(t *testing.T) {
+	c := newTestOutlierConf()
+	svcCfg := BuildServiceConfig(c)
+
+	var cfg struct {
+		LoadBalancingConfig []map[string]json.RawMessage `json:"loadBalancingConfig"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(svcCfg), &cfg))
+	assert.Len(t, cfg.LoadBalancingConfig, 1)
+
+	b := new(p2cBuilder)
+	assert.Equal(t, Name, b.Name())
+	parsed, err := b.ParseConfig(cfg.LoadBalancingConfig[0][Name])
+	assert.NoError(t, err)
+	assert.Equal(t, c, *parsed.(*lbConfig).OutlierDetection)
+
+	_, err = b.ParseConfig(json.RawMessage("bad"))
+	assert.Error(t, err)
+
+	bal := &p2cBalancer{
+		Balancer: mockBalancer{},
+		builder:  new(p2cPickerBuilder),
+	}
+	assert.NoError(t, bal.UpdateClientConnState(balancer.ClientConnState{
+		BalancerConfig: parsed,
+	}))
+	detector := bal.builder.detector
+	assert.NotNil(t, detector)
+	assert.NoError(t, bal.UpdateClientConnState(balancer.ClientConnState{
+		BalancerConfig: parsed,
+	}))
+	assert.Equal(t, detector, bal.builder.detector)
+	assert.NoError(t, bal.UpdateClientConnState(balancer.ClientConnState{}))
+	assert.Nil(t, bal.builder.detector)
+}
+
 func TestPickerWithEmptyConns(t *testing.T) {
 	var picker p2cPicker
 	_, err := picker.Pick(balancer.PickInfo{
@@ -148,3 +269,11 @@ func (m mockClientConn) Shutdown() {
 
 	//func (m mockClientConn) Shutdown()
 }
+
+type mockBalancer struct {
+	balancer.Balancer
+}
+
+func (m mockBalancer) UpdateClientConnState(_ balancer.ClientConnState) error {
+	return nil
+}
@@ -0,0 +1,128 @@
+package wrr
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jialequ/linux-sdk/core/logx"
+	"github.com/jialequ/linux-sdk/core/syncx"
+	"github.com/jialequ/linux-sdk/core/timex"
+	"github.com/jialequ/linux-sdk/zrpc/internal/instance"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/resolver"
+)
+
+const (
+	// Name is the name of weighted round robin balancer.
+	Name = "wrr"
+
+	logInterval = time.Minute
+)
+
+var emptyPickResult balancer.PickResult
+
+func init() {
+	balancer.Register(newBuilder())
+}
+
+type wrrPickerBuilder struct{}
+
+func (b *wrrPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	return instance.NewColorPicker(info.ReadySCs, b.build)
+}
+
+func (b *wrrPickerBuilder) build(readySCs map[balancer.SubConn]base.SubConnInfo) balancer.Picker {
+	readySCs = instance.PreferLocal(readySCs)
+	if len(readySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+
+	var conns []*subConn
+	for conn, connInfo := range readySCs {
+		conns = append(conns, &subConn{
+			addr:   connInfo.Address,
+			conn:   conn,
+			weight: int64(instance.Weight(connInfo.Address)),
+		})
+	}
+
+	return &wrrPicker{
+		conns: conns,
+		stamp: syncx.NewAtomicDuration(),
+	}
+}
+
+func newBuilder() balancer.Builder {
+	return base.NewBalancerBuilder(Name, new(wrrPickerBuilder), base.Config{HealthCheck: true})
+}
+
+// wrrPicker picks the conns with smooth weighted round robin, same as nginx.
+type wrrPicker struct {
+	conns []*subConn
+	stamp *syncx.AtomicDuration
+	lock  sync.Mutex
+}
+
+func (p *wrrPicker) Pick(_ balancer.PickInfo) (balancer.PickResult, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if len(p.conns) == 0 {
+		return emptyPickResult, balancer.ErrNoSubConnAvailable
+	}
+
+	var total int64
+	var chosen *subConn
+	for _, conn := range p.conns {
+		conn.current += conn.weight
+		total += conn.weight
+		if chosen == nil || conn.current > chosen.current {
+			chosen = conn
+		}
+	}
+	chosen.current -= total
+	atomic.AddInt64(&chosen.requests, 1)
+
+	return balancer.PickResult{
+		SubConn: chosen.conn,
+		Done:    p.buildDoneFunc(),
+	}, nil
+}
+
+func (p *wrrPicker) buildDoneFunc() func(info balancer.DoneInfo) {
+	return func(info balancer.DoneInfo) {
+		now := timex.Now()
+		stamp := p.stamp.Load()
+		if now-stamp >= logInterval {
+			if p.stamp.CompareAndSwap(stamp, now) {
+				p.logStats()
+			}
+		}
+	}
+}
+
+func (p *wrrPicker) logStats() {
+	var stats []string
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, conn := range p.conns {
+		stats = append(stats, fmt.Sprintf("conn: %s, weight: %d, reqs: %d",
+			conn.addr.Addr, conn.weight, atomic.SwapInt64(&conn.requests, 0)))
+	}
+
+	logx.Statf("wrr - %s", strings.Join(stats, "; "))
+}
+
+type subConn struct {
+	weight   int64
+	current  int64
+	requests int64
+	addr     resolver.Address
+	conn     balancer.SubConn
+}
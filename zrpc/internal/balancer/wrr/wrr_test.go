@@ -0,0 +1,117 @@
+package wrr
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/jialequ/linux-sdk/core/canary"
+	"github.com/jialequ/linux-sdk/core/logx"
+	"github.com/jialequ/linux-sdk/zrpc/internal/instance"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/resolver"
+)
+
+func init() {
+	logx.Disable()
+}
+
+func TestWrrPickerPickNil(t *testing.T) {
+	builder := new(wrrPickerBuilder)
+	picker := builder.Build(base.PickerBuildInfo{})
+	_, err := picker.Pick(balancer.PickInfo{
+		FullMethodName: "/",
+		Ctx:            context.Background(),
+	})
+	assert.NotNil(t, err)
+}
+
+func TestWrrPickerPick(t *testing.T) {
+	ready := make(map[balancer.SubConn]base.SubConnInfo)
+	for i := 1; i <= 3; i++ {
+		ready[mockClientConn{id: strconv.Itoa(i)}] = base.SubConnInfo{
+			Address: instance.WithMetadata(resolver.Address{
+				Addr: strconv.Itoa(i),
+			}, instance.Metadata{instance.WeightKey: strconv.Itoa(i)}),
+		}
+	}
+
+	picker := new(wrrPickerBuilder).Build(base.PickerBuildInfo{
+		ReadySCs: ready,
+	})
+	dist := make(map[string]int)
+	for i := 0; i < 600; i++ {
+		result, err := picker.Pick(balancer.PickInfo{
+			FullMethodName: "/",
+			Ctx:            context.Background(),
+		})
+		assert.NoError(t, err)
+		result.Done(balancer.DoneInfo{})
+		dist[result.SubConn.(mockClientConn).id]++
+	}
+
+	assert.Equal(t, 100, dist["1"])
+	assert.Equal(t, 200, dist["2"])
+	assert.Equal(t, 300, dist["3"])
+	picker.(*wrrPicker).logStats()
+}
+
+func TestWrrPickerPickColored(t *testing.T) {
+	ready := make(map[balancer.SubConn]base.SubConnInfo)
+	for _, color := range []string{"", "v2"} {
+		ready[mockClientConn{id: color}] = base.SubConnInfo{
+			Address: instance.WithMetadata(resolver.Address{
+				Addr: color,
+			}, instance.Metadata{instance.ColorKey: color}),
+		}
+	}
+
+	picker := new(wrrPickerBuilder).Build(base.PickerBuildInfo{
+		ReadySCs: ready,
+	})
+	for i := 0; i < 10; i++ {
+		result, err := picker.Pick(balancer.PickInfo{
+			FullMethodName: "/",
+			Ctx:            canary.NewContext(context.Background(), "v2"),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "v2", result.SubConn.(mockClientConn).id)
+
+		result, err = picker.Pick(balancer.PickInfo{
+			FullMethodName: "/",
+			Ctx:            context.Background(),
+		})
+		assert.NoError(t, err)
+		assert.Empty(t, result.SubConn.(mockClientConn).id)
+	}
+}
+
+func TestPickerWithEmptyConns(t *testing.T) {
+	var picker wrrPicker
+	_, err := picker.Pick(balancer.PickInfo{
+		FullMethodName: "/",
+		Ctx:            context.Background(),
+	})
+	assert.ErrorIs(t, err, balancer.ErrNoSubConnAvailable)
+}
+
+type mockClientConn struct {
+	// add random string member to avoid map key equality.
+	id string
+}
+
+func (m mockClientConn) GetOrBuildProducer(builder balancer.ProducerBuilder) (
+	p balancer.Producer, close func()) {
+	return builder.Build(m)
+}
+
+func (m mockClientConn) UpdateAddresses(_ []resolver.Address) {
+}
+
+func (m mockClientConn) Connect() {
+}
+
+func (m mockClientConn) Shutdown() {
+}
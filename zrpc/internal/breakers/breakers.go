@@ -0,0 +1,260 @@
+package breakers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/jialequ/linux-sdk/core/breaker"
+	"github.com/jialequ/linux-sdk/core/logx"
+	zcodes "github.com/jialequ/linux-sdk/zrpc/internal/codes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+const wildcardAll = "*"
+
+// defaultFailureCodes are the codes counted as failures, same as codes.Acceptable.
+var defaultFailureCodes = []codes.Code{
+	codes.DeadlineExceeded, codes.Internal, codes.Unavailable, codes.DataLoss,
+	codes.Unimplemented, codes.ResourceExhausted,
+}
+
+type (
+	// Conf defines the breaker config of the methods.
+	Conf struct {
+		Rules []RuleConf `json:",optional"`
+	}
+
+	// RuleConf defines the breaker rule of the methods.
+	RuleConf struct {
+		// FullMethod is the method to apply, like /pkg.Service/Method,
+		// /pkg.Service/* matches all the methods of the service, empty matches all methods.
+		FullMethod string `json:",optional"`
+		// Disabled turns off the breaker of the methods.
+		Disabled bool `json:",optional"`
+		// K is the sensitivity of the breaker, defaults to 1.5,
+		// the smaller k is, the more aggressively the breaker drops the calls.
+		K float64 `json:",optional,range=[0:10]"`
+		// FailureCodes are the codes counted as failures, like Unavailable or UNAVAILABLE,
+		// defaults to DeadlineExceeded, Internal, Unavailable, DataLoss, Unimplemented
+		// and ResourceExhausted.
+		FailureCodes []string `json:",optional"`
+		// AcceptableCodes are the codes not counted as failures, removed from FailureCodes.
+		AcceptableCodes []string `json:",optional"`
+		// Fallback is the response in JSON returned to the unary calls when the breaker is open.
+		Fallback string `json:",optional"`
+	}
+
+	// Breakers is the breakers of the methods with the rules.
+	// A nil *Breakers uses the default breakers of the methods.
+	Breakers struct {
+		methods  map[string]*rule
+		services map[string]*rule
+		fallback *rule
+		breakers map[string]breaker.Breaker
+		lock     sync.Mutex
+	}
+
+	rule struct {
+		RuleConf
+		failures map[codes.Code]struct{}
+	}
+)
+
+// New returns a Breakers with the rules in c.
+func New(c Conf) (*Breakers, error) {
+	b := &Breakers{
+		methods:  make(map[string]*rule),
+		services: make(map[string]*rule),
+		breakers: make(map[string]breaker.Breaker),
+	}
+
+	for _, rc := range c.Rules {
+		r, err := newRule(rc)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case len(rc.FullMethod) == 0 || rc.FullMethod == wildcardAll:
+			b.fallback = r
+		case strings.HasSuffix(rc.FullMethod, "/"+wildcardAll):
+			b.services[strings.TrimSuffix(rc.FullMethod, wildcardAll)] = r
+		default:
+			b.methods[rc.FullMethod] = r
+		}
+	}
+
+	return b, nil
+}
+
+// Acceptable checks if err is not a failure of method.
+func (b *Breakers) Acceptable(method string, err error) bool {
+	r := b.match(method)
+	if r == nil {
+		return zcodes.Acceptable(err)
+	}
+
+	return r.acceptable(err)
+}
+
+// Allow checks if the call of method is allowed by the breaker with name.
+// The promise is nil if the breaker of method is disabled.
+func (b *Breakers) Allow(name, method string) (breaker.Promise, error) {
+	r := b.match(method)
+	if r != nil && r.Disabled {
+		return nil, nil
+	}
+
+	return b.breaker(name, r).Allow()
+}
+
+// Do runs req of method with the breaker with name.
+func (b *Breakers) Do(name, method string, req func() error) error {
+	r := b.match(method)
+	if r == nil {
+		return breaker.DoWithAcceptable(name, req, zcodes.Acceptable)
+	}
+	if r.Disabled {
+		return req()
+	}
+
+	return b.breaker(name, r).DoWithAcceptable(req, r.acceptable)
+}
+
+// Fallback returns the fallback response of method, reply is filled if not nil,
+// otherwise the response is created by the output type of method.
+func (b *Breakers) Fallback(method string, reply any) (any, bool) {
+	r := b.match(method)
+	if r == nil || len(r.Fallback) == 0 {
+		return nil, false
+	}
+
+	msg, ok := reply.(proto.Message)
+	if !ok {
+		if msg, ok = newOutputMessage(method); !ok {
+			logx.Errorf("breaker fallback of %s ignored, unknown response type", method)
+			return nil, false
+		}
+	}
+
+	if err := protojson.Unmarshal([]byte(r.Fallback), msg); err != nil {
+		logx.Errorf("breaker fallback of %s ignored, error: %v", method, err)
+		return nil, false
+	}
+
+	return msg, true
+}
+
+func (b *Breakers) breaker(name string, r *rule) breaker.Breaker {
+	if r == nil || r.K <= 0 {
+		return breaker.GetBreaker(name)
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	brk, ok := b.breakers[name]
+	if !ok {
+		brk = breaker.NewBreaker(breaker.WithName(name), breaker.WithK(r.K))
+		b.breakers[name] = brk
+	}
+
+	return brk
+}
+
+func (b *Breakers) match(method string) *rule {
+	if b == nil {
+		return nil
+	}
+
+	if r, ok := b.methods[method]; ok {
+		return r
+	}
+
+	if index := strings.LastIndexByte(method, '/'); index >= 0 {
+		if r, ok := b.services[method[:index+1]]; ok {
+			return r
+		}
+	}
+
+	return b.fallback
+}
+
+func newRule(c RuleConf) (*rule, error) {
+	if len(c.Fallback) > 0 && !json.Valid([]byte(c.Fallback)) {
+		return nil, fmt.Errorf("breaker: invalid fallback of %q", c.FullMethod)
+	}
+
+	failures := make(map[codes.Code]struct{})
+	if len(c.FailureCodes) == 0 {
+		for _, code := range defaultFailureCodes {
+			failures[code] = struct{}{}
+		}
+	}
+	for _, name := range c.FailureCodes {
+		code, err := parseCode(name)
+		if err != nil {
+			return nil, err
+		}
+
+		failures[code] = struct{}{}
+	}
+	for _, name := range c.AcceptableCodes {
+		code, err := parseCode(name)
+		if err != nil {
+			return nil, err
+		}
+
+		delete(failures, code)
+	}
+
+	return &rule{
+		RuleConf: c,
+		failures: failures,
+	}, nil
+}
+
+func (r *rule) acceptable(err error) bool {
+	_, ok := r.failures[status.Code(err)]
+	return !ok
+}
+
+// newOutputMessage returns a new message of the output type of method, like /pkg.Service/Method.
+func newOutputMessage(method string) (proto.Message, bool) {
+	name := protoreflect.FullName(strings.ReplaceAll(strings.TrimPrefix(method, "/"), "/", "."))
+	desc, err := protoregistry.GlobalFiles.FindDescriptorByName(name)
+	if err != nil {
+		return nil, false
+	}
+
+	md, ok := desc.(protoreflect.MethodDescriptor)
+	if !ok {
+		return nil, false
+	}
+
+	mt, err := protoregistry.GlobalTypes.FindMessageByName(md.Output().FullName())
+	if err != nil {
+		return nil, false
+	}
+
+	return mt.New().Interface(), true
+}
+
+// parseCode parses the code by name, like NotFound or NOT_FOUND.
+func parseCode(name string) (codes.Code, error) {
+	normalized := strings.ReplaceAll(name, "_", "")
+	for code := codes.OK; code <= codes.Unauthenticated; code++ {
+		if strings.EqualFold(code.String(), normalized) {
+			return code, nil
+		}
+	}
+
+	return codes.Unknown, fmt.Errorf("breaker: unknown code %q", name)
+}
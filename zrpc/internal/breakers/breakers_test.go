@@ -0,0 +1,159 @@
+package breakers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jialequ/linux-sdk/core/breaker"
+	"github.com/jialequ/linux-sdk/core/stat"
+	"github.com/jialequ/linux-sdk/internal/mock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const depositMethod = "/mock.DepositService/Deposit"
+
+func init() {
+	stat.SetReporter(nil)
+}
+
+func TestNewInvalid(t *testing.T) {
+	_, err := New(Conf{Rules: []RuleConf{{FailureCodes: []string{"Bad"}}}})
+	assert.Error(t, err)
+
+	_, err = New(Conf{Rules: []RuleConf{{AcceptableCodes: []string{"Bad"}}}})
+	assert.Error(t, err)
+
+	_, err = New(Conf{Rules: []RuleConf{{Fallback: "{"}}})
+	assert.Error(t, err)
+}
+
+func TestBreakersAcceptable(t *testing.T) {
+	b, err := New(Conf{
+		Rules: []RuleConf{
+			{
+				FullMethod:   "/pkg.Service/Get",
+				FailureCodes: []string{"Unavailable", "NOT_FOUND"},
+			},
+			{
+				FullMethod:      "/pkg.Service/*",
+				AcceptableCodes: []string{"ResourceExhausted"},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	notFound := status.Error(codes.NotFound, "not found")
+	exhausted := status.Error(codes.ResourceExhausted, "exhausted")
+	internal := status.Error(codes.Internal, "internal")
+
+	assert.False(t, b.Acceptable("/pkg.Service/Get", notFound))
+	assert.True(t, b.Acceptable("/pkg.Service/Get", internal))
+	assert.True(t, b.Acceptable("/pkg.Service/List", notFound))
+	assert.True(t, b.Acceptable("/pkg.Service/List", exhausted))
+	assert.False(t, b.Acceptable("/pkg.Service/List", internal))
+	assert.False(t, b.Acceptable("/pkg.Other/List", exhausted))
+	assert.True(t, b.Acceptable("/pkg.Other/List", nil))
+
+	var nilBreakers *Breakers
+	assert.False(t, nilBreakers.Acceptable("/pkg.Other/List", exhausted))
+}
+
+func TestBreakersDo(t *testing.T) {
+	b, err := New(Conf{
+		Rules: []RuleConf{
+			{
+				FullMethod: "/pkg.Service/Disabled",
+				Disabled:   true,
+			},
+			{
+				FullMethod: "/pkg.Service/Sensitive",
+				K:          1.1,
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	errInternal := status.Error(codes.Internal, "internal")
+	for i := 0; i < 1000; i++ {
+		assert.Equal(t, errInternal, b.Do("disabled", "/pkg.Service/Disabled", func() error {
+			return errInternal
+		}))
+	}
+	promise, err := b.Allow("disabled", "/pkg.Service/Disabled")
+	assert.NoError(t, err)
+	assert.Nil(t, promise)
+
+	var dropped bool
+	for i := 0; i < 1000; i++ {
+		if errors.Is(b.Do("sensitive", "/pkg.Service/Sensitive", func() error {
+			return errInternal
+		}), breaker.ErrServiceUnavailable) {
+			dropped = true
+		}
+	}
+	assert.True(t, dropped)
+	assert.Equal(t, b.breaker("sensitive", b.match("/pkg.Service/Sensitive")), b.breakers["sensitive"])
+
+	assert.NoError(t, b.Do("default", "/pkg.Service/Default", func() error {
+		return nil
+	}))
+	promise, err = b.Allow("default", "/pkg.Service/Default")
+	assert.NoError(t, err)
+	assert.NotNil(t, promise)
+	promise.Accept()
+}
+
+func TestBreakersFallback(t *testing.T) {
+	b, err := New(Conf{
+		Rules: []RuleConf{
+			{
+				FullMethod: depositMethod,
+				Fallback:   `{"ok": true}`,
+			},
+			{
+				FullMethod: "/pkg.Service/Unknown",
+				Fallback:   `{"ok": true}`,
+			},
+			{
+				FullMethod: "/mock.DepositService/*",
+				Fallback:   `{"bad": true}`,
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	resp, ok := b.Fallback(depositMethod, nil)
+	assert.True(t, ok)
+	assert.True(t, resp.(*mock.DepositResponse).Ok)
+
+	reply := new(mock.DepositResponse)
+	resp, ok = b.Fallback(depositMethod, reply)
+	assert.True(t, ok)
+	assert.Equal(t, reply, resp)
+	assert.True(t, reply.Ok)
+
+	_, ok = b.Fallback("/pkg.Service/Unknown", nil)
+	assert.False(t, ok)
+	_, ok = b.Fallback("/mock.DepositService/Other", new(mock.DepositResponse))
+	assert.False(t, ok)
+	_, ok = b.Fallback("/pkg.Service/None", nil)
+	assert.False(t, ok)
+}
+
+func TestParseCode(t *testing.T) {
+	for name, expect := range map[string]codes.Code{
+		"NotFound":            codes.NotFound,
+		"NOT_FOUND":           codes.NotFound,
+		"unavailable":         codes.Unavailable,
+		"FAILED_PRECONDITION": codes.FailedPrecondition,
+	} {
+		code, err := parseCode(name)
+		assert.NoError(t, err)
+		assert.Equal(t, expect, code)
+	}
+
+	_, err := parseCode("Unknown_Code")
+	assert.Error(t, err)
+}
@@ -7,10 +7,18 @@ import (
 	"strings"
 	"time"
 
+	threading "github.com/jialequ/linux-sdk/core/dist"
+	"github.com/jialequ/linux-sdk/internal/fault"
+	"github.com/jialequ/linux-sdk/zrpc/internal/balancer/consistenthash"
+	_ "github.com/jialequ/linux-sdk/zrpc/internal/balancer/leastrequest"
 	"github.com/jialequ/linux-sdk/zrpc/internal/balancer/p2c"
+	_ "github.com/jialequ/linux-sdk/zrpc/internal/balancer/wrr"
+	"github.com/jialequ/linux-sdk/zrpc/internal/breakers"
 	"github.com/jialequ/linux-sdk/zrpc/internal/clientinterceptors"
+	"github.com/jialequ/linux-sdk/zrpc/internal/message"
 	"github.com/jialequ/linux-sdk/zrpc/resolver"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 )
@@ -32,9 +40,14 @@ type (
 
 	// A ClientOptions is a client options.
 	ClientOptions struct {
-		NonBlock    bool
-		Timeout     time.Duration
-		Secure      bool
+		NonBlock bool
+		Timeout  time.Duration
+		Secure   bool
+		Balancer string
+		// OutlierDetection ejects the failing servers, only for p2c balancer.
+		OutlierDetection *p2c.OutlierDetectionConf
+		// Message applies the size limits and the compression of the messages.
+		Message     *message.Matcher
 		DialOptions []grpc.DialOption
 	}
 
@@ -42,8 +55,11 @@ type (
 	ClientOption func(options *ClientOptions)
 
 	client struct {
-		conn        *grpc.ClientConn
-		middlewares ClientMiddlewaresConf
+		conn             *grpc.ClientConn
+		middlewares      ClientMiddlewaresConf
+		retryInterceptor grpc.UnaryClientInterceptor
+		faultInjector    *fault.Injector
+		breakers         *breakers.Breakers
 	}
 )
 
@@ -53,13 +69,44 @@ func NewClient(target string, middlewares ClientMiddlewaresConf, opts ...ClientO
 		middlewares: middlewares,
 	}
 
-	svcCfg := fmt.Sprintf(`{"loadBalancingPolicy":"%s"}`, p2c.Name)
-	balancerOpt := WithDialOption(grpc.WithDefaultServiceConfig(svcCfg))
-	opts = append([]ClientOption{balancerOpt}, opts...)
+	if middlewares.Breaker && len(middlewares.BreakerConf.Rules) > 0 {
+		b, err := breakers.New(middlewares.BreakerConf)
+		if err != nil {
+			return nil, err
+		}
+
+		cli.breakers = b
+	}
+
+	if middlewares.Retry {
+		interceptor, err := clientinterceptors.RetryInterceptor(middlewares.RetryConf)
+		if err != nil {
+			return nil, err
+		}
+
+		cli.retryInterceptor = interceptor
+	}
+
+	if middlewares.Fault {
+		injector, err := fault.NewInjector(middlewares.FaultConf)
+		if err != nil {
+			return nil, err
+		}
+
+		cli.faultInjector = injector
+	}
+
 	if err := cli.dial(target, opts...); err != nil {
+		if cli.faultInjector != nil {
+			cli.faultInjector.Stop()
+		}
 		return nil, err
 	}
 
+	if cli.faultInjector != nil {
+		threading.GoSafe(cli.stopInjectorOnClose)
+	}
+
 	return &cli, nil
 }
 
@@ -83,10 +130,38 @@ func (c *client) buildDialOptions(opts ...ClientOption) []grpc.DialOption {
 		options = append(options, grpc.WithBlock())
 	}
 
+	balancerName := cliOpts.Balancer
+	if len(balancerName) == 0 {
+		balancerName = p2c.Name
+	}
+	svcCfg := fmt.Sprintf(`{"loadBalancingPolicy":"%s"}`, balancerName)
+	if balancerName == p2c.Name && cliOpts.OutlierDetection != nil {
+		svcCfg = p2c.BuildServiceConfig(*cliOpts.OutlierDetection)
+	}
 	options = append(options,
+		grpc.WithDefaultServiceConfig(svcCfg),
 		grpc.WithChainUnaryInterceptor(c.buildUnaryInterceptors(cliOpts.Timeout)...),
 		grpc.WithChainStreamInterceptor(c.buildStreamInterceptors()...),
 	)
+	if c.middlewares.Prometheus {
+		options = append(options, grpc.WithStatsHandler(clientinterceptors.PrometheusStatsHandler()))
+	}
+	if cliOpts.Message != nil {
+		options = append(options, grpc.WithDefaultCallOptions(cliOpts.Message.CallOptions("")...),
+			grpc.WithStatsHandler(newMessageSizeLogger(true)))
+		if cliOpts.Message.HasOverrides() {
+			options = append(options,
+				grpc.WithChainUnaryInterceptor(clientinterceptors.UnaryMessageInterceptor(cliOpts.Message)),
+				grpc.WithChainStreamInterceptor(clientinterceptors.StreamMessageInterceptor(cliOpts.Message)),
+			)
+		}
+	}
+	if balancerName == consistenthash.Name {
+		options = append(options,
+			grpc.WithChainUnaryInterceptor(clientinterceptors.UnaryHashKeyInterceptor),
+			grpc.WithChainStreamInterceptor(clientinterceptors.StreamHashKeyInterceptor),
+		)
+	}
 
 	return append(options, cliOpts.DialOptions...)
 }
@@ -97,6 +172,33 @@ func (c *client) buildStreamInterceptors() []grpc.StreamClientInterceptor {
 	if c.middlewares.Trace {
 		interceptors = append(interceptors, clientinterceptors.StreamTracingInterceptor)
 	}
+	if c.middlewares.RequestId {
+		interceptors = append(interceptors, clientinterceptors.StreamRequestIdInterceptor)
+	}
+	if c.middlewares.Canary {
+		interceptors = append(interceptors, clientinterceptors.StreamCanaryInterceptor)
+	}
+	if c.middlewares.Duration {
+		interceptors = append(interceptors, clientinterceptors.StreamDurationInterceptor)
+	}
+	if c.middlewares.Prometheus {
+		interceptors = append(interceptors, clientinterceptors.StreamPrometheusInterceptor)
+	}
+	if c.middlewares.Budget {
+		interceptors = append(interceptors,
+			clientinterceptors.StreamBudgetInterceptor(c.middlewares.BudgetMargin))
+	}
+	if c.middlewares.Breaker {
+		if c.breakers != nil {
+			interceptors = append(interceptors, clientinterceptors.StreamBreakersInterceptor(c.breakers))
+		} else {
+			interceptors = append(interceptors, clientinterceptors.StreamBreakerInterceptor)
+		}
+	}
+	// the timeout is not applied on streams, because streams are usually long-lived.
+	if c.faultInjector != nil {
+		interceptors = append(interceptors, clientinterceptors.StreamFaultInterceptor(c.faultInjector))
+	}
 
 	return interceptors
 }
@@ -107,22 +209,54 @@ func (c *client) buildUnaryInterceptors(timeout time.Duration) []grpc.UnaryClien
 	if c.middlewares.Trace {
 		interceptors = append(interceptors, clientinterceptors.UnaryTracingInterceptor)
 	}
+	if c.middlewares.RequestId {
+		interceptors = append(interceptors, clientinterceptors.UnaryRequestIdInterceptor)
+	}
+	if c.middlewares.Canary {
+		interceptors = append(interceptors, clientinterceptors.UnaryCanaryInterceptor)
+	}
 	if c.middlewares.Duration {
 		interceptors = append(interceptors, clientinterceptors.DurationInterceptor)
 	}
 	if c.middlewares.Prometheus {
 		interceptors = append(interceptors, clientinterceptors.PrometheusInterceptor)
 	}
+	if c.middlewares.Budget {
+		interceptors = append(interceptors,
+			clientinterceptors.UnaryBudgetInterceptor(c.middlewares.BudgetMargin))
+	}
 	if c.middlewares.Breaker {
-		interceptors = append(interceptors, clientinterceptors.BreakerInterceptor)
+		if c.breakers != nil {
+			interceptors = append(interceptors, clientinterceptors.BreakersInterceptor(c.breakers))
+		} else {
+			interceptors = append(interceptors, clientinterceptors.BreakerInterceptor)
+		}
 	}
 	if c.middlewares.Timeout {
 		interceptors = append(interceptors, clientinterceptors.TimeoutInterceptor(timeout))
 	}
+	// retries are placed after timeout to share the deadline of the call.
+	if c.retryInterceptor != nil {
+		interceptors = append(interceptors, c.retryInterceptor)
+	}
+	// fault injection is placed after breaker and timeout to exercise them.
+	if c.faultInjector != nil {
+		interceptors = append(interceptors, clientinterceptors.UnaryFaultInterceptor(c.faultInjector))
+	}
 
 	return interceptors
 }
 
+// stopInjectorOnClose stops the fault injector after the conn is closed,
+// to release the watcher of the rules file.
+func (c *client) stopInjectorOnClose() {
+	for state := c.conn.GetState(); state != connectivity.Shutdown; state = c.conn.GetState() {
+		c.conn.WaitForStateChange(context.Background(), state)
+	}
+
+	c.faultInjector.Stop()
+}
+
 func (c *client) dial(server string, opts ...ClientOption) error {
 	options := c.buildDialOptions(opts...)
 	timeCtx, cancel := context.WithTimeout(context.Background(), dialTimeout)
@@ -145,6 +279,13 @@ func (c *client) dial(server string, opts ...ClientOption) error {
 	return nil
 }
 
+// WithBalancer returns a func to customize a ClientOptions with given balancer name.
+func WithBalancer(name string) ClientOption {
+	return func(options *ClientOptions) {
+		options.Balancer = name
+	}
+}
+
 // WithDialOption returns a func to customize a ClientOptions with given dial option.
 func WithDialOption(opt grpc.DialOption) ClientOption {
 	return func(options *ClientOptions) {
@@ -152,6 +293,14 @@ func WithDialOption(opt grpc.DialOption) ClientOption {
 	}
 }
 
+// WithMessage returns a func to customize a ClientOptions to apply the size limits and
+// the compression of the messages.
+func WithMessage(m *message.Matcher) ClientOption {
+	return func(options *ClientOptions) {
+		options.Message = m
+	}
+}
+
 // WithNonBlock sets the dialing to be nonblock.
 func WithNonBlock() ClientOption {
 	return func(options *ClientOptions) {
@@ -159,6 +308,14 @@ func WithNonBlock() ClientOption {
 	}
 }
 
+// WithOutlierDetection returns a func to customize a ClientOptions to eject the failing servers,
+// only for p2c balancer.
+func WithOutlierDetection(c OutlierDetectionConf) ClientOption {
+	return func(options *ClientOptions) {
+		options.OutlierDetection = &c
+	}
+}
+
 // WithStreamClientInterceptor returns a func to customize a ClientOptions with given interceptor.
 func WithStreamClientInterceptor(interceptor grpc.StreamClientInterceptor) ClientOption {
 	return func(options *ClientOptions) {
@@ -8,10 +8,25 @@ import (
 	"testing"
 	"time"
 
+	"github.com/jialequ/linux-sdk/zrpc/internal/message"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc"
 )
 
+func TestWithBalancer(t *testing.T) {
+	var options ClientOptions
+	opt := WithBalancer("foo")
+	opt(&options)
+	assert.Equal(t, "foo", options.Balancer)
+}
+
+func TestWithOutlierDetection(t *testing.T) {
+	var options ClientOptions
+	opt := WithOutlierDetection(OutlierDetectionConf{ConsecutiveErrors: 3})
+	opt(&options)
+	assert.Equal(t, 3, options.OutlierDetection.ConsecutiveErrors)
+}
+
 func TestWithDialOption(t *testing.T) {
 	var options ClientOptions
 	agent := grpc.WithUserAgent("chrome")
@@ -70,6 +85,7 @@ func TestBuildDialOptions(t *testing.T) {
 			Prometheus: true,
 			Breaker:    true,
 			Timeout:    true,
+			RequestId:  true,
 		},
 	}
 	agent := grpc.WithUserAgent("chrome")
@@ -77,6 +93,16 @@ func TestBuildDialOptions(t *testing.T) {
 	assert.Contains(t, opts, agent)
 }
 
+func TestBuildDialOptionsWithMessage(t *testing.T) {
+	var c client
+	opts := c.buildDialOptions()
+
+	m, err := message.NewMatcher(message.Conf{MaxRecvSize: 1024})
+	assert.NoError(t, err)
+	// the default call options and the message size logger.
+	assert.Len(t, c.buildDialOptions(WithMessage(m)), len(opts)+2)
+}
+
 func TestClientDial(t *testing.T) {
 	var addr string
 	var wg sync.WaitGroup
@@ -126,3 +152,52 @@ func TestClientDialFail(t *testing.T) {
 	assert.Error(t, err)
 	assert.True(t, strings.Contains(err.Error(), "localhost:54321/fail"))
 }
+
+func TestClientWithBadRetryConf(t *testing.T) {
+	_, err := NewClient("localhost:54321", ClientMiddlewaresConf{
+		Retry: true,
+		RetryConf: RetryConf{
+			Policies: []RetryPolicyConf{
+				{
+					RetryableCodes: []string{"foo"},
+				},
+			},
+		},
+	})
+	assert.Error(t, err)
+}
+
+func TestClientWithBadBreakerConf(t *testing.T) {
+	_, err := NewClient("localhost:54321", ClientMiddlewaresConf{
+		Breaker: true,
+		BreakerConf: BreakerConf{
+			Rules: []BreakerRuleConf{
+				{
+					FailureCodes: []string{"foo"},
+				},
+			},
+		},
+	})
+	assert.Error(t, err)
+}
+
+func TestClientStopInjectorOnClose(t *testing.T) {
+	c, err := NewClient("localhost:54321", ClientMiddlewaresConf{
+		Fault: true,
+	}, WithNonBlock())
+	assert.NoError(t, err)
+
+	cli := c.(*client)
+	done := make(chan struct{})
+	go func() {
+		cli.stopInjectorOnClose()
+		close(done)
+	}()
+	assert.NoError(t, cli.Conn().Close())
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("fault injector not stopped after the conn closed")
+	}
+}
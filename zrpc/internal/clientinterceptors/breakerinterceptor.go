@@ -2,11 +2,14 @@ package clientinterceptors
 
 import (
 	"context"
+	"errors"
 	"path"
 
 	"github.com/jialequ/linux-sdk/core/breaker"
+	"github.com/jialequ/linux-sdk/zrpc/internal/breakers"
 	"github.com/jialequ/linux-sdk/zrpc/internal/codes"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
 )
 
 // BreakerInterceptor is an interceptor that acts as a circuit breaker.
@@ -17,3 +20,89 @@ func BreakerInterceptor(ctx context.Context, method string, req, reply any,
 		return invoker(ctx, method, req, reply, cc, opts...)
 	}, codes.Acceptable)
 }
+
+// StreamBreakerInterceptor is an interceptor that acts as a circuit breaker on streams.
+// The stream is accepted or rejected by the breaker when it finishes.
+func StreamBreakerInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+	method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	breakerName := path.Join(cc.Target(), method)
+	promise, err := breaker.GetBreaker(breakerName).Allow()
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		acceptOrReject(promise, err, codes.Acceptable)
+		return nil, err
+	}
+
+	stream := wrapClientStream(ctx, s, desc)
+	go func() {
+		acceptOrReject(promise, <-stream.Finished, codes.Acceptable)
+	}()
+
+	return stream, nil
+}
+
+// BreakersInterceptor returns an interceptor that acts as a circuit breaker
+// with the per-method rules in b, the fallback response is returned if the breaker is open.
+func BreakersInterceptor(b *breakers.Breakers) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any,
+		cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		breakerName := path.Join(cc.Target(), method)
+		err := b.Do(breakerName, method, func() error {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		})
+		if errors.Is(err, breaker.ErrServiceUnavailable) {
+			if _, ok := b.Fallback(method, reply); ok {
+				return nil
+			}
+		}
+
+		return err
+	}
+}
+
+// StreamBreakersInterceptor returns an interceptor that acts as a circuit breaker on streams
+// with the per-method rules in b.
+func StreamBreakersInterceptor(b *breakers.Breakers) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+		method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		promise, err := b.Allow(path.Join(cc.Target(), method), method)
+		if err != nil {
+			return nil, err
+		}
+		if promise == nil {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+
+		acceptable := func(err error) bool {
+			return b.Acceptable(method, err)
+		}
+		s, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			acceptOrReject(promise, err, acceptable)
+			return nil, err
+		}
+
+		stream := wrapClientStream(ctx, s, desc)
+		go func() {
+			acceptOrReject(promise, <-stream.Finished, acceptable)
+		}()
+
+		return stream, nil
+	}
+}
+
+func acceptOrReject(promise breaker.Promise, err error, acceptable breaker.Acceptable) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = status.FromContextError(err).Err()
+	}
+
+	if acceptable(err) {
+		promise.Accept()
+	} else {
+		promise.Reject(err.Error())
+	}
+}
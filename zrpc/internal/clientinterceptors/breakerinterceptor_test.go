@@ -3,10 +3,13 @@ package clientinterceptors
 import (
 	"context"
 	"errors"
+	"io"
 	"testing"
 
 	"github.com/jialequ/linux-sdk/core/breaker"
 	"github.com/jialequ/linux-sdk/core/stat"
+	"github.com/jialequ/linux-sdk/internal/mock"
+	"github.com/jialequ/linux-sdk/zrpc/internal/breakers"
 	rcodes "github.com/jialequ/linux-sdk/zrpc/internal/codes"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc"
@@ -79,3 +82,134 @@ func TestBreakerInterceptor(t *testing.T) {
 		})
 	}
 }
+
+func TestStreamBreakerInterceptor(t *testing.T) {
+	tests := []struct {
+		name      string
+		streamErr error
+		recvErr   error
+	}{
+		{
+			name:    "nil",
+			recvErr: io.EOF,
+		},
+		{
+			name:    "finish with error",
+			recvErr: status.Error(codes.DataLoss, "mock"),
+		},
+		{
+			name:      "stream with error",
+			streamErr: errors.New("mock"),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cc := new(grpc.ClientConn)
+			stream, err := StreamBreakerInterceptor(context.Background(), new(grpc.StreamDesc), cc,
+				"/foo", func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+					method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+					if test.streamErr != nil {
+						return nil, test.streamErr
+					}
+					return &mockedClientStream{err: test.recvErr}, nil
+				})
+			assert.Equal(t, test.streamErr, err)
+			if err == nil {
+				assert.Equal(t, test.recvErr, stream.RecvMsg(nil))
+				<-stream.(*clientStream).eventsDone
+			}
+		})
+	}
+}
+
+func TestStreamBreakerInterceptorDeadlineExceeded(t *testing.T) {
+	cc := new(grpc.ClientConn)
+	errs := make(map[error]int)
+	for i := 0; i < 1000; i++ {
+		_, err := StreamBreakerInterceptor(context.Background(), new(grpc.StreamDesc), cc,
+			"/deadline", func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+				method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+				return nil, context.DeadlineExceeded
+			})
+		errs[err]++
+	}
+	assert.Equal(t, 2, len(errs))
+	assert.True(t, errs[context.DeadlineExceeded] > 0)
+	assert.True(t, errs[breaker.ErrServiceUnavailable] > 0)
+}
+
+func TestBreakersInterceptorFallback(t *testing.T) {
+	b, err := breakers.New(breakers.Conf{
+		Rules: []breakers.RuleConf{
+			{
+				FullMethod: "/mock.DepositService/Deposit",
+				K:          1.1,
+				Fallback:   `{"ok": true}`,
+			},
+			{
+				FullMethod:      "/mock.DepositService/*",
+				AcceptableCodes: []string{"Internal"},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	cc := new(grpc.ClientConn)
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn,
+		opts ...grpc.CallOption) error {
+		return status.Error(codes.Internal, "mock")
+	}
+	var fallbacks int
+	for i := 0; i < 1000; i++ {
+		reply := new(mock.DepositResponse)
+		err := BreakersInterceptor(b)(context.Background(), "/mock.DepositService/Deposit",
+			nil, reply, cc, invoker)
+		if err == nil {
+			assert.True(t, reply.Ok)
+			fallbacks++
+		}
+	}
+	assert.True(t, fallbacks > 0)
+
+	for i := 0; i < 1000; i++ {
+		err := BreakersInterceptor(b)(context.Background(), "/mock.DepositService/Other",
+			nil, nil, cc, invoker)
+		assert.Equal(t, codes.Internal, status.Code(err))
+	}
+}
+
+func TestStreamBreakersInterceptor(t *testing.T) {
+	b, err := breakers.New(breakers.Conf{
+		Rules: []breakers.RuleConf{
+			{
+				FullMethod: "/disabled",
+				Disabled:   true,
+			},
+			{
+				FullMethod:      "/acceptable",
+				AcceptableCodes: []string{"DataLoss"},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	cc := new(grpc.ClientConn)
+	for _, method := range []string{"/disabled", "/acceptable", "/default"} {
+		t.Run(method, func(t *testing.T) {
+			stream, err := StreamBreakersInterceptor(b)(context.Background(), new(grpc.StreamDesc), cc,
+				method, func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+					method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+					return &mockedClientStream{err: status.Error(codes.DataLoss, "mock")}, nil
+				})
+			assert.NoError(t, err)
+			assert.Equal(t, codes.DataLoss, status.Code(stream.RecvMsg(nil)))
+		})
+	}
+
+	_, err = StreamBreakersInterceptor(b)(context.Background(), new(grpc.StreamDesc), cc,
+		"/default", func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+			method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return nil, errors.New("mock")
+		})
+	assert.Error(t, err)
+}
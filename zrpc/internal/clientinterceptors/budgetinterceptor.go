@@ -0,0 +1,71 @@
+package clientinterceptors
+
+import (
+	"context"
+	"time"
+
+	"github.com/jialequ/linux-sdk/core/budget"
+	"github.com/jialequ/linux-sdk/core/logx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryBudgetInterceptor returns an interceptor that reserves margin of the deadline budget
+// of the caller, and refuses the calls with the exhausted budget before sending them.
+// The deadline of the call is the earlier one of the reserved budget and the call timeout.
+func UnaryBudgetInterceptor(margin time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		reserved, cancel, err := reserveBudget(ctx, method, margin)
+		if err != nil {
+			return err
+		}
+		defer cancel()
+
+		return invoker(reserved, method, req, reply, cc, opts...)
+	}
+}
+
+// StreamBudgetInterceptor returns an interceptor that reserves margin of the deadline budget
+// of the caller on streams, and refuses the streams with the exhausted budget.
+func StreamBudgetInterceptor(margin time.Duration) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+		streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if _, ok := ctx.Deadline(); !ok {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+
+		reserved, cancel, err := reserveBudget(ctx, method, margin)
+		if err != nil {
+			return nil, err
+		}
+
+		s, err := streamer(reserved, desc, cc, method, opts...)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+
+		stream := wrapClientStream(reserved, s, desc)
+		go func() {
+			<-stream.Finished
+			cancel()
+		}()
+
+		return stream, nil
+	}
+}
+
+func reserveBudget(ctx context.Context, method string, margin time.Duration) (
+	context.Context, context.CancelFunc, error) {
+	reserved, cancel, err := budget.Reserve(ctx, margin)
+	if err != nil {
+		remaining, _ := budget.Remaining(ctx)
+		logx.WithContext(ctx).Errorf("%s, method: %s, remaining: %s, margin: %s",
+			err.Error(), method, remaining, margin)
+		return nil, nil, status.Error(codes.DeadlineExceeded, err.Error())
+	}
+
+	return reserved, cancel, nil
+}
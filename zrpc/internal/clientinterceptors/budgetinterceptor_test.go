@@ -0,0 +1,88 @@
+package clientinterceptors
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/jialequ/linux-sdk/core/logx/logtest"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryBudgetInterceptor(t *testing.T) {
+	interceptor := UnaryBudgetInterceptor(time.Millisecond * 100)
+	cc := new(grpc.ClientConn)
+
+	err := interceptor(context.Background(), "/foo", nil, nil, cc,
+		func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn,
+			opts ...grpc.CallOption) error {
+			_, ok := ctx.Deadline()
+			assert.False(t, ok)
+			return nil
+		})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	parentDeadline, _ := ctx.Deadline()
+	err = interceptor(ctx, "/foo", nil, nil, cc,
+		func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn,
+			opts ...grpc.CallOption) error {
+			deadline, ok := ctx.Deadline()
+			assert.True(t, ok)
+			assert.Equal(t, parentDeadline.Add(-time.Millisecond*100), deadline)
+			return nil
+		})
+	assert.NoError(t, err)
+
+	c := logtest.NewCollector(t)
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	var called bool
+	err = interceptor(ctx, "/foo", nil, nil, cc,
+		func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn,
+			opts ...grpc.CallOption) error {
+			called = true
+			return nil
+		})
+	assert.False(t, called)
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	assert.Contains(t, c.String(), "deadline budget exhausted")
+	assert.Contains(t, c.String(), "/foo")
+}
+
+func TestStreamBudgetInterceptor(t *testing.T) {
+	interceptor := StreamBudgetInterceptor(time.Millisecond * 100)
+	cc := new(grpc.ClientConn)
+	streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+		method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return &mockedClientStream{err: io.EOF}, nil
+	}
+
+	_, err := interceptor(context.Background(), new(grpc.StreamDesc), cc, "/foo", streamer)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	stream, err := interceptor(ctx, new(grpc.StreamDesc), cc, "/foo", streamer)
+	assert.NoError(t, err)
+	assert.Equal(t, io.EOF, stream.RecvMsg(nil))
+	<-stream.(*clientStream).eventsDone
+
+	_, err = interceptor(ctx, new(grpc.StreamDesc), cc, "/foo",
+		func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+			method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return nil, errors.New("mock")
+		})
+	assert.Error(t, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	_, err = interceptor(ctx, new(grpc.StreamDesc), cc, "/foo", streamer)
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+}
@@ -0,0 +1,35 @@
+package clientinterceptors
+
+import (
+	"context"
+
+	"github.com/jialequ/linux-sdk/core/canary"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// StreamCanaryInterceptor is an interceptor that propagates the traffic color on stream calls.
+func StreamCanaryInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+	method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(injectCanary(ctx), desc, cc, method, opts...)
+}
+
+// UnaryCanaryInterceptor is an interceptor that propagates the traffic color on unary calls.
+func UnaryCanaryInterceptor(ctx context.Context, method string, req, reply any,
+	cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(injectCanary(ctx), method, req, reply, cc, opts...)
+}
+
+func injectCanary(ctx context.Context) context.Context {
+	color := canary.FromContext(ctx)
+	if len(color) == 0 {
+		return ctx
+	}
+
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if ok && len(md.Get(canary.MetadataKey)) > 0 {
+		return ctx
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, canary.MetadataKey, color)
+}
@@ -0,0 +1,65 @@
+package clientinterceptors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jialequ/linux-sdk/core/canary"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestUnaryCanaryInterceptor(t *testing.T) {
+	cc := new(grpc.ClientConn)
+	ctx := canary.NewContext(context.Background(), "v2")
+	err := UnaryCanaryInterceptor(ctx, "/foo", nil, nil, cc,
+		func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn,
+			opts ...grpc.CallOption) error {
+			md, ok := metadata.FromOutgoingContext(ctx)
+			assert.True(t, ok)
+			assert.Equal(t, []string{"v2"}, md.Get(canary.MetadataKey))
+			return nil
+		})
+	assert.Nil(t, err)
+}
+
+func TestUnaryCanaryInterceptorWithoutColor(t *testing.T) {
+	cc := new(grpc.ClientConn)
+	err := UnaryCanaryInterceptor(context.Background(), "/foo", nil, nil, cc,
+		func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn,
+			opts ...grpc.CallOption) error {
+			_, ok := metadata.FromOutgoingContext(ctx)
+			assert.False(t, ok)
+			return nil
+		})
+	assert.Nil(t, err)
+}
+
+func TestUnaryCanaryInterceptorNotOverride(t *testing.T) {
+	cc := new(grpc.ClientConn)
+	ctx := canary.NewContext(context.Background(), "v2")
+	ctx = metadata.AppendToOutgoingContext(ctx, canary.MetadataKey, "v3")
+	err := UnaryCanaryInterceptor(ctx, "/foo", nil, nil, cc,
+		func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn,
+			opts ...grpc.CallOption) error {
+			md, _ := metadata.FromOutgoingContext(ctx)
+			assert.Equal(t, []string{"v3"}, md.Get(canary.MetadataKey))
+			return nil
+		})
+	assert.Nil(t, err)
+}
+
+func TestStreamCanaryInterceptor(t *testing.T) {
+	cc := new(grpc.ClientConn)
+	ctx := canary.NewContext(context.Background(), "v2")
+	_, err := StreamCanaryInterceptor(ctx, nil, cc, "/foo",
+		func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+			opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			md, ok := metadata.FromOutgoingContext(ctx)
+			assert.True(t, ok)
+			assert.Equal(t, []string{"v2"}, md.Get(canary.MetadataKey))
+			return nil, nil
+		})
+	assert.Nil(t, err)
+}
@@ -50,11 +50,40 @@ func DurationInterceptor(ctx context.Context, method string, req, reply any,
 	return err
 }
 
+// StreamDurationInterceptor is an interceptor that logs the processing time of streams.
+func StreamDurationInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+	method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	serverName := path.Join(cc.Target(), method)
+	start := timex.Now()
+	s, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		logStreamDuration(ctx, serverName, timex.Since(start), err)
+		return nil, err
+	}
+
+	stream := wrapClientStream(ctx, s, desc)
+	go func() {
+		err := <-stream.Finished
+		logStreamDuration(ctx, serverName, timex.Since(start), err)
+	}()
+
+	return stream, nil
+}
+
 // DontLogContentForMethod disable logging content for given method.
 func DontLogContentForMethod(method string) {
 	notLoggingContentMethods.Store(method, lang.Placeholder)
 }
 
+func logStreamDuration(ctx context.Context, serverName string, elapsed time.Duration, err error) {
+	logger := logx.WithContext(ctx).WithDuration(elapsed)
+	if err != nil {
+		logger.Errorf("fail - %s - %s", serverName, err.Error())
+	} else if elapsed > slowThreshold.Load() {
+		logger.Slowf("[RPC] ok - slowcall - %s", serverName)
+	}
+}
+
 // SetSlowThreshold sets the slow threshold.
 func SetSlowThreshold(threshold time.Duration) {
 	slowThreshold.Set(threshold)
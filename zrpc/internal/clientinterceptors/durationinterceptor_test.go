@@ -3,6 +3,7 @@ package clientinterceptors
 import (
 	"context"
 	"errors"
+	"io"
 	"testing"
 	"time"
 
@@ -107,6 +108,51 @@ func TestDurationInterceptorWithSlowThreshold(t *testing.T) {
 	}
 }
 
+func TestStreamDurationInterceptor(t *testing.T) {
+	SetSlowThreshold(time.Microsecond)
+	t.Cleanup(func() {
+		SetSlowThreshold(defaultSlowThreshold)
+	})
+
+	tests := []struct {
+		name      string
+		streamErr error
+		recvErr   error
+	}{
+		{
+			name:    "nil",
+			recvErr: io.EOF,
+		},
+		{
+			name:    "finish with error",
+			recvErr: errors.New("mock"),
+		},
+		{
+			name:      "stream with error",
+			streamErr: errors.New("mock"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cc := new(grpc.ClientConn)
+			stream, err := StreamDurationInterceptor(context.Background(), new(grpc.StreamDesc), cc,
+				"/foo", func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+					method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+					if test.streamErr != nil {
+						return nil, test.streamErr
+					}
+					return &mockedClientStream{err: test.recvErr}, nil
+				})
+			assert.Equal(t, test.streamErr, err)
+			if err == nil {
+				assert.Equal(t, test.recvErr, stream.RecvMsg(nil))
+				<-stream.(*clientStream).eventsDone
+			}
+		})
+	}
+}
+
 func TestSetSlowThreshold(t *testing.T) {
 	assert.Equal(t, defaultSlowThreshold, slowThreshold.Load())
 	SetSlowThreshold(time.Second)
@@ -0,0 +1,79 @@
+package clientinterceptors
+
+import (
+	"context"
+
+	"github.com/jialequ/linux-sdk/core/logx"
+	"github.com/jialequ/linux-sdk/internal/fault"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// StreamFaultInterceptor returns a func that injects faults into stream calls.
+func StreamFaultInterceptor(injector *fault.Injector) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+		streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		f, ok := injectFault(ctx, injector, method)
+		if !ok {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+
+		if err := fault.DelayOrAbort(ctx, f, method); err != nil {
+			return nil, err
+		}
+
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil || !f.Drop {
+			return stream, err
+		}
+
+		logx.WithContext(ctx).Infof("fault injected, %s responses dropped", method)
+		return &droppedClientStream{ClientStream: stream}, nil
+	}
+}
+
+// UnaryFaultInterceptor returns a func that injects faults into unary calls.
+func UnaryFaultInterceptor(injector *fault.Injector) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		f, ok := injectFault(ctx, injector, method)
+		if !ok {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		if err := fault.DelayOrAbort(ctx, f, method); err != nil {
+			return err
+		}
+
+		if !f.Drop {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		if err := invoker(ctx, method, req, reply, cc, opts...); err != nil {
+			return err
+		}
+
+		return fault.DropResponse(ctx, method)
+	}
+}
+
+// droppedClientStream drops all the received messages.
+type droppedClientStream struct {
+	grpc.ClientStream
+}
+
+func (s *droppedClientStream) RecvMsg(_ any) error {
+	ctx := s.Context()
+	<-ctx.Done()
+	return status.FromContextError(ctx.Err()).Err()
+}
+
+func injectFault(ctx context.Context, injector *fault.Injector, method string) (fault.Fault, bool) {
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if !ok {
+		return fault.Fault{}, false
+	}
+
+	return injector.InjectMetadata(md, method)
+}
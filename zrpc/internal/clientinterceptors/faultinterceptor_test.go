@@ -0,0 +1,129 @@
+package clientinterceptors
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jialequ/linux-sdk/internal/fault"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryFaultInterceptor(t *testing.T) {
+	injector, err := fault.NewInjector(fault.Conf{
+		Header: "X-Fault-Inject",
+		Rules: []fault.RuleConf{
+			{
+				Routes:  []string{"/foo/abort"},
+				Percent: 100,
+				Abort:   int(codes.Unavailable),
+			},
+			{
+				Routes:  []string{"/foo/delay"},
+				Percent: 100,
+				Delay:   time.Second,
+			},
+			{
+				Routes:  []string{"/foo/drop"},
+				Percent: 100,
+				Drop:    true,
+			},
+		},
+	})
+	assert.Nil(t, err)
+	interceptor := UnaryFaultInterceptor(injector)
+	cc := new(grpc.ClientConn)
+	enabled := metadata.AppendToOutgoingContext(context.Background(), "x-fault-inject", "true")
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		method  string
+		code    codes.Code
+		invoked bool
+	}{
+		{
+			name:    "not enabled",
+			ctx:     context.Background(),
+			method:  "/foo/abort",
+			code:    codes.OK,
+			invoked: true,
+		},
+		{
+			name:   "abort",
+			ctx:    enabled,
+			method: "/foo/abort",
+			code:   codes.Unavailable,
+		},
+		{
+			name:   "delay timeout",
+			ctx:    enabled,
+			method: "/foo/delay",
+			code:   codes.DeadlineExceeded,
+		},
+		{
+			name:    "drop",
+			ctx:     enabled,
+			method:  "/foo/drop",
+			code:    codes.DeadlineExceeded,
+			invoked: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(test.ctx, time.Millisecond*50)
+			defer cancel()
+
+			var invoked bool
+			err := interceptor(ctx, test.method, nil, nil, cc,
+				func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn,
+					opts ...grpc.CallOption) error {
+					invoked = true
+					return nil
+				})
+			assert.Equal(t, test.code, status.Code(err))
+			assert.Equal(t, test.invoked, invoked)
+		})
+	}
+}
+
+func TestStreamFaultInterceptor(t *testing.T) {
+	injector, err := fault.NewInjector(fault.Conf{
+		Header: "X-Fault-Inject",
+		Rules: []fault.RuleConf{
+			{
+				Routes:  []string{"/foo/abort"},
+				Percent: 100,
+				Abort:   int(codes.Unavailable),
+			},
+		},
+	})
+	assert.Nil(t, err)
+	interceptor := StreamFaultInterceptor(injector)
+	cc := new(grpc.ClientConn)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-fault-inject", "true")
+
+	_, err = interceptor(ctx, nil, cc, "/foo/abort",
+		func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+			opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			t.Fatal("should not be invoked")
+			return nil, nil
+		})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	var invoked bool
+	_, err = interceptor(ctx, nil, cc, "/foo/bar",
+		func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+			opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			invoked = true
+			return nil, nil
+		})
+	assert.Nil(t, err)
+	assert.True(t, invoked)
+}
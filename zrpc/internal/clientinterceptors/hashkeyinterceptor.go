@@ -0,0 +1,43 @@
+package clientinterceptors
+
+import (
+	"context"
+
+	"github.com/jialequ/linux-sdk/zrpc/internal/balancer/consistenthash"
+	"google.golang.org/grpc"
+)
+
+// HashKeyCallOption is a call option that carries the hash key for consistent hash balancer.
+type HashKeyCallOption struct {
+	grpc.EmptyCallOption
+	key string
+}
+
+// StreamHashKeyInterceptor is an interceptor that passes the hash key to the balancer on streams.
+func StreamHashKeyInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+	method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(withHashKey(ctx, opts), desc, cc, method, opts...)
+}
+
+// UnaryHashKeyInterceptor is an interceptor that passes the hash key to the balancer.
+func UnaryHashKeyInterceptor(ctx context.Context, method string, req, reply any,
+	cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(withHashKey(ctx, opts), method, req, reply, cc, opts...)
+}
+
+// WithHashKey returns a call option that routes the call by key with consistent hash balancer.
+func WithHashKey(key string) grpc.CallOption {
+	return HashKeyCallOption{
+		key: key,
+	}
+}
+
+func withHashKey(ctx context.Context, opts []grpc.CallOption) context.Context {
+	for _, opt := range opts {
+		if o, ok := opt.(HashKeyCallOption); ok {
+			return consistenthash.NewContext(ctx, o.key)
+		}
+	}
+
+	return ctx
+}
@@ -0,0 +1,39 @@
+package clientinterceptors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+func TestUnaryHashKeyInterceptor(t *testing.T) {
+	cc := new(grpc.ClientConn)
+	err := UnaryHashKeyInterceptor(context.Background(), "/foo", nil, nil, cc,
+		func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn,
+			opts ...grpc.CallOption) error {
+			assert.NotEqual(t, context.Background(), ctx)
+			return nil
+		}, WithHashKey("bar"))
+	assert.Nil(t, err)
+
+	err = UnaryHashKeyInterceptor(context.Background(), "/foo", nil, nil, cc,
+		func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn,
+			opts ...grpc.CallOption) error {
+			assert.Equal(t, context.Background(), ctx)
+			return nil
+		})
+	assert.Nil(t, err)
+}
+
+func TestStreamHashKeyInterceptor(t *testing.T) {
+	cc := new(grpc.ClientConn)
+	_, err := StreamHashKeyInterceptor(context.Background(), nil, cc, "/foo",
+		func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+			opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			assert.NotEqual(t, context.Background(), ctx)
+			return nil, nil
+		}, WithHashKey("bar"))
+	assert.Nil(t, err)
+}
@@ -0,0 +1,29 @@
+package clientinterceptors
+
+import (
+	"context"
+
+	"github.com/jialequ/linux-sdk/zrpc/internal/message"
+	"google.golang.org/grpc"
+)
+
+// UnaryMessageInterceptor returns an interceptor that applies the size limits and
+// the compressor of the methods overridden in m.
+func UnaryMessageInterceptor(m *message.Matcher) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		// the call options of the caller take precedence.
+		opts = append(m.CallOptions(method), opts...)
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// StreamMessageInterceptor returns an interceptor that applies the size limits and
+// the compressor of the methods overridden in m on streams.
+func StreamMessageInterceptor(m *message.Matcher) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+		streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		opts = append(m.CallOptions(method), opts...)
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
@@ -0,0 +1,50 @@
+package clientinterceptors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jialequ/linux-sdk/zrpc/internal/message"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+func TestMessageInterceptors(t *testing.T) {
+	m, err := message.NewMatcher(message.Conf{
+		Methods: []message.MethodConf{
+			{
+				FullMethod:  "/foo",
+				MaxRecvSize: 1024,
+				Compressor:  message.Gzip,
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	callOpt := grpc.WaitForReady(true)
+	cc := new(grpc.ClientConn)
+	err = UnaryMessageInterceptor(m)(context.Background(), "/foo", nil, nil, cc,
+		func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn,
+			opts ...grpc.CallOption) error {
+			assert.Len(t, opts, 3)
+			assert.Equal(t, callOpt, opts[2])
+			return nil
+		}, callOpt)
+	assert.NoError(t, err)
+
+	err = UnaryMessageInterceptor(m)(context.Background(), "/bar", nil, nil, cc,
+		func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn,
+			opts ...grpc.CallOption) error {
+			assert.Len(t, opts, 0)
+			return nil
+		})
+	assert.NoError(t, err)
+
+	_, err = StreamMessageInterceptor(m)(context.Background(), new(grpc.StreamDesc), cc, "/foo",
+		func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+			opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			assert.Len(t, opts, 2)
+			return nil, nil
+		})
+	assert.NoError(t, err)
+}
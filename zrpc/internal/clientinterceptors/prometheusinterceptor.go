@@ -7,6 +7,7 @@ import (
 	"github.com/jialequ/linux-sdk/core/metric"
 	"github.com/jialequ/linux-sdk/core/timex"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/stats"
 	"google.golang.org/grpc/status"
 )
 
@@ -29,8 +30,71 @@ var (
 		Help:      "rpc client requests code count.",
 		Labels:    []string{"method", "code"},
 	})
+
+	metricClientStreamMsgReceived = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: clientNamespace,
+		Subsystem: "stream",
+		Name:      "msg_received_total",
+		Help:      "rpc client stream messages received count.",
+		Labels:    []string{"method"},
+	})
+
+	metricClientStreamMsgSent = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: clientNamespace,
+		Subsystem: "stream",
+		Name:      "msg_sent_total",
+		Help:      "rpc client stream messages sent count.",
+		Labels:    []string{"method"},
+	})
+
+	metricClientMsgBytes = metric.NewHistogramVec(&metric.HistogramVecOpts{
+		Namespace: clientNamespace,
+		Subsystem: "messages",
+		Name:      "bytes",
+		Help:      "rpc client uncompressed message bytes.",
+		Labels:    []string{"method", "direction"},
+		Buckets:   []float64{64, 256, 1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20, 4 << 20, 16 << 20},
+	})
+
+	metricClientMsgCompressionRatio = metric.NewHistogramVec(&metric.HistogramVecOpts{
+		Namespace: clientNamespace,
+		Subsystem: "messages",
+		Name:      "compression_ratio",
+		Help:      "rpc client compressed bytes to uncompressed bytes ratio of compressed messages.",
+		Labels:    []string{"method", "direction"},
+		Buckets:   []float64{0.05, 0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9, 1, 1.5},
+	})
+)
+
+type (
+	clientPrometheusStatsHandler struct{}
+
+	clientStatsMethodKey struct{}
 )
 
+type monitoredClientStream struct {
+	grpc.ClientStream
+	method string
+}
+
+func (s *monitoredClientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err == nil {
+		metricClientStreamMsgReceived.Inc(s.method)
+	}
+
+	return err
+}
+
+func (s *monitoredClientStream) SendMsg(m any) error {
+	err := s.ClientStream.SendMsg(m)
+	if err == nil {
+		metricClientStreamMsgSent.Inc(s.method)
+	}
+
+	return err
+}
+
 // PrometheusInterceptor is an interceptor that reports to prometheus server.
 func PrometheusInterceptor(ctx context.Context, method string, req, reply any,
 	cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
@@ -40,3 +104,64 @@ func PrometheusInterceptor(ctx context.Context, method string, req, reply any,
 	metricClientReqCodeTotal.Inc(method, strconv.Itoa(int(status.Code(err))))
 	return err
 }
+
+// StreamPrometheusInterceptor is an interceptor that reports the statistics of streams
+// to prometheus server.
+func StreamPrometheusInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+	method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	startTime := timex.Now()
+	s, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		metricClientReqDur.Observe(timex.Since(startTime).Milliseconds(), method)
+		metricClientReqCodeTotal.Inc(method, strconv.Itoa(int(status.Code(err))))
+		return nil, err
+	}
+
+	stream := wrapClientStream(ctx, &monitoredClientStream{
+		ClientStream: s,
+		method:       method,
+	}, desc)
+	go func() {
+		err := <-stream.Finished
+		metricClientReqDur.Observe(timex.Since(startTime).Milliseconds(), method)
+		metricClientReqCodeTotal.Inc(method, strconv.Itoa(int(status.Code(err))))
+	}()
+
+	return stream, nil
+}
+
+// PrometheusStatsHandler returns a stats.Handler that reports the message bytes and
+// the compression ratio to prometheus server, which are not visible to the interceptors.
+func PrometheusStatsHandler() stats.Handler {
+	return clientPrometheusStatsHandler{}
+}
+
+func (h clientPrometheusStatsHandler) HandleConn(context.Context, stats.ConnStats) {
+}
+
+func (h clientPrometheusStatsHandler) HandleRPC(ctx context.Context, rs stats.RPCStats) {
+	method, _ := ctx.Value(clientStatsMethodKey{}).(string)
+	switch s := rs.(type) {
+	case *stats.InPayload:
+		observeClientMessage(method, "recv", s.Length, s.CompressedLength)
+	case *stats.OutPayload:
+		observeClientMessage(method, "send", s.Length, s.CompressedLength)
+	}
+}
+
+func (h clientPrometheusStatsHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (h clientPrometheusStatsHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	return context.WithValue(ctx, clientStatsMethodKey{}, info.FullMethodName)
+}
+
+func observeClientMessage(method, direction string, length, compressedLength int) {
+	metricClientMsgBytes.Observe(int64(length), method, direction)
+	// the compressed length equals to the length if not compressed.
+	if length > 0 && compressedLength != length {
+		metricClientMsgCompressionRatio.ObserveFloat(float64(compressedLength)/float64(length),
+			method, direction)
+	}
+}
@@ -3,11 +3,13 @@ package clientinterceptors
 import (
 	"context"
 	"errors"
+	"io"
 	"testing"
 
 	"github.com/jialequ/linux-sdk/core/prometheus"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/stats"
 )
 
 func TestPromMetricInterceptor(t *testing.T) {
@@ -48,3 +50,62 @@ func TestPromMetricInterceptor(t *testing.T) {
 		})
 	}
 }
+
+func TestStreamPromMetricInterceptor(t *testing.T) {
+	prometheus.StartAgent(prometheus.Config{
+		Host: "localhost",
+		Path: "/",
+	})
+
+	tests := []struct {
+		name      string
+		streamErr error
+		recvErr   error
+	}{
+		{
+			name:    "nil",
+			recvErr: io.EOF,
+		},
+		{
+			name:      "stream with error",
+			streamErr: errors.New("mock"),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cc := new(grpc.ClientConn)
+			stream, err := StreamPrometheusInterceptor(context.Background(), new(grpc.StreamDesc), cc,
+				"/foo", func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+					method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+					if test.streamErr != nil {
+						return nil, test.streamErr
+					}
+					return &mockedClientStream{err: test.recvErr}, nil
+				})
+			assert.Equal(t, test.streamErr, err)
+			if err == nil {
+				assert.Equal(t, test.recvErr, stream.RecvMsg(nil))
+				<-stream.(*clientStream).eventsDone
+			}
+		})
+	}
+}
+
+func TestPrometheusStatsHandler(t *testing.T) {
+	prometheus.StartAgent(prometheus.Config{
+		Host: "localhost",
+		Path: "/",
+	})
+
+	h := PrometheusStatsHandler()
+	ctx := h.TagConn(context.Background(), &stats.ConnTagInfo{})
+	h.HandleConn(ctx, &stats.ConnBegin{})
+	ctx = h.TagRPC(ctx, &stats.RPCTagInfo{FullMethodName: "/foo"})
+	assert.Equal(t, "/foo", ctx.Value(clientStatsMethodKey{}))
+	assert.NotPanics(t, func() {
+		h.HandleRPC(ctx, &stats.Begin{})
+		h.HandleRPC(ctx, &stats.InPayload{Length: 100, CompressedLength: 20})
+		h.HandleRPC(ctx, &stats.OutPayload{Length: 100, CompressedLength: 100})
+		h.HandleRPC(ctx, &stats.OutPayload{})
+	})
+}
@@ -0,0 +1,35 @@
+package clientinterceptors
+
+import (
+	"context"
+
+	"github.com/jialequ/linux-sdk/core/requestid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// StreamRequestIdInterceptor is an interceptor that propagates the request id on stream calls.
+func StreamRequestIdInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+	method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(injectRequestId(ctx), desc, cc, method, opts...)
+}
+
+// UnaryRequestIdInterceptor is an interceptor that propagates the request id on unary calls.
+func UnaryRequestIdInterceptor(ctx context.Context, method string, req, reply any,
+	cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(injectRequestId(ctx), method, req, reply, cc, opts...)
+}
+
+func injectRequestId(ctx context.Context) context.Context {
+	id := requestid.FromContext(ctx)
+	if len(id) == 0 {
+		return ctx
+	}
+
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if ok && len(md.Get(requestid.MetadataKey)) > 0 {
+		return ctx
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, requestid.MetadataKey, id)
+}
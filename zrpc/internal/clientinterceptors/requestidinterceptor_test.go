@@ -0,0 +1,65 @@
+package clientinterceptors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jialequ/linux-sdk/core/requestid"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestUnaryRequestIdInterceptor(t *testing.T) {
+	cc := new(grpc.ClientConn)
+	ctx := requestid.NewContext(context.Background(), "foo")
+	err := UnaryRequestIdInterceptor(ctx, "/foo", nil, nil, cc,
+		func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn,
+			opts ...grpc.CallOption) error {
+			md, ok := metadata.FromOutgoingContext(ctx)
+			assert.True(t, ok)
+			assert.Equal(t, []string{"foo"}, md.Get(requestid.MetadataKey))
+			return nil
+		})
+	assert.Nil(t, err)
+}
+
+func TestUnaryRequestIdInterceptorWithoutId(t *testing.T) {
+	cc := new(grpc.ClientConn)
+	err := UnaryRequestIdInterceptor(context.Background(), "/foo", nil, nil, cc,
+		func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn,
+			opts ...grpc.CallOption) error {
+			_, ok := metadata.FromOutgoingContext(ctx)
+			assert.False(t, ok)
+			return nil
+		})
+	assert.Nil(t, err)
+}
+
+func TestUnaryRequestIdInterceptorNotOverride(t *testing.T) {
+	cc := new(grpc.ClientConn)
+	ctx := requestid.NewContext(context.Background(), "foo")
+	ctx = metadata.AppendToOutgoingContext(ctx, requestid.MetadataKey, "bar")
+	err := UnaryRequestIdInterceptor(ctx, "/foo", nil, nil, cc,
+		func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn,
+			opts ...grpc.CallOption) error {
+			md, _ := metadata.FromOutgoingContext(ctx)
+			assert.Equal(t, []string{"bar"}, md.Get(requestid.MetadataKey))
+			return nil
+		})
+	assert.Nil(t, err)
+}
+
+func TestStreamRequestIdInterceptor(t *testing.T) {
+	cc := new(grpc.ClientConn)
+	ctx := requestid.NewContext(context.Background(), "foo")
+	_, err := StreamRequestIdInterceptor(ctx, nil, cc, "/foo",
+		func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+			opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			md, ok := metadata.FromOutgoingContext(ctx)
+			assert.True(t, ok)
+			assert.Equal(t, []string{"foo"}, md.Get(requestid.MetadataKey))
+			return nil, nil
+		})
+	assert.Nil(t, err)
+}
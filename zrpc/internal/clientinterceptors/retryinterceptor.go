@@ -0,0 +1,296 @@
+package clientinterceptors
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/jialequ/linux-sdk/core/collection"
+	"github.com/jialequ/linux-sdk/core/logx"
+	"github.com/jialequ/linux-sdk/core/timex"
+	ztrace "github.com/jialequ/linux-sdk/core/trace"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	retryBudgetBuckets  = 10
+	retryBudgetInterval = time.Second
+	retryEventName      = "retry"
+	hedgeEventName      = "hedge"
+)
+
+var retryAttemptKey = attribute.Key("rpc.retry.attempt")
+
+type (
+	// RetryConf defines the retry policies of methods, and the retry budget of a client.
+	RetryConf struct {
+		Policies []RetryPolicyConf `json:",optional"`
+		// BudgetRatio is the max ratio of retries to requests in the last 10 seconds,
+		// used to stop the retry storms.
+		BudgetRatio float64 `json:",default=0.1,range=[0:1]"`
+		// MinRetries is the retries always allowed in the last 10 seconds.
+		MinRetries int `json:",default=10"`
+	}
+
+	// RetryPolicyConf defines the retry policy of a method.
+	RetryPolicyConf struct {
+		// FullMethod is the method to retry, empty means all methods.
+		FullMethod        string        `json:",optional"`
+		MaxAttempts       int           `json:",default=3,range=[1:10]"`
+		InitialBackoff    time.Duration `json:",default=50ms"`
+		MaxBackoff        time.Duration `json:",default=1s"`
+		BackoffMultiplier float64       `json:",default=2"`
+		// RetryableCodes are the grpc codes to retry, like Unavailable, default to Unavailable.
+		RetryableCodes []string `json:",optional"`
+		// HedgingDelay turns on hedging, sends another request if no response after the delay.
+		HedgingDelay time.Duration `json:",optional"`
+	}
+
+	retryPolicy struct {
+		maxAttempts  int
+		backoff      func(attempt int) time.Duration
+		codes        map[codes.Code]struct{}
+		hedgingDelay time.Duration
+	}
+
+	retryBudget struct {
+		ratio      float64
+		minRetries int
+		// Sum is the retries, Count is the requests plus retries.
+		window *collection.RollingWindow
+	}
+
+	hedgeResult struct {
+		reply proto.Message
+		err   error
+	}
+)
+
+// RetryInterceptor returns an interceptor that retries the failed calls with the policies.
+// The retries share the deadline of the call, so it should be placed after TimeoutInterceptor.
+func RetryInterceptor(c RetryConf) (grpc.UnaryClientInterceptor, error) {
+	defaultPolicy, policies, err := buildRetryPolicies(c.Policies)
+	if err != nil {
+		return nil, err
+	}
+
+	budget := &retryBudget{
+		ratio:      c.BudgetRatio,
+		minRetries: c.MinRetries,
+		window:     collection.NewRollingWindow(retryBudgetBuckets, retryBudgetInterval),
+	}
+
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		policy, ok := policies[method]
+		if !ok {
+			policy = defaultPolicy
+		}
+		if policy == nil {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		budget.request()
+		if msg, ok := reply.(proto.Message); ok && policy.hedgingDelay > 0 {
+			return policy.hedge(ctx, budget, method, req, msg, cc, invoker, opts...)
+		}
+
+		return policy.retry(ctx, budget, method, req, reply, cc, invoker, opts...)
+	}, nil
+}
+
+func (b *retryBudget) allow() bool {
+	var retries float64
+	var total int64
+	b.window.Reduce(func(bucket *collection.Bucket) {
+		retries += bucket.Sum
+		total += bucket.Count
+	})
+
+	requests := float64(total) - retries
+	if retries >= math.Max(float64(b.minRetries), requests*b.ratio) {
+		return false
+	}
+
+	b.window.Add(1)
+	return true
+}
+
+func (b *retryBudget) request() {
+	b.window.Add(0)
+}
+
+func (p *retryPolicy) hedge(ctx context.Context, budget *retryBudget, method string, req any,
+	reply proto.Message, cc *grpc.ClientConn, invoker grpc.UnaryInvoker,
+	opts ...grpc.CallOption) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// buffered to make sure the attempts not blocked after the call returned.
+	results := make(chan hedgeResult, p.maxAttempts)
+	send := func() {
+		r := proto.Clone(reply)
+		go func() {
+			err := invoker(ctx, method, req, r, cc, opts...)
+			results <- hedgeResult{reply: r, err: err}
+		}()
+	}
+
+	start := timex.Now()
+	serverName := path.Join(cc.Target(), method)
+	attempts, inflight := 1, 1
+	send()
+	timer := time.NewTimer(p.hedgingDelay)
+	defer timer.Stop()
+
+	var lastErr error
+	for {
+		select {
+		case res := <-results:
+			inflight--
+			if res.err == nil || !p.retryable(res.err) {
+				if res.err == nil {
+					proto.Reset(reply)
+					proto.Merge(reply, res.reply)
+				}
+				return res.err
+			}
+
+			lastErr = res.err
+			if attempts < p.maxAttempts && budget.allow() {
+				attempts++
+				inflight++
+				logAttempt(ctx, retryEventName, serverName, attempts, timex.Since(start), res.err)
+				send()
+			} else if inflight == 0 {
+				return lastErr
+			}
+		case <-timer.C:
+			if attempts < p.maxAttempts && budget.allow() {
+				attempts++
+				inflight++
+				logAttempt(ctx, hedgeEventName, serverName, attempts, timex.Since(start), nil)
+				send()
+				timer.Reset(p.hedgingDelay)
+			}
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+}
+
+func (p *retryPolicy) retry(ctx context.Context, budget *retryBudget, method string, req, reply any,
+	cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := timex.Now()
+	serverName := path.Join(cc.Target(), method)
+	for attempt := 1; ; attempt++ {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if err == nil || attempt >= p.maxAttempts || !p.retryable(err) {
+			return err
+		}
+
+		backoff := p.backoff(attempt)
+		// no need to retry if the backoff exceeds the deadline of the call.
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= backoff {
+			return err
+		}
+		if !budget.allow() {
+			return err
+		}
+
+		logAttempt(ctx, retryEventName, serverName, attempt+1, timex.Since(start), err)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+func (p *retryPolicy) retryable(err error) bool {
+	_, ok := p.codes[status.Code(err)]
+	return ok
+}
+
+func buildRetryPolicies(confs []RetryPolicyConf) (*retryPolicy, map[string]*retryPolicy, error) {
+	var defaultPolicy *retryPolicy
+	policies := make(map[string]*retryPolicy)
+	for _, c := range confs {
+		policy, err := newRetryPolicy(c)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(c.FullMethod) == 0 {
+			defaultPolicy = policy
+		} else {
+			policies[c.FullMethod] = policy
+		}
+	}
+
+	return defaultPolicy, policies, nil
+}
+
+func newRetryPolicy(c RetryPolicyConf) (*retryPolicy, error) {
+	retryableCodes := make(map[codes.Code]struct{})
+	if len(c.RetryableCodes) == 0 {
+		retryableCodes[codes.Unavailable] = struct{}{}
+	}
+	for _, name := range c.RetryableCodes {
+		code, ok := parseCode(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown retryable code: %s", name)
+		}
+		retryableCodes[code] = struct{}{}
+	}
+
+	multiplier := math.Max(c.BackoffMultiplier, 1)
+	return &retryPolicy{
+		maxAttempts: c.MaxAttempts,
+		backoff: func(attempt int) time.Duration {
+			backoff := float64(c.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+			if c.MaxBackoff > 0 && backoff > float64(c.MaxBackoff) {
+				return c.MaxBackoff
+			}
+			return time.Duration(backoff)
+		},
+		codes:        retryableCodes,
+		hedgingDelay: c.HedgingDelay,
+	}, nil
+}
+
+func logAttempt(ctx context.Context, event, serverName string, attempt int,
+	elapsed time.Duration, err error) {
+	attrs := []attribute.KeyValue{retryAttemptKey.Int(attempt)}
+	if err != nil {
+		attrs = append(attrs, ztrace.StatusCodeAttr(status.Code(err)))
+	}
+	trace.SpanFromContext(ctx).AddEvent(event, trace.WithAttributes(attrs...))
+
+	logger := logx.WithContext(ctx).WithDuration(elapsed)
+	if err != nil {
+		logger.Infof("%s - %s - attempt: %d - %s", event, serverName, attempt, err.Error())
+	} else {
+		logger.Infof("%s - %s - attempt: %d", event, serverName, attempt)
+	}
+}
+
+func parseCode(name string) (codes.Code, bool) {
+	for c := codes.OK; c <= codes.Unauthenticated; c++ {
+		if strings.EqualFold(c.String(), name) {
+			return c, true
+		}
+	}
+
+	return codes.OK, false
+}
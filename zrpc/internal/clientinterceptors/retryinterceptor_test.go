@@ -0,0 +1,225 @@
+package clientinterceptors
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestRetryInterceptor(t *testing.T) {
+	tests := []struct {
+		name     string
+		conf     RetryConf
+		errs     []error
+		attempts int32
+		code     codes.Code
+	}{
+		{
+			name:     "no policy",
+			conf:     RetryConf{},
+			errs:     []error{status.Error(codes.Unavailable, "any")},
+			attempts: 1,
+			code:     codes.Unavailable,
+		},
+		{
+			name: "retry until success",
+			conf: newRetryConf(RetryPolicyConf{}),
+			errs: []error{
+				status.Error(codes.Unavailable, "any"),
+				status.Error(codes.Unavailable, "any"),
+				nil,
+			},
+			attempts: 3,
+			code:     codes.OK,
+		},
+		{
+			name: "exceed max attempts",
+			conf: newRetryConf(RetryPolicyConf{}),
+			errs: []error{
+				status.Error(codes.Unavailable, "any"),
+				status.Error(codes.Unavailable, "any"),
+				status.Error(codes.Unavailable, "any"),
+				nil,
+			},
+			attempts: 3,
+			code:     codes.Unavailable,
+		},
+		{
+			name:     "not retryable",
+			conf:     newRetryConf(RetryPolicyConf{}),
+			errs:     []error{status.Error(codes.InvalidArgument, "any"), nil},
+			attempts: 1,
+			code:     codes.InvalidArgument,
+		},
+		{
+			name: "custom retryable codes",
+			conf: newRetryConf(RetryPolicyConf{
+				RetryableCodes: []string{"ResourceExhausted"},
+			}),
+			errs:     []error{status.Error(codes.ResourceExhausted, "any"), nil},
+			attempts: 2,
+			code:     codes.OK,
+		},
+		{
+			name: "other method",
+			conf: newRetryConf(RetryPolicyConf{
+				FullMethod: "/bar",
+			}),
+			errs:     []error{status.Error(codes.Unavailable, "any"), nil},
+			attempts: 1,
+			code:     codes.Unavailable,
+		},
+		{
+			name: "budget exhausted",
+			conf: RetryConf{
+				Policies: []RetryPolicyConf{
+					{
+						MaxAttempts:    3,
+						InitialBackoff: time.Millisecond,
+					},
+				},
+			},
+			errs:     []error{status.Error(codes.Unavailable, "any"), nil},
+			attempts: 1,
+			code:     codes.Unavailable,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			interceptor, err := RetryInterceptor(test.conf)
+			assert.NoError(t, err)
+
+			var attempts int32
+			err = interceptor(context.Background(), "/foo", nil, nil, new(grpc.ClientConn),
+				func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn,
+					opts ...grpc.CallOption) error {
+					n := atomic.AddInt32(&attempts, 1)
+					return test.errs[n-1]
+				})
+			assert.Equal(t, test.code, status.Code(err))
+			assert.Equal(t, test.attempts, atomic.LoadInt32(&attempts))
+		})
+	}
+}
+
+func TestRetryInterceptorWithDeadline(t *testing.T) {
+	interceptor, err := RetryInterceptor(newRetryConf(RetryPolicyConf{
+		InitialBackoff: time.Second,
+	}))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	var attempts int32
+	err = interceptor(ctx, "/foo", nil, nil, new(grpc.ClientConn),
+		func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn,
+			opts ...grpc.CallOption) error {
+			atomic.AddInt32(&attempts, 1)
+			return status.Error(codes.Unavailable, "any")
+		})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+}
+
+func TestRetryInterceptorWithHedging(t *testing.T) {
+	interceptor, err := RetryInterceptor(newRetryConf(RetryPolicyConf{
+		HedgingDelay: time.Millisecond * 10,
+	}))
+	assert.NoError(t, err)
+
+	var attempts int32
+	reply := wrapperspb.String("")
+	err = interceptor(context.Background(), "/foo", nil, reply, new(grpc.ClientConn),
+		func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn,
+			opts ...grpc.CallOption) error {
+			if atomic.AddInt32(&attempts, 1) == 1 {
+				<-ctx.Done()
+				return status.FromContextError(ctx.Err()).Err()
+			}
+
+			reply.(*wrapperspb.StringValue).Value = "hedged"
+			return nil
+		})
+	assert.NoError(t, err)
+	assert.Equal(t, "hedged", reply.Value)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+}
+
+func TestRetryInterceptorWithHedgingFailures(t *testing.T) {
+	interceptor, err := RetryInterceptor(newRetryConf(RetryPolicyConf{
+		HedgingDelay: time.Second,
+	}))
+	assert.NoError(t, err)
+
+	var attempts int32
+	err = interceptor(context.Background(), "/foo", nil, wrapperspb.String(""),
+		new(grpc.ClientConn), func(ctx context.Context, method string, req, reply any,
+			cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			atomic.AddInt32(&attempts, 1)
+			return status.Error(codes.Unavailable, "any")
+		})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+}
+
+func TestRetryInterceptorWithHedgingTimeout(t *testing.T) {
+	interceptor, err := RetryInterceptor(newRetryConf(RetryPolicyConf{
+		HedgingDelay: time.Millisecond,
+	}))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	err = interceptor(ctx, "/foo", nil, wrapperspb.String(""),
+		new(grpc.ClientConn), func(ctx context.Context, method string, req, reply any,
+			cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			<-ctx.Done()
+			return status.FromContextError(ctx.Err()).Err()
+		})
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+}
+
+func TestRetryInterceptorWithUnknownCode(t *testing.T) {
+	_, err := RetryInterceptor(newRetryConf(RetryPolicyConf{
+		RetryableCodes: []string{"foo"},
+	}))
+	assert.Error(t, err)
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy, err := newRetryPolicy(RetryPolicyConf{
+		MaxAttempts:       5,
+		InitialBackoff:    time.Millisecond * 10,
+		MaxBackoff:        time.Millisecond * 50,
+		BackoffMultiplier: 2,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, time.Millisecond*10, policy.backoff(1))
+	assert.Equal(t, time.Millisecond*20, policy.backoff(2))
+	assert.Equal(t, time.Millisecond*40, policy.backoff(3))
+	assert.Equal(t, time.Millisecond*50, policy.backoff(4))
+}
+
+func newRetryConf(policy RetryPolicyConf) RetryConf {
+	policy.MaxAttempts = 3
+	if policy.InitialBackoff == 0 {
+		policy.InitialBackoff = time.Millisecond
+	}
+
+	return RetryConf{
+		Policies:    []RetryPolicyConf{policy},
+		BudgetRatio: 0.1,
+		MinRetries:  10,
+	}
+}
@@ -1,11 +1,69 @@
 package internal
 
-import "github.com/jialequ/linux-sdk/zrpc/internal/serverinterceptors"
+import (
+	"time"
+
+	"github.com/jialequ/linux-sdk/internal/fault"
+	"github.com/jialequ/linux-sdk/zrpc/internal/auth"
+	"github.com/jialequ/linux-sdk/zrpc/internal/balancer/p2c"
+	"github.com/jialequ/linux-sdk/zrpc/internal/breakers"
+	"github.com/jialequ/linux-sdk/zrpc/internal/clientinterceptors"
+	"github.com/jialequ/linux-sdk/zrpc/internal/grpcweb"
+	"github.com/jialequ/linux-sdk/zrpc/internal/instance"
+	"github.com/jialequ/linux-sdk/zrpc/internal/message"
+	"github.com/jialequ/linux-sdk/zrpc/internal/ratelimit"
+	"github.com/jialequ/linux-sdk/zrpc/internal/serverinterceptors"
+	"github.com/jialequ/linux-sdk/zrpc/internal/tlsx"
+)
 
 type (
+	// AclRuleConf defines the required scopes or roles of the methods.
+	AclRuleConf = auth.AclRuleConf
+	// JwtAuthConf defines the jwt authentication and authorization config.
+	JwtAuthConf = auth.JwtConf
+
+	// BreakerConf defines the breaker config of the methods.
+	BreakerConf = breakers.Conf
+	// BreakerRuleConf defines the breaker rule of the methods.
+	BreakerRuleConf = breakers.RuleConf
+
+	// FaultConf defines the fault injection config.
+	FaultConf = fault.Conf
+	// FaultRuleConf defines the fault injection rule config.
+	FaultRuleConf = fault.RuleConf
+
+	// MessageConf defines the size limits and the compression of the messages.
+	MessageConf = message.Conf
+	// MessageMethodConf defines the size limits and the compression of the messages of the methods.
+	MessageMethodConf = message.MethodConf
+
+	// MetadataConf defines the metadata of a server instance to publish.
+	MetadataConf = instance.MetadataConf
+	// RoutingConf defines how a client routes the calls by the instance metadata.
+	RoutingConf = instance.RoutingConf
+
+	// OutlierDetectionConf defines the config to eject the failing servers from the p2c balancer.
+	OutlierDetectionConf = p2c.OutlierDetectionConf
+
+	// RateLimitConf defines the rate limit config.
+	RateLimitConf = ratelimit.Conf
+	// RateLimitRuleConf defines the rate limit rule config of a method.
+	RateLimitRuleConf = ratelimit.RuleConf
+
+	// RetryConf defines the retry config.
+	RetryConf = clientinterceptors.RetryConf
+	// RetryPolicyConf defines the retry policy config of a method.
+	RetryPolicyConf = clientinterceptors.RetryPolicyConf
+
 	// StatConf defines the stat config.
 	StatConf = serverinterceptors.StatConf
 
+	// TLSConf defines the TLS config.
+	TLSConf = tlsx.Conf
+
+	// WebConf defines the config to serve gRPC-Web and Connect requests.
+	WebConf = grpcweb.Conf
+
 	// ClientMiddlewaresConf defines whether to use client middlewares.
 	ClientMiddlewaresConf struct {
 		Trace      bool `json:",default=true"`
@@ -13,6 +71,20 @@ type (
 		Prometheus bool `json:",default=true"`
 		Breaker    bool `json:",default=true"`
 		Timeout    bool `json:",default=true"`
+		RequestId  bool `json:",default=true"`
+		Canary     bool `json:",default=true"`
+		// Budget bounds the deadlines of the calls with the remaining budget of the callers,
+		// BudgetMargin is reserved for the callers to handle the responses.
+		Budget       bool          `json:",default=true"`
+		BudgetMargin time.Duration `json:",default=5ms"`
+		// BreakerConf tunes the breakers of the methods, like the failure codes and the fallbacks.
+		BreakerConf BreakerConf `json:",optional"`
+		// Retry turns on the retries and hedging of unary calls with the policies in RetryConf.
+		Retry     bool      `json:",optional"`
+		RetryConf RetryConf `json:",optional"`
+		// Fault turns on the fault injection, only for resilience drills.
+		Fault     bool      `json:",optional"`
+		FaultConf FaultConf `json:",optional"`
 	}
 
 	// ServerMiddlewaresConf defines whether to use server middlewares.
@@ -23,6 +95,20 @@ type (
 		StatConf   StatConf `json:",optional"`
 		Prometheus bool     `json:",default=true"`
 		Breaker    bool     `json:",default=true"`
+		RequestId  bool     `json:",default=true"`
+		// Canary routes the downstream calls by the traffic color in the incoming metadata,
+		// turn it on only if the callers are trusted to set the color.
+		Canary bool `json:",optional"`
+		// Validate validates the requests with their Validate methods or the rules in the proto files.
+		Validate bool `json:",optional"`
+		// BreakerConf tunes the breakers of the methods, like the failure codes and the fallbacks.
+		BreakerConf BreakerConf `json:",optional"`
+		// RateLimit turns on the rate limit of the callers with the rules in RateLimitConf.
+		RateLimit     bool          `json:",optional"`
+		RateLimitConf RateLimitConf `json:",optional"`
+		// Fault turns on the fault injection, only for resilience drills.
+		Fault     bool      `json:",optional"`
+		FaultConf FaultConf `json:",optional"`
 	}
 
 	// MethodTimeoutConf defines specified timeout for gRPC methods.
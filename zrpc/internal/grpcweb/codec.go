@@ -0,0 +1,51 @@
+package grpcweb
+
+import (
+	"fmt"
+	"sync"
+
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const jsonCodecName = "json"
+
+// jsonCodec is used to serve the Connect requests in JSON, aka application/grpc+json.
+type jsonCodec struct{}
+
+var registerOnce sync.Once
+
+// RegisterCodec registers the json codec to serve the Connect requests in JSON,
+// it's called only if the web requests are served, because the codecs are registered
+// globally, which are shared by all the grpc servers in the process.
+// Notice: it should be called on setting up, not concurrently with serving.
+func RegisterCodec() {
+	registerOnce.Do(func() {
+		if encoding.GetCodec(jsonCodecName) == nil {
+			encoding.RegisterCodec(jsonCodec{})
+		}
+	})
+}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("failed to marshal, message is %T, want proto.Message", v)
+	}
+
+	return protojson.Marshal(msg)
+}
+
+func (jsonCodec) Name() string {
+	return jsonCodecName
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("failed to unmarshal, message is %T, want proto.Message", v)
+	}
+
+	return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, msg)
+}
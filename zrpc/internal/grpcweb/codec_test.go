@@ -0,0 +1,27 @@
+package grpcweb
+
+import (
+	"testing"
+
+	"github.com/jialequ/linux-sdk/internal/mock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/encoding"
+)
+
+func TestJsonCodec(t *testing.T) {
+	RegisterCodec()
+	codec := encoding.GetCodec(jsonCodecName)
+	assert.Equal(t, jsonCodecName, codec.Name())
+
+	data, err := codec.Marshal(&mock.DepositRequest{Amount: 1})
+	assert.NoError(t, err)
+
+	var req mock.DepositRequest
+	assert.NoError(t, codec.Unmarshal(data, &req))
+	assert.Equal(t, float32(1), req.Amount)
+	assert.NoError(t, codec.Unmarshal([]byte(`{"amount":2,"unknown":1}`), &req))
+
+	_, err = codec.Marshal("foo")
+	assert.Error(t, err)
+	assert.Error(t, codec.Unmarshal(data, new(string)))
+}
@@ -0,0 +1,264 @@
+package grpcweb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/http2"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	connectProtoContentType = "application/proto"
+	connectJSONContentType  = "application/json"
+	connectTimeoutHeader    = "Connect-Timeout-Ms"
+	connectVersionHeader    = "Connect-Protocol-Version"
+	connectTrailerPrefix    = "Trailer-"
+	contentEncodingHeader   = "Content-Encoding"
+	identityEncoding        = "identity"
+	grpcTimeoutHeader       = "Grpc-Timeout"
+	grpcStatusHeader        = "Grpc-Status"
+	grpcMessageHeader       = "Grpc-Message"
+	grpcDetailsHeader       = "Grpc-Status-Details-Bin"
+	grpcHeaderPrefix        = "Grpc-"
+	typeUrlPrefix           = "type.googleapis.com/"
+	// grpc-timeout allows at most 8 digits.
+	maxGrpcTimeoutValue = 1e8
+)
+
+var connectCodes = map[codes.Code]struct {
+	name       string
+	httpStatus int
+}{
+	codes.Canceled:           {"canceled", 499},
+	codes.Unknown:            {"unknown", http.StatusInternalServerError},
+	codes.InvalidArgument:    {"invalid_argument", http.StatusBadRequest},
+	codes.DeadlineExceeded:   {"deadline_exceeded", http.StatusGatewayTimeout},
+	codes.NotFound:           {"not_found", http.StatusNotFound},
+	codes.AlreadyExists:      {"already_exists", http.StatusConflict},
+	codes.PermissionDenied:   {"permission_denied", http.StatusForbidden},
+	codes.ResourceExhausted:  {"resource_exhausted", http.StatusTooManyRequests},
+	codes.FailedPrecondition: {"failed_precondition", http.StatusBadRequest},
+	codes.Aborted:            {"aborted", http.StatusConflict},
+	codes.OutOfRange:         {"out_of_range", http.StatusBadRequest},
+	codes.Unimplemented:      {"unimplemented", http.StatusNotImplemented},
+	codes.Internal:           {"internal", http.StatusInternalServerError},
+	codes.Unavailable:        {"unavailable", http.StatusServiceUnavailable},
+	codes.DataLoss:           {"data_loss", http.StatusInternalServerError},
+	codes.Unauthenticated:    {"unauthenticated", http.StatusUnauthorized},
+}
+
+type (
+	connectError struct {
+		Code    string          `json:"code"`
+		Message string          `json:"message,omitempty"`
+		Details []connectDetail `json:"details,omitempty"`
+	}
+
+	connectDetail struct {
+		Type  string `json:"type"`
+		Value string `json:"value"`
+	}
+
+	// responseRecorder records the gRPC response to translate into a Connect unary response.
+	responseRecorder struct {
+		header      http.Header
+		wroteHeader http.Header
+		code        int
+		body        bytes.Buffer
+	}
+)
+
+func (h *Handler) serveConnect(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if encoding := r.Header.Get(contentEncodingHeader); len(encoding) > 0 && encoding != identityEncoding {
+		writeConnectError(w, status.Newf(codes.Unimplemented, "unsupported content encoding: %s", encoding))
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, int64(h.maxRecvSize)))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeConnectError(w, status.Newf(codes.ResourceExhausted,
+				"request larger than max (%d)", h.maxRecvSize))
+		} else {
+			writeConnectError(w, status.New(codes.InvalidArgument, err.Error()))
+		}
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get(contentTypeHeader))
+	subtype := "+proto"
+	if mediaType == connectJSONContentType {
+		subtype = "+" + jsonCodecName
+	}
+
+	frame := make([]byte, frameHeaderSize, frameHeaderSize+len(body))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(body)))
+	frame = append(frame, body...)
+
+	req := toGrpcRequest(r, grpcContentType+subtype)
+	req.Body = io.NopCloser(bytes.NewReader(frame))
+	req.Header.Del(connectVersionHeader)
+	if timeout := req.Header.Get(connectTimeoutHeader); len(timeout) > 0 {
+		req.Header.Del(connectTimeoutHeader)
+		if ms, err := strconv.ParseInt(timeout, 10, 64); err == nil && ms > 0 {
+			if ms < maxGrpcTimeoutValue {
+				req.Header.Set(grpcTimeoutHeader, strconv.FormatInt(ms, 10)+"m")
+			} else {
+				req.Header.Set(grpcTimeoutHeader, strconv.FormatInt(ms/1000, 10)+"S")
+			}
+		}
+	}
+
+	rec := &responseRecorder{
+		header: make(http.Header),
+	}
+	h.server.ServeHTTP(rec, req)
+
+	header := w.Header()
+	copyMetadata(header, rec.wroteHeader, "")
+	copyMetadata(header, rec.trailers(), connectTrailerPrefix)
+
+	st := rec.status()
+	if st.Code() != codes.OK {
+		writeConnectError(w, st)
+		return
+	}
+
+	payload := rec.body.Bytes()
+	if len(payload) < frameHeaderSize {
+		writeConnectError(w, status.New(codes.Internal, "missing response message"))
+		return
+	}
+
+	header.Set(contentTypeHeader, mediaType)
+	header.Set(contentLengthHeader, strconv.Itoa(len(payload)-frameHeaderSize))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(payload[frameHeaderSize:])
+}
+
+func (rec *responseRecorder) Flush() {
+	if rec.wroteHeader == nil {
+		rec.WriteHeader(http.StatusOK)
+	}
+}
+
+func (rec *responseRecorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *responseRecorder) Write(p []byte) (int, error) {
+	if rec.wroteHeader == nil {
+		rec.WriteHeader(http.StatusOK)
+	}
+
+	return rec.body.Write(p)
+}
+
+func (rec *responseRecorder) WriteHeader(code int) {
+	if rec.wroteHeader != nil {
+		return
+	}
+
+	rec.code = code
+	rec.wroteHeader = rec.header.Clone()
+}
+
+func (rec *responseRecorder) status() *status.Status {
+	if rec.code != http.StatusOK {
+		return status.New(codes.Unknown, strings.TrimSpace(rec.body.String()))
+	}
+
+	code, err := strconv.Atoi(rec.header.Get(grpcStatusHeader))
+	if err != nil {
+		return status.New(codes.Unknown, "missing grpc status")
+	}
+
+	if details := rec.header.Get(grpcDetailsHeader); len(details) > 0 {
+		if bs, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(details, "=")); err == nil {
+			var st spb.Status
+			if err = proto.Unmarshal(bs, &st); err == nil {
+				return status.FromProto(&st)
+			}
+		}
+	}
+
+	msg := rec.header.Get(grpcMessageHeader)
+	if unescaped, err := url.PathUnescape(msg); err == nil {
+		msg = unescaped
+	}
+
+	return status.New(codes.Code(code), msg)
+}
+
+func (rec *responseRecorder) trailers() http.Header {
+	trailers := make(http.Header)
+	for k, v := range rec.header {
+		if strings.HasPrefix(k, http2.TrailerPrefix) {
+			trailers[http.CanonicalHeaderKey(k[len(http2.TrailerPrefix):])] = v
+		}
+	}
+
+	return trailers
+}
+
+func copyMetadata(dst, src http.Header, prefix string) {
+	for k, v := range src {
+		if k == contentTypeHeader || k == trailerHeader || strings.HasPrefix(k, grpcHeaderPrefix) ||
+			strings.HasPrefix(k, http2.TrailerPrefix) {
+			continue
+		}
+
+		dst[prefix+k] = v
+	}
+}
+
+func isConnect(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return mediaType == connectProtoContentType || mediaType == connectJSONContentType
+}
+
+func writeConnectError(w http.ResponseWriter, st *status.Status) {
+	code, ok := connectCodes[st.Code()]
+	if !ok {
+		code = connectCodes[codes.Unknown]
+	}
+
+	ce := connectError{
+		Code:    code.name,
+		Message: st.Message(),
+	}
+	for _, detail := range st.Proto().GetDetails() {
+		ce.Details = append(ce.Details, connectDetail{
+			Type:  strings.TrimPrefix(detail.GetTypeUrl(), typeUrlPrefix),
+			Value: base64.RawStdEncoding.EncodeToString(detail.GetValue()),
+		})
+	}
+
+	w.Header().Set(contentTypeHeader, connectJSONContentType)
+	w.WriteHeader(code.httpStatus)
+	_ = json.NewEncoder(w).Encode(ce)
+}
@@ -0,0 +1,191 @@
+package grpcweb
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jialequ/linux-sdk/internal/mock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestServeConnect(t *testing.T) {
+	svr := httptest.NewServer(NewHandler(newTestServer(t), Conf{}))
+	defer svr.Close()
+	url := svr.URL + "/mock.DepositService/Deposit"
+
+	t.Run("proto", func(t *testing.T) {
+		body, err := proto.Marshal(&mock.DepositRequest{Amount: 1})
+		assert.NoError(t, err)
+
+		resp, err := http.Post(url, connectProtoContentType, bytes.NewReader(body))
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, connectProtoContentType, resp.Header.Get(contentTypeHeader))
+
+		content, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		var reply mock.DepositResponse
+		assert.NoError(t, proto.Unmarshal(content, &reply))
+		assert.True(t, reply.Ok)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		resp, err := http.Post(url, connectJSONContentType+"; charset=utf-8",
+			bytes.NewReader([]byte(`{"amount":1}`)))
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		content, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"ok":true}`, string(content))
+	})
+
+	tests := []struct {
+		name     string
+		method   string
+		url      string
+		body     string
+		header   map[string]string
+		status   int
+		code     string
+		hasError bool
+	}{
+		{
+			name:     "invalid argument",
+			method:   http.MethodPost,
+			url:      url,
+			body:     `{"amount":-1}`,
+			status:   http.StatusBadRequest,
+			code:     "invalid_argument",
+			hasError: true,
+		},
+		{
+			name:     "unimplemented",
+			method:   http.MethodPost,
+			url:      svr.URL + "/mock.DepositService/Unknown",
+			body:     `{}`,
+			status:   http.StatusNotImplemented,
+			code:     "unimplemented",
+			hasError: true,
+		},
+		{
+			name:     "timeout",
+			method:   http.MethodPost,
+			url:      url,
+			body:     `{"amount":100}`,
+			header:   map[string]string{connectTimeoutHeader: "1"},
+			status:   http.StatusGatewayTimeout,
+			code:     "deadline_exceeded",
+			hasError: true,
+		},
+		{
+			name:     "long timeout",
+			method:   http.MethodPost,
+			url:      url,
+			body:     `{"amount":1}`,
+			header:   map[string]string{connectTimeoutHeader: "1000000000"},
+			status:   http.StatusOK,
+			hasError: false,
+		},
+		{
+			name:     "compressed",
+			method:   http.MethodPost,
+			url:      url,
+			body:     `{}`,
+			header:   map[string]string{contentEncodingHeader: "gzip"},
+			status:   http.StatusNotImplemented,
+			code:     "unimplemented",
+			hasError: true,
+		},
+		{
+			name:   "get",
+			method: http.MethodGet,
+			url:    url,
+			status: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest(test.method, test.url, bytes.NewReader([]byte(test.body)))
+			assert.NoError(t, err)
+			req.Header.Set(contentTypeHeader, connectJSONContentType)
+			for k, v := range test.header {
+				req.Header.Set(k, v)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, test.status, resp.StatusCode)
+
+			if test.hasError {
+				var ce connectError
+				assert.NoError(t, json.NewDecoder(resp.Body).Decode(&ce))
+				assert.Equal(t, test.code, ce.Code)
+			}
+		})
+	}
+}
+
+func TestWriteConnectError(t *testing.T) {
+	st, err := status.New(codes.ResourceExhausted, "too many requests").WithDetails(&errdetails.QuotaFailure{})
+	assert.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	writeConnectError(w, st)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	var ce connectError
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &ce))
+	assert.Equal(t, "resource_exhausted", ce.Code)
+	assert.Equal(t, "too many requests", ce.Message)
+	assert.Equal(t, "google.rpc.QuotaFailure", ce.Details[0].Type)
+
+	w = httptest.NewRecorder()
+	writeConnectError(w, status.New(codes.Code(100), "foo"))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestResponseRecorderStatus(t *testing.T) {
+	rec := &responseRecorder{header: make(http.Header)}
+	rec.WriteHeader(http.StatusBadRequest)
+	_, _ = rec.Write([]byte("bad request"))
+	assert.Equal(t, codes.Unknown, rec.status().Code())
+
+	rec = &responseRecorder{header: make(http.Header)}
+	rec.Flush()
+	assert.Equal(t, codes.Unknown, rec.status().Code())
+
+	rec.header.Set(grpcStatusHeader, "5")
+	rec.header.Set(grpcMessageHeader, "not%20found")
+	st := rec.status()
+	assert.Equal(t, codes.NotFound, st.Code())
+	assert.Equal(t, "not found", st.Message())
+}
+
+func TestServeConnectTooLarge(t *testing.T) {
+	svr := httptest.NewServer(NewHandler(newTestServer(t), Conf{}, WithMaxRecvSize(8)))
+	defer svr.Close()
+
+	resp, err := http.Post(svr.URL+"/mock.DepositService/Deposit", connectJSONContentType,
+		bytes.NewReader([]byte(`{"amount":1000000}`)))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+
+	var ce connectError
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&ce))
+	assert.Equal(t, "resource_exhausted", ce.Code)
+}
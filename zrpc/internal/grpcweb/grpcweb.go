@@ -0,0 +1,148 @@
+package grpcweb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/http2"
+)
+
+const (
+	grpcWebContentType     = "application/grpc-web"
+	grpcWebTextContentType = "application/grpc-web-text"
+	contentLengthHeader    = "Content-Length"
+	trailerHeader          = "Trailer"
+	frameHeaderSize        = 5
+	trailerFlag            = 0x80
+)
+
+// webResponseWriter translates the gRPC responses into gRPC-Web,
+// the trailers are sent as the last frame of the body.
+type webResponseWriter struct {
+	w           http.ResponseWriter
+	header      http.Header
+	contentType string
+	text        bool
+	wroteHeader bool
+}
+
+func (h *Handler) serveGrpcWeb(w http.ResponseWriter, r *http.Request) {
+	contentType := r.Header.Get(contentTypeHeader)
+	text := strings.HasPrefix(contentType, grpcWebTextContentType)
+	var subtype string
+	if index := strings.IndexByte(contentType, '+'); index >= 0 {
+		subtype = contentType[index:]
+	}
+
+	req := toGrpcRequest(r, grpcContentType+subtype)
+	if text {
+		req.Body = io.NopCloser(base64.NewDecoder(base64.StdEncoding, r.Body))
+	}
+
+	rw := &webResponseWriter{
+		w:           w,
+		header:      make(http.Header),
+		contentType: contentType,
+		text:        text,
+	}
+	h.server.ServeHTTP(rw, req)
+	rw.finish()
+}
+
+func (rw *webResponseWriter) Flush() {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+
+	if flusher, ok := rw.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (rw *webResponseWriter) Header() http.Header {
+	return rw.header
+}
+
+func (rw *webResponseWriter) Write(p []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+
+	if !rw.text {
+		return rw.w.Write(p)
+	}
+
+	// each write is encoded separately, the clients decode the padded chunks one by one.
+	if _, err := io.WriteString(rw.w, base64.StdEncoding.EncodeToString(p)); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+func (rw *webResponseWriter) WriteHeader(code int) {
+	if rw.wroteHeader {
+		return
+	}
+
+	rw.wroteHeader = true
+	header := rw.w.Header()
+	for k, v := range rw.header {
+		if k == trailerHeader || strings.HasPrefix(k, http2.TrailerPrefix) {
+			continue
+		}
+
+		header[k] = v
+	}
+	header.Set(contentTypeHeader, rw.contentType)
+	header.Del(contentLengthHeader)
+	rw.w.WriteHeader(code)
+}
+
+func (rw *webResponseWriter) finish() {
+	var buf bytes.Buffer
+	for _, name := range rw.header.Values(trailerHeader) {
+		for _, v := range rw.header.Values(name) {
+			writeTrailer(&buf, name, v)
+		}
+	}
+	for k, vals := range rw.header {
+		if strings.HasPrefix(k, http2.TrailerPrefix) {
+			for _, v := range vals {
+				writeTrailer(&buf, k[len(http2.TrailerPrefix):], v)
+			}
+		}
+	}
+
+	frame := make([]byte, frameHeaderSize, frameHeaderSize+buf.Len())
+	frame[0] = trailerFlag
+	binary.BigEndian.PutUint32(frame[1:], uint32(buf.Len()))
+	frame = append(frame, buf.Bytes()...)
+	_, _ = rw.Write(frame)
+	rw.Flush()
+}
+
+func isGrpcWeb(contentType string) bool {
+	return strings.HasPrefix(contentType, grpcWebContentType)
+}
+
+// toGrpcRequest returns a request that can be served by grpc.Server.ServeHTTP.
+func toGrpcRequest(r *http.Request, contentType string) *http.Request {
+	req := r.Clone(r.Context())
+	req.ProtoMajor, req.ProtoMinor, req.Proto = 2, 0, "HTTP/2"
+	req.Header.Set(contentTypeHeader, contentType)
+	req.Header.Del(contentLengthHeader)
+	req.ContentLength = -1
+	return req
+}
+
+func writeTrailer(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(strings.ToLower(name))
+	buf.WriteString(": ")
+	buf.WriteString(value)
+	buf.WriteString("\r\n")
+}
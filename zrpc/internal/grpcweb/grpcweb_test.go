@@ -0,0 +1,121 @@
+package grpcweb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jialequ/linux-sdk/internal/mock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestServeGrpcWeb(t *testing.T) {
+	svr := httptest.NewServer(NewHandler(newTestServer(t), Conf{}))
+	defer svr.Close()
+
+	tests := []struct {
+		name        string
+		contentType string
+		amount      float32
+		ok          bool
+		trailer     string
+	}{
+		{
+			name:        "binary",
+			contentType: "application/grpc-web+proto",
+			amount:      1,
+			ok:          true,
+			trailer:     "grpc-status: 0\r\n",
+		},
+		{
+			name:        "text",
+			contentType: "application/grpc-web-text",
+			amount:      1,
+			ok:          true,
+			trailer:     "grpc-status: 0\r\n",
+		},
+		{
+			name:        "error",
+			contentType: "application/grpc-web",
+			amount:      -1,
+			trailer:     "grpc-status: 3\r\n",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			text := strings.HasPrefix(test.contentType, grpcWebTextContentType)
+			body := encodeFrame(t, 0, &mock.DepositRequest{Amount: test.amount})
+			if text {
+				body = []byte(base64.StdEncoding.EncodeToString(body))
+			}
+
+			resp, err := http.Post(svr.URL+"/mock.DepositService/Deposit", test.contentType,
+				bytes.NewReader(body))
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, test.contentType, resp.Header.Get(contentTypeHeader))
+
+			content, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			if text {
+				content = decodeChunks(t, content)
+			}
+
+			var reply mock.DepositResponse
+			var trailer string
+			for len(content) >= frameHeaderSize {
+				size := binary.BigEndian.Uint32(content[1:frameHeaderSize])
+				payload := content[frameHeaderSize : frameHeaderSize+size]
+				if content[0]&trailerFlag == trailerFlag {
+					trailer = string(payload)
+				} else {
+					assert.NoError(t, proto.Unmarshal(payload, &reply))
+				}
+				content = content[frameHeaderSize+size:]
+			}
+
+			assert.Equal(t, test.ok, reply.Ok)
+			assert.Contains(t, trailer, test.trailer)
+		})
+	}
+}
+
+func decodeChunks(t *testing.T, content []byte) []byte {
+	var decoded []byte
+	for len(content) > 0 {
+		end := bytes.IndexByte(content, '=')
+		if end < 0 {
+			end = len(content)
+		} else {
+			for end < len(content) && content[end] == '=' {
+				end++
+			}
+		}
+
+		chunk, err := base64.StdEncoding.DecodeString(string(content[:end]))
+		assert.NoError(t, err)
+		decoded = append(decoded, chunk...)
+		content = content[end:]
+	}
+
+	return decoded
+}
+
+func encodeFrame(t *testing.T, flag byte, msg proto.Message) []byte {
+	payload, err := proto.Marshal(msg)
+	assert.NoError(t, err)
+
+	frame := make([]byte, frameHeaderSize, frameHeaderSize+len(payload))
+	frame[0] = flag
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(payload)))
+	return append(frame, payload...)
+}
@@ -0,0 +1,167 @@
+package grpcweb
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
+)
+
+const (
+	contentTypeHeader = "Content-Type"
+	originHeader      = "Origin"
+	grpcContentType   = "application/grpc"
+
+	allowOrigin    = "Access-Control-Allow-Origin"
+	allowMethods   = "Access-Control-Allow-Methods"
+	allowHeaders   = "Access-Control-Allow-Headers"
+	exposeHeaders  = "Access-Control-Expose-Headers"
+	maxAge         = "Access-Control-Max-Age"
+	requestHeaders = "Access-Control-Request-Headers"
+	varyHeader     = "Vary"
+	allOrigins     = "*"
+	maxAgeVal      = "86400"
+	exposeVal      = "Grpc-Status, Grpc-Message, Grpc-Status-Details-Bin"
+	// allowHeadersVal are the headers that the gRPC-Web and Connect clients send,
+	// the other headers, like Authorization, need to be allowed by AllowedHeaders.
+	allowHeadersVal = "Content-Type, X-Grpc-Web, X-User-Agent, Grpc-Timeout, " +
+		"Connect-Protocol-Version, Connect-Timeout-Ms"
+	// defaultMaxRecvSize is the default max receive message size of grpc.Server.
+	defaultMaxRecvSize = 4 * 1024 * 1024
+)
+
+type (
+	// Conf defines the config to serve gRPC-Web and Connect requests.
+	Conf struct {
+		// ListenOn is the side address to serve, empty means sharing the rpc address.
+		// Notice: on the shared address, the native gRPC requests are served by
+		// grpc.Server.ServeHTTP, the experimental http handler transport of grpc-go,
+		// which lacks some features and performs worse than the native transport,
+		// set ListenOn to keep serving the native gRPC requests with the native transport.
+		ListenOn string `json:",optional"`
+		// AllowedOrigins are the origins allowed for CORS, * means all origins.
+		AllowedOrigins []string
+		// AllowedHeaders are the headers allowed for CORS besides the ones of
+		// the gRPC-Web and Connect protocols, like Authorization.
+		AllowedHeaders []string `json:",optional"`
+	}
+
+	// A Handler serves the gRPC-Web and Connect requests with a grpc.Server,
+	// the requests go through the interceptors of the grpc.Server.
+	Handler struct {
+		server       *grpc.Server
+		origins      map[string]struct{}
+		allOrigins   bool
+		allowHeaders string
+		maxRecvSize  int
+	}
+
+	// HandlerOption customizes a Handler.
+	HandlerOption func(h *Handler)
+)
+
+var errNoAllowedOrigins = errors.New("no allowed origins of the web requests, use * to allow all")
+
+// Validate validates c.
+func (c Conf) Validate() error {
+	if len(c.AllowedOrigins) == 0 {
+		return errNoAllowedOrigins
+	}
+
+	return nil
+}
+
+// NewHandler returns a Handler, the cross-origin requests are rejected
+// if the origins are not in c.AllowedOrigins.
+func NewHandler(server *grpc.Server, c Conf, opts ...HandlerOption) *Handler {
+	h := &Handler{
+		server:       server,
+		origins:      make(map[string]struct{}, len(c.AllowedOrigins)),
+		allowHeaders: allowHeadersVal,
+		maxRecvSize:  defaultMaxRecvSize,
+	}
+	for _, origin := range c.AllowedOrigins {
+		if origin == allOrigins {
+			h.allOrigins = true
+		} else {
+			h.origins[strings.ToLower(origin)] = struct{}{}
+		}
+	}
+	if len(c.AllowedHeaders) > 0 {
+		h.allowHeaders = strings.Join(append([]string{allowHeadersVal}, c.AllowedHeaders...), ", ")
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+// WithMaxRecvSize returns a HandlerOption that limits the size of the Connect requests,
+// which are buffered before handling, it should be the max receive message size of the server.
+func WithMaxRecvSize(size int) HandlerOption {
+	return func(h *Handler) {
+		if size > 0 {
+			h.maxRecvSize = size
+		}
+	}
+}
+
+// ServeHTTP serves the gRPC-Web, Connect and the native gRPC over HTTP/2 requests.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	contentType := r.Header.Get(contentTypeHeader)
+	if r.ProtoMajor == 2 && isGrpc(contentType) {
+		h.server.ServeHTTP(w, r)
+		return
+	}
+
+	if !h.cors(w, r) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	switch {
+	case isGrpcWeb(contentType):
+		h.serveGrpcWeb(w, r)
+	case isConnect(contentType):
+		h.serveConnect(w, r)
+	default:
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+	}
+}
+
+func (h *Handler) cors(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get(originHeader)
+	if len(origin) == 0 {
+		return true
+	}
+
+	header := w.Header()
+	header.Add(varyHeader, originHeader)
+	if h.allOrigins {
+		header.Set(allowOrigin, allOrigins)
+	} else if _, ok := h.origins[strings.ToLower(origin)]; ok {
+		header.Set(allowOrigin, origin)
+	} else {
+		return false
+	}
+
+	header.Set(exposeHeaders, exposeVal)
+	if r.Method == http.MethodOptions {
+		header.Set(allowMethods, http.MethodPost)
+		header.Set(allowHeaders, h.allowHeaders)
+		header.Set(maxAge, maxAgeVal)
+	}
+
+	return true
+}
+
+func isGrpc(contentType string) bool {
+	return contentType == grpcContentType || strings.HasPrefix(contentType, grpcContentType+"+") ||
+		strings.HasPrefix(contentType, grpcContentType+";")
+}
@@ -0,0 +1,85 @@
+package grpcweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jialequ/linux-sdk/internal/mock"
+	"github.com/jialequ/linux-sdk/zrpc/internal/serverinterceptors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+func TestHandlerCors(t *testing.T) {
+	handler := NewHandler(newTestServer(t), Conf{
+		AllowedOrigins: []string{"http://example.com"},
+	})
+
+	r := httptest.NewRequest(http.MethodOptions, "/mock.DepositService/Deposit", http.NoBody)
+	r.Header.Set(originHeader, "http://EXAMPLE.com")
+	r.Header.Set(requestHeaders, "content-type,authorization")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "http://EXAMPLE.com", w.Header().Get(allowOrigin))
+	assert.Equal(t, allowHeadersVal, w.Header().Get(allowHeaders))
+	assert.Equal(t, http.MethodPost, w.Header().Get(allowMethods))
+
+	r = httptest.NewRequest(http.MethodOptions, "/mock.DepositService/Deposit", http.NoBody)
+	r.Header.Set(originHeader, "http://other.com")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	handler = NewHandler(newTestServer(t), Conf{})
+	r = httptest.NewRequest(http.MethodOptions, "/mock.DepositService/Deposit", http.NoBody)
+	r.Header.Set(originHeader, "http://other.com")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	handler = NewHandler(newTestServer(t), Conf{
+		AllowedOrigins: []string{allOrigins},
+		AllowedHeaders: []string{"Authorization"},
+	})
+	r = httptest.NewRequest(http.MethodOptions, "/mock.DepositService/Deposit", http.NoBody)
+	r.Header.Set(originHeader, "http://other.com")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, allOrigins, w.Header().Get(allowOrigin))
+	assert.Equal(t, allowHeadersVal+", Authorization", w.Header().Get(allowHeaders))
+}
+
+func TestConfValidate(t *testing.T) {
+	assert.Equal(t, errNoAllowedOrigins, Conf{}.Validate())
+	assert.NoError(t, Conf{AllowedOrigins: []string{allOrigins}}.Validate())
+}
+
+func TestHandlerUnsupportedMediaType(t *testing.T) {
+	handler := NewHandler(newTestServer(t), Conf{})
+	r := httptest.NewRequest(http.MethodPost, "/mock.DepositService/Deposit", strings.NewReader("foo"))
+	r.Header.Set(contentTypeHeader, "text/plain")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+}
+
+func TestIsGrpc(t *testing.T) {
+	assert.True(t, isGrpc("application/grpc"))
+	assert.True(t, isGrpc("application/grpc+proto"))
+	assert.False(t, isGrpc("application/grpc-web"))
+	assert.False(t, isGrpc("application/json"))
+}
+
+func newTestServer(t *testing.T) *grpc.Server {
+	RegisterCodec()
+	// the timeout interceptor makes sure the deadlines of the web requests are respected.
+	server := grpc.NewServer(grpc.UnaryInterceptor(serverinterceptors.UnaryTimeoutInterceptor(time.Minute)))
+	mock.RegisterDepositServiceServer(server, new(mock.DepositServer))
+	t.Cleanup(server.Stop)
+	return server
+}
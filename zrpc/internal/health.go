@@ -0,0 +1,164 @@
+package internal
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	threading "github.com/jialequ/linux-sdk/core/dist"
+	"github.com/jialequ/linux-sdk/core/logx"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const defaultHealthCheckInterval = 5 * time.Second
+
+type (
+	// A HealthCheck checks a dependency of a service, returns an error if it's unavailable.
+	HealthCheck func(ctx context.Context) error
+
+	// healthServer serves the grpc health checks with the serving status of each service,
+	// a service is SERVING only if it's set to serving and all of its checks pass.
+	healthServer struct {
+		*health.Server
+		interval time.Duration
+		serving  map[string]bool
+		failed   map[string]bool
+		checks   map[string][]HealthCheck
+		done     chan struct{}
+		once     sync.Once
+		lock     sync.Mutex
+	}
+)
+
+func newHealthServer(interval time.Duration) *healthServer {
+	if interval <= 0 {
+		interval = defaultHealthCheckInterval
+	}
+
+	return &healthServer{
+		Server:   health.NewServer(),
+		interval: interval,
+		serving:  make(map[string]bool),
+		failed:   make(map[string]bool),
+		checks:   make(map[string][]HealthCheck),
+		done:     make(chan struct{}),
+	}
+}
+
+// AddCheck adds a check of the dependency of service.
+func (h *healthServer) AddCheck(service string, check HealthCheck) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.checks[service] = append(h.checks[service], check)
+	if _, ok := h.serving[service]; !ok {
+		h.serving[service] = true
+	}
+}
+
+// AddServices adds the services as serving, the services already set are ignored.
+func (h *healthServer) AddServices(services ...string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	for _, service := range services {
+		if _, ok := h.serving[service]; !ok {
+			h.serving[service] = true
+			h.update(service)
+		}
+	}
+}
+
+// Resume sets the services to their statuses, and starts the checks.
+func (h *healthServer) Resume() {
+	// Resume sets all the services to SERVING, so we need to restore the statuses.
+	h.Server.Resume()
+
+	h.lock.Lock()
+	for service := range h.serving {
+		h.update(service)
+	}
+	hasChecks := len(h.checks) > 0
+	h.lock.Unlock()
+
+	if hasChecks {
+		threading.GoSafe(h.startChecks)
+	}
+}
+
+// SetServingStatus sets the serving status of service.
+func (h *healthServer) SetServingStatus(service string, serving bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.serving[service] = serving
+	h.update(service)
+}
+
+// Shutdown sets all the services to NOT_SERVING, and stops the checks.
+func (h *healthServer) Shutdown() {
+	h.once.Do(func() {
+		close(h.done)
+	})
+	h.Server.Shutdown()
+}
+
+func (h *healthServer) runChecks() {
+	h.lock.Lock()
+	checks := make(map[string][]HealthCheck, len(h.checks))
+	for service, each := range h.checks {
+		checks[service] = each
+	}
+	h.lock.Unlock()
+
+	for service, each := range checks {
+		err := h.check(each)
+		if err != nil {
+			logx.Errorf("health check of service %q failed, error: %v", service, err)
+		}
+
+		h.lock.Lock()
+		h.failed[service] = err != nil
+		h.update(service)
+		h.lock.Unlock()
+	}
+}
+
+func (h *healthServer) check(checks []HealthCheck) error {
+	ctx, cancel := context.WithTimeout(context.Background(), h.interval)
+	defer cancel()
+
+	for _, check := range checks {
+		if err := check(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (h *healthServer) startChecks() {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	h.runChecks()
+	for {
+		select {
+		case <-ticker.C:
+			h.runChecks()
+		case <-h.done:
+			return
+		}
+	}
+}
+
+// update must be called with h.lock held.
+func (h *healthServer) update(service string) {
+	status := grpc_health_v1.HealthCheckResponse_SERVING
+	if !h.serving[service] || h.failed[service] {
+		status = grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	}
+
+	h.Server.SetServingStatus(service, status)
+}
@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func TestHealthServerServingStatus(t *testing.T) {
+	h := newHealthServer(0)
+	assert.Equal(t, defaultHealthCheckInterval, h.interval)
+
+	h.AddServices("foo", "bar")
+	h.SetServingStatus("bar", false)
+	// the services set before are kept.
+	h.AddServices("bar")
+	h.Resume()
+	defer h.Shutdown()
+
+	assertHealthStatus(t, h, "", grpc_health_v1.HealthCheckResponse_SERVING)
+	assertHealthStatus(t, h, "foo", grpc_health_v1.HealthCheckResponse_SERVING)
+	assertHealthStatus(t, h, "bar", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+
+	h.SetServingStatus("foo", false)
+	h.SetServingStatus("bar", true)
+	assertHealthStatus(t, h, "foo", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+	assertHealthStatus(t, h, "bar", grpc_health_v1.HealthCheckResponse_SERVING)
+
+	_, err := h.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "baz"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestHealthServerChecks(t *testing.T) {
+	h := newHealthServer(time.Millisecond * 10)
+	failed := make(chan error, 1)
+	failed <- errors.New("redis down")
+	h.AddCheck("orders", func(ctx context.Context) error {
+		select {
+		case err := <-failed:
+			return err
+		default:
+			return nil
+		}
+	})
+	h.AddCheck("orders", func(ctx context.Context) error {
+		return nil
+	})
+
+	h.runChecks()
+	assertHealthStatus(t, h, "orders", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+	h.runChecks()
+	assertHealthStatus(t, h, "orders", grpc_health_v1.HealthCheckResponse_SERVING)
+
+	// the failed checks keep the service NOT_SERVING after set serving.
+	failed <- errors.New("redis down")
+	h.runChecks()
+	h.SetServingStatus("orders", true)
+	assertHealthStatus(t, h, "orders", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+
+	h.Resume()
+	assert.Eventually(t, func() bool {
+		resp, err := h.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "orders"})
+		return err == nil && resp.Status == grpc_health_v1.HealthCheckResponse_SERVING
+	}, time.Second, time.Millisecond*10)
+
+	h.Shutdown()
+	h.Shutdown()
+	assertHealthStatus(t, h, "orders", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+}
+
+func assertHealthStatus(t *testing.T, h *healthServer, service string,
+	expect grpc_health_v1.HealthCheckResponse_ServingStatus) {
+	resp, err := h.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: service})
+	if assert.NoError(t, err) {
+		assert.Equal(t, expect, resp.Status)
+	}
+}
@@ -0,0 +1,69 @@
+package instance
+
+import (
+	"github.com/jialequ/linux-sdk/core/canary"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+)
+
+// ColorKey is the metadata key of the traffic color of a canary instance,
+// only the calls with the same color are routed to the colored instances.
+const ColorKey = "color"
+
+type (
+	// A PickerBuildFunc builds a picker with the given ready SubConns.
+	PickerBuildFunc func(readySCs map[balancer.SubConn]base.SubConnInfo) balancer.Picker
+
+	colorPicker struct {
+		baseline balancer.Picker
+		colored  map[string]balancer.Picker
+	}
+)
+
+// NewColorPicker returns a picker that routes the calls by the traffic color in the context.
+// The colored calls go to the instances of the color, or the baseline instances without color
+// if no instances of the color. The calls without color only go to the baseline instances,
+// unless there are no baseline instances.
+func NewColorPicker(readySCs map[balancer.SubConn]base.SubConnInfo, build PickerBuildFunc) balancer.Picker {
+	groups := make(map[string]map[balancer.SubConn]base.SubConnInfo)
+	for conn, info := range readySCs {
+		color := FromAddress(info.Address)[ColorKey]
+		scs, ok := groups[color]
+		if !ok {
+			scs = make(map[balancer.SubConn]base.SubConnInfo)
+			groups[color] = scs
+		}
+		scs[conn] = info
+	}
+
+	baseline, ok := groups[""]
+	if len(groups) == 0 || ok && len(groups) == 1 {
+		return build(readySCs)
+	}
+
+	delete(groups, "")
+	picker := &colorPicker{
+		colored: make(map[string]balancer.Picker, len(groups)),
+	}
+	for color, scs := range groups {
+		picker.colored[color] = build(scs)
+	}
+	if len(baseline) > 0 {
+		picker.baseline = build(baseline)
+	} else {
+		// all the instances are colored, don't fail the calls without color.
+		picker.baseline = build(readySCs)
+	}
+
+	return picker
+}
+
+func (p *colorPicker) Pick(info balancer.PickInfo) (balancer.PickResult, error) {
+	if color := canary.FromContext(info.Ctx); len(color) > 0 {
+		if picker, ok := p.colored[color]; ok {
+			return picker.Pick(info)
+		}
+	}
+
+	return p.baseline.Pick(info)
+}
@@ -0,0 +1,148 @@
+package instance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jialequ/linux-sdk/core/canary"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/resolver"
+)
+
+func TestNewColorPicker(t *testing.T) {
+	build := func(colors ...string) map[balancer.SubConn]base.SubConnInfo {
+		scs := make(map[balancer.SubConn]base.SubConnInfo)
+		for _, color := range colors {
+			addr := resolver.Address{Addr: color}
+			if len(color) > 0 {
+				addr = WithMetadata(addr, Metadata{ColorKey: color})
+			}
+			scs[new(mockedSubConn)] = base.SubConnInfo{Address: addr}
+		}
+		return scs
+	}
+
+	tests := []struct {
+		name   string
+		colors []string
+		color  string
+		expect []string
+	}{
+		{
+			name:   "no colored instances",
+			colors: []string{"", ""},
+			color:  "v2",
+			expect: []string{"", ""},
+		},
+		{
+			name:   "colored call",
+			colors: []string{"", "v2", "v3"},
+			color:  "v2",
+			expect: []string{"v2"},
+		},
+		{
+			name:   "fallback to baseline",
+			colors: []string{"", "v3"},
+			color:  "v2",
+			expect: []string{""},
+		},
+		{
+			name:   "call without color",
+			colors: []string{"", "v2"},
+			expect: []string{""},
+		},
+		{
+			name:   "no baseline",
+			colors: []string{"v2", "v3"},
+			expect: []string{"v2", "v3"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			picker := NewColorPicker(build(test.colors...), func(
+				readySCs map[balancer.SubConn]base.SubConnInfo) balancer.Picker {
+				return &mockedPicker{readySCs: readySCs}
+			})
+
+			ctx := context.Background()
+			if len(test.color) > 0 {
+				ctx = canary.NewContext(ctx, test.color)
+			}
+			_, err := picker.Pick(balancer.PickInfo{Ctx: ctx})
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, test.expect, pickedAddrs(picker))
+		})
+	}
+}
+
+type mockedPicker struct {
+	readySCs map[balancer.SubConn]base.SubConnInfo
+	picked   bool
+}
+
+func (p *mockedPicker) Pick(_ balancer.PickInfo) (balancer.PickResult, error) {
+	p.picked = true
+	return balancer.PickResult{}, nil
+}
+
+func (p *mockedPicker) addrs() []string {
+	var addrs []string
+	for _, info := range p.readySCs {
+		addrs = append(addrs, info.Address.Addr)
+	}
+	return addrs
+}
+
+func pickedAddrs(picker balancer.Picker) []string {
+	cp, ok := picker.(*colorPicker)
+	if !ok {
+		return picker.(*mockedPicker).addrs()
+	}
+
+	for _, each := range cp.colored {
+		if each.(*mockedPicker).picked {
+			return each.(*mockedPicker).addrs()
+		}
+	}
+
+	return cp.baseline.(*mockedPicker).addrs()
+}
+
+func TestNewColorPickerPreferLocal(t *testing.T) {
+	scs := make(map[balancer.SubConn]base.SubConnInfo)
+	add := func(name, color string, total int) {
+		addr := resolver.Address{Addr: name}
+		if len(color) > 0 {
+			addr = WithMetadata(addr, Metadata{ColorKey: color})
+		}
+		if total > 0 {
+			addr = WithLocality(addr, total, 0.5)
+		}
+		scs[new(mockedSubConn)] = base.SubConnInfo{Address: addr}
+	}
+	// all the baseline locals are ready, while only one of the four v2 locals is ready.
+	add("local", "", 2)
+	add("local", "", 2)
+	add("remote", "", 0)
+	add("local-v2", "v2", 4)
+	add("remote-v2", "v2", 0)
+
+	picker := NewColorPicker(scs, func(readySCs map[balancer.SubConn]base.SubConnInfo) balancer.Picker {
+		return &mockedPicker{readySCs: PreferLocal(readySCs)}
+	})
+
+	_, err := picker.Pick(balancer.PickInfo{Ctx: context.Background()})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"local", "local"}, pickedAddrs(picker))
+
+	picker = NewColorPicker(scs, func(readySCs map[balancer.SubConn]base.SubConnInfo) balancer.Picker {
+		return &mockedPicker{readySCs: PreferLocal(readySCs)}
+	})
+	_, err = picker.Pick(balancer.PickInfo{Ctx: canary.NewContext(context.Background(), "v2")})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"local-v2", "remote-v2"}, pickedAddrs(picker))
+}
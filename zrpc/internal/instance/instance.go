@@ -0,0 +1,72 @@
+package instance
+
+import (
+	"strconv"
+
+	"google.golang.org/grpc/resolver"
+)
+
+const (
+	// WeightKey is the metadata key of the instance weight.
+	WeightKey = "weight"
+
+	defaultWeight = 1
+)
+
+type (
+	// Metadata is the metadata of a service instance, like weight, zone and so on.
+	Metadata map[string]string
+
+	metadataKey struct{}
+)
+
+// Equal checks if m equals to o, used to compare resolver.Address attributes.
+func (m Metadata) Equal(o any) bool {
+	md, ok := o.(Metadata)
+	if !ok || len(md) != len(m) {
+		return false
+	}
+
+	for k, v := range m {
+		if val, ok := md[k]; !ok || val != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// FromAddress returns the Metadata of addr.
+func FromAddress(addr resolver.Address) Metadata {
+	if addr.Attributes == nil {
+		return nil
+	}
+
+	md, ok := addr.Attributes.Value(metadataKey{}).(Metadata)
+	if !ok {
+		return nil
+	}
+
+	return md
+}
+
+// Weight returns the weight of addr, 1 if not set or invalid.
+func Weight(addr resolver.Address) int {
+	val, ok := FromAddress(addr)[WeightKey]
+	if !ok {
+		return defaultWeight
+	}
+
+	weight, err := strconv.Atoi(val)
+	if err != nil || weight <= 0 {
+		return defaultWeight
+	}
+
+	return weight
+}
+
+// WithMetadata returns a copy of addr with md attached.
+func WithMetadata(addr resolver.Address, md Metadata) resolver.Address {
+	addr.Attributes = addr.Attributes.WithValue(metadataKey{}, md)
+	return addr
+}
@@ -0,0 +1,67 @@
+package instance
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/resolver"
+)
+
+func TestMetadataEqual(t *testing.T) {
+	md := Metadata{"a": "b"}
+	assert.True(t, md.Equal(Metadata{"a": "b"}))
+	assert.False(t, md.Equal(Metadata{"a": "c"}))
+	assert.False(t, md.Equal(Metadata{"a": "b", "c": "d"}))
+	assert.False(t, md.Equal(Metadata{"c": "d"}))
+	assert.False(t, md.Equal(map[string]string{"a": "b"}))
+}
+
+func TestFromAddress(t *testing.T) {
+	addr := resolver.Address{Addr: "localhost:8080"}
+	assert.Nil(t, FromAddress(addr))
+
+	md := Metadata{"zone": "a"}
+	addr = WithMetadata(addr, md)
+	assert.Equal(t, md, FromAddress(addr))
+	assert.True(t, addr.Equal(WithMetadata(resolver.Address{Addr: "localhost:8080"},
+		Metadata{"zone": "a"})))
+}
+
+func TestWeight(t *testing.T) {
+	tests := []struct {
+		name   string
+		md     Metadata
+		expect int
+	}{
+		{
+			name:   "not set",
+			expect: 1,
+		},
+		{
+			name:   "valid",
+			md:     Metadata{WeightKey: "5"},
+			expect: 5,
+		},
+		{
+			name:   "invalid",
+			md:     Metadata{WeightKey: "a"},
+			expect: 1,
+		},
+		{
+			name:   "negative",
+			md:     Metadata{WeightKey: "-1"},
+			expect: 1,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			addr := resolver.Address{Addr: "localhost:8080"}
+			if test.md != nil {
+				addr = WithMetadata(addr, test.md)
+			}
+			assert.Equal(t, test.expect, Weight(addr))
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package instance
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/resolver"
+)
+
+const (
+	// ZoneKey is the metadata key of the instance zone.
+	ZoneKey = "zone"
+	// VersionKey is the metadata key of the instance version.
+	VersionKey = "version"
+	// TagsKey is the metadata key of the instance tags, separated by comma.
+	TagsKey = "tags"
+
+	tagSep                 = ","
+	minHealthyRatioKey     = "minHealthyRatio"
+	defaultMinHealthyRatio = 0.5
+)
+
+type (
+	// MetadataConf defines the metadata of a server instance to publish.
+	MetadataConf struct {
+		Zone    string   `json:",optional"`
+		Version string   `json:",optional"`
+		Weight  int      `json:",optional"`
+		Tags    []string `json:",optional"`
+		// Color is the traffic color of a canary instance, like v2.
+		Color string `json:",optional"`
+		// Labels are the custom metadata.
+		Labels map[string]string `json:",optional"`
+	}
+
+	// RoutingConf defines how a client routes the calls by the instance metadata.
+	RoutingConf struct {
+		// Zone is the zone of the client, the instances in the same zone are preferred.
+		Zone string `json:",optional"`
+		// MinHealthyRatio is the min ratio of the ready instances in the zone to keep the calls
+		// in the zone, otherwise the calls spill over to the other zones.
+		MinHealthyRatio float64 `json:",default=0.5,range=[0:1]"`
+		// Version restricts the calls to the instances with the version or the version tag,
+		// like the canaries.
+		Version string `json:",optional"`
+	}
+
+	locality struct {
+		// total is the count of the instances in the local zone.
+		total           int
+		minHealthyRatio float64
+	}
+
+	localityKey struct{}
+)
+
+// Metadata returns the Metadata of c.
+func (c MetadataConf) Metadata() Metadata {
+	md := make(Metadata, len(c.Labels)+5)
+	for k, v := range c.Labels {
+		md[k] = v
+	}
+	if len(c.Zone) > 0 {
+		md[ZoneKey] = c.Zone
+	}
+	if len(c.Version) > 0 {
+		md[VersionKey] = c.Version
+	}
+	if c.Weight > 0 {
+		md[WeightKey] = strconv.Itoa(c.Weight)
+	}
+	if len(c.Tags) > 0 {
+		md[TagsKey] = strings.Join(c.Tags, tagSep)
+	}
+	if len(c.Color) > 0 {
+		md[ColorKey] = c.Color
+	}
+
+	return md
+}
+
+// Query returns the query values of c, used to pass c to the resolvers with the target.
+func (c RoutingConf) Query() url.Values {
+	vals := url.Values{}
+	if len(c.Zone) > 0 {
+		vals.Set(ZoneKey, c.Zone)
+		vals.Set(minHealthyRatioKey, strconv.FormatFloat(c.MinHealthyRatio, 'f', -1, 64))
+	}
+	if len(c.Version) > 0 {
+		vals.Set(VersionKey, c.Version)
+	}
+
+	return vals
+}
+
+// HasRouting checks if the query values of a target carry any routing config.
+func HasRouting(query url.Values) bool {
+	return query.Has(ZoneKey) || query.Has(VersionKey) || query.Has(minHealthyRatioKey)
+}
+
+// Match checks if the instance with md is allowed by the version restriction of c.
+func (c RoutingConf) Match(md Metadata) bool {
+	if len(c.Version) == 0 {
+		return true
+	}
+	if md[VersionKey] == c.Version {
+		return true
+	}
+
+	for _, tag := range strings.Split(md[TagsKey], tagSep) {
+		if tag == c.Version {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Local checks if the instance with md is in the zone of c.
+func (c RoutingConf) Local(md Metadata) bool {
+	return len(c.Zone) > 0 && md[ZoneKey] == c.Zone
+}
+
+// ParseRouting parses the RoutingConf from the query values of a target.
+func ParseRouting(query url.Values) (RoutingConf, error) {
+	c := RoutingConf{
+		Zone:            query.Get(ZoneKey),
+		MinHealthyRatio: defaultMinHealthyRatio,
+		Version:         query.Get(VersionKey),
+	}
+
+	if val := query.Get(minHealthyRatioKey); len(val) > 0 {
+		ratio, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return RoutingConf{}, err
+		}
+
+		c.MinHealthyRatio = ratio
+	}
+
+	return c, nil
+}
+
+// WithLocality returns a copy of addr marked as in the local zone, total is the count of
+// the instances in the local zone with the same color as addr, used by PreferLocal to check
+// if the local zone is healthy.
+func WithLocality(addr resolver.Address, total int, minHealthyRatio float64) resolver.Address {
+	addr.BalancerAttributes = addr.BalancerAttributes.WithValue(localityKey{}, locality{
+		total:           total,
+		minHealthyRatio: minHealthyRatio,
+	})
+	return addr
+}
+
+// PreferLocal returns the ready SubConns in the local zone if the zone is healthy,
+// otherwise returns all the ready SubConns to spill over to the other zones.
+func PreferLocal(readySCs map[balancer.SubConn]base.SubConnInfo) map[balancer.SubConn]base.SubConnInfo {
+	var minHealthyRatio float64
+	// the totals are counted by color, readySCs might be a color group or mixed colors.
+	totals := make(map[string]int)
+	local := make(map[balancer.SubConn]base.SubConnInfo)
+	for conn, info := range readySCs {
+		l, ok := info.Address.BalancerAttributes.Value(localityKey{}).(locality)
+		if !ok {
+			continue
+		}
+
+		totals[FromAddress(info.Address)[ColorKey]] = l.total
+		minHealthyRatio = l.minHealthyRatio
+		local[conn] = info
+	}
+
+	var total int
+	for _, val := range totals {
+		total += val
+	}
+
+	if len(local) == 0 || float64(len(local)) < float64(total)*minHealthyRatio {
+		return readySCs
+	}
+
+	return local
+}
@@ -0,0 +1,126 @@
+package instance
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/resolver"
+)
+
+func TestMetadataConfMetadata(t *testing.T) {
+	assert.Empty(t, MetadataConf{}.Metadata())
+	assert.Equal(t, Metadata{
+		ZoneKey:    "a",
+		VersionKey: "v1",
+		WeightKey:  "3",
+		TagsKey:    "canary,blue",
+		"foo":      "bar",
+	}, MetadataConf{
+		Zone:    "a",
+		Version: "v1",
+		Weight:  3,
+		Tags:    []string{"canary", "blue"},
+		Labels:  map[string]string{"foo": "bar"},
+	}.Metadata())
+}
+
+func TestRoutingConfQuery(t *testing.T) {
+	assert.Empty(t, RoutingConf{MinHealthyRatio: 0.5}.Query())
+
+	c := RoutingConf{
+		Zone:            "a",
+		MinHealthyRatio: 0.7,
+		Version:         "v2",
+	}
+	query := c.Query()
+	assert.Equal(t, "minHealthyRatio=0.7&version=v2&zone=a", query.Encode())
+
+	parsed, err := ParseRouting(query)
+	assert.NoError(t, err)
+	assert.Equal(t, c, parsed)
+}
+
+func TestParseRouting(t *testing.T) {
+	c, err := ParseRouting(url.Values{})
+	assert.NoError(t, err)
+	assert.Equal(t, RoutingConf{MinHealthyRatio: defaultMinHealthyRatio}, c)
+
+	_, err = ParseRouting(url.Values{minHealthyRatioKey: []string{"bad"}})
+	assert.Error(t, err)
+}
+
+func TestRoutingConfMatch(t *testing.T) {
+	assert.True(t, RoutingConf{}.Match(nil))
+
+	c := RoutingConf{Version: "v2"}
+	assert.True(t, c.Match(Metadata{VersionKey: "v2"}))
+	assert.True(t, c.Match(Metadata{TagsKey: "canary,v2"}))
+	assert.False(t, c.Match(Metadata{VersionKey: "v1", TagsKey: "canary"}))
+	assert.False(t, c.Match(nil))
+}
+
+func TestRoutingConfLocal(t *testing.T) {
+	assert.False(t, RoutingConf{}.Local(Metadata{ZoneKey: ""}))
+	assert.True(t, RoutingConf{Zone: "a"}.Local(Metadata{ZoneKey: "a"}))
+	assert.False(t, RoutingConf{Zone: "a"}.Local(Metadata{ZoneKey: "b"}))
+}
+
+func TestPreferLocal(t *testing.T) {
+	build := func(locals, remotes, total int) map[balancer.SubConn]base.SubConnInfo {
+		scs := make(map[balancer.SubConn]base.SubConnInfo)
+		for i := 0; i < locals; i++ {
+			scs[new(mockedSubConn)] = base.SubConnInfo{
+				Address: WithLocality(resolver.Address{Addr: "local"}, total, 0.5),
+			}
+		}
+		for i := 0; i < remotes; i++ {
+			scs[new(mockedSubConn)] = base.SubConnInfo{
+				Address: resolver.Address{Addr: "remote"},
+			}
+		}
+		return scs
+	}
+
+	tests := []struct {
+		name    string
+		locals  int
+		remotes int
+		total   int
+		expect  int
+	}{
+		{
+			name:    "no local",
+			remotes: 3,
+			expect:  3,
+		},
+		{
+			name:    "healthy local",
+			locals:  2,
+			remotes: 3,
+			total:   4,
+			expect:  2,
+		},
+		{
+			name:    "unhealthy local",
+			locals:  1,
+			remotes: 3,
+			total:   4,
+			expect:  4,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			scs := PreferLocal(build(test.locals, test.remotes, test.total))
+			assert.Len(t, scs, test.expect)
+		})
+	}
+}
+
+type mockedSubConn struct {
+	balancer.SubConn
+}
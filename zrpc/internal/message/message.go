@@ -0,0 +1,243 @@
+package message
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// Gzip compresses the messages with gzip.
+	Gzip = gzip.Name
+	// Identity sends the messages without compression.
+	Identity = encoding.Identity
+	// Zstd compresses the messages with zstd, the decoded messages are bounded by
+	// the largest max recv size of the message configs, 4MB by default.
+	Zstd = "zstd"
+
+	// the defaults of grpc.
+	defaultMaxRecvSize = 4 * 1024 * 1024
+	defaultMaxSendSize = math.MaxInt32
+
+	wildcardAll = "*"
+)
+
+type (
+	// Conf defines the size limits and the compression of the messages.
+	Conf struct {
+		// MaxSendSize is the max bytes of the messages to send, defaults to math.MaxInt32.
+		MaxSendSize int `json:",optional,range=[0:]"`
+		// MaxRecvSize is the max bytes of the messages to receive, defaults to 4MB.
+		MaxRecvSize int `json:",optional,range=[0:]"`
+		// Compressor compresses the messages to send, no compression if empty.
+		Compressor string `json:",optional,options=gzip|zstd"`
+		// Methods overrides the config of the methods.
+		Methods []MethodConf `json:",optional"`
+	}
+
+	// MethodConf defines the size limits and the compression of the messages of the methods,
+	// the zero values are inherited from Conf. The limits of a method can't exceed the max
+	// limits of the server, the grpc server rejects the larger messages before the limits
+	// of the methods are checked. The server max limits are raised to the largest ones of
+	// the methods, unless overridden by grpc.MaxRecvMsgSize or grpc.MaxSendMsgSize options.
+	MethodConf struct {
+		// FullMethod is the method to apply, like /pkg.Service/Method,
+		// /pkg.Service/* matches all the methods of the service.
+		FullMethod  string
+		MaxSendSize int `json:",optional,range=[0:]"`
+		MaxRecvSize int `json:",optional,range=[0:]"`
+		// Compressor overrides Conf.Compressor, identity means no compression.
+		Compressor string `json:",optional,options=identity|gzip|zstd"`
+	}
+
+	// A Matcher matches the message config of the methods.
+	Matcher struct {
+		base        MethodConf
+		methods     map[string]MethodConf
+		services    map[string]MethodConf
+		maxRecvSize int
+		maxSendSize int
+	}
+)
+
+// IsZero checks if c is not configured.
+func (c Conf) IsZero() bool {
+	return c.MaxSendSize == 0 && c.MaxRecvSize == 0 && len(c.Compressor) == 0 && len(c.Methods) == 0
+}
+
+// NewMatcher returns a Matcher with c.
+func NewMatcher(c Conf) (*Matcher, error) {
+	m := &Matcher{
+		base: MethodConf{
+			MaxSendSize: c.MaxSendSize,
+			MaxRecvSize: c.MaxRecvSize,
+			Compressor:  c.Compressor,
+		},
+		methods:  make(map[string]MethodConf),
+		services: make(map[string]MethodConf),
+	}
+	if err := m.base.validate(); err != nil {
+		return nil, err
+	}
+
+	for _, mc := range c.Methods {
+		if err := mc.validate(); err != nil {
+			return nil, err
+		}
+
+		if strings.HasSuffix(mc.FullMethod, "/"+wildcardAll) {
+			m.services[strings.TrimSuffix(mc.FullMethod, wildcardAll)] = mc
+		} else {
+			m.methods[mc.FullMethod] = mc
+		}
+	}
+	m.maxRecvSize = m.maxSize(m.base.MaxRecvSize, defaultMaxRecvSize, func(mc MethodConf) int {
+		return mc.MaxRecvSize
+	})
+	m.maxSendSize = m.maxSize(m.base.MaxSendSize, defaultMaxSendSize, func(mc MethodConf) int {
+		return mc.MaxSendSize
+	})
+	zstdCodec.raiseMaxSize(m.maxRecvSize)
+
+	return m, nil
+}
+
+// CallOptions returns the call options of method, the defaults are returned with empty method.
+func (m *Matcher) CallOptions(method string) []grpc.CallOption {
+	mc := m.base
+	if len(method) > 0 {
+		var ok bool
+		if mc, ok = m.Match(method); !ok {
+			return nil
+		}
+	}
+
+	var opts []grpc.CallOption
+	if mc.MaxSendSize > 0 {
+		opts = append(opts, grpc.MaxCallSendMsgSize(mc.MaxSendSize))
+	}
+	if mc.MaxRecvSize > 0 {
+		opts = append(opts, grpc.MaxCallRecvMsgSize(mc.MaxRecvSize))
+	}
+	if len(mc.Compressor) > 0 {
+		opts = append(opts, grpc.UseCompressor(mc.Compressor))
+	}
+
+	return opts
+}
+
+// HasOverrides checks if any method overrides the defaults.
+func (m *Matcher) HasOverrides() bool {
+	return len(m.methods) > 0 || len(m.services) > 0
+}
+
+// Match returns the config of method merged with the defaults,
+// false if method has no overrides.
+func (m *Matcher) Match(method string) (MethodConf, bool) {
+	mc, ok := m.methods[method]
+	if !ok {
+		if index := strings.LastIndexByte(method, '/'); index >= 0 {
+			mc, ok = m.services[method[:index+1]]
+		}
+	}
+	if !ok {
+		return m.base, false
+	}
+
+	if mc.MaxSendSize == 0 {
+		mc.MaxSendSize = m.base.MaxSendSize
+	}
+	if mc.MaxRecvSize == 0 {
+		mc.MaxRecvSize = m.base.MaxRecvSize
+	}
+	if len(mc.Compressor) == 0 {
+		mc.Compressor = m.base.Compressor
+	}
+
+	return mc, true
+}
+
+// MaxRecvSize returns the max bytes of the messages to receive of all the methods.
+func (m *Matcher) MaxRecvSize() int {
+	return m.maxRecvSize
+}
+
+// MaxSendSize returns the max bytes of the messages to send of all the methods.
+func (m *Matcher) MaxSendSize() int {
+	return m.maxSendSize
+}
+
+// ServerLimits returns the size limits of method to check in the server interceptors,
+// 0 means the limit is enforced by grpc with MaxRecvSize or MaxSendSize.
+func (m *Matcher) ServerLimits(method string) (recv, send int) {
+	mc, _ := m.Match(method)
+	recv = mc.MaxRecvSize
+	if recv == 0 {
+		recv = defaultMaxRecvSize
+	}
+	if recv >= m.maxRecvSize {
+		recv = 0
+	}
+
+	send = mc.MaxSendSize
+	if send == 0 {
+		send = defaultMaxSendSize
+	}
+	if send >= m.maxSendSize {
+		send = 0
+	}
+
+	return recv, send
+}
+
+func (m *Matcher) maxSize(size, defaultSize int, get func(MethodConf) int) int {
+	if size == 0 {
+		size = defaultSize
+	}
+
+	for _, mcs := range []map[string]MethodConf{m.methods, m.services} {
+		for _, mc := range mcs {
+			size = max(size, get(mc))
+		}
+	}
+
+	return size
+}
+
+// ErrRecvTooLarge returns the error of a received message larger than limit,
+// same as the one returned by grpc.
+func ErrRecvTooLarge(size, limit int) error {
+	return status.Errorf(codes.ResourceExhausted,
+		"grpc: received message larger than max (%d vs. %d)", size, limit)
+}
+
+// ErrSendTooLarge returns the error of a message to send larger than limit,
+// same as the one returned by grpc.
+func ErrSendTooLarge(size, limit int) error {
+	return status.Errorf(codes.ResourceExhausted,
+		"grpc: trying to send message larger than max (%d vs. %d)", size, limit)
+}
+
+// IsTooLarge checks if err is caused by an oversize message.
+func IsTooLarge(err error) bool {
+	st, ok := status.FromError(err)
+	return ok && st.Code() == codes.ResourceExhausted && strings.Contains(st.Message(), "larger than max")
+}
+
+func (c MethodConf) validate() error {
+	if c.MaxSendSize < 0 || c.MaxRecvSize < 0 {
+		return fmt.Errorf("message: negative size of %q", c.FullMethod)
+	}
+
+	if len(c.Compressor) > 0 && c.Compressor != Identity && encoding.GetCompressor(c.Compressor) == nil {
+		return fmt.Errorf("message: unknown compressor %q", c.Compressor)
+	}
+
+	return nil
+}
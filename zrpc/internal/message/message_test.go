@@ -0,0 +1,123 @@
+package message
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestConfIsZero(t *testing.T) {
+	assert.True(t, Conf{}.IsZero())
+	assert.False(t, Conf{Compressor: Gzip}.IsZero())
+	assert.False(t, Conf{Methods: []MethodConf{{FullMethod: "/foo"}}}.IsZero())
+}
+
+func TestNewMatcherInvalid(t *testing.T) {
+	_, err := NewMatcher(Conf{Compressor: "foo"})
+	assert.Error(t, err)
+
+	_, err = NewMatcher(Conf{MaxRecvSize: -1})
+	assert.Error(t, err)
+
+	_, err = NewMatcher(Conf{
+		Methods: []MethodConf{
+			{
+				FullMethod: "/foo",
+				Compressor: "bar",
+			},
+		},
+	})
+	assert.Error(t, err)
+}
+
+func TestMatcherMatch(t *testing.T) {
+	m, err := NewMatcher(Conf{
+		MaxRecvSize: 1024,
+		Compressor:  Gzip,
+		Methods: []MethodConf{
+			{
+				FullMethod:  "/pkg.Service/Upload",
+				MaxRecvSize: 1 << 20,
+				Compressor:  Zstd,
+			},
+			{
+				FullMethod:  "/pkg.Service/*",
+				MaxSendSize: 2048,
+				Compressor:  Identity,
+			},
+		},
+	})
+	assert.NoError(t, err)
+	assert.True(t, m.HasOverrides())
+
+	mc, ok := m.Match("/pkg.Service/Upload")
+	assert.True(t, ok)
+	assert.Equal(t, 1<<20, mc.MaxRecvSize)
+	assert.Equal(t, 0, mc.MaxSendSize)
+	assert.Equal(t, Zstd, mc.Compressor)
+
+	mc, ok = m.Match("/pkg.Service/Get")
+	assert.True(t, ok)
+	assert.Equal(t, 1024, mc.MaxRecvSize)
+	assert.Equal(t, 2048, mc.MaxSendSize)
+	assert.Equal(t, Identity, mc.Compressor)
+
+	mc, ok = m.Match("/pkg.Other/Get")
+	assert.False(t, ok)
+	assert.Equal(t, 1024, mc.MaxRecvSize)
+	assert.Equal(t, Gzip, mc.Compressor)
+
+	assert.Len(t, m.CallOptions(""), 2)
+	assert.Len(t, m.CallOptions("/pkg.Service/Get"), 3)
+	assert.Nil(t, m.CallOptions("/pkg.Other/Get"))
+}
+
+func TestMatcherServerLimits(t *testing.T) {
+	m, err := NewMatcher(Conf{
+		Methods: []MethodConf{
+			{
+				FullMethod:  "/pkg.Service/Upload",
+				MaxRecvSize: 16 << 20,
+			},
+			{
+				FullMethod:  "/pkg.Service/Get",
+				MaxSendSize: 1024,
+			},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 16<<20, m.MaxRecvSize())
+	assert.Equal(t, math.MaxInt32, m.MaxSendSize())
+
+	recv, send := m.ServerLimits("/pkg.Service/Upload")
+	assert.Equal(t, 0, recv)
+	assert.Equal(t, 0, send)
+
+	recv, send = m.ServerLimits("/pkg.Service/Get")
+	assert.Equal(t, defaultMaxRecvSize, recv)
+	assert.Equal(t, 1024, send)
+
+	recv, send = m.ServerLimits("/pkg.Other/Get")
+	assert.Equal(t, defaultMaxRecvSize, recv)
+	assert.Equal(t, 0, send)
+
+	m, err = NewMatcher(Conf{MaxRecvSize: 1024})
+	assert.NoError(t, err)
+	assert.False(t, m.HasOverrides())
+	assert.Equal(t, 1024, m.MaxRecvSize())
+	recv, send = m.ServerLimits("/pkg.Other/Get")
+	assert.Equal(t, 0, recv)
+	assert.Equal(t, 0, send)
+}
+
+func TestIsTooLarge(t *testing.T) {
+	assert.True(t, IsTooLarge(ErrRecvTooLarge(2, 1)))
+	assert.True(t, IsTooLarge(ErrSendTooLarge(2, 1)))
+	assert.False(t, IsTooLarge(status.Error(codes.ResourceExhausted, "quota exceeded")))
+	assert.False(t, IsTooLarge(errors.New("larger than max")))
+	assert.False(t, IsTooLarge(nil))
+}
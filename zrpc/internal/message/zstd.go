@@ -0,0 +1,128 @@
+package message
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/grpc/encoding"
+)
+
+// zstdWindowSize is the window size of the encoders, the decoders reject the windows
+// larger than the max recv size, so it's kept small to decode on the smaller limits.
+const zstdWindowSize = 1 << 20
+
+var zstdCodec = newZstdCompressor()
+
+func init() {
+	encoding.RegisterCompressor(zstdCodec)
+}
+
+type (
+	// zstdCompressor is a grpc compressor with zstd, the encoders and decoders are pooled.
+	zstdCompressor struct {
+		encoders sync.Pool
+		decoders sync.Pool
+		// maxSize is the largest max recv size of the matchers, which bounds the window
+		// and memory of the decoders, to not let the small messages allocate huge memory
+		// before the size of the messages are checked.
+		maxSize atomic.Int64
+	}
+
+	zstdWriter struct {
+		*zstd.Encoder
+		pool *sync.Pool
+	}
+
+	zstdDecoder struct {
+		*zstd.Decoder
+		maxSize int64
+	}
+
+	zstdReader struct {
+		decoder *zstdDecoder
+		pool    *sync.Pool
+	}
+)
+
+func newZstdCompressor() *zstdCompressor {
+	c := new(zstdCompressor)
+	c.maxSize.Store(defaultMaxRecvSize)
+	return c
+}
+
+func (c *zstdCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	if encoder, ok := c.encoders.Get().(*zstd.Encoder); ok {
+		encoder.Reset(w)
+		return &zstdWriter{Encoder: encoder, pool: &c.encoders}, nil
+	}
+
+	encoder, err := zstd.NewWriter(w, zstd.WithEncoderConcurrency(1),
+		zstd.WithWindowSize(zstdWindowSize))
+	if err != nil {
+		return nil, err
+	}
+
+	return &zstdWriter{Encoder: encoder, pool: &c.encoders}, nil
+}
+
+func (c *zstdCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	maxSize := c.maxSize.Load()
+	// the decoders created before the max size raised are dropped.
+	if decoder, ok := c.decoders.Get().(*zstdDecoder); ok && decoder.maxSize == maxSize {
+		if err := decoder.Reset(r); err != nil {
+			c.decoders.Put(decoder)
+			return nil, err
+		}
+
+		return &zstdReader{decoder: decoder, pool: &c.decoders}, nil
+	}
+
+	window := min(max(maxSize, zstd.MinWindowSize), zstd.MaxWindowSize)
+	decoder, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1),
+		zstd.WithDecoderMaxWindow(uint64(window)), zstd.WithDecoderMaxMemory(uint64(maxSize)))
+	if err != nil {
+		return nil, err
+	}
+
+	return &zstdReader{
+		decoder: &zstdDecoder{Decoder: decoder, maxSize: maxSize},
+		pool:    &c.decoders,
+	}, nil
+}
+
+func (c *zstdCompressor) Name() string {
+	return Zstd
+}
+
+// raiseMaxSize raises the max size of the decoders to size if larger.
+func (c *zstdCompressor) raiseMaxSize(size int) {
+	for {
+		current := c.maxSize.Load()
+		if int64(size) <= current || c.maxSize.CompareAndSwap(current, int64(size)) {
+			return
+		}
+	}
+}
+
+func (w *zstdWriter) Close() error {
+	defer w.pool.Put(w.Encoder)
+	return w.Encoder.Close()
+}
+
+func (r *zstdReader) Read(p []byte) (int, error) {
+	if r.decoder == nil {
+		return 0, io.EOF
+	}
+
+	n, err := r.decoder.Read(p)
+	if err == io.EOF {
+		// the decoder can be reused only after the whole message is read.
+		_ = r.decoder.Reset(nil)
+		r.pool.Put(r.decoder)
+		r.decoder = nil
+	}
+
+	return n, err
+}
@@ -0,0 +1,91 @@
+package message
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/encoding"
+)
+
+func TestZstdCompressor(t *testing.T) {
+	compressor := encoding.GetCompressor(Zstd)
+	assert.NotNil(t, compressor)
+	assert.Equal(t, Zstd, compressor.Name())
+
+	content := strings.Repeat("hello world ", 100)
+	// run twice to reuse the pooled encoders and decoders.
+	for i := 0; i < 2; i++ {
+		var buf bytes.Buffer
+		w, err := compressor.Compress(&buf)
+		assert.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		assert.NoError(t, err)
+		assert.NoError(t, w.Close())
+		assert.True(t, buf.Len() < len(content))
+
+		r, err := compressor.Decompress(&buf)
+		assert.NoError(t, err)
+		val, err := io.ReadAll(r)
+		assert.NoError(t, err)
+		assert.Equal(t, content, string(val))
+
+		n, err := r.Read(make([]byte, 1))
+		assert.Equal(t, 0, n)
+		assert.Equal(t, io.EOF, err)
+	}
+}
+
+func TestZstdCompressorBadInput(t *testing.T) {
+	compressor := encoding.GetCompressor(Zstd)
+	r, err := compressor.Decompress(strings.NewReader("not zstd"))
+	if err == nil {
+		_, err = io.ReadAll(r)
+	}
+	assert.Error(t, err)
+}
+
+func TestZstdCompressorMaxSize(t *testing.T) {
+	c := newZstdCompressor()
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+	assert.NoError(t, err)
+	// the window of the encoder is larger than the max size.
+	bomb := encoder.EncodeAll(make([]byte, defaultMaxRecvSize*2), nil)
+
+	r, err := c.Decompress(bytes.NewReader(bomb))
+	if err == nil {
+		_, err = io.ReadAll(r)
+	}
+	assert.Error(t, err)
+
+	c.raiseMaxSize(defaultMaxRecvSize / 2)
+	assert.Equal(t, int64(defaultMaxRecvSize), c.maxSize.Load())
+	c.raiseMaxSize(defaultMaxRecvSize * 4)
+	assert.Equal(t, int64(defaultMaxRecvSize*4), c.maxSize.Load())
+	r, err = c.Decompress(bytes.NewReader(bomb))
+	assert.NoError(t, err)
+	val, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Len(t, val, defaultMaxRecvSize*2)
+}
+
+func TestZstdCompressorLargeMessage(t *testing.T) {
+	c := newZstdCompressor()
+	// the messages within the max size are decoded with the small window of the encoders.
+	content := make([]byte, defaultMaxRecvSize-1)
+	var buf bytes.Buffer
+	w, err := c.Compress(&buf)
+	assert.NoError(t, err)
+	_, err = w.Write(content)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	r, err := c.Decompress(&buf)
+	assert.NoError(t, err)
+	val, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Len(t, val, len(content))
+}
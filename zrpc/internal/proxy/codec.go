@@ -0,0 +1,48 @@
+package proxy
+
+import (
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/encoding/proto"
+)
+
+type (
+	// frame is a raw message forwarded without decoding.
+	frame struct {
+		payload []byte
+	}
+
+	// codec passes through the frames, and falls back to proto for the other messages,
+	// to keep the registered services working on the same server.
+	codec struct {
+		fallback encoding.Codec
+	}
+)
+
+var passCodec = codec{fallback: encoding.GetCodec(proto.Name)}
+
+// Codec returns the codec to forward the raw messages, which should be forced on the proxy
+// server and the backend streams only, not registered to replace the proto codec globally.
+func Codec() encoding.Codec {
+	return passCodec
+}
+
+func (c codec) Marshal(v any) ([]byte, error) {
+	if f, ok := v.(*frame); ok {
+		return f.payload, nil
+	}
+
+	return c.fallback.Marshal(v)
+}
+
+func (c codec) Name() string {
+	return proto.Name
+}
+
+func (c codec) Unmarshal(data []byte, v any) error {
+	if f, ok := v.(*frame); ok {
+		f.payload = data
+		return nil
+	}
+
+	return c.fallback.Unmarshal(data, v)
+}
@@ -0,0 +1,27 @@
+package proxy
+
+import (
+	"testing"
+
+	"github.com/jialequ/linux-sdk/internal/mock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestCodec(t *testing.T) {
+	c := Codec()
+	assert.Equal(t, "proto", c.Name())
+
+	var f frame
+	assert.NoError(t, c.Unmarshal([]byte("foo"), &f))
+	data, err := c.Marshal(&f)
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", string(data))
+
+	req := &mock.DepositRequest{Amount: 1}
+	data, err = c.Marshal(req)
+	assert.NoError(t, err)
+	var decoded mock.DepositRequest
+	assert.NoError(t, c.Unmarshal(data, &decoded))
+	assert.True(t, proto.Equal(req, &decoded))
+}
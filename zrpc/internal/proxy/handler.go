@@ -0,0 +1,144 @@
+package proxy
+
+import (
+	"context"
+	"io"
+
+	threading "github.com/jialequ/linux-sdk/core/dist"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var clientStreamDesc = &grpc.StreamDesc{
+	ServerStreams: true,
+	ClientStreams: true,
+}
+
+type (
+	// A Director returns the conn of the backend to forward the call of fullMethod.
+	Director func(ctx context.Context, fullMethod string) (*grpc.ClientConn, error)
+
+	// A Handler forwards the unary and streaming calls to the backends without decoding,
+	// used as the unknown service handler of a grpc.Server.
+	Handler struct {
+		director Director
+		filter   *metadataFilter
+	}
+)
+
+// NewHandler returns a Handler, the metadata are forwarded if in allowed or allowed is empty,
+// and not in denied.
+func NewHandler(director Director, allowed, denied []string) *Handler {
+	return &Handler{
+		director: director,
+		filter:   newMetadataFilter(allowed, denied),
+	}
+}
+
+// Handle forwards the call on serverStream to the backend.
+func (h *Handler) Handle(_ any, serverStream grpc.ServerStream) error {
+	fullMethod, ok := grpc.MethodFromServerStream(serverStream)
+	if !ok {
+		return status.Error(codes.Internal, "no method in the stream")
+	}
+
+	ctx := serverStream.Context()
+	conn, err := h.director(ctx, fullMethod)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = metadata.NewOutgoingContext(ctx, h.filter.filter(md))
+	clientStream, err := conn.NewStream(ctx, clientStreamDesc, fullMethod, grpc.ForceCodec(Codec()))
+	if err != nil {
+		return err
+	}
+
+	s2c := forwardServerToClient(serverStream, clientStream)
+	c2s := forwardClientToServer(clientStream, serverStream)
+	for {
+		select {
+		case err := <-s2c:
+			if err == io.EOF {
+				// the caller finished sending, half close the backend stream,
+				// and keep forwarding the responses.
+				s2c = nil
+				if err = clientStream.CloseSend(); err != nil {
+					return err
+				}
+				continue
+			}
+
+			// the caller is gone or the backend refused the message, abort the backend stream.
+			cancel()
+			return status.Convert(err).Err()
+		case err := <-c2s:
+			serverStream.SetTrailer(clientStream.Trailer())
+			if err == io.EOF {
+				return nil
+			}
+
+			// the status of the backend is returned as is.
+			return err
+		}
+	}
+}
+
+func forwardClientToServer(src grpc.ClientStream, dst grpc.ServerStream) <-chan error {
+	ch := make(chan error, 1)
+	threading.GoSafe(func() {
+		for i := 0; ; i++ {
+			f := new(frame)
+			if err := src.RecvMsg(f); err != nil {
+				ch <- err
+				return
+			}
+
+			// the header is only available after the first message.
+			if i == 0 {
+				md, err := src.Header()
+				if err != nil {
+					ch <- err
+					return
+				}
+				if err = dst.SendHeader(md); err != nil {
+					ch <- err
+					return
+				}
+			}
+
+			if err := dst.SendMsg(f); err != nil {
+				ch <- err
+				return
+			}
+		}
+	})
+
+	return ch
+}
+
+func forwardServerToClient(src grpc.ServerStream, dst grpc.ClientStream) <-chan error {
+	ch := make(chan error, 1)
+	threading.GoSafe(func() {
+		for {
+			f := new(frame)
+			if err := src.RecvMsg(f); err != nil {
+				ch <- err
+				return
+			}
+
+			if err := dst.SendMsg(f); err != nil {
+				ch <- err
+				return
+			}
+		}
+	})
+
+	return ch
+}
@@ -0,0 +1,159 @@
+package proxy
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jialequ/linux-sdk/internal/mock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestHandlerUnary(t *testing.T) {
+	backend := newBackend(t)
+	conn := newTestProxy(t, backend, nil, []string{"x-secret"})
+
+	var header metadata.MD
+	ctx := metadata.AppendToOutgoingContext(context.Background(),
+		"x-user", "foo", "x-secret", "bar")
+	cli := mock.NewDepositServiceClient(conn)
+	resp, err := cli.Deposit(ctx, &mock.DepositRequest{Amount: 1}, grpc.Header(&header))
+	assert.NoError(t, err)
+	assert.True(t, resp.Ok)
+	assert.Equal(t, []string{"backend"}, header.Get("x-from"))
+
+	md := backend.lastMetadata()
+	assert.Equal(t, []string{"foo"}, md.Get("x-user"))
+	assert.Empty(t, md.Get("x-secret"))
+
+	_, err = cli.Deposit(ctx, &mock.DepositRequest{Amount: -1})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestHandlerAllowedMetadata(t *testing.T) {
+	backend := newBackend(t)
+	conn := newTestProxy(t, backend, []string{"X-User"}, nil)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(),
+		"x-user", "foo", "x-other", "bar")
+	_, err := mock.NewDepositServiceClient(conn).Deposit(ctx, &mock.DepositRequest{Amount: 1})
+	assert.NoError(t, err)
+
+	md := backend.lastMetadata()
+	assert.Equal(t, []string{"foo"}, md.Get("x-user"))
+	assert.Empty(t, md.Get("x-other"))
+}
+
+func TestHandlerStream(t *testing.T) {
+	backend := newBackend(t)
+	conn := newTestProxy(t, backend, nil, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := grpc_health_v1.NewHealthClient(conn).Watch(ctx, &grpc_health_v1.HealthCheckRequest{})
+	assert.NoError(t, err)
+	resp, err := stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.Status)
+
+	backend.health.SetServingStatus("", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+	resp, err = stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, resp.Status)
+}
+
+func TestHandlerDirectorError(t *testing.T) {
+	conn := newProxyConn(t, NewHandler(func(context.Context, string) (*grpc.ClientConn, error) {
+		return nil, status.Error(codes.Unimplemented, "unknown service")
+	}, nil, nil))
+
+	_, err := mock.NewDepositServiceClient(conn).Deposit(context.Background(),
+		&mock.DepositRequest{Amount: 1})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+}
+
+type backend struct {
+	conn   *grpc.ClientConn
+	health *health.Server
+	md     chan metadata.MD
+}
+
+func newBackend(t *testing.T) *backend {
+	b := &backend{
+		health: health.NewServer(),
+		md:     make(chan metadata.MD, 10),
+	}
+
+	lis := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req any,
+		_ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		b.md <- md
+		if err := grpc.SetHeader(ctx, metadata.Pairs("x-from", "backend")); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}))
+	mock.RegisterDepositServiceServer(server, &mock.DepositServer{})
+	grpc_health_v1.RegisterHealthServer(server, b.health)
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("backend", grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}))
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	b.conn = conn
+
+	return b
+}
+
+func (b *backend) lastMetadata() metadata.MD {
+	select {
+	case md := <-b.md:
+		return md
+	case <-time.After(time.Second):
+		return nil
+	}
+}
+
+func newTestProxy(t *testing.T, b *backend, allowed, denied []string) *grpc.ClientConn {
+	return newProxyConn(t, NewHandler(func(context.Context, string) (*grpc.ClientConn, error) {
+		return b.conn, nil
+	}, allowed, denied))
+}
+
+func newProxyConn(t *testing.T, handler *Handler) *grpc.ClientConn {
+	lis := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(grpc.UnknownServiceHandler(handler.Handle), grpc.ForceServerCodec(Codec()))
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("proxy", grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}))
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	return conn
+}
@@ -0,0 +1,62 @@
+package proxy
+
+import (
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// the transport headers are set by the backend connections.
+var transportMetadata = []string{"content-type", "user-agent", "te", "connection", "host"}
+
+type metadataFilter struct {
+	allowed map[string]struct{}
+	denied  map[string]struct{}
+}
+
+func newMetadataFilter(allowed, denied []string) *metadataFilter {
+	f := &metadataFilter{
+		denied: make(map[string]struct{}, len(denied)+len(transportMetadata)),
+	}
+	if len(allowed) > 0 {
+		f.allowed = make(map[string]struct{}, len(allowed))
+		for _, key := range allowed {
+			f.allowed[strings.ToLower(key)] = struct{}{}
+		}
+	}
+	for _, key := range transportMetadata {
+		f.denied[key] = struct{}{}
+	}
+	for _, key := range denied {
+		f.denied[strings.ToLower(key)] = struct{}{}
+	}
+
+	return f
+}
+
+func (f *metadataFilter) allow(key string) bool {
+	// pseudo headers and the reserved grpc headers are never forwarded.
+	if strings.HasPrefix(key, ":") || strings.HasPrefix(key, "grpc-") {
+		return false
+	}
+	if _, ok := f.denied[key]; ok {
+		return false
+	}
+	if f.allowed == nil {
+		return true
+	}
+
+	_, ok := f.allowed[key]
+	return ok
+}
+
+func (f *metadataFilter) filter(md metadata.MD) metadata.MD {
+	filtered := make(metadata.MD, len(md))
+	for key, vals := range md {
+		if f.allow(key) {
+			filtered[key] = append([]string(nil), vals...)
+		}
+	}
+
+	return filtered
+}
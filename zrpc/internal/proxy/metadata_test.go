@@ -0,0 +1,55 @@
+package proxy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestMetadataFilter(t *testing.T) {
+	md := metadata.Pairs(
+		":authority", "localhost",
+		"content-type", "application/grpc",
+		"user-agent", "grpc-go",
+		"grpc-accept-encoding", "gzip",
+		"x-user", "foo",
+		"x-secret", "bar",
+		"authorization", "Bearer token",
+	)
+
+	tests := []struct {
+		name    string
+		allowed []string
+		denied  []string
+		expect  metadata.MD
+	}{
+		{
+			name: "default",
+			expect: metadata.Pairs(
+				"x-user", "foo",
+				"x-secret", "bar",
+				"authorization", "Bearer token",
+			),
+		},
+		{
+			name:   "denied",
+			denied: []string{"X-Secret", "authorization"},
+			expect: metadata.Pairs("x-user", "foo"),
+		},
+		{
+			name:    "allowed",
+			allowed: []string{"x-user", "x-secret", "user-agent"},
+			denied:  []string{"x-secret"},
+			expect:  metadata.Pairs("x-user", "foo"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			f := newMetadataFilter(test.allowed, test.denied)
+			assert.Equal(t, test.expect, f.filter(md))
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jialequ/linux-sdk/core/collection"
+	"github.com/jialequ/linux-sdk/core/limit"
+	"github.com/jialequ/linux-sdk/core/stores/redis"
+	"github.com/jialequ/linux-sdk/zrpc/internal/auth"
+	xrate "golang.org/x/time/rate"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const (
+	// KeyByApp means limiting by the app credential of the callers, which requires Auth
+	// of the server to verify the credential, otherwise the callers can spoof the apps.
+	KeyByApp = "app"
+	// KeyByMetadata means limiting by the value of given metadata key.
+	// The metadata is sent by the callers, which can bypass the limit by changing the value,
+	// so only use it if the metadata is set by a trusted proxy, like the gateway,
+	// and the callers can't reach the servers directly.
+	KeyByMetadata = "metadata"
+
+	limitersExpire = time.Minute * 10
+	limitersLimit  = 10000
+	rateLimited    = "rate limited"
+)
+
+var (
+	// ErrInvalidRate is an error that indicates the rate is not positive.
+	ErrInvalidRate = errors.New("rate limit: rate must be positive")
+	// ErrMissingMetadataKey is an error that indicates the metadata key is not set.
+	ErrMissingMetadataKey = errors.New("rate limit: metadata key required")
+	// ErrKeyByAppWithoutAuth is an error that indicates the rules keyed by app without Auth.
+	ErrKeyByAppWithoutAuth = errors.New("rate limit: keyed by app requires Auth")
+)
+
+type (
+	// Conf defines the rate limit config.
+	Conf struct {
+		// Redis is used to share the quotas across the instances, limits in process if not set.
+		Redis redis.RedisConf `json:",optional"`
+		Key   string          `json:",default=zrpc:ratelimit"`
+		Rules []RuleConf      `json:",optional"`
+	}
+
+	// RuleConf defines the rate limit rule of a method.
+	// The calls without the key of the callers share one quota.
+	RuleConf struct {
+		// FullMethod is the method to limit, empty means all methods.
+		FullMethod string `json:",optional"`
+		// KeyBy is how to get the callers, app requires Auth of the server.
+		KeyBy string `json:",default=app,options=app|metadata"`
+		// MetadataKey is the metadata key to get the callers, only used with KeyBy=metadata.
+		// It must be set by a trusted proxy, because the callers can change it to bypass the limit.
+		MetadataKey string `json:",optional"`
+		// Rate is the allowed calls per second of each caller.
+		Rate int
+		// Burst is the max burst calls of each caller, default to Rate.
+		Burst int `json:",optional"`
+	}
+
+	// A Limiter limits the calls with the rules.
+	Limiter struct {
+		key         string
+		store       *redis.Redis
+		defaultRule *RuleConf
+		rules       map[string]RuleConf
+		limiters    *collection.Cache
+	}
+
+	allower interface {
+		AllowCtx(ctx context.Context) bool
+	}
+
+	localLimiter struct {
+		*xrate.Limiter
+	}
+)
+
+// KeysByApp checks if any rule of c is keyed by app, the rules without KeyBy are keyed by app.
+func (c Conf) KeysByApp() bool {
+	for _, rule := range c.Rules {
+		if rule.KeyBy != KeyByMetadata {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NewLimiter returns a Limiter.
+func NewLimiter(c Conf) (*Limiter, error) {
+	limiters, err := collection.NewCache(limitersExpire, collection.WithLimit(limitersLimit))
+	if err != nil {
+		return nil, err
+	}
+
+	l := &Limiter{
+		key:      c.Key,
+		rules:    make(map[string]RuleConf),
+		limiters: limiters,
+	}
+	for _, rule := range c.Rules {
+		if rule.Rate <= 0 {
+			return nil, ErrInvalidRate
+		}
+		if rule.KeyBy == KeyByMetadata && len(rule.MetadataKey) == 0 {
+			return nil, ErrMissingMetadataKey
+		}
+		if rule.Burst <= 0 {
+			rule.Burst = rule.Rate
+		}
+
+		if len(rule.FullMethod) == 0 {
+			defaultRule := rule
+			l.defaultRule = &defaultRule
+		} else {
+			l.rules[rule.FullMethod] = rule
+		}
+	}
+
+	if len(c.Redis.Host) > 0 {
+		store, err := redis.NewRedis(c.Redis)
+		if err != nil {
+			return nil, err
+		}
+
+		l.store = store
+	}
+
+	return l, nil
+}
+
+// Allow checks if the call on method is allowed, returns an error with codes.ResourceExhausted
+// and the retry info in the details if not allowed.
+func (l *Limiter) Allow(ctx context.Context, method string) error {
+	rule, ok := l.rules[method]
+	if !ok {
+		if l.defaultRule == nil {
+			return nil
+		}
+
+		rule = *l.defaultRule
+	}
+
+	key := fmt.Sprintf("%s:%s:%s", l.key, method, callerOf(ctx, rule))
+	val, err := l.limiters.Take(key, func() (any, error) {
+		if l.store == nil {
+			return localLimiter{
+				Limiter: xrate.NewLimiter(xrate.Limit(rule.Rate), rule.Burst),
+			}, nil
+		}
+
+		return limit.NewTokenLimiter(rule.Rate, rule.Burst, l.store, key), nil
+	})
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	if val.(allower).AllowCtx(ctx) {
+		return nil
+	}
+
+	// the key is internal, only the method is exposed to the callers.
+	return buildError(method, time.Duration(float64(time.Second)/float64(rule.Rate)))
+}
+
+func (l localLimiter) AllowCtx(_ context.Context) bool {
+	return l.Allow()
+}
+
+func buildError(method string, retryDelay time.Duration) error {
+	st, err := status.New(codes.ResourceExhausted, rateLimited).WithDetails(
+		&errdetails.RetryInfo{
+			RetryDelay: durationpb.New(retryDelay),
+		},
+		&errdetails.QuotaFailure{
+			Violations: []*errdetails.QuotaFailure_Violation{
+				{
+					Subject:     method,
+					Description: rateLimited,
+				},
+			},
+		},
+	)
+	if err != nil {
+		return status.Error(codes.ResourceExhausted, rateLimited)
+	}
+
+	return st.Err()
+}
+
+func callerOf(ctx context.Context, rule RuleConf) string {
+	if rule.KeyBy == KeyByMetadata {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if vals := md.Get(rule.MetadataKey); len(vals) > 0 {
+				return vals[0]
+			}
+		}
+
+		return ""
+	}
+
+	return auth.ParseCredential(ctx).App
+}
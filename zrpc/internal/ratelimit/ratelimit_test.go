@@ -0,0 +1,126 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/jialequ/linux-sdk/core/stores/redis"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestNewLimiter(t *testing.T) {
+	_, err := NewLimiter(Conf{
+		Rules: []RuleConf{{}},
+	})
+	assert.ErrorIs(t, err, ErrInvalidRate)
+
+	_, err = NewLimiter(Conf{
+		Rules: []RuleConf{
+			{
+				KeyBy: KeyByMetadata,
+				Rate:  1,
+			},
+		},
+	})
+	assert.ErrorIs(t, err, ErrMissingMetadataKey)
+}
+
+func TestLimiterAllow(t *testing.T) {
+	l, err := NewLimiter(Conf{
+		Key: "foo",
+		Rules: []RuleConf{
+			{
+				FullMethod: "/foo",
+				KeyBy:      KeyByApp,
+				Rate:       1,
+			},
+			{
+				KeyBy:       KeyByMetadata,
+				MetadataKey: "user",
+				Rate:        1,
+				Burst:       2,
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	app := func(name string) context.Context {
+		return metadata.NewIncomingContext(context.Background(),
+			metadata.Pairs("app", name, "token", "any"))
+	}
+	assert.NoError(t, l.Allow(app("a"), "/foo"))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(l.Allow(app("a"), "/foo")))
+	assert.NoError(t, l.Allow(app("b"), "/foo"))
+
+	user := func(name string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs("user", name))
+	}
+	assert.NoError(t, l.Allow(user("a"), "/bar"))
+	assert.NoError(t, l.Allow(user("a"), "/bar"))
+	err = l.Allow(user("a"), "/bar")
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.NoError(t, l.Allow(user("b"), "/bar"))
+	// calls without callers share one quota
+	assert.NoError(t, l.Allow(context.Background(), "/bar"))
+
+	var retryInfo *errdetails.RetryInfo
+	var quotaFailure *errdetails.QuotaFailure
+	for _, detail := range status.Convert(err).Details() {
+		switch val := detail.(type) {
+		case *errdetails.RetryInfo:
+			retryInfo = val
+		case *errdetails.QuotaFailure:
+			quotaFailure = val
+		}
+	}
+	if assert.NotNil(t, retryInfo) {
+		assert.Equal(t, int64(1), retryInfo.RetryDelay.Seconds)
+	}
+	// the internal key is not exposed.
+	if assert.NotNil(t, quotaFailure) {
+		assert.Equal(t, "/bar", quotaFailure.Violations[0].Subject)
+	}
+}
+
+func TestConfKeysByApp(t *testing.T) {
+	assert.False(t, Conf{}.KeysByApp())
+	assert.False(t, Conf{Rules: []RuleConf{{KeyBy: KeyByMetadata}}}.KeysByApp())
+	assert.True(t, Conf{Rules: []RuleConf{{KeyBy: KeyByMetadata}, {KeyBy: KeyByApp}}}.KeysByApp())
+	assert.True(t, Conf{Rules: []RuleConf{{}}}.KeysByApp())
+}
+
+func TestLimiterAllowWithoutRules(t *testing.T) {
+	l, err := NewLimiter(Conf{})
+	assert.NoError(t, err)
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, l.Allow(context.Background(), "/foo"))
+	}
+}
+
+func TestLimiterAllowWithRedis(t *testing.T) {
+	r := miniredis.RunT(t)
+	l, err := NewLimiter(Conf{
+		Redis: redis.RedisConf{
+			Host: r.Addr(),
+			Type: redis.NodeType,
+		},
+		Key: "foo",
+		Rules: []RuleConf{
+			{
+				KeyBy: KeyByApp,
+				Rate:  1,
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	ctx := metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs("app", "a", "token", "any"))
+	assert.NoError(t, l.Allow(ctx, "/foo"))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(l.Allow(ctx, "/foo")))
+}
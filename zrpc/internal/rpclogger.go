@@ -1,15 +1,29 @@
 package internal
 
 import (
+	"context"
+
 	"github.com/jialequ/linux-sdk/core/logx"
+	"github.com/jialequ/linux-sdk/zrpc/internal/message"
 	"google.golang.org/grpc/grpclog"
+	"google.golang.org/grpc/stats"
 )
 
 // because grpclog.errorLog is not exported, we need to define our own.
 const errorLevel = 2
 
-// A Logger is a rpc logger.
-type Logger struct{}
+type (
+	// A Logger is a rpc logger.
+	Logger struct{}
+
+	// messageSizeLogger is a stats.Handler that logs the calls failed with oversize messages,
+	// which are rejected by grpc before the interceptors.
+	messageSizeLogger struct {
+		side string
+	}
+
+	loggerMethodKey struct{}
+)
 
 func init() {
 	grpclog.SetLoggerV2(new(Logger))
@@ -79,3 +93,33 @@ func (l *Logger) Warningf(_ string, _ ...any) {
 func (l *Logger) Warningln(_ ...any) {
 	// ignore builtin grpc warning
 }
+
+func newMessageSizeLogger(client bool) stats.Handler {
+	if client {
+		return messageSizeLogger{side: "client"}
+	}
+
+	return messageSizeLogger{side: "server"}
+}
+
+func (l messageSizeLogger) HandleConn(context.Context, stats.ConnStats) {
+}
+
+func (l messageSizeLogger) HandleRPC(ctx context.Context, rs stats.RPCStats) {
+	end, ok := rs.(*stats.End)
+	if !ok || !message.IsTooLarge(end.Error) {
+		return
+	}
+
+	method, _ := ctx.Value(loggerMethodKey{}).(string)
+	logx.WithContext(ctx).Errorf("rpc %s message too large, method: %s, error: %v",
+		l.side, method, end.Error)
+}
+
+func (l messageSizeLogger) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (l messageSizeLogger) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	return context.WithValue(ctx, loggerMethodKey{}, info.FullMethodName)
+}
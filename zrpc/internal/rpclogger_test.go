@@ -1,10 +1,15 @@
 package internal
 
 import (
+	"context"
 	"testing"
 
 	"github.com/jialequ/linux-sdk/core/logx/logtest"
+	"github.com/jialequ/linux-sdk/zrpc/internal/message"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
 )
 
 const content = "foo"
@@ -100,3 +105,20 @@ func TestLoggerV(t *testing.T) {
 	// grpclog.infoLog
 	assert.False(t, logger.V(0))
 }
+
+func TestMessageSizeLogger(t *testing.T) {
+	for _, client := range []bool{true, false} {
+		c := logtest.NewCollector(t)
+		h := newMessageSizeLogger(client)
+		ctx := h.TagConn(context.Background(), &stats.ConnTagInfo{})
+		h.HandleConn(ctx, &stats.ConnBegin{})
+		ctx = h.TagRPC(ctx, &stats.RPCTagInfo{FullMethodName: "/foo"})
+		h.HandleRPC(ctx, &stats.Begin{})
+		h.HandleRPC(ctx, &stats.End{Error: status.Error(codes.ResourceExhausted, "quota exceeded")})
+		assert.Empty(t, c.String())
+
+		h.HandleRPC(ctx, &stats.End{Error: message.ErrRecvTooLarge(2, 1)})
+		assert.Contains(t, c.String(), "/foo")
+		assert.Contains(t, c.String(), "message too large")
+	}
+}
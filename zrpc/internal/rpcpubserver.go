@@ -3,6 +3,7 @@ package internal
 import (
 	"os"
 	"strings"
+	"sync"
 
 	"github.com/jialequ/linux-sdk/core/discov"
 	"github.com/jialequ/linux-sdk/core/netx"
@@ -16,7 +17,12 @@ const (
 // NewRpcPubServer returns a Server.
 func NewRpcPubServer(etcd discov.EtcdConf, listenOn string, middlewares ServerMiddlewaresConf,
 	opts ...ServerOption) (Server, error) {
-	registerEtcd := func() error {
+	var options rpcServerOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	registerEtcd := func() (*discov.Publisher, error) {
 		pubListenOn := figureOutListenOn(listenOn)
 		var pubOpts []discov.PubOption
 		if etcd.HasAccount() {
@@ -29,10 +35,13 @@ func NewRpcPubServer(etcd discov.EtcdConf, listenOn string, middlewares ServerMi
 		if etcd.HasID() {
 			pubOpts = append(pubOpts, discov.WithId(etcd.ID))
 		}
+		if len(options.metadata) > 0 {
+			pubOpts = append(pubOpts, discov.WithPubMetadata(options.metadata))
+		}
 		pubClient := discov.NewPublisher(etcd.Hosts, etcd.Key, pubListenOn, pubOpts...)
-		return pubClient.KeepAlive()
+		return pubClient, pubClient.KeepAlive()
 	}
-	server := keepAliveServer{
+	server := &keepAliveServer{
 		registerEtcd: registerEtcd,
 		Server:       NewRpcServer(listenOn, middlewares, opts...),
 	}
@@ -41,18 +50,37 @@ func NewRpcPubServer(etcd discov.EtcdConf, listenOn string, middlewares ServerMi
 }
 
 type keepAliveServer struct {
-	registerEtcd func() error
+	registerEtcd func() (*discov.Publisher, error)
+	publisher    *discov.Publisher
+	lock         sync.Mutex
 	Server
 }
 
-func (s keepAliveServer) Start(fn RegisterFn) error {
-	if err := s.registerEtcd(); err != nil {
+func (s *keepAliveServer) Start(fn RegisterFn) error {
+	publisher, err := s.registerEtcd()
+	if err != nil {
 		return err
 	}
 
+	s.lock.Lock()
+	s.publisher = publisher
+	s.lock.Unlock()
+
 	return s.Server.Start(fn)
 }
 
+// Stop revokes the registration from etcd, then stops the server.
+func (s *keepAliveServer) Stop() {
+	s.lock.Lock()
+	publisher := s.publisher
+	s.lock.Unlock()
+
+	if publisher != nil {
+		publisher.Stop()
+	}
+	s.Server.Stop()
+}
+
 func figureOutListenOn(listenOn string) string {
 	fields := strings.Split(listenOn, ":")
 	if len(fields) == 0 {
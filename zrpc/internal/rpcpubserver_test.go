@@ -5,6 +5,7 @@ import (
 
 	"github.com/jialequ/linux-sdk/core/discov"
 	"github.com/jialequ/linux-sdk/core/netx"
+	"github.com/jialequ/linux-sdk/zrpc/internal/instance"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -20,6 +21,20 @@ func TestNewRpcPubServer(t *testing.T) {
 	})
 }
 
+func TestNewRpcPubServerWithMetadata(t *testing.T) {
+	s, err := NewRpcPubServer(discov.EtcdConf{
+		User: "user",
+		Pass: "pass",
+		ID:   10,
+	}, "", ServerMiddlewaresConf{}, WithMetadata(instance.Metadata{
+		instance.ZoneKey: "a",
+	}))
+	assert.NoError(t, err)
+	assert.NotPanics(t, func() {
+		s.Start(nil)
+	})
+}
+
 func TestFigureOutListenOn(t *testing.T) {
 	tests := []struct {
 		input  string
@@ -1,15 +1,31 @@
 package internal
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"net"
+	"net/http"
+	"sync"
+	"time"
 
+	threading "github.com/jialequ/linux-sdk/core/dist"
+	"github.com/jialequ/linux-sdk/core/logx"
 	"github.com/jialequ/linux-sdk/core/proc"
 	"github.com/jialequ/linux-sdk/core/stat"
+	"github.com/jialequ/linux-sdk/core/syncx"
 	"github.com/jialequ/linux-sdk/internal/health"
+	"github.com/jialequ/linux-sdk/zrpc/internal/breakers"
+	"github.com/jialequ/linux-sdk/zrpc/internal/grpcweb"
+	"github.com/jialequ/linux-sdk/zrpc/internal/instance"
+	"github.com/jialequ/linux-sdk/zrpc/internal/message"
 	"github.com/jialequ/linux-sdk/zrpc/internal/serverinterceptors"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 )
 
 const probeNamePrefix = "zrpc"
@@ -19,8 +35,20 @@ type (
 	ServerOption func(options *rpcServerOptions)
 
 	rpcServerOptions struct {
-		metrics *stat.Metrics
-		health  bool
+		metrics             *stat.Metrics
+		health              bool
+		healthCheckInterval time.Duration
+		reflection          bool
+		breakers            *breakers.Breakers
+		message             *message.Matcher
+		listener            net.Listener
+		web                 *webOptions
+		metadata            instance.Metadata
+	}
+
+	webOptions struct {
+		conf      WebConf
+		tlsConfig *tls.Config
 	}
 
 	rpcServer struct {
@@ -28,6 +56,16 @@ type (
 		name          string
 		middlewares   ServerMiddlewaresConf
 		healthManager health.Probe
+		listener      net.Listener
+		web           *webOptions
+		reflection    bool
+		breakers      *breakers.Breakers
+		message       *message.Matcher
+		server        *grpc.Server
+		webServer     *http.Server
+		stopped       bool
+		done          *syncx.DoneChan
+		lock          sync.Mutex
 	}
 )
 
@@ -40,11 +78,26 @@ func NewRpcServer(addr string, middlewares ServerMiddlewaresConf, opts ...Server
 	if options.metrics == nil {
 		options.metrics = stat.NewMetrics(addr)
 	}
+	if options.web != nil {
+		grpcweb.RegisterCodec()
+	}
+
+	base := newBaseRpcServer(addr, &options)
+	if options.message != nil {
+		base.AddOptions(grpc.MaxRecvMsgSize(options.message.MaxRecvSize()),
+			grpc.MaxSendMsgSize(options.message.MaxSendSize()))
+	}
 
 	return &rpcServer{
-		baseRpcServer: newBaseRpcServer(addr, &options),
+		baseRpcServer: base,
 		middlewares:   middlewares,
 		healthManager: health.NewHealthManager(fmt.Sprintf("%s-%s", probeNamePrefix, addr)),
+		listener:      options.listener,
+		web:           options.web,
+		reflection:    options.reflection,
+		breakers:      options.breakers,
+		message:       options.message,
+		done:          syncx.NewDoneChan(),
 	}
 }
 
@@ -54,7 +107,7 @@ func (s *rpcServer) SetName(name string) {
 }
 
 func (s *rpcServer) Start(register RegisterFn) error {
-	lis, err := net.Listen("tcp", s.address)
+	lis, err := s.listen()
 	if err != nil {
 		return err
 	}
@@ -63,28 +116,139 @@ func (s *rpcServer) Start(register RegisterFn) error {
 	streamInterceptorOption := grpc.ChainStreamInterceptor(s.buildStreamInterceptors()...)
 
 	options := append(s.options, unaryInterceptorOption, streamInterceptorOption)
+	if s.message != nil {
+		options = append(options, grpc.StatsHandler(newMessageSizeLogger(false)))
+	}
+	if s.middlewares.Prometheus {
+		options = append(options, grpc.StatsHandler(serverinterceptors.PrometheusStatsHandler()))
+	}
 	server := grpc.NewServer(options...)
 	register(server)
 
 	// register the health check service
 	if s.health != nil {
+		services := make([]string, 0, len(server.GetServiceInfo()))
+		for service := range server.GetServiceInfo() {
+			services = append(services, service)
+		}
+		s.health.AddServices(services...)
 		grpc_health_v1.RegisterHealthServer(server, s.health)
 		s.health.Resume()
 	}
+	if s.reflection {
+		reflection.Register(server)
+	}
 	s.healthManager.MarkReady()
 	health.AddProbe(s.healthManager)
 
+	serve := func() error {
+		return server.Serve(lis)
+	}
+	var webServer *http.Server
+	if s.web != nil {
+		if webServer, serve, err = s.buildWebServer(server, lis); err != nil {
+			return err
+		}
+	}
+
+	s.lock.Lock()
+	// the server is stopped before serving, like on shutdown.
+	if s.stopped {
+		s.lock.Unlock()
+		return lis.Close()
+	}
+	s.server = server
+	s.webServer = webServer
+	s.lock.Unlock()
+
 	// we need to make sure all others are wrapped up,
 	// so we do graceful stop at shutdown phase instead of wrap up phase
-	waitForCalled := proc.AddShutdownListener(func() {
-		if s.health != nil {
-			s.health.Shutdown()
-		}
+	proc.AddShutdownListener(s.Stop)
+
+	if err = serve(); err != nil {
+		return err
+	}
+
+	// serve returns once the stopping starts, wait for the graceful stop to finish.
+	<-s.done.Done()
+	return nil
+}
+
+// Stop stops the server gracefully, along with the health checks and the web server.
+func (s *rpcServer) Stop() {
+	s.lock.Lock()
+	if s.stopped {
+		s.lock.Unlock()
+		return
+	}
+	s.stopped = true
+	server := s.server
+	webServer := s.webServer
+	s.lock.Unlock()
+
+	defer s.done.Close()
+
+	s.healthManager.MarkNotReady()
+	if s.health != nil {
+		s.health.Shutdown()
+	}
+	// the web requests are served by grpc.Server.ServeHTTP, which can't be drained by GracefulStop.
+	if webServer != nil {
+		_ = webServer.Shutdown(context.Background())
+	}
+	if server != nil {
 		server.GracefulStop()
-	})
-	defer waitForCalled()
+	}
+}
+
+// buildWebServer returns the http server to serve gRPC-Web and Connect requests,
+// and the func to serve both the gRPC and web requests.
+func (s *rpcServer) buildWebServer(server *grpc.Server, lis net.Listener) (*http.Server, func() error, error) {
+	var opts []grpcweb.HandlerOption
+	if s.message != nil {
+		opts = append(opts, grpcweb.WithMaxRecvSize(s.message.MaxRecvSize()))
+	}
+	webServer := &http.Server{
+		Handler: h2c.NewHandler(grpcweb.NewHandler(server, s.web.conf, opts...), &http2.Server{}),
+	}
 
-	return server.Serve(lis)
+	shared := len(s.web.conf.ListenOn) == 0
+	webLis := lis
+	if !shared {
+		var err error
+		if webLis, err = net.Listen("tcp", s.web.conf.ListenOn); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if s.web.tlsConfig != nil {
+		if err := http2.ConfigureServer(webServer, nil); err != nil {
+			return nil, nil, err
+		}
+		webLis = tls.NewListener(webLis, s.web.tlsConfig)
+	}
+
+	serveWeb := func() error {
+		if err := webServer.Serve(webLis); !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+
+		return nil
+	}
+	// on the shared address, the gRPC requests are served by the web server with HTTP/2.
+	if shared {
+		return webServer, serveWeb, nil
+	}
+
+	return webServer, func() error {
+		threading.GoSafe(func() {
+			if err := serveWeb(); err != nil {
+				logx.Error(err)
+			}
+		})
+
+		return server.Serve(lis)
+	}, nil
 }
 
 func (s *rpcServer) buildStreamInterceptors() []grpc.StreamServerInterceptor {
@@ -93,14 +257,40 @@ func (s *rpcServer) buildStreamInterceptors() []grpc.StreamServerInterceptor {
 	if s.middlewares.Trace {
 		interceptors = append(interceptors, serverinterceptors.StreamTracingInterceptor)
 	}
+	if s.middlewares.RequestId {
+		interceptors = append(interceptors, serverinterceptors.StreamRequestIdInterceptor)
+	}
+	if s.middlewares.Canary {
+		interceptors = append(interceptors, serverinterceptors.StreamCanaryInterceptor)
+	}
 	if s.middlewares.Recover {
 		interceptors = append(interceptors, serverinterceptors.StreamRecoverInterceptor)
 	}
+	if s.middlewares.Stat {
+		interceptors = append(interceptors,
+			serverinterceptors.StreamStatInterceptor(s.streamMetrics, s.middlewares.StatConf))
+	}
+	if s.middlewares.Prometheus {
+		interceptors = append(interceptors, serverinterceptors.StreamPrometheusInterceptor)
+	}
 	if s.middlewares.Breaker {
-		interceptors = append(interceptors, serverinterceptors.StreamBreakerInterceptor)
+		if s.breakers != nil {
+			interceptors = append(interceptors, serverinterceptors.StreamBreakersInterceptor(s.breakers))
+		} else {
+			interceptors = append(interceptors, serverinterceptors.StreamBreakerInterceptor)
+		}
+	}
+	if s.message != nil {
+		interceptors = append(interceptors, serverinterceptors.StreamMessageInterceptor(s.message))
+	}
+	interceptors = append(interceptors, s.streamInterceptors...)
+	// validation is placed last to only validate the authenticated requests,
+	// including the ones authenticated by the added interceptors.
+	if s.middlewares.Validate {
+		interceptors = append(interceptors, serverinterceptors.StreamValidateInterceptor)
 	}
 
-	return append(interceptors, s.streamInterceptors...)
+	return interceptors
 }
 
 func (s *rpcServer) buildUnaryInterceptors() []grpc.UnaryServerInterceptor {
@@ -109,6 +299,12 @@ func (s *rpcServer) buildUnaryInterceptors() []grpc.UnaryServerInterceptor {
 	if s.middlewares.Trace {
 		interceptors = append(interceptors, serverinterceptors.UnaryTracingInterceptor)
 	}
+	if s.middlewares.RequestId {
+		interceptors = append(interceptors, serverinterceptors.UnaryRequestIdInterceptor)
+	}
+	if s.middlewares.Canary {
+		interceptors = append(interceptors, serverinterceptors.UnaryCanaryInterceptor)
+	}
 	if s.middlewares.Recover {
 		interceptors = append(interceptors, serverinterceptors.UnaryRecoverInterceptor)
 	}
@@ -120,10 +316,66 @@ func (s *rpcServer) buildUnaryInterceptors() []grpc.UnaryServerInterceptor {
 		interceptors = append(interceptors, serverinterceptors.UnaryPrometheusInterceptor)
 	}
 	if s.middlewares.Breaker {
-		interceptors = append(interceptors, serverinterceptors.UnaryBreakerInterceptor)
+		if s.breakers != nil {
+			interceptors = append(interceptors, serverinterceptors.UnaryBreakersInterceptor(s.breakers))
+		} else {
+			interceptors = append(interceptors, serverinterceptors.UnaryBreakerInterceptor)
+		}
+	}
+	if s.message != nil {
+		interceptors = append(interceptors, serverinterceptors.UnaryMessageInterceptor(s.message))
+	}
+	interceptors = append(interceptors, s.unaryInterceptors...)
+	// validation is placed last to only validate the authenticated requests,
+	// including the ones authenticated by the added interceptors.
+	if s.middlewares.Validate {
+		interceptors = append(interceptors, serverinterceptors.UnaryValidateInterceptor)
+	}
+
+	return interceptors
+}
+
+func (s *rpcServer) listen() (net.Listener, error) {
+	if s.listener != nil {
+		return s.listener, nil
 	}
 
-	return append(interceptors, s.unaryInterceptors...)
+	return net.Listen("tcp", s.address)
+}
+
+// WithListener returns a func that sets the listener to a Server,
+// the server serves on the given listener instead of listening on its address.
+func WithListener(listener net.Listener) ServerOption {
+	return func(options *rpcServerOptions) {
+		options.listener = listener
+	}
+}
+
+// WithWeb returns a func that makes a Server serve gRPC-Web and Connect requests,
+// tlsConfig is used to secure the web requests if not nil.
+func WithWeb(c WebConf, tlsConfig *tls.Config) ServerOption {
+	return func(options *rpcServerOptions) {
+		options.web = &webOptions{
+			conf:      c,
+			tlsConfig: tlsConfig,
+		}
+	}
+}
+
+// WithMetadata returns a func that sets the instance metadata to a Server,
+// the metadata is published with the address if the Server is registered to etcd.
+func WithMetadata(md instance.Metadata) ServerOption {
+	return func(options *rpcServerOptions) {
+		options.metadata = md
+	}
+}
+
+// WithServerMessage returns a func that sets a Server to apply the size limits and
+// the compression of the messages.
+func WithServerMessage(m *message.Matcher) ServerOption {
+	return func(options *rpcServerOptions) {
+		options.message = m
+	}
 }
 
 // WithMetrics returns a func that sets metrics to a Server.
@@ -133,6 +385,27 @@ func WithMetrics(metrics *stat.Metrics) ServerOption {
 	}
 }
 
+// WithBreakers returns a func that sets the breakers with the per-method rules to a Server.
+func WithBreakers(b *breakers.Breakers) ServerOption {
+	return func(options *rpcServerOptions) {
+		options.breakers = b
+	}
+}
+
+// WithHealthCheckInterval returns a func that sets the interval of the health checks to a Server.
+func WithHealthCheckInterval(interval time.Duration) ServerOption {
+	return func(options *rpcServerOptions) {
+		options.healthCheckInterval = interval
+	}
+}
+
+// WithReflection returns a func that sets the reflection switch to a Server.
+func WithReflection(reflection bool) ServerOption {
+	return func(options *rpcServerOptions) {
+		options.reflection = reflection
+	}
+}
+
 // WithRpcHealth returns a func that sets rpc health switch to a Server.
 func WithRpcHealth(health bool) ServerOption {
 	return func(options *rpcServerOptions) {
@@ -2,15 +2,28 @@ package internal
 
 import (
 	"context"
+	"io"
+	"net"
+	"net/http"
+	"reflect"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/jialequ/linux-sdk/core/logx/logtest"
 	"github.com/jialequ/linux-sdk/core/proc"
 	"github.com/jialequ/linux-sdk/core/stat"
 	"github.com/jialequ/linux-sdk/internal/mock"
+	"github.com/jialequ/linux-sdk/zrpc/internal/breakers"
+	"github.com/jialequ/linux-sdk/zrpc/internal/message"
+	"github.com/jialequ/linux-sdk/zrpc/internal/serverinterceptors"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/test/bufconn"
 )
 
 func TestRpcServer(t *testing.T) {
@@ -68,6 +81,226 @@ func TestRpcServerWithBadAddress(t *testing.T) {
 	proc.WrapUp()
 }
 
+func TestRpcServerWithListener(t *testing.T) {
+	listener := bufconn.Listen(1024 * 1024)
+	server := NewRpcServer("localhost:111111", ServerMiddlewaresConf{},
+		WithMetrics(stat.NewMetrics("foo")), WithListener(listener))
+	server.SetName("mock")
+	started := make(chan *grpc.Server, 1)
+	go func() {
+		assert.Nil(t, server.Start(func(server *grpc.Server) {
+			mock.RegisterDepositServiceServer(server, new(mock.DepositServer))
+			started <- server
+		}))
+	}()
+
+	grpcServer := <-started
+	conn, err := grpc.Dial("bufnet", grpc.WithContextDialer(
+		func(ctx context.Context, s string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	resp, err := mock.NewDepositServiceClient(conn).Deposit(context.Background(),
+		&mock.DepositRequest{Amount: 1})
+	assert.Nil(t, err)
+	assert.True(t, resp.Ok)
+
+	grpcServer.Stop()
+}
+
+func TestRpcServerStop(t *testing.T) {
+	listener := bufconn.Listen(1024 * 1024)
+	server := NewRpcServer("localhost:111111", ServerMiddlewaresConf{},
+		WithMetrics(stat.NewMetrics("foo")), WithListener(listener), WithRpcHealth(true))
+	server.SetName("mock")
+	started := make(chan struct{})
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- server.Start(func(server *grpc.Server) {
+			mock.RegisterDepositServiceServer(server, new(mock.DepositServer))
+			close(started)
+		})
+	}()
+
+	<-started
+	server.Stop()
+	server.Stop()
+	select {
+	case err := <-stopped:
+		assert.Nil(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("server not stopped")
+	}
+
+	assert.Nil(t, server.Start(func(server *grpc.Server) {}))
+}
+
+func TestRpcServerWithReflectionAndHealth(t *testing.T) {
+	listener := bufconn.Listen(1024 * 1024)
+	server := NewRpcServer("localhost:111111", ServerMiddlewaresConf{},
+		WithMetrics(stat.NewMetrics("foo")), WithListener(listener),
+		WithRpcHealth(true), WithReflection(true))
+	server.SetName("mock")
+	server.SetServingStatus("orders", false)
+	started := make(chan *grpc.Server, 1)
+	go func() {
+		assert.Nil(t, server.Start(func(server *grpc.Server) {
+			mock.RegisterDepositServiceServer(server, new(mock.DepositServer))
+			started <- server
+		}))
+	}()
+
+	grpcServer := <-started
+	defer grpcServer.Stop()
+	conn, err := grpc.Dial("bufnet", grpc.WithContextDialer(
+		func(ctx context.Context, s string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	client := grpc_health_v1.NewHealthClient(conn)
+	resp, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{
+		Service: "mock.DepositService",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.Status)
+	resp, err = client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{
+		Service: "orders",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, resp.Status)
+
+	stream, err := grpc_reflection_v1.NewServerReflectionClient(conn).ServerReflectionInfo(
+		context.Background())
+	assert.Nil(t, err)
+	assert.Nil(t, stream.Send(&grpc_reflection_v1.ServerReflectionRequest{
+		MessageRequest: &grpc_reflection_v1.ServerReflectionRequest_ListServices{},
+	}))
+	reply, err := stream.Recv()
+	assert.Nil(t, err)
+	var services []string
+	for _, service := range reply.GetListServicesResponse().GetService() {
+		services = append(services, service.Name)
+	}
+	assert.Contains(t, services, "mock.DepositService")
+	assert.Nil(t, stream.CloseSend())
+}
+
+func TestRpcServerWithMessage(t *testing.T) {
+	serverMatcher, err := message.NewMatcher(message.Conf{
+		Compressor: message.Zstd,
+		Methods: []message.MethodConf{
+			{
+				FullMethod:  "/mock.DepositService/*",
+				MaxRecvSize: 1,
+			},
+		},
+	})
+	assert.Nil(t, err)
+	clientMatcher, err := message.NewMatcher(message.Conf{
+		Compressor: message.Zstd,
+	})
+	assert.Nil(t, err)
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := NewRpcServer("localhost:111111", ServerMiddlewaresConf{Prometheus: true},
+		WithMetrics(stat.NewMetrics("foo")), WithListener(listener), WithServerMessage(serverMatcher))
+	server.SetName("mock")
+	started := make(chan *grpc.Server, 1)
+	go func() {
+		assert.Nil(t, server.Start(func(server *grpc.Server) {
+			mock.RegisterDepositServiceServer(server, new(mock.DepositServer))
+			started <- server
+		}))
+	}()
+
+	grpcServer := <-started
+	defer grpcServer.Stop()
+	cli, err := NewClient("bufnet", ClientMiddlewaresConf{Prometheus: true},
+		WithDialOption(grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		})), WithMessage(clientMatcher))
+	assert.Nil(t, err)
+	defer cli.Conn().Close()
+
+	c := logtest.NewCollector(t)
+	client := mock.NewDepositServiceClient(cli.Conn())
+	resp, err := client.Deposit(context.Background(), &mock.DepositRequest{})
+	assert.Nil(t, err)
+	assert.True(t, resp.Ok)
+
+	_, err = client.Deposit(context.Background(), &mock.DepositRequest{Amount: 1})
+	assert.True(t, message.IsTooLarge(err))
+	assert.Contains(t, c.String(), "message too large")
+}
+
+func TestRpcServerWithWeb(t *testing.T) {
+	tests := []struct {
+		name   string
+		shared bool
+	}{
+		{
+			name:   "shared",
+			shared: true,
+		},
+		{
+			name: "side",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			assert.Nil(t, err)
+			webAddr := listener.Addr().String()
+			var conf WebConf
+			if !test.shared {
+				webAddr = freeAddress(t)
+				conf.ListenOn = webAddr
+			}
+
+			server := NewRpcServer(listener.Addr().String(), ServerMiddlewaresConf{
+				Recover: true,
+			}, WithMetrics(stat.NewMetrics("foo")), WithListener(listener), WithWeb(conf, nil))
+			server.SetName("mock")
+			started := make(chan *grpc.Server, 1)
+			go func() {
+				_ = server.Start(func(server *grpc.Server) {
+					mock.RegisterDepositServiceServer(server, new(mock.DepositServer))
+					started <- server
+				})
+			}()
+			grpcServer := <-started
+			defer grpcServer.Stop()
+
+			conn, err := grpc.Dial(listener.Addr().String(),
+				grpc.WithTransportCredentials(insecure.NewCredentials()))
+			assert.Nil(t, err)
+			defer conn.Close()
+
+			resp, err := mock.NewDepositServiceClient(conn).Deposit(context.Background(),
+				&mock.DepositRequest{Amount: 1})
+			assert.Nil(t, err)
+			assert.True(t, resp.Ok)
+
+			var webResp *http.Response
+			assert.Eventually(t, func() bool {
+				webResp, err = http.Post("http://"+webAddr+"/mock.DepositService/Deposit",
+					"application/json", strings.NewReader(`{"amount":1}`))
+				return err == nil
+			}, time.Second*5, time.Millisecond*10)
+			defer webResp.Body.Close()
+			body, err := io.ReadAll(webResp.Body)
+			assert.Nil(t, err)
+			assert.JSONEq(t, `{"ok":true}`, string(body))
+		})
+	}
+}
+
 func TestRpcServerbuildUnaryInterceptor(t *testing.T) {
 	tests := []struct {
 		name string
@@ -112,9 +345,31 @@ func TestRpcServerbuildUnaryInterceptor(t *testing.T) {
 					Stat:       true,
 					Prometheus: true,
 					Breaker:    true,
+					RequestId:  true,
 				},
 			},
-			len: 6,
+			len: 7,
+		},
+		{
+			name: "breakers",
+			r: &rpcServer{
+				baseRpcServer: &baseRpcServer{},
+				middlewares: ServerMiddlewaresConf{
+					Breaker: true,
+				},
+				breakers: new(breakers.Breakers),
+			},
+			len: 1,
+		},
+		{
+			name: "validate",
+			r: &rpcServer{
+				baseRpcServer: &baseRpcServer{},
+				middlewares: ServerMiddlewaresConf{
+					Validate: true,
+				},
+			},
+			len: 1,
 		},
 	}
 
@@ -125,6 +380,38 @@ func TestRpcServerbuildUnaryInterceptor(t *testing.T) {
 	}
 }
 
+func TestRpcServerValidateLast(t *testing.T) {
+	r := &rpcServer{
+		baseRpcServer: &baseRpcServer{
+			unaryInterceptors: []grpc.UnaryServerInterceptor{
+				func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+					handler grpc.UnaryHandler) (interface{}, error) {
+					return nil, nil
+				},
+			},
+			streamInterceptors: []grpc.StreamServerInterceptor{
+				func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+					handler grpc.StreamHandler) error {
+					return nil
+				},
+			},
+		},
+		middlewares: ServerMiddlewaresConf{
+			Recover:  true,
+			Validate: true,
+		},
+	}
+
+	unary := r.buildUnaryInterceptors()
+	assert.Len(t, unary, 3)
+	assert.Equal(t, reflect.ValueOf(serverinterceptors.UnaryValidateInterceptor).Pointer(),
+		reflect.ValueOf(unary[2]).Pointer())
+	stream := r.buildStreamInterceptors()
+	assert.Len(t, stream, 3)
+	assert.Equal(t, reflect.ValueOf(serverinterceptors.StreamValidateInterceptor).Pointer(),
+		reflect.ValueOf(stream[2]).Pointer())
+}
+
 func TestRpcServerbuildStreamInterceptor(t *testing.T) {
 	tests := []struct {
 		name string
@@ -164,12 +451,36 @@ func TestRpcServerbuildStreamInterceptor(t *testing.T) {
 					},
 				},
 				middlewares: ServerMiddlewaresConf{
-					Trace:   true,
-					Recover: true,
+					Trace:      true,
+					Recover:    true,
+					Stat:       true,
+					Prometheus: true,
+					Breaker:    true,
+					RequestId:  true,
+				},
+			},
+			len: 7,
+		},
+		{
+			name: "breakers",
+			r: &rpcServer{
+				baseRpcServer: &baseRpcServer{},
+				middlewares: ServerMiddlewaresConf{
 					Breaker: true,
 				},
+				breakers: new(breakers.Breakers),
+			},
+			len: 1,
+		},
+		{
+			name: "validate",
+			r: &rpcServer{
+				baseRpcServer: &baseRpcServer{},
+				middlewares: ServerMiddlewaresConf{
+					Validate: true,
+				},
 			},
-			len: 4,
+			len: 1,
 		},
 	}
 
@@ -179,3 +490,10 @@ func TestRpcServerbuildStreamInterceptor(t *testing.T) {
 		})
 	}
 }
+
+func freeAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer listener.Close()
+	return listener.Addr().String()
+}
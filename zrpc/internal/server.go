@@ -5,11 +5,13 @@ import (
 
 	"github.com/jialequ/linux-sdk/core/stat"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/keepalive"
 )
 
-const defaultConnectionIdleDuration = time.Minute * 5
+const (
+	defaultConnectionIdleDuration = time.Minute * 5
+	streamMetricsSuffix           = "-stream"
+)
 
 type (
 	// RegisterFn defines the method to register a server.
@@ -17,17 +19,23 @@ type (
 
 	// Server interface represents a rpc server.
 	Server interface {
+		AddHealthCheck(service string, check HealthCheck)
 		AddOptions(options ...grpc.ServerOption)
 		AddStreamInterceptors(interceptors ...grpc.StreamServerInterceptor)
 		AddUnaryInterceptors(interceptors ...grpc.UnaryServerInterceptor)
 		SetName(string)
+		SetServingStatus(service string, serving bool)
 		Start(register RegisterFn) error
+		Stop()
 	}
 
 	baseRpcServer struct {
-		address            string
-		health             *health.Server
-		metrics            *stat.Metrics
+		address string
+		health  *healthServer
+		metrics *stat.Metrics
+		// streamMetrics are the stats of the streams, separated from the metrics of the
+		// unary calls, because the durations of the streams are their lifetimes.
+		streamMetrics      *stat.Metrics
 		options            []grpc.ServerOption
 		streamInterceptors []grpc.StreamServerInterceptor
 		unaryInterceptors  []grpc.UnaryServerInterceptor
@@ -35,20 +43,29 @@ type (
 )
 
 func newBaseRpcServer(address string, rpcServerOpts *rpcServerOptions) *baseRpcServer {
-	var h *health.Server
+	var h *healthServer
 	if rpcServerOpts.health {
-		h = health.NewServer()
+		h = newHealthServer(rpcServerOpts.healthCheckInterval)
 	}
 	return &baseRpcServer{
-		address: address,
-		health:  h,
-		metrics: rpcServerOpts.metrics,
+		address:       address,
+		health:        h,
+		metrics:       rpcServerOpts.metrics,
+		streamMetrics: stat.NewMetrics(address + streamMetricsSuffix),
 		options: []grpc.ServerOption{grpc.KeepaliveParams(keepalive.ServerParameters{
 			MaxConnectionIdle: defaultConnectionIdleDuration,
 		})},
 	}
 }
 
+// AddHealthCheck adds a check of the dependency of service,
+// the service is NOT_SERVING while the check fails.
+func (s *baseRpcServer) AddHealthCheck(service string, check HealthCheck) {
+	if s.health != nil {
+		s.health.AddCheck(service, check)
+	}
+}
+
 func (s *baseRpcServer) AddOptions(options ...grpc.ServerOption) {
 	s.options = append(s.options, options...)
 }
@@ -63,4 +80,12 @@ func (s *baseRpcServer) AddUnaryInterceptors(interceptors ...grpc.UnaryServerInt
 
 func (s *baseRpcServer) SetName(name string) {
 	s.metrics.SetName(name)
+	s.streamMetrics.SetName(name + streamMetricsSuffix)
+}
+
+// SetServingStatus sets the serving status of service in the health checks.
+func (s *baseRpcServer) SetServingStatus(service string, serving bool) {
+	if s.health != nil {
+		s.health.SetServingStatus(service, serving)
+	}
 }
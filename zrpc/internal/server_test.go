@@ -18,6 +18,14 @@ func TestBaseRpcServerAddOptions(t *testing.T) {
 	assert.Contains(t, server.options, opt)
 }
 
+func TestBaseRpcServerStreamMetrics(t *testing.T) {
+	metrics := stat.NewMetrics("foo")
+	server := newBaseRpcServer("foo", &rpcServerOptions{metrics: metrics})
+	server.SetName("bar")
+	assert.NotNil(t, server.streamMetrics)
+	assert.False(t, server.streamMetrics == server.metrics)
+}
+
 func TestBaseRpcServerAddStreamInterceptors(t *testing.T) {
 	metrics := stat.NewMetrics("foo")
 	server := newBaseRpcServer("foo", &rpcServerOptions{metrics: metrics})
@@ -5,6 +5,7 @@ import (
 	"errors"
 
 	"github.com/jialequ/linux-sdk/core/breaker"
+	"github.com/jialequ/linux-sdk/zrpc/internal/breakers"
 	"github.com/jialequ/linux-sdk/zrpc/internal/codes"
 	"google.golang.org/grpc"
 	gcodes "google.golang.org/grpc/codes"
@@ -35,3 +36,36 @@ func UnaryBreakerInterceptor(ctx context.Context, req any, info *grpc.UnaryServe
 
 	return resp, err
 }
+
+// StreamBreakersInterceptor returns an interceptor that acts as a circuit breaker
+// with the per-method rules in b.
+func StreamBreakersInterceptor(b *breakers.Breakers) grpc.StreamServerInterceptor {
+	return func(svr any, stream grpc.ServerStream, info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) error {
+		return b.Do(info.FullMethod, info.FullMethod, func() error {
+			return handler(svr, stream)
+		})
+	}
+}
+
+// UnaryBreakersInterceptor returns an interceptor that acts as a circuit breaker
+// with the per-method rules in b, the fallback response is returned if the breaker is open.
+func UnaryBreakersInterceptor(b *breakers.Breakers) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (resp any, err error) {
+		err = b.Do(info.FullMethod, info.FullMethod, func() error {
+			var err error
+			resp, err = handler(ctx, req)
+			return err
+		})
+		if errors.Is(err, breaker.ErrServiceUnavailable) {
+			if fallback, ok := b.Fallback(info.FullMethod, nil); ok {
+				return fallback, nil
+			}
+
+			err = status.Error(gcodes.Unavailable, err.Error())
+		}
+
+		return resp, err
+	}
+}
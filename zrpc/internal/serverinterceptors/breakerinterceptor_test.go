@@ -5,6 +5,8 @@ import (
 	"testing"
 
 	"github.com/jialequ/linux-sdk/core/breaker"
+	"github.com/jialequ/linux-sdk/internal/mock"
+	"github.com/jialequ/linux-sdk/zrpc/internal/breakers"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -37,3 +39,51 @@ func TestUnaryBreakerInterceptorUnavailable(t *testing.T) {
 	})
 	assert.NotNil(t, err)
 }
+
+func TestStreamBreakersInterceptor(t *testing.T) {
+	b, err := breakers.New(breakers.Conf{
+		Rules: []breakers.RuleConf{
+			{
+				FullMethod: "disabled",
+				Disabled:   true,
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	for i := 0; i < 1000; i++ {
+		err = StreamBreakersInterceptor(b)(nil, nil, &grpc.StreamServerInfo{
+			FullMethod: "disabled",
+		}, func(_ any, _ grpc.ServerStream) error {
+			return status.New(codes.DeadlineExceeded, "any").Err()
+		})
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	}
+}
+
+func TestUnaryBreakersInterceptor(t *testing.T) {
+	b, err := breakers.New(breakers.Conf{
+		Rules: []breakers.RuleConf{
+			{
+				FullMethod: "/mock.DepositService/Deposit",
+				Fallback:   `{"ok": true}`,
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	resp, err := UnaryBreakersInterceptor(b)(context.Background(), nil, &grpc.UnaryServerInfo{
+		FullMethod: "/mock.DepositService/Deposit",
+	}, func(_ context.Context, _ any) (any, error) {
+		return nil, breaker.ErrServiceUnavailable
+	})
+	assert.NoError(t, err)
+	assert.True(t, resp.(*mock.DepositResponse).Ok)
+
+	_, err = UnaryBreakersInterceptor(b)(context.Background(), nil, &grpc.UnaryServerInfo{
+		FullMethod: "any",
+	}, func(_ context.Context, _ any) (any, error) {
+		return nil, breaker.ErrServiceUnavailable
+	})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
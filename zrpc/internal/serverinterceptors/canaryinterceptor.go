@@ -0,0 +1,38 @@
+package serverinterceptors
+
+import (
+	"context"
+
+	"github.com/jialequ/linux-sdk/core/canary"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// StreamCanaryInterceptor is an interceptor that carries the traffic color on stream requests.
+func StreamCanaryInterceptor(svr any, ss grpc.ServerStream, _ *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+	return handler(svr, &contextServerStream{
+		ServerStream: ss,
+		ctx:          withCanary(ss.Context()),
+	})
+}
+
+// UnaryCanaryInterceptor is an interceptor that carries the traffic color on unary requests.
+func UnaryCanaryInterceptor(ctx context.Context, req any, _ *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (any, error) {
+	return handler(withCanary(ctx), req)
+}
+
+func withCanary(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+
+	vals := md.Get(canary.MetadataKey)
+	if len(vals) == 0 || !canary.IsValid(vals[0]) {
+		return ctx
+	}
+
+	return canary.NewContext(ctx, vals[0])
+}
@@ -0,0 +1,51 @@
+package serverinterceptors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jialequ/linux-sdk/core/canary"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestUnaryCanaryInterceptor(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs(canary.MetadataKey, "v2"))
+	_, err := UnaryCanaryInterceptor(ctx, nil, nil,
+		func(ctx context.Context, req any) (any, error) {
+			assert.Equal(t, "v2", canary.FromContext(ctx))
+			return nil, nil
+		})
+	assert.Nil(t, err)
+}
+
+func TestUnaryCanaryInterceptorInvalid(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs(canary.MetadataKey, "v 2"))
+	_, err := UnaryCanaryInterceptor(ctx, nil, nil,
+		func(ctx context.Context, req any) (any, error) {
+			assert.Empty(t, canary.FromContext(ctx))
+			return nil, nil
+		})
+	assert.Nil(t, err)
+
+	_, err = UnaryCanaryInterceptor(context.Background(), nil, nil,
+		func(ctx context.Context, req any) (any, error) {
+			assert.Empty(t, canary.FromContext(ctx))
+			return nil, nil
+		})
+	assert.Nil(t, err)
+}
+
+func TestStreamCanaryInterceptor(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs(canary.MetadataKey, "v2"))
+	err := StreamCanaryInterceptor(nil, &mockedServerStream{ctx: ctx}, nil,
+		func(svr any, stream grpc.ServerStream) error {
+			assert.Equal(t, "v2", canary.FromContext(stream.Context()))
+			return nil
+		})
+	assert.Nil(t, err)
+}
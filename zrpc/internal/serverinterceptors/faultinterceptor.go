@@ -0,0 +1,78 @@
+package serverinterceptors
+
+import (
+	"context"
+
+	"github.com/jialequ/linux-sdk/internal/fault"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// StreamFaultInterceptor returns a func that injects faults into stream requests.
+func StreamFaultInterceptor(injector *fault.Injector) grpc.StreamServerInterceptor {
+	return func(svr any, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) error {
+		ctx := ss.Context()
+		f, ok := injectFault(ctx, injector, info.FullMethod)
+		if !ok {
+			return handler(svr, ss)
+		}
+
+		if err := fault.DelayOrAbort(ctx, f, info.FullMethod); err != nil {
+			return err
+		}
+
+		if !f.Drop {
+			return handler(svr, ss)
+		}
+
+		if err := handler(svr, &droppedServerStream{ServerStream: ss}); err != nil {
+			return err
+		}
+
+		return fault.DropResponse(ctx, info.FullMethod)
+	}
+}
+
+// UnaryFaultInterceptor returns a func that injects faults into unary requests.
+func UnaryFaultInterceptor(injector *fault.Injector) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (any, error) {
+		f, ok := injectFault(ctx, injector, info.FullMethod)
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		if err := fault.DelayOrAbort(ctx, f, info.FullMethod); err != nil {
+			return nil, err
+		}
+
+		if !f.Drop {
+			return handler(ctx, req)
+		}
+
+		if _, err := handler(ctx, req); err != nil {
+			return nil, err
+		}
+
+		return nil, fault.DropResponse(ctx, info.FullMethod)
+	}
+}
+
+// droppedServerStream drops all the messages to send.
+type droppedServerStream struct {
+	grpc.ServerStream
+}
+
+func (s *droppedServerStream) SendMsg(_ any) error {
+	return nil
+}
+
+func injectFault(ctx context.Context, injector *fault.Injector, method string) (fault.Fault, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return fault.Fault{}, false
+	}
+
+	return injector.InjectMetadata(md, method)
+}
@@ -0,0 +1,132 @@
+package serverinterceptors
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jialequ/linux-sdk/internal/fault"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryFaultInterceptor(t *testing.T) {
+	injector, err := fault.NewInjector(fault.Conf{
+		Header: "X-Fault-Inject",
+		Rules: []fault.RuleConf{
+			{
+				Routes:  []string{"/foo/abort"},
+				Percent: 100,
+				Delay:   time.Millisecond,
+				Abort:   int(codes.Unavailable),
+			},
+			{
+				Routes:  []string{"/foo/drop"},
+				Percent: 100,
+				Drop:    true,
+			},
+		},
+	})
+	assert.Nil(t, err)
+	interceptor := UnaryFaultInterceptor(injector)
+	enabled := metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs("x-fault-inject", "true"))
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		method  string
+		code    codes.Code
+		handled bool
+	}{
+		{
+			name:    "not enabled",
+			ctx:     context.Background(),
+			method:  "/foo/abort",
+			code:    codes.OK,
+			handled: true,
+		},
+		{
+			name:    "not matched",
+			ctx:     enabled,
+			method:  "/foo/bar",
+			code:    codes.OK,
+			handled: true,
+		},
+		{
+			name:   "abort",
+			ctx:    enabled,
+			method: "/foo/abort",
+			code:   codes.Unavailable,
+		},
+		{
+			name:    "drop",
+			ctx:     enabled,
+			method:  "/foo/drop",
+			code:    codes.DeadlineExceeded,
+			handled: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(test.ctx, time.Millisecond*50)
+			defer cancel()
+
+			var handled bool
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{
+				FullMethod: test.method,
+			}, func(ctx context.Context, req any) (any, error) {
+				handled = true
+				return nil, nil
+			})
+			assert.Equal(t, test.code, status.Code(err))
+			assert.Equal(t, test.handled, handled)
+		})
+	}
+}
+
+func TestStreamFaultInterceptor(t *testing.T) {
+	injector, err := fault.NewInjector(fault.Conf{
+		Header: "X-Fault-Inject",
+		Rules: []fault.RuleConf{
+			{
+				Routes:  []string{"/foo/abort"},
+				Percent: 100,
+				Abort:   int(codes.Unavailable),
+			},
+			{
+				Routes:  []string{"/foo/drop"},
+				Percent: 100,
+				Drop:    true,
+			},
+		},
+	})
+	assert.Nil(t, err)
+	interceptor := StreamFaultInterceptor(injector)
+	ctx, cancel := context.WithTimeout(metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs("x-fault-inject", "true")), time.Millisecond*50)
+	defer cancel()
+
+	err = interceptor(nil, &mockedServerStream{ctx: ctx}, &grpc.StreamServerInfo{
+		FullMethod: "/foo/abort",
+	}, func(svr any, stream grpc.ServerStream) error {
+		t.Fatal("should not be handled")
+		return nil
+	})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	var handled bool
+	err = interceptor(nil, &mockedServerStream{ctx: ctx}, &grpc.StreamServerInfo{
+		FullMethod: "/foo/drop",
+	}, func(svr any, stream grpc.ServerStream) error {
+		handled = true
+		return stream.SendMsg(nil)
+	})
+	assert.True(t, handled)
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+}
@@ -0,0 +1,37 @@
+package serverinterceptors
+
+import (
+	"context"
+
+	"github.com/jialequ/linux-sdk/zrpc/internal/auth"
+	"google.golang.org/grpc"
+)
+
+// StreamJwtAuthInterceptor returns a func that uses given authenticator in processing stream requests.
+func StreamJwtAuthInterceptor(authenticator *auth.JwtAuthenticator) grpc.StreamServerInterceptor {
+	return func(svr any, stream grpc.ServerStream, info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) error {
+		ctx, err := authenticator.Authenticate(stream.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+
+		return handler(svr, &contextServerStream{
+			ServerStream: stream,
+			ctx:          ctx,
+		})
+	}
+}
+
+// UnaryJwtAuthInterceptor returns a func that uses given authenticator in processing unary requests.
+func UnaryJwtAuthInterceptor(authenticator *auth.JwtAuthenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticator.Authenticate(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,77 @@
+package serverinterceptors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/jialequ/linux-sdk/zrpc/internal/auth"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryJwtAuthInterceptor(t *testing.T) {
+	authenticator, err := auth.NewJwtAuthenticator(auth.JwtConf{
+		Secret: "secret",
+		Rules: []auth.AclRuleConf{
+			{
+				FullMethod: "/foo",
+				Roles:      []string{"admin"},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	interceptor := UnaryJwtAuthInterceptor(authenticator)
+	handler := func(ctx context.Context, req any) (any, error) {
+		claims, ok := auth.ClaimsFromContext(ctx)
+		assert.True(t, ok)
+		return claims["sub"], nil
+	}
+
+	_, err = interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/foo"}, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := jwtContext(t, jwt.MapClaims{"sub": "bar"})
+	_, err = interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/foo"}, handler)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	ctx = jwtContext(t, jwt.MapClaims{"sub": "bar", "roles": []string{"admin"}})
+	resp, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/foo"}, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", resp)
+}
+
+func TestStreamJwtAuthInterceptor(t *testing.T) {
+	authenticator, err := auth.NewJwtAuthenticator(auth.JwtConf{
+		Secret: "secret",
+	})
+	assert.NoError(t, err)
+
+	interceptor := StreamJwtAuthInterceptor(authenticator)
+	handler := func(srv any, stream grpc.ServerStream) error {
+		claims, ok := auth.ClaimsFromContext(stream.Context())
+		assert.True(t, ok)
+		assert.Equal(t, "bar", claims["sub"])
+		return nil
+	}
+
+	err = interceptor(nil, &mockedServerStream{ctx: context.Background()},
+		&grpc.StreamServerInfo{FullMethod: "/foo"}, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	err = interceptor(nil, &mockedServerStream{ctx: jwtContext(t, jwt.MapClaims{"sub": "bar"})},
+		&grpc.StreamServerInfo{FullMethod: "/foo"}, handler)
+	assert.NoError(t, err)
+}
+
+func jwtContext(t *testing.T, claims jwt.MapClaims) context.Context {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret"))
+	assert.NoError(t, err)
+
+	return metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs("authorization", "Bearer "+token))
+}
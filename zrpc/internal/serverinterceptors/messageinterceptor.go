@@ -0,0 +1,120 @@
+package serverinterceptors
+
+import (
+	"context"
+
+	"github.com/jialequ/linux-sdk/core/logx"
+	"github.com/jialequ/linux-sdk/zrpc/internal/message"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+type messageServerStream struct {
+	grpc.ServerStream
+	maxRecvSize int
+	maxSendSize int
+}
+
+func (s *messageServerStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	return checkRecvSize(m, s.maxRecvSize)
+}
+
+func (s *messageServerStream) SendMsg(m any) error {
+	if err := checkSendSize(m, s.maxSendSize); err != nil {
+		return err
+	}
+
+	return s.ServerStream.SendMsg(m)
+}
+
+// StreamMessageInterceptor returns an interceptor that applies the size limits and
+// the compressor of the methods in m on streams.
+// The server options should be set with m.MaxRecvSize and m.MaxSendSize.
+func StreamMessageInterceptor(m *message.Matcher) grpc.StreamServerInterceptor {
+	return func(svr any, stream grpc.ServerStream, info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) error {
+		mc, _ := m.Match(info.FullMethod)
+		setSendCompressor(stream.Context(), mc.Compressor)
+		recv, send := m.ServerLimits(info.FullMethod)
+		if recv == 0 && send == 0 {
+			return handler(svr, stream)
+		}
+
+		return handler(svr, &messageServerStream{
+			ServerStream: stream,
+			maxRecvSize:  recv,
+			maxSendSize:  send,
+		})
+	}
+}
+
+// UnaryMessageInterceptor returns an interceptor that applies the size limits and
+// the compressor of the methods in m.
+// The server options should be set with m.MaxRecvSize and m.MaxSendSize.
+func UnaryMessageInterceptor(m *message.Matcher) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (any, error) {
+		mc, _ := m.Match(info.FullMethod)
+		setSendCompressor(ctx, mc.Compressor)
+		recv, send := m.ServerLimits(info.FullMethod)
+		if err := checkRecvSize(req, recv); err != nil {
+			return nil, err
+		}
+
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return resp, err
+		}
+
+		if err = checkSendSize(resp, send); err != nil {
+			return nil, err
+		}
+
+		return resp, nil
+	}
+}
+
+// checkRecvSize checks the size of the received message m, the messages larger than
+// the server limit are rejected by grpc before the interceptors.
+func checkRecvSize(m any, limit int) error {
+	if limit <= 0 {
+		return nil
+	}
+
+	if msg, ok := m.(proto.Message); ok {
+		if size := proto.Size(msg); size > limit {
+			return message.ErrRecvTooLarge(size, limit)
+		}
+	}
+
+	return nil
+}
+
+func checkSendSize(m any, limit int) error {
+	if limit <= 0 {
+		return nil
+	}
+
+	if msg, ok := m.(proto.Message); ok {
+		if size := proto.Size(msg); size > limit {
+			return message.ErrSendTooLarge(size, limit)
+		}
+	}
+
+	return nil
+}
+
+func setSendCompressor(ctx context.Context, name string) {
+	if len(name) == 0 {
+		return
+	}
+
+	// the messages are sent without compression if the caller doesn't support the compressor.
+	if err := grpc.SetSendCompressor(ctx, name); err != nil {
+		logx.WithContext(ctx).Debugf("compressor %s not applied, error: %v", name, err)
+	}
+}
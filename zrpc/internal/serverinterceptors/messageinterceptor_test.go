@@ -0,0 +1,91 @@
+package serverinterceptors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jialequ/linux-sdk/internal/mock"
+	"github.com/jialequ/linux-sdk/zrpc/internal/message"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryMessageInterceptor(t *testing.T) {
+	m, err := message.NewMatcher(message.Conf{
+		Compressor: message.Gzip,
+		Methods: []message.MethodConf{
+			{
+				FullMethod:  "/recv",
+				MaxRecvSize: 1,
+			},
+			{
+				FullMethod:  "/send",
+				MaxSendSize: 1,
+			},
+			{
+				FullMethod:  "/large",
+				MaxRecvSize: 16 << 20,
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	req := &mock.DepositRequest{Amount: 100}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return &mock.DepositResponse{Ok: true}, nil
+	}
+	interceptor := UnaryMessageInterceptor(m)
+
+	_, err = interceptor(context.Background(), req, &grpc.UnaryServerInfo{FullMethod: "/recv"}, handler)
+	assert.True(t, message.IsTooLarge(err))
+
+	_, err = interceptor(context.Background(), req, &grpc.UnaryServerInfo{FullMethod: "/send"}, handler)
+	assert.True(t, message.IsTooLarge(err))
+
+	resp, err := interceptor(context.Background(), req, &grpc.UnaryServerInfo{FullMethod: "/large"}, handler)
+	assert.NoError(t, err)
+	assert.True(t, resp.(*mock.DepositResponse).Ok)
+
+	_, err = interceptor(context.Background(), req, &grpc.UnaryServerInfo{FullMethod: "/large"},
+		func(ctx context.Context, req any) (any, error) {
+			return nil, status.Error(codes.Internal, "mock")
+		})
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
+
+func TestStreamMessageInterceptor(t *testing.T) {
+	m, err := message.NewMatcher(message.Conf{
+		Methods: []message.MethodConf{
+			{
+				FullMethod:  "/limited",
+				MaxRecvSize: 1,
+				MaxSendSize: 1,
+			},
+			{
+				FullMethod:  "/large",
+				MaxRecvSize: 16 << 20,
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	interceptor := StreamMessageInterceptor(m)
+	err = interceptor(nil, new(mockedServerStream), &grpc.StreamServerInfo{FullMethod: "/limited"},
+		func(_ any, stream grpc.ServerStream) error {
+			assert.True(t, message.IsTooLarge(stream.SendMsg(&mock.DepositResponse{Ok: true})))
+			assert.NoError(t, stream.SendMsg(&mock.DepositResponse{}))
+			assert.True(t, message.IsTooLarge(stream.RecvMsg(&mock.DepositRequest{Amount: 100})))
+			return nil
+		})
+	assert.NoError(t, err)
+
+	err = interceptor(nil, new(mockedServerStream), &grpc.StreamServerInfo{FullMethod: "/large"},
+		func(_ any, stream grpc.ServerStream) error {
+			_, ok := stream.(*mockedServerStream)
+			assert.True(t, ok)
+			return nil
+		})
+	assert.NoError(t, err)
+}
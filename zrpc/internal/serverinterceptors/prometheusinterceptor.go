@@ -7,6 +7,7 @@ import (
 	"github.com/jialequ/linux-sdk/core/metric"
 	"github.com/jialequ/linux-sdk/core/timex"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/stats"
 	"google.golang.org/grpc/status"
 )
 
@@ -29,8 +30,84 @@ var (
 		Help:      "rpc server requests code count.",
 		Labels:    []string{"method", "code"},
 	})
+
+	metricServerStreamMsgReceived = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: serverNamespace,
+		Subsystem: "stream",
+		Name:      "msg_received_total",
+		Help:      "rpc server stream messages received count.",
+		Labels:    []string{"method"},
+	})
+
+	metricServerStreamMsgSent = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: serverNamespace,
+		Subsystem: "stream",
+		Name:      "msg_sent_total",
+		Help:      "rpc server stream messages sent count.",
+		Labels:    []string{"method"},
+	})
+
+	metricServerMsgBytes = metric.NewHistogramVec(&metric.HistogramVecOpts{
+		Namespace: serverNamespace,
+		Subsystem: "messages",
+		Name:      "bytes",
+		Help:      "rpc server uncompressed message bytes.",
+		Labels:    []string{"method", "direction"},
+		Buckets:   []float64{64, 256, 1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20, 4 << 20, 16 << 20},
+	})
+
+	metricServerMsgCompressionRatio = metric.NewHistogramVec(&metric.HistogramVecOpts{
+		Namespace: serverNamespace,
+		Subsystem: "messages",
+		Name:      "compression_ratio",
+		Help:      "rpc server compressed bytes to uncompressed bytes ratio of compressed messages.",
+		Labels:    []string{"method", "direction"},
+		Buckets:   []float64{0.05, 0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9, 1, 1.5},
+	})
+)
+
+type (
+	serverPrometheusStatsHandler struct{}
+
+	serverStatsMethodKey struct{}
 )
 
+type monitoredServerStream struct {
+	grpc.ServerStream
+	method string
+}
+
+func (s *monitoredServerStream) RecvMsg(m any) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		metricServerStreamMsgReceived.Inc(s.method)
+	}
+
+	return err
+}
+
+func (s *monitoredServerStream) SendMsg(m any) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		metricServerStreamMsgSent.Inc(s.method)
+	}
+
+	return err
+}
+
+// StreamPrometheusInterceptor reports the statistics of streams to the prometheus server.
+func StreamPrometheusInterceptor(svr any, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+	startTime := timex.Now()
+	err := handler(svr, &monitoredServerStream{
+		ServerStream: ss,
+		method:       info.FullMethod,
+	})
+	metricServerReqDur.Observe(timex.Since(startTime).Milliseconds(), info.FullMethod)
+	metricServerReqCodeTotal.Inc(info.FullMethod, strconv.Itoa(int(status.Code(err))))
+	return err
+}
+
 // UnaryPrometheusInterceptor reports the statistics to the prometheus server.
 func UnaryPrometheusInterceptor(ctx context.Context, req any,
 	info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
@@ -40,3 +117,39 @@ func UnaryPrometheusInterceptor(ctx context.Context, req any,
 	metricServerReqCodeTotal.Inc(info.FullMethod, strconv.Itoa(int(status.Code(err))))
 	return resp, err
 }
+
+// PrometheusStatsHandler returns a stats.Handler that reports the message bytes and
+// the compression ratio to prometheus server, which are not visible to the interceptors.
+func PrometheusStatsHandler() stats.Handler {
+	return serverPrometheusStatsHandler{}
+}
+
+func (h serverPrometheusStatsHandler) HandleConn(context.Context, stats.ConnStats) {
+}
+
+func (h serverPrometheusStatsHandler) HandleRPC(ctx context.Context, rs stats.RPCStats) {
+	method, _ := ctx.Value(serverStatsMethodKey{}).(string)
+	switch s := rs.(type) {
+	case *stats.InPayload:
+		observeServerMessage(method, "recv", s.Length, s.CompressedLength)
+	case *stats.OutPayload:
+		observeServerMessage(method, "send", s.Length, s.CompressedLength)
+	}
+}
+
+func (h serverPrometheusStatsHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (h serverPrometheusStatsHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	return context.WithValue(ctx, serverStatsMethodKey{}, info.FullMethodName)
+}
+
+func observeServerMessage(method, direction string, length, compressedLength int) {
+	metricServerMsgBytes.Observe(int64(length), method, direction)
+	// the compressed length equals to the length if not compressed.
+	if length > 0 && compressedLength != length {
+		metricServerMsgCompressionRatio.ObserveFloat(float64(compressedLength)/float64(length),
+			method, direction)
+	}
+}
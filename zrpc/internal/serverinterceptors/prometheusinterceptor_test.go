@@ -7,6 +7,7 @@ import (
 	"github.com/jialequ/linux-sdk/core/prometheus"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/stats"
 )
 
 func TestUnaryPromMetricInterceptorDisabled(t *testing.T) {
@@ -30,3 +31,37 @@ func TestUnaryPromMetricInterceptorEnabled(t *testing.T) {
 	})
 	assert.Nil(t, err)
 }
+
+func TestStreamPromMetricInterceptor(t *testing.T) {
+	prometheus.StartAgent(prometheus.Config{
+		Host: "localhost",
+		Path: "/",
+	})
+	err := StreamPrometheusInterceptor(nil, new(mockedServerStream), &grpc.StreamServerInfo{
+		FullMethod: "/",
+	}, func(svr any, stream grpc.ServerStream) error {
+		assert.Nil(t, stream.RecvMsg(nil))
+		assert.Nil(t, stream.SendMsg(nil))
+		return nil
+	})
+	assert.Nil(t, err)
+}
+
+func TestPrometheusStatsHandler(t *testing.T) {
+	prometheus.StartAgent(prometheus.Config{
+		Host: "localhost",
+		Path: "/",
+	})
+
+	h := PrometheusStatsHandler()
+	ctx := h.TagConn(context.Background(), &stats.ConnTagInfo{})
+	h.HandleConn(ctx, &stats.ConnBegin{})
+	ctx = h.TagRPC(ctx, &stats.RPCTagInfo{FullMethodName: "/foo"})
+	assert.Equal(t, "/foo", ctx.Value(serverStatsMethodKey{}))
+	assert.NotPanics(t, func() {
+		h.HandleRPC(ctx, &stats.Begin{})
+		h.HandleRPC(ctx, &stats.InPayload{Length: 100, CompressedLength: 20})
+		h.HandleRPC(ctx, &stats.OutPayload{Length: 100, CompressedLength: 100})
+		h.HandleRPC(ctx, &stats.OutPayload{})
+	})
+}
@@ -0,0 +1,32 @@
+package serverinterceptors
+
+import (
+	"context"
+
+	"github.com/jialequ/linux-sdk/zrpc/internal/ratelimit"
+	"google.golang.org/grpc"
+)
+
+// StreamRateLimitInterceptor returns a func that limits the stream requests with given limiter.
+func StreamRateLimitInterceptor(limiter *ratelimit.Limiter) grpc.StreamServerInterceptor {
+	return func(svr any, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) error {
+		if err := limiter.Allow(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+
+		return handler(svr, ss)
+	}
+}
+
+// UnaryRateLimitInterceptor returns a func that limits the unary requests with given limiter.
+func UnaryRateLimitInterceptor(limiter *ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (any, error) {
+		if err := limiter.Allow(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,59 @@
+package serverinterceptors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jialequ/linux-sdk/zrpc/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryRateLimitInterceptor(t *testing.T) {
+	limiter, err := ratelimit.NewLimiter(ratelimit.Conf{
+		Rules: []ratelimit.RuleConf{
+			{
+				KeyBy: ratelimit.KeyByApp,
+				Rate:  1,
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	interceptor := UnaryRateLimitInterceptor(limiter)
+	call := func() error {
+		_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{
+			FullMethod: "/foo",
+		}, func(ctx context.Context, req any) (any, error) {
+			return nil, nil
+		})
+		return err
+	}
+	assert.NoError(t, call())
+	assert.Equal(t, codes.ResourceExhausted, status.Code(call()))
+}
+
+func TestStreamRateLimitInterceptor(t *testing.T) {
+	limiter, err := ratelimit.NewLimiter(ratelimit.Conf{
+		Rules: []ratelimit.RuleConf{
+			{
+				KeyBy: ratelimit.KeyByApp,
+				Rate:  1,
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	interceptor := StreamRateLimitInterceptor(limiter)
+	call := func() error {
+		return interceptor(nil, new(mockedServerStream), &grpc.StreamServerInfo{
+			FullMethod: "/foo",
+		}, func(svr any, stream grpc.ServerStream) error {
+			return nil
+		})
+	}
+	assert.NoError(t, call())
+	assert.Equal(t, codes.ResourceExhausted, status.Code(call()))
+}
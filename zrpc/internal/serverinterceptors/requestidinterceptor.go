@@ -0,0 +1,49 @@
+package serverinterceptors
+
+import (
+	"context"
+
+	"github.com/jialequ/linux-sdk/core/requestid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// contextServerStream wraps around the embedded grpc.ServerStream,
+// and overrides its context with the given one.
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// StreamRequestIdInterceptor is an interceptor that carries the request id on stream requests.
+func StreamRequestIdInterceptor(svr any, ss grpc.ServerStream, _ *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+	return handler(svr, &contextServerStream{
+		ServerStream: ss,
+		ctx:          withRequestId(ss.Context()),
+	})
+}
+
+// UnaryRequestIdInterceptor is an interceptor that carries the request id on unary requests.
+func UnaryRequestIdInterceptor(ctx context.Context, req any, _ *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (any, error) {
+	return handler(withRequestId(ctx), req)
+}
+
+func withRequestId(ctx context.Context) context.Context {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if vals := md.Get(requestid.MetadataKey); len(vals) > 0 {
+			id = vals[0]
+		}
+	}
+	if !requestid.IsValid(id) {
+		id = requestid.New()
+	}
+
+	return requestid.NewContext(ctx, id)
+}
@@ -0,0 +1,42 @@
+package serverinterceptors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jialequ/linux-sdk/core/requestid"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestUnaryRequestIdInterceptor(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs(requestid.MetadataKey, "foo"))
+	_, err := UnaryRequestIdInterceptor(ctx, nil, nil,
+		func(ctx context.Context, req any) (any, error) {
+			assert.Equal(t, "foo", requestid.FromContext(ctx))
+			return nil, nil
+		})
+	assert.Nil(t, err)
+}
+
+func TestUnaryRequestIdInterceptorGenerate(t *testing.T) {
+	_, err := UnaryRequestIdInterceptor(context.Background(), nil, nil,
+		func(ctx context.Context, req any) (any, error) {
+			assert.True(t, requestid.IsValid(requestid.FromContext(ctx)))
+			return nil, nil
+		})
+	assert.Nil(t, err)
+}
+
+func TestStreamRequestIdInterceptor(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs(requestid.MetadataKey, "foo"))
+	err := StreamRequestIdInterceptor(nil, &mockedServerStream{ctx: ctx}, nil,
+		func(svr any, stream grpc.ServerStream) error {
+			assert.Equal(t, "foo", requestid.FromContext(stream.Context()))
+			return nil
+		})
+	assert.Nil(t, err)
+}
@@ -19,6 +19,36 @@ var (
 	lock         sync.Mutex
 )
 
+// StreamSheddingInterceptor returns a func that does load shedding on processing stream requests.
+func StreamSheddingInterceptor(shedder load.Shedder, metrics *stat.Metrics) grpc.StreamServerInterceptor {
+	ensureSheddingStat()
+
+	return func(svr any, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) (err error) {
+		sheddingStat.IncrementTotal()
+		var promise load.Promise
+		promise, err = shedder.Allow()
+		if err != nil {
+			metrics.AddDrop()
+			sheddingStat.IncrementDrop()
+			return status.Error(codes.ResourceExhausted, err.Error())
+		}
+
+		// the promise is resolved once the stream is set up, otherwise the lifetimes of
+		// the long-lived streams are taken as the latencies, and the streams are counted
+		// as in flight until they end, which sheds the following requests.
+		stream := &sheddingServerStream{
+			ServerStream: ss,
+			promise:      promise,
+		}
+		defer func() {
+			stream.resolve(err)
+		}()
+
+		return handler(svr, stream)
+	}
+}
+
 // UnarySheddingInterceptor returns a func that does load shedding on processing unary requests.
 func UnarySheddingInterceptor(shedder load.Shedder, metrics *stat.Metrics) grpc.UnaryServerInterceptor {
 	ensureSheddingStat()
@@ -48,6 +78,37 @@ func UnarySheddingInterceptor(shedder load.Shedder, metrics *stat.Metrics) grpc.
 	}
 }
 
+// sheddingServerStream wraps around the embedded grpc.ServerStream,
+// and resolves the promise once the first message is received or sent.
+type sheddingServerStream struct {
+	grpc.ServerStream
+	promise load.Promise
+	once    sync.Once
+}
+
+func (s *sheddingServerStream) RecvMsg(m any) error {
+	err := s.ServerStream.RecvMsg(m)
+	s.resolve(err)
+	return err
+}
+
+func (s *sheddingServerStream) SendMsg(m any) error {
+	err := s.ServerStream.SendMsg(m)
+	s.resolve(err)
+	return err
+}
+
+func (s *sheddingServerStream) resolve(err error) {
+	s.once.Do(func() {
+		if errors.Is(err, context.DeadlineExceeded) {
+			s.promise.Fail()
+		} else {
+			sheddingStat.IncrementPass()
+			s.promise.Pass()
+		}
+	})
+}
+
 func ensureSheddingStat() {
 	lock.Lock()
 	if sheddingStat == nil {
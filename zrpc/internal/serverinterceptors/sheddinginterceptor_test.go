@@ -2,6 +2,7 @@ package serverinterceptors
 
 import (
 	"context"
+	"sync/atomic"
 	"testing"
 
 	"github.com/jialequ/linux-sdk/core/load"
@@ -57,6 +58,111 @@ func TestUnarySheddingInterceptor(t *testing.T) {
 	}
 }
 
+func TestStreamSheddingInterceptor(t *testing.T) {
+	tests := []struct {
+		name      string
+		allow     bool
+		handleErr error
+		expect    error
+	}{
+		{
+			name:   "allow",
+			allow:  true,
+			expect: nil,
+		},
+		{
+			name:      "allow with deadline exceeded",
+			allow:     true,
+			handleErr: context.DeadlineExceeded,
+			expect:    context.DeadlineExceeded,
+		},
+		{
+			name:   "reject",
+			allow:  false,
+			expect: status.Error(codes.ResourceExhausted, load.ErrServiceOverloaded.Error()),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			shedder := mockedShedder{allow: test.allow}
+			metrics := stat.NewMetrics("mock")
+			interceptor := StreamSheddingInterceptor(shedder, metrics)
+			err := interceptor(nil, new(mockedServerStream), &grpc.StreamServerInfo{
+				FullMethod: "/",
+			}, func(svr any, stream grpc.ServerStream) error {
+				return test.handleErr
+			})
+			assert.Equal(t, test.expect, err)
+		})
+	}
+}
+
+func TestStreamSheddingInterceptorResolveOnSetup(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		passes int32
+		fails  int32
+	}{
+		{
+			name:   "pass",
+			passes: 1,
+		},
+		{
+			name:  "fail",
+			err:   context.DeadlineExceeded,
+			fails: 1,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			promise := new(countedPromise)
+			interceptor := StreamSheddingInterceptor(countedShedder{promise: promise},
+				stat.NewMetrics("mock"))
+			err := interceptor(nil, &mockedServerStream{err: test.err}, &grpc.StreamServerInfo{
+				FullMethod: "/",
+			}, func(svr any, stream grpc.ServerStream) error {
+				assert.Equal(t, test.err, stream.RecvMsg(nil))
+				// resolved once the first message is received, before the stream ends.
+				assert.Equal(t, test.passes, promise.passes.Load())
+				assert.Equal(t, test.fails, promise.fails.Load())
+				assert.Equal(t, test.err, stream.SendMsg(nil))
+				return nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, test.passes, promise.passes.Load())
+			assert.Equal(t, test.fails, promise.fails.Load())
+		})
+	}
+}
+
+type countedShedder struct {
+	promise *countedPromise
+}
+
+func (s countedShedder) Allow() (load.Promise, error) {
+	return s.promise, nil
+}
+
+type countedPromise struct {
+	passes atomic.Int32
+	fails  atomic.Int32
+}
+
+func (p *countedPromise) Pass() {
+	p.passes.Add(1)
+}
+
+func (p *countedPromise) Fail() {
+	p.fails.Add(1)
+}
+
 type mockedShedder struct {
 	allow bool
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jialequ/linux-sdk/core/collection"
@@ -29,6 +30,36 @@ type StatConf struct {
 	IgnoreContentMethods []string      `json:",optional"`
 }
 
+// countedServerStream wraps around the embedded grpc.ServerStream,
+// and counts the messages received and sent successfully.
+type countedServerStream struct {
+	grpc.ServerStream
+	received atomic.Int64
+	sent     atomic.Int64
+}
+
+func (s *countedServerStream) RecvMsg(m any) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.received.Add(1)
+	}
+
+	return err
+}
+
+func (s *countedServerStream) SendMsg(m any) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.sent.Add(1)
+	}
+
+	return err
+}
+
+func (s *countedServerStream) counts() (received, sent int64) {
+	return s.received.Load(), s.sent.Load()
+}
+
 // DontLogContentForMethod disable logging content for given method.
 // Deprecated: use StatConf instead.
 func DontLogContentForMethod(method string) {
@@ -41,6 +72,30 @@ func SetSlowThreshold(threshold time.Duration) {
 	slowThreshold.Set(threshold)
 }
 
+// StreamStatInterceptor returns a func that uses given metrics to report stats on streams,
+// metrics should not be shared with the unary calls, because the durations are the lifetimes
+// of the streams.
+func StreamStatInterceptor(metrics *stat.Metrics, conf StatConf) grpc.StreamServerInterceptor {
+	staticNotLoggingContentMethods := collection.NewSet()
+	staticNotLoggingContentMethods.AddStr(conf.IgnoreContentMethods...)
+
+	return func(svr any, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) error {
+		stream := &countedServerStream{ServerStream: ss}
+		startTime := timex.Now()
+		defer func() {
+			duration := timex.Since(startTime)
+			metrics.Add(stat.Task{
+				Duration: duration,
+			})
+			logStreamDuration(ss.Context(), info.FullMethod, stream, duration,
+				staticNotLoggingContentMethods, conf.SlowThreshold)
+		}()
+
+		return handler(svr, stream)
+	}
+}
+
 // UnaryStatInterceptor returns a func that uses given metrics to report stats.
 func UnaryStatInterceptor(metrics *stat.Metrics, conf StatConf) grpc.UnaryServerInterceptor {
 	staticNotLoggingContentMethods := collection.NewSet()
@@ -92,6 +147,24 @@ func logDuration(ctx context.Context, method string, req any, duration time.Dura
 	}
 }
 
+func logStreamDuration(ctx context.Context, method string, stream *countedServerStream,
+	duration time.Duration, ignoreMethods *collection.Set, durationThreshold time.Duration) {
+	var addr string
+	client, ok := peer.FromContext(ctx)
+	if ok {
+		addr = client.Addr.String()
+	}
+
+	logger := logx.WithContext(ctx).WithDuration(duration)
+	received, sent := stream.counts()
+	if isSlow(duration, durationThreshold) {
+		logger.Slowf("[RPC] slowcall - %s - %s - received: %d, sent: %d",
+			addr, method, received, sent)
+	} else if shouldLogContent(method, ignoreMethods) {
+		logger.Infof("%s - %s - received: %d, sent: %d", addr, method, received, sent)
+	}
+}
+
 func shouldLogContent(method string, ignoreMethods *collection.Set) bool {
 	_, ok := ignoreContentMethods.Load(method)
 	return !ok && !ignoreMethods.Contains(method)
@@ -37,6 +37,74 @@ func TestUnaryStatInterceptor(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestStreamStatInterceptor(t *testing.T) {
+	metrics := stat.NewMetrics("mock")
+	interceptor := StreamStatInterceptor(metrics, StatConf{})
+	err := interceptor(nil, new(mockedServerStream), &grpc.StreamServerInfo{
+		FullMethod: "/",
+	}, func(svr any, stream grpc.ServerStream) error {
+		assert.Nil(t, stream.RecvMsg(nil))
+		assert.Nil(t, stream.SendMsg(nil))
+		assert.Nil(t, stream.SendMsg(nil))
+		received, sent := stream.(*countedServerStream).counts()
+		assert.Equal(t, int64(1), received)
+		assert.Equal(t, int64(2), sent)
+		return nil
+	})
+	assert.Nil(t, err)
+}
+
+func TestLogStreamDuration(t *testing.T) {
+	addrs, err := net.InterfaceAddrs()
+	assert.Nil(t, err)
+	assert.True(t, len(addrs) > 0)
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		duration  time.Duration
+		threshold time.Duration
+		ignore    bool
+	}{
+		{
+			name: "normal",
+			ctx:  context.Background(),
+		},
+		{
+			name:     "slow",
+			ctx:      context.Background(),
+			duration: defaultSlowThreshold + time.Second,
+		},
+		{
+			name:      "slow by threshold",
+			ctx:       peer.NewContext(context.Background(), &peer.Peer{Addr: addrs[0]}),
+			duration:  time.Second,
+			threshold: time.Millisecond,
+		},
+		{
+			name:   "ignore content",
+			ctx:    context.Background(),
+			ignore: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ignoreMethods := collection.NewSet()
+			if test.ignore {
+				ignoreMethods.AddStr("foo")
+			}
+			assert.NotPanics(t, func() {
+				logStreamDuration(test.ctx, "foo", new(countedServerStream), test.duration,
+					ignoreMethods, test.threshold)
+			})
+		})
+	}
+}
+
 func TestLogDuration(t *testing.T) {
 	addrs, err := net.InterfaceAddrs()
 	assert.Nil(t, err)
@@ -24,6 +24,34 @@ type (
 	methodTimeouts map[string]time.Duration
 )
 
+// StreamTimeoutInterceptor returns a func that sets timeout to incoming stream requests.
+// Streams are usually long-lived, so only the methods in methodTimeouts are limited.
+func StreamTimeoutInterceptor(methodTimeouts ...MethodTimeoutConf) grpc.StreamServerInterceptor {
+	timeouts := buildMethodTimeouts(methodTimeouts)
+	return func(svr any, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) error {
+		t, ok := timeouts[info.FullMethod]
+		if !ok || t <= 0 {
+			return handler(svr, ss)
+		}
+
+		ctx, cancel := context.WithTimeout(ss.Context(), t)
+		defer cancel()
+
+		// the handler runs in the calling goroutine, because the stream can't be used
+		// after the rpc returns, the handler is expected to return on ctx done.
+		err := handler(svr, &timeoutServerStream{
+			ServerStream: ss,
+			ctx:          ctx,
+		})
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return toTimeoutError(ctxErr)
+		}
+
+		return err
+	}
+}
+
 // UnaryTimeoutInterceptor returns a func that sets timeout to incoming unary requests.
 func UnaryTimeoutInterceptor(timeout time.Duration,
 	methodTimeouts ...MethodTimeoutConf) grpc.UnaryServerInterceptor {
@@ -62,17 +90,37 @@ func UnaryTimeoutInterceptor(timeout time.Duration,
 			defer lock.Unlock()
 			return resp, err
 		case <-ctx.Done():
-			err := ctx.Err()
-			if errors.Is(err, context.Canceled) {
-				err = status.Error(codes.Canceled, err.Error())
-			} else if errors.Is(err, context.DeadlineExceeded) {
-				err = status.Error(codes.DeadlineExceeded, err.Error())
-			}
-			return nil, err
+			return nil, toTimeoutError(ctx.Err())
 		}
 	}
 }
 
+// timeoutServerStream carries the deadline of the stream, and fails the messages after the deadline.
+type timeoutServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *timeoutServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *timeoutServerStream) RecvMsg(m any) error {
+	if err := s.ctx.Err(); err != nil {
+		return toTimeoutError(err)
+	}
+
+	return s.ServerStream.RecvMsg(m)
+}
+
+func (s *timeoutServerStream) SendMsg(m any) error {
+	if err := s.ctx.Err(); err != nil {
+		return toTimeoutError(err)
+	}
+
+	return s.ServerStream.SendMsg(m)
+}
+
 func buildMethodTimeouts(timeouts []MethodTimeoutConf) methodTimeouts {
 	mt := make(methodTimeouts, len(timeouts))
 	for _, st := range timeouts {
@@ -84,6 +132,16 @@ func buildMethodTimeouts(timeouts []MethodTimeoutConf) methodTimeouts {
 	return mt
 }
 
+func toTimeoutError(err error) error {
+	if errors.Is(err, context.Canceled) {
+		return status.Error(codes.Canceled, err.Error())
+	} else if errors.Is(err, context.DeadlineExceeded) {
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+
+	return err
+}
+
 func getTimeoutByUnaryServerInfo(method string, timeouts methodTimeouts,
 	defaultTimeout time.Duration) time.Duration {
 	if v, ok := timeouts[method]; ok {
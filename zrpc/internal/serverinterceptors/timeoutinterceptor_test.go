@@ -243,3 +243,66 @@ func TestUnaryTimeoutInterceptorSpecifiedTimeout(t *testing.T) {
 		})
 	}
 }
+
+func TestStreamTimeoutInterceptor(t *testing.T) {
+	interceptor := StreamTimeoutInterceptor(MethodTimeoutConf{
+		FullMethod: "/foo",
+		Timeout:    time.Millisecond * 10,
+	})
+
+	t.Run("not specified", func(t *testing.T) {
+		err := interceptor(nil, new(mockedServerStream), &grpc.StreamServerInfo{
+			FullMethod: "/bar",
+		}, func(svr any, stream grpc.ServerStream) error {
+			_, ok := stream.Context().Deadline()
+			assert.False(t, ok)
+			return nil
+		})
+		assert.Nil(t, err)
+	})
+
+	t.Run("in time", func(t *testing.T) {
+		err := interceptor(nil, new(mockedServerStream), &grpc.StreamServerInfo{
+			FullMethod: "/foo",
+		}, func(svr any, stream grpc.ServerStream) error {
+			_, ok := stream.Context().Deadline()
+			assert.True(t, ok)
+			return nil
+		})
+		assert.Nil(t, err)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		err := interceptor(nil, new(mockedServerStream), &grpc.StreamServerInfo{
+			FullMethod: "/foo",
+		}, func(svr any, stream grpc.ServerStream) error {
+			<-stream.Context().Done()
+			time.Sleep(time.Millisecond * 10)
+			return nil
+		})
+		assert.EqualValues(t, deadlineExceededErr, err)
+	})
+
+	t.Run("send after timeout", func(t *testing.T) {
+		var sendErr error
+		err := interceptor(nil, new(mockedServerStream), &grpc.StreamServerInfo{
+			FullMethod: "/foo",
+		}, func(svr any, stream grpc.ServerStream) error {
+			<-stream.Context().Done()
+			sendErr = stream.SendMsg(nil)
+			return sendErr
+		})
+		assert.EqualValues(t, deadlineExceededErr, err)
+		assert.EqualValues(t, deadlineExceededErr, sendErr)
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		assert.Panics(t, func() {
+			_ = interceptor(nil, new(mockedServerStream), &grpc.StreamServerInfo{
+				FullMethod: "/foo",
+			}, func(svr any, stream grpc.ServerStream) error {
+				panic("any")
+			})
+		})
+	})
+}
@@ -0,0 +1,84 @@
+package serverinterceptors
+
+import (
+	"context"
+
+	"github.com/jialequ/linux-sdk/zrpc/validate"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+type (
+	// fieldError is the error of a field, like the ones of protoc-gen-validate.
+	fieldError interface {
+		Field() string
+		Reason() string
+	}
+
+	validateServerStream struct {
+		grpc.ServerStream
+	}
+)
+
+func (s *validateServerStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	return validateMessage(m)
+}
+
+// StreamValidateInterceptor is an interceptor that validates the messages received on streams.
+func StreamValidateInterceptor(svr any, stream grpc.ServerStream, _ *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+	return handler(svr, &validateServerStream{ServerStream: stream})
+}
+
+// UnaryValidateInterceptor is an interceptor that validates the requests,
+// with their Validate methods or the rules annotated in their descriptors.
+func UnaryValidateInterceptor(ctx context.Context, req any, _ *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (any, error) {
+	if err := validateMessage(req); err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+func validateMessage(m any) error {
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return nil
+	}
+
+	err := validate.Validate(msg)
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	fe, ok := err.(fieldError)
+	if !ok {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	st, e := status.New(codes.InvalidArgument, err.Error()).WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{
+				Field:       fe.Field(),
+				Description: fe.Reason(),
+			},
+		},
+	})
+	if e != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return st.Err()
+}
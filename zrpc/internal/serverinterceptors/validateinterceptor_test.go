@@ -0,0 +1,127 @@
+package serverinterceptors
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/jialequ/linux-sdk/internal/mock"
+	"github.com/jialequ/linux-sdk/zrpc/validate"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryValidateInterceptor(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		code    codes.Code
+		details bool
+	}{
+		{
+			name: "valid",
+			code: codes.OK,
+		},
+		{
+			name: "violations",
+			err: &validate.Error{Violations: []validate.FieldViolation{
+				{Field: "amount", Description: "must be greater than 0"},
+			}},
+			code:    codes.InvalidArgument,
+			details: true,
+		},
+		{
+			name:    "field error",
+			err:     mockedFieldError{},
+			code:    codes.InvalidArgument,
+			details: true,
+		},
+		{
+			name: "plain error",
+			err:  errors.New("bad request"),
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "status error",
+			err:  status.Error(codes.FailedPrecondition, "mock"),
+			code: codes.FailedPrecondition,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var called bool
+			req := validatedRequest{DepositRequest: new(mock.DepositRequest), err: test.err}
+			_, err := UnaryValidateInterceptor(context.Background(), req, nil,
+				func(ctx context.Context, req any) (any, error) {
+					called = true
+					return nil, nil
+				})
+			assert.Equal(t, test.code, status.Code(err))
+			assert.Equal(t, test.err == nil, called)
+			if test.details {
+				st, _ := status.FromError(err)
+				assert.Len(t, st.Details(), 1)
+				_, ok := st.Details()[0].(*errdetails.BadRequest)
+				assert.True(t, ok)
+			}
+		})
+	}
+}
+
+func TestUnaryValidateInterceptorNotProto(t *testing.T) {
+	var called bool
+	_, err := UnaryValidateInterceptor(context.Background(), "foo", nil,
+		func(ctx context.Context, req any) (any, error) {
+			called = true
+			return nil, nil
+		})
+	assert.NoError(t, err)
+	assert.True(t, called)
+}
+
+func TestStreamValidateInterceptor(t *testing.T) {
+	err := StreamValidateInterceptor(nil, new(mockedServerStream), nil,
+		func(_ any, stream grpc.ServerStream) error {
+			req := validatedRequest{DepositRequest: new(mock.DepositRequest), err: errors.New("bad")}
+			assert.Equal(t, codes.InvalidArgument, status.Code(stream.RecvMsg(req)))
+			req.err = nil
+			assert.NoError(t, stream.RecvMsg(req))
+			return nil
+		})
+	assert.NoError(t, err)
+
+	err = StreamValidateInterceptor(nil, &mockedServerStream{err: io.EOF}, nil,
+		func(_ any, stream grpc.ServerStream) error {
+			return stream.RecvMsg(new(mock.DepositRequest))
+		})
+	assert.Equal(t, io.EOF, err)
+}
+
+type validatedRequest struct {
+	*mock.DepositRequest
+	err error
+}
+
+func (r validatedRequest) Validate() error {
+	return r.err
+}
+
+type mockedFieldError struct{}
+
+func (e mockedFieldError) Error() string {
+	return "invalid amount"
+}
+
+func (e mockedFieldError) Field() string {
+	return "amount"
+}
+
+func (e mockedFieldError) Reason() string {
+	return "must be greater than 0"
+}
@@ -0,0 +1,201 @@
+package tlsx
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+)
+
+var (
+	// ErrMissingKeyPair is an error that indicates the CertFile or KeyFile is missing.
+	ErrMissingKeyPair = errors.New("CertFile and KeyFile are required together")
+	// ErrMissingCACert is an error that indicates the CACertFile is required to verify client certs.
+	ErrMissingCACert = errors.New("CACertFile is required to verify client certificates")
+	// ErrPeerNotAllowed is an error that indicates the peer certificate is not in the AllowedSANs.
+	ErrPeerNotAllowed = errors.New("peer certificate is not allowed")
+)
+
+// Conf defines the TLS config of the rpc servers and clients.
+type Conf struct {
+	// CertFile and KeyFile are the certificate of this side, required on server side,
+	// and on client side with mutual TLS.
+	CertFile string `json:",optional"`
+	KeyFile  string `json:",optional"`
+	// CACertFile is used to verify the peer certificates, system roots are used if empty.
+	CACertFile string `json:",optional"`
+	// RequireClientCert requires and verifies the client certificates, only on server side.
+	RequireClientCert bool `json:",optional"`
+	// AllowedSANs restricts the peers to the ones with any of the DNS, URI, IP or email SANs,
+	// like spiffe://cluster.local/ns/default/sa/foo, empty means no restrictions.
+	// On server side, the client certificates are required if set, so CACertFile is required too.
+	AllowedSANs []string `json:",optional"`
+	// ServerName overrides the server name to verify, only on client side.
+	ServerName string `json:",optional"`
+	// InsecureSkipVerify skips verifying the server certificate chain and name, only on client side,
+	// the AllowedSANs are still verified if set.
+	InsecureSkipVerify bool `json:",optional"`
+	// ReloadInterval is the interval to check the changes of the cert files.
+	ReloadInterval time.Duration `json:",default=10s"`
+}
+
+// NewServerCredentials returns the server transport credentials with given c,
+// the certificates are reloaded when the files change.
+func NewServerCredentials(c Conf) (credentials.TransportCredentials, error) {
+	cfg, err := NewServerConfig(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return credentials.NewTLS(cfg), nil
+}
+
+// NewServerConfig returns the server tls.Config with given c.
+func NewServerConfig(c Conf) (*tls.Config, error) {
+	if len(c.CertFile) == 0 || len(c.KeyFile) == 0 {
+		return nil, ErrMissingKeyPair
+	}
+	if (c.RequireClientCert || len(c.AllowedSANs) > 0) && len(c.CACertFile) == 0 {
+		return nil, ErrMissingCACert
+	}
+
+	keyPair, err := newKeyPair(c.CertFile, c.KeyFile, c.ReloadInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	var roots *certPool
+	if len(c.CACertFile) > 0 {
+		if roots, err = newCertPool(c.CACertFile, c.ReloadInterval); err != nil {
+			return nil, err
+		}
+	}
+
+	allowed := newSANSet(c.AllowedSANs)
+	base := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// http/1.1 is used to serve the gRPC-Web and Connect requests.
+		NextProtos: []string{"h2", "http/1.1"},
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return keyPair.certificate(), nil
+		},
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				if len(allowed) > 0 {
+					return ErrPeerNotAllowed
+				}
+
+				return nil
+			}
+
+			return allowed.verify(cs.PeerCertificates[0])
+		},
+	}
+	if roots != nil {
+		base.ClientAuth = tls.VerifyClientCertIfGiven
+		// the client certificates are required to check the AllowedSANs.
+		if c.RequireClientCert || len(allowed) > 0 {
+			base.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	// GetConfigForClient is used to pick up the reloaded client CAs on each handshake.
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cfg := base.Clone()
+			if roots != nil {
+				cfg.ClientCAs = roots.pool()
+			}
+			return cfg, nil
+		},
+	}, nil
+}
+
+// NewClientCredentials returns the client transport credentials with given c,
+// the certificates are reloaded when the files change.
+func NewClientCredentials(c Conf) (credentials.TransportCredentials, error) {
+	cfg, err := NewClientConfig(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return credentials.NewTLS(cfg), nil
+}
+
+// NewClientConfig returns the client tls.Config with given c.
+func NewClientConfig(c Conf) (*tls.Config, error) {
+	if len(c.CertFile) > 0 != (len(c.KeyFile) > 0) {
+		return nil, ErrMissingKeyPair
+	}
+
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: c.ServerName,
+	}
+
+	if len(c.CertFile) > 0 {
+		keyPair, err := newKeyPair(c.CertFile, c.KeyFile, c.ReloadInterval)
+		if err != nil {
+			return nil, err
+		}
+
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return keyPair.certificate(), nil
+		}
+	}
+
+	var roots *certPool
+	if len(c.CACertFile) > 0 {
+		var err error
+		if roots, err = newCertPool(c.CACertFile, c.ReloadInterval); err != nil {
+			return nil, err
+		}
+	}
+
+	allowed := newSANSet(c.AllowedSANs)
+	if c.InsecureSkipVerify || roots == nil {
+		// the chain and the name are verified by crypto/tls if not skipped.
+		cfg.InsecureSkipVerify = c.InsecureSkipVerify
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return ErrPeerNotAllowed
+			}
+
+			return allowed.verify(cs.PeerCertificates[0])
+		}
+		return cfg, nil
+	}
+
+	// the default verification is replaced to pick up the reloaded CAs on each handshake.
+	cfg.InsecureSkipVerify = true
+	cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+		if err := verifyPeer(cs, roots.pool()); err != nil {
+			return err
+		}
+
+		return allowed.verify(cs.PeerCertificates[0])
+	}
+
+	return cfg, nil
+}
+
+func verifyPeer(cs tls.ConnectionState, roots *x509.CertPool) error {
+	if len(cs.PeerCertificates) == 0 {
+		return ErrPeerNotAllowed
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range cs.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+		DNSName:       cs.ServerName,
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+	return err
+}
@@ -0,0 +1,297 @@
+package tlsx
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	file string
+}
+
+func TestNewServerConfig(t *testing.T) {
+	ca := newTestCA(t, "ca")
+	certFile, keyFile := ca.issue(t, "server", "localhost")
+
+	_, err := NewServerConfig(Conf{})
+	assert.ErrorIs(t, err, ErrMissingKeyPair)
+	_, err = NewServerConfig(Conf{CertFile: certFile, KeyFile: keyFile, RequireClientCert: true})
+	assert.ErrorIs(t, err, ErrMissingCACert)
+	_, err = NewServerConfig(Conf{CertFile: certFile, KeyFile: keyFile, AllowedSANs: []string{"foo"}})
+	assert.ErrorIs(t, err, ErrMissingCACert)
+	_, err = NewServerConfig(Conf{CertFile: certFile, KeyFile: "not-exist"})
+	assert.Error(t, err)
+	_, err = NewServerConfig(Conf{CertFile: certFile, KeyFile: keyFile, CACertFile: keyFile})
+	assert.Error(t, err)
+	_, err = NewServerCredentials(Conf{CertFile: certFile, KeyFile: keyFile})
+	assert.NoError(t, err)
+}
+
+func TestNewClientConfig(t *testing.T) {
+	ca := newTestCA(t, "ca")
+	certFile, _ := ca.issue(t, "client", "client")
+
+	_, err := NewClientConfig(Conf{CertFile: certFile})
+	assert.ErrorIs(t, err, ErrMissingKeyPair)
+	_, err = NewClientConfig(Conf{CertFile: certFile, KeyFile: certFile})
+	assert.Error(t, err)
+	_, err = NewClientConfig(Conf{CACertFile: "not-exist"})
+	assert.Error(t, err)
+	_, err = NewClientCredentials(Conf{CACertFile: ca.file})
+	assert.NoError(t, err)
+	cfg, err := NewClientConfig(Conf{InsecureSkipVerify: true})
+	assert.NoError(t, err)
+	assert.True(t, cfg.InsecureSkipVerify)
+}
+
+func TestHandshake(t *testing.T) {
+	ca := newTestCA(t, "ca")
+	other := newTestCA(t, "other")
+	serverCert, serverKey := ca.issue(t, "server", "localhost")
+	clientCert, clientKey := ca.issue(t, "client", "spiffe://test/client")
+	otherCert, otherKey := other.issue(t, "other", "spiffe://test/client")
+
+	tests := []struct {
+		name   string
+		server Conf
+		client Conf
+		ok     bool
+	}{
+		{
+			name:   "tls",
+			server: Conf{CertFile: serverCert, KeyFile: serverKey},
+			client: Conf{CACertFile: ca.file, ServerName: "localhost"},
+			ok:     true,
+		},
+		{
+			name:   "wrong server name",
+			server: Conf{CertFile: serverCert, KeyFile: serverKey},
+			client: Conf{CACertFile: ca.file, ServerName: "example.com"},
+		},
+		{
+			name:   "unknown server ca",
+			server: Conf{CertFile: serverCert, KeyFile: serverKey},
+			client: Conf{CACertFile: other.file, ServerName: "localhost"},
+		},
+		{
+			name: "mtls",
+			server: Conf{CertFile: serverCert, KeyFile: serverKey, CACertFile: ca.file,
+				RequireClientCert: true, AllowedSANs: []string{"spiffe://test/client"}},
+			client: Conf{CertFile: clientCert, KeyFile: clientKey, CACertFile: ca.file,
+				ServerName: "localhost", AllowedSANs: []string{"localhost"}},
+			ok: true,
+		},
+		{
+			name: "mtls without client cert",
+			server: Conf{CertFile: serverCert, KeyFile: serverKey, CACertFile: ca.file,
+				RequireClientCert: true},
+			client: Conf{CACertFile: ca.file, ServerName: "localhost"},
+		},
+		{
+			name: "mtls with unknown client ca",
+			server: Conf{CertFile: serverCert, KeyFile: serverKey, CACertFile: ca.file,
+				RequireClientCert: true},
+			client: Conf{CertFile: otherCert, KeyFile: otherKey, CACertFile: ca.file,
+				ServerName: "localhost"},
+		},
+		{
+			name: "client not allowed",
+			server: Conf{CertFile: serverCert, KeyFile: serverKey, CACertFile: ca.file,
+				RequireClientCert: true, AllowedSANs: []string{"spiffe://test/other"}},
+			client: Conf{CertFile: clientCert, KeyFile: clientKey, CACertFile: ca.file,
+				ServerName: "localhost"},
+		},
+		{
+			name: "allowed sans without client cert",
+			server: Conf{CertFile: serverCert, KeyFile: serverKey, CACertFile: ca.file,
+				AllowedSANs: []string{"spiffe://test/client"}},
+			client: Conf{CACertFile: ca.file, ServerName: "localhost"},
+		},
+		{
+			name:   "insecure client with allowed server",
+			server: Conf{CertFile: serverCert, KeyFile: serverKey},
+			client: Conf{InsecureSkipVerify: true, AllowedSANs: []string{"localhost"}},
+			ok:     true,
+		},
+		{
+			name:   "insecure client with server not allowed",
+			server: Conf{CertFile: serverCert, KeyFile: serverKey},
+			client: Conf{InsecureSkipVerify: true, AllowedSANs: []string{"example.com"}},
+		},
+		{
+			name:   "server not allowed",
+			server: Conf{CertFile: serverCert, KeyFile: serverKey},
+			client: Conf{CACertFile: ca.file, ServerName: "localhost",
+				AllowedSANs: []string{"example.com"}},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			serverCfg, err := NewServerConfig(test.server)
+			assert.NoError(t, err)
+			clientCfg, err := NewClientConfig(test.client)
+			assert.NoError(t, err)
+
+			err = handshake(serverCfg, clientCfg)
+			if test.ok {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestReload(t *testing.T) {
+	ca := newTestCA(t, "ca")
+	certFile, keyFile := ca.issue(t, "server", "localhost")
+	serverCfg, err := NewServerConfig(Conf{
+		CertFile:       certFile,
+		KeyFile:        keyFile,
+		ReloadInterval: time.Millisecond * 10,
+	})
+	assert.NoError(t, err)
+
+	renewed := newTestCA(t, "renewed")
+	renewedCert, renewedKey := renewed.issue(t, "server", "localhost")
+	clientCfg, err := NewClientConfig(Conf{
+		CACertFile:     renewed.file,
+		ServerName:     "localhost",
+		ReloadInterval: time.Millisecond * 10,
+	})
+	assert.NoError(t, err)
+	assert.Error(t, handshake(serverCfg, clientCfg))
+
+	// an invalid file keeps the old certificate.
+	assert.NoError(t, os.WriteFile(keyFile, []byte("bad"), 0o600))
+	time.Sleep(time.Millisecond * 50)
+	copyFile(t, renewedCert, certFile)
+	copyFile(t, renewedKey, keyFile)
+	assert.Eventually(t, func() bool {
+		return handshake(serverCfg, clientCfg) == nil
+	}, time.Second*5, time.Millisecond*20)
+}
+
+func handshake(serverCfg, clientCfg *tls.Config) error {
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", serverCfg)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			done <- err
+			return
+		}
+		defer conn.Close()
+
+		err = conn.(*tls.Conn).Handshake()
+		if err == nil {
+			// make sure the client receives the result of client cert verification.
+			_, err = conn.Write([]byte{1})
+		}
+		done <- err
+	}()
+
+	conn, err := tls.Dial("tcp", listener.Addr().String(), clientCfg)
+	if err == nil {
+		_, err = conn.Read(make([]byte, 1))
+		_ = conn.Close()
+	}
+	if serverErr := <-done; err == nil {
+		err = serverErr
+	}
+
+	return err
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+
+	file := filepath.Join(t.TempDir(), name+".pem")
+	writePEM(t, file, "CERTIFICATE", der)
+	return &testCA{
+		cert: cert,
+		key:  key,
+		file: file,
+	}
+}
+
+func (ca *testCA) issue(t *testing.T, name, san string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if u, err := url.Parse(san); err == nil && len(u.Scheme) > 0 {
+		tmpl.URIs = []*url.URL{u}
+	} else {
+		tmpl.DNSNames = []string{san}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	assert.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, name+".pem")
+	keyFile := filepath.Join(dir, name+".key")
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDer)
+	return certFile, keyFile
+}
+
+func copyFile(t *testing.T, src, dst string) {
+	content, err := os.ReadFile(src)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(dst, content, 0o600))
+}
+
+func writePEM(t *testing.T, file, typ string, der []byte) {
+	assert.NoError(t, os.WriteFile(file, pem.EncodeToMemory(&pem.Block{
+		Type:  typ,
+		Bytes: der,
+	}), 0o600))
+}
@@ -0,0 +1,88 @@
+package tlsx
+
+import (
+	"context"
+	"crypto/x509"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// PeerIdentity is the identity of the peer from its verified TLS certificate.
+type PeerIdentity struct {
+	CommonName string
+	DNSNames   []string
+	URIs       []string
+	IPs        []string
+	Emails     []string
+}
+
+// PeerIdentityFromContext returns the identity of the peer of the rpc call in ctx,
+// false if the connection is not secured by TLS or no peer certificate.
+func PeerIdentityFromContext(ctx context.Context) (PeerIdentity, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return PeerIdentity{}, false
+	}
+
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.PeerCertificates) == 0 {
+		return PeerIdentity{}, false
+	}
+
+	return newPeerIdentity(info.State.PeerCertificates[0]), true
+}
+
+// SANs returns all the subject alternative names of the identity.
+func (id PeerIdentity) SANs() []string {
+	sans := make([]string, 0, len(id.DNSNames)+len(id.URIs)+len(id.IPs)+len(id.Emails))
+	sans = append(sans, id.DNSNames...)
+	sans = append(sans, id.URIs...)
+	sans = append(sans, id.IPs...)
+	return append(sans, id.Emails...)
+}
+
+func newPeerIdentity(cert *x509.Certificate) PeerIdentity {
+	id := PeerIdentity{
+		CommonName: cert.Subject.CommonName,
+		DNSNames:   cert.DNSNames,
+		Emails:     cert.EmailAddresses,
+	}
+	for _, uri := range cert.URIs {
+		id.URIs = append(id.URIs, uri.String())
+	}
+	for _, ip := range cert.IPAddresses {
+		id.IPs = append(id.IPs, ip.String())
+	}
+
+	return id
+}
+
+type sanSet map[string]struct{}
+
+func newSANSet(sans []string) sanSet {
+	if len(sans) == 0 {
+		return nil
+	}
+
+	set := make(sanSet, len(sans))
+	for _, san := range sans {
+		set[san] = struct{}{}
+	}
+
+	return set
+}
+
+func (s sanSet) verify(cert *x509.Certificate) error {
+	if len(s) == 0 {
+		return nil
+	}
+
+	for _, san := range newPeerIdentity(cert).SANs() {
+		if _, ok := s[san]; ok {
+			return nil
+		}
+	}
+
+	return ErrPeerNotAllowed
+}
@@ -0,0 +1,49 @@
+package tlsx
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+func TestPeerIdentityFromContext(t *testing.T) {
+	_, ok := PeerIdentityFromContext(context.Background())
+	assert.False(t, ok)
+
+	ctx := peer.NewContext(context.Background(), &peer.Peer{})
+	_, ok = PeerIdentityFromContext(ctx)
+	assert.False(t, ok)
+
+	u, err := url.Parse("spiffe://test/foo")
+	assert.NoError(t, err)
+	cert := &x509.Certificate{
+		Subject:        pkix.Name{CommonName: "foo"},
+		DNSNames:       []string{"foo.local"},
+		URIs:           []*url.URL{u},
+		IPAddresses:    []net.IP{net.ParseIP("127.0.0.1")},
+		EmailAddresses: []string{"foo@test"},
+	}
+	ctx = peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{
+			State: tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{cert},
+			},
+		},
+	})
+	id, ok := PeerIdentityFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "foo", id.CommonName)
+	assert.Equal(t, []string{"foo.local", "spiffe://test/foo", "127.0.0.1", "foo@test"}, id.SANs())
+
+	assert.NoError(t, newSANSet(nil).verify(cert))
+	assert.NoError(t, newSANSet([]string{"127.0.0.1"}).verify(cert))
+	assert.ErrorIs(t, newSANSet([]string{"bar"}).verify(cert), ErrPeerNotAllowed)
+}
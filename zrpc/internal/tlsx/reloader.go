@@ -0,0 +1,178 @@
+package tlsx
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jialequ/linux-sdk/core/filex"
+	"github.com/jialequ/linux-sdk/core/logx"
+	"github.com/jialequ/linux-sdk/core/proc"
+)
+
+var (
+	errNoCACerts = errors.New("no valid CA certificates")
+
+	// the reloaders are shared by the configs on the same files,
+	// to watch the files once instead of once per server or client.
+	keyPairs      = make(map[string]*keyPair)
+	certPools     = make(map[string]*certPool)
+	reloadersLock sync.Mutex
+)
+
+type (
+	keyPair struct {
+		certFile string
+		keyFile  string
+		cert     atomic.Pointer[tls.Certificate]
+	}
+
+	certPool struct {
+		file  string
+		roots atomic.Pointer[x509.CertPool]
+	}
+)
+
+func newKeyPair(certFile, keyFile string, interval time.Duration) (*keyPair, error) {
+	key := fmt.Sprintf("%s|%s|%s", certFile, keyFile, interval)
+	reloadersLock.Lock()
+	defer reloadersLock.Unlock()
+
+	if kp, ok := keyPairs[key]; ok {
+		return kp, nil
+	}
+
+	kp := &keyPair{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+	if err := kp.load(); err != nil {
+		return nil, err
+	}
+
+	// the cert and key files are usually updated together, reload on either changes.
+	watchers, err := watchFiles(kp.reload, interval, certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPairs[key] = kp
+	proc.AddShutdownListener(func() {
+		reloadersLock.Lock()
+		delete(keyPairs, key)
+		reloadersLock.Unlock()
+		stopWatchers(watchers)
+	})
+
+	return kp, nil
+}
+
+func (kp *keyPair) certificate() *tls.Certificate {
+	return kp.cert.Load()
+}
+
+func (kp *keyPair) load() error {
+	cert, err := tls.LoadX509KeyPair(kp.certFile, kp.keyFile)
+	if err != nil {
+		return err
+	}
+
+	kp.cert.Store(&cert)
+	return nil
+}
+
+func (kp *keyPair) reload() {
+	// keep using the old certificate if the files are partially updated.
+	if err := kp.load(); err != nil {
+		logx.Errorf("failed to reload certificate %s, error: %v", kp.certFile, err)
+		return
+	}
+
+	logx.Infof("reloaded certificate %s", kp.certFile)
+}
+
+func newCertPool(file string, interval time.Duration) (*certPool, error) {
+	key := fmt.Sprintf("%s|%s", file, interval)
+	reloadersLock.Lock()
+	defer reloadersLock.Unlock()
+
+	if cp, ok := certPools[key]; ok {
+		return cp, nil
+	}
+
+	cp := &certPool{
+		file: file,
+	}
+	if err := cp.load(); err != nil {
+		return nil, err
+	}
+
+	watchers, err := watchFiles(cp.reload, interval, file)
+	if err != nil {
+		return nil, err
+	}
+
+	certPools[key] = cp
+	proc.AddShutdownListener(func() {
+		reloadersLock.Lock()
+		delete(certPools, key)
+		reloadersLock.Unlock()
+		stopWatchers(watchers)
+	})
+
+	return cp, nil
+}
+
+func (cp *certPool) load() error {
+	content, err := os.ReadFile(cp.file)
+	if err != nil {
+		return err
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(content) {
+		return errNoCACerts
+	}
+
+	cp.roots.Store(roots)
+	return nil
+}
+
+func (cp *certPool) pool() *x509.CertPool {
+	return cp.roots.Load()
+}
+
+func (cp *certPool) reload() {
+	if err := cp.load(); err != nil {
+		logx.Errorf("failed to reload CA certificates %s, error: %v", cp.file, err)
+		return
+	}
+
+	logx.Infof("reloaded CA certificates %s", cp.file)
+}
+
+func stopWatchers(watchers []*filex.Watcher) {
+	for _, w := range watchers {
+		w.Stop()
+	}
+}
+
+func watchFiles(listener func(), interval time.Duration, files ...string) ([]*filex.Watcher, error) {
+	watchers := make([]*filex.Watcher, 0, len(files))
+	for _, file := range files {
+		w, err := filex.NewWatcher(file, listener, filex.WithWatchInterval(interval))
+		if err != nil {
+			stopWatchers(watchers)
+			return nil, err
+		}
+
+		watchers = append(watchers, w)
+	}
+
+	return watchers, nil
+}
@@ -0,0 +1,61 @@
+package tlsx
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCertPoolReload(t *testing.T) {
+	ca := newTestCA(t, "ca")
+	cp, err := newCertPool(ca.file, time.Millisecond*10)
+	assert.NoError(t, err)
+	pool := cp.pool()
+
+	// invalid content keeps the old pool.
+	assert.NoError(t, os.WriteFile(ca.file, []byte("bad"), 0o600))
+	cp.reload()
+	assert.Equal(t, pool, cp.pool())
+
+	other := newTestCA(t, "other")
+	copyFile(t, other.file, ca.file)
+	cp.reload()
+	assert.NotEqual(t, pool, cp.pool())
+}
+
+func TestKeyPairReload(t *testing.T) {
+	ca := newTestCA(t, "ca")
+	certFile, keyFile := ca.issue(t, "server", "localhost")
+	kp, err := newKeyPair(certFile, keyFile, time.Millisecond*10)
+	assert.NoError(t, err)
+	cert := kp.certificate()
+
+	assert.NoError(t, os.WriteFile(certFile, []byte("bad"), 0o600))
+	kp.reload()
+	assert.Equal(t, cert, kp.certificate())
+
+	renewedCert, renewedKey := ca.issue(t, "server", "localhost")
+	copyFile(t, renewedCert, certFile)
+	copyFile(t, renewedKey, keyFile)
+	kp.reload()
+	assert.NotEqual(t, cert, kp.certificate())
+}
+
+func TestReloadersShared(t *testing.T) {
+	ca := newTestCA(t, "ca")
+	certFile, keyFile := ca.issue(t, "server", "localhost")
+
+	kp, err := newKeyPair(certFile, keyFile, time.Millisecond*10)
+	assert.NoError(t, err)
+	other, err := newKeyPair(certFile, keyFile, time.Millisecond*10)
+	assert.NoError(t, err)
+	assert.Same(t, kp, other)
+
+	cp, err := newCertPool(ca.file, time.Millisecond*10)
+	assert.NoError(t, err)
+	otherPool, err := newCertPool(ca.file, time.Millisecond*10)
+	assert.NoError(t, err)
+	assert.Same(t, cp, otherPool)
+}
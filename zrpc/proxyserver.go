@@ -0,0 +1,193 @@
+package zrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/jialequ/linux-sdk/core/logx"
+	"github.com/jialequ/linux-sdk/zrpc/internal/proxy"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const anyService = "*"
+
+var (
+	// ErrProxyRouteAuth is an error that indicates the route auth is set without JwtAuth.
+	ErrProxyRouteAuth = errors.New("auth of proxy routes requires JwtAuth")
+	// ErrNoProxyRoutes is an error that indicates no routes configured for a proxy server.
+	ErrNoProxyRoutes = errors.New("no proxy routes")
+
+	errProxyClosed = status.Error(codes.Unavailable, "proxy server is closed")
+)
+
+type proxyRouter struct {
+	routes  map[string]ProxyRouteConf
+	clients map[string]Client
+	closed  bool
+	lock    sync.Mutex
+}
+
+// MustNewProxyServer returns a RpcServer that forwards the calls by routes, exits on any error.
+func MustNewProxyServer(c RpcProxyConf, opts ...ServerOption) *RpcServer {
+	server, err := NewProxyServer(c, opts...)
+	logx.Must(err)
+	return server
+}
+
+// NewProxyServer returns a RpcServer that forwards the unary and streaming calls of the services
+// to the backends by routes, without knowing the services. The backends are dialed on creating.
+// All the calls are forwarded as streams, so they go through the stream interceptors of the
+// server, like auth, rate limit and the method timeouts, but not the unary ones, which means
+// the server timeout is not applied. The messages are forwarded without decoding, so they are
+// not validated, and only the server max message sizes apply, not the method message limits.
+// The codec of the server is forced to forward the raw messages, so the json requests of Web
+// are not supported. The calls go through the resolvers, balancers and interceptors of the
+// backend clients.
+func NewProxyServer(c RpcProxyConf, opts ...ServerOption) (*RpcServer, error) {
+	router, err := newProxyRouter(c.Routes)
+	if err != nil {
+		return nil, err
+	}
+
+	if rules := router.aclRules(); len(rules) > 0 {
+		if !c.JwtAuth {
+			return nil, ErrProxyRouteAuth
+		}
+
+		// the explicit rules take precedence over the route rules on the same methods.
+		c.JwtAuthConf.Rules = append(rules, c.JwtAuthConf.Rules...)
+	}
+
+	if err = router.dial(); err != nil {
+		return nil, err
+	}
+
+	server, err := NewServer(c.RpcServerConf, func(*grpc.Server) {}, opts...)
+	if err != nil {
+		router.close()
+		return nil, err
+	}
+
+	server.AddOptions(grpc.ForceServerCodec(proxy.Codec()))
+	handler := proxy.NewHandler(router.conn, c.AllowedMetadata, c.DeniedMetadata)
+	server.AddOptions(grpc.UnknownServiceHandler(handler.Handle))
+	server.closers = append(server.closers, router.close)
+
+	return server, nil
+}
+
+func newProxyRouter(routes []ProxyRouteConf) (*proxyRouter, error) {
+	if len(routes) == 0 {
+		return nil, ErrNoProxyRoutes
+	}
+
+	router := &proxyRouter{
+		routes:  make(map[string]ProxyRouteConf, len(routes)),
+		clients: make(map[string]Client),
+	}
+	for _, route := range routes {
+		service := strings.TrimPrefix(route.Service, "/")
+		if len(service) == 0 {
+			return nil, fmt.Errorf("empty service of proxy route to %q", route.Target.Target)
+		}
+		if _, ok := router.routes[service]; ok {
+			return nil, fmt.Errorf("duplicated proxy route of service %q", service)
+		}
+
+		route.Service = service
+		router.routes[service] = route
+	}
+
+	return router, nil
+}
+
+func (r *proxyRouter) aclRules() []AclRuleConf {
+	var rules []AclRuleConf
+	for service, route := range r.routes {
+		if !route.Public && len(route.Scopes) == 0 && len(route.Roles) == 0 {
+			continue
+		}
+
+		method := anyService
+		if service != anyService {
+			method = "/" + service + "/" + anyService
+		}
+		rules = append(rules, AclRuleConf{
+			FullMethod: method,
+			Scopes:     route.Scopes,
+			Roles:      route.Roles,
+			Public:     route.Public,
+		})
+	}
+
+	return rules
+}
+
+func (r *proxyRouter) conn(_ context.Context, fullMethod string) (*grpc.ClientConn, error) {
+	service := serviceOfMethod(fullMethod)
+	route, ok := r.routes[service]
+	if !ok {
+		if route, ok = r.routes[anyService]; !ok {
+			return nil, status.Errorf(codes.Unimplemented, "unknown service %s", service)
+		}
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.closed {
+		return nil, errProxyClosed
+	}
+
+	return r.clients[route.Service].Conn(), nil
+}
+
+// dial dials the backends of the routes, the dialed ones are closed on any error,
+// to not dial on the request path.
+func (r *proxyRouter) dial() error {
+	for service, route := range r.routes {
+		client, err := NewClient(route.Target)
+		if err != nil {
+			r.close()
+			return fmt.Errorf("proxy route of service %q: %w", service, err)
+		}
+
+		r.lock.Lock()
+		r.clients[service] = client
+		r.lock.Unlock()
+	}
+
+	return nil
+}
+
+// close closes the clients of the routes, the calls after closing are rejected.
+func (r *proxyRouter) close() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.closed = true
+	for service, client := range r.clients {
+		closeClient(client)
+		delete(r.clients, service)
+	}
+}
+
+func closeClient(client Client) {
+	if err := client.Conn().Close(); err != nil {
+		logx.Error(err)
+	}
+}
+
+// serviceOfMethod returns the service of fullMethod, like pkg.Service of /pkg.Service/Method.
+func serviceOfMethod(fullMethod string) string {
+	method := strings.TrimPrefix(fullMethod, "/")
+	if pos := strings.LastIndexByte(method, '/'); pos >= 0 {
+		return method[:pos]
+	}
+
+	return method
+}
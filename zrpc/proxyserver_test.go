@@ -0,0 +1,168 @@
+package zrpc
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/jialequ/linux-sdk/core/service"
+	"github.com/jialequ/linux-sdk/internal/mock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/status"
+)
+
+func TestNewProxyServer(t *testing.T) {
+	conf := RpcProxyConf{
+		RpcServerConf: RpcServerConf{
+			ServiceConf: service.ServiceConf{
+				Name: "proxy",
+			},
+			ListenOn: "localhost:0",
+		},
+		Routes: []ProxyRouteConf{
+			{
+				Service: "mock.DepositService",
+				Target: RpcClientConf{
+					Endpoints: []string{"localhost:1"},
+					NonBlock:  true,
+				},
+			},
+		},
+	}
+
+	server, err := NewProxyServer(conf)
+	assert.NoError(t, err)
+	assert.NotNil(t, server)
+
+	conf.Routes[0].Scopes = []string{"deposit"}
+	_, err = NewProxyServer(conf)
+	assert.ErrorIs(t, err, ErrProxyRouteAuth)
+
+	conf.JwtAuth = true
+	conf.JwtAuthConf.Secret = "secret"
+	server, err = NewProxyServer(conf)
+	assert.NoError(t, err)
+	assert.NotNil(t, server)
+
+	conf.Routes[0].Target = RpcClientConf{}
+	_, err = NewProxyServer(conf)
+	assert.Error(t, err)
+
+	conf.Routes = nil
+	_, err = NewProxyServer(conf)
+	assert.ErrorIs(t, err, ErrNoProxyRoutes)
+}
+
+func TestNewProxyRouterInvalid(t *testing.T) {
+	_, err := newProxyRouter([]ProxyRouteConf{{}})
+	assert.Error(t, err)
+
+	_, err = newProxyRouter([]ProxyRouteConf{
+		{Service: "pkg.Service"},
+		{Service: "/pkg.Service"},
+	})
+	assert.Error(t, err)
+}
+
+func TestProxyRouterAclRules(t *testing.T) {
+	router, err := newProxyRouter([]ProxyRouteConf{
+		{Service: "pkg.Public", Public: true},
+		{Service: "pkg.Private"},
+		{Service: anyService, Roles: []string{"admin"}},
+	})
+	assert.NoError(t, err)
+
+	rules := router.aclRules()
+	assert.ElementsMatch(t, []AclRuleConf{
+		{FullMethod: "/pkg.Public/*", Public: true},
+		{FullMethod: anyService, Roles: []string{"admin"}},
+	}, rules)
+}
+
+func TestProxyRouterConn(t *testing.T) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	assert.NoError(t, err)
+	backend := grpc.NewServer()
+	mock.RegisterDepositServiceServer(backend, &mock.DepositServer{})
+	go backend.Serve(listener)
+	defer backend.Stop()
+
+	router, err := newProxyRouter([]ProxyRouteConf{
+		{
+			Service: "mock.DepositService",
+			Target: RpcClientConf{
+				Endpoints: []string{listener.Addr().String()},
+			},
+		},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, router.dial())
+	defer router.close()
+
+	_, err = router.conn(context.Background(), "/pkg.Unknown/Method")
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+
+	conn, err := router.conn(context.Background(), "/mock.DepositService/Deposit")
+	assert.NoError(t, err)
+	again, err := router.conn(context.Background(), "/mock.DepositService/Deposit")
+	assert.NoError(t, err)
+	assert.Equal(t, conn, again)
+
+	resp, err := mock.NewDepositServiceClient(conn).Deposit(context.Background(),
+		&mock.DepositRequest{Amount: 100})
+	assert.NoError(t, err)
+	assert.True(t, resp.GetOk())
+}
+
+func TestProxyRouterClose(t *testing.T) {
+	router, err := newProxyRouter([]ProxyRouteConf{
+		{
+			Service: "mock.DepositService",
+			Target: RpcClientConf{
+				Endpoints: []string{"localhost:1"},
+				NonBlock:  true,
+			},
+		},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, router.dial())
+
+	conn, err := router.conn(context.Background(), "/mock.DepositService/Deposit")
+	assert.NoError(t, err)
+
+	router.close()
+	router.close()
+	assert.Equal(t, connectivity.Shutdown, conn.GetState())
+	assert.Empty(t, router.clients)
+	_, err = router.conn(context.Background(), "/mock.DepositService/Deposit")
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestProxyRouterDialFailed(t *testing.T) {
+	router, err := newProxyRouter([]ProxyRouteConf{
+		{
+			Service: "pkg.Valid",
+			Target: RpcClientConf{
+				Endpoints: []string{"localhost:1"},
+				NonBlock:  true,
+			},
+		},
+		{
+			Service: "pkg.Invalid",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Error(t, router.dial())
+	assert.Empty(t, router.clients)
+	_, err = router.conn(context.Background(), "/pkg.Valid/Method")
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestServiceOfMethod(t *testing.T) {
+	assert.Equal(t, "pkg.Service", serviceOfMethod("/pkg.Service/Method"))
+	assert.Equal(t, "pkg.Service", serviceOfMethod("pkg.Service/Method"))
+	assert.Equal(t, "pkg.Service", serviceOfMethod("pkg.Service"))
+}
@@ -11,6 +11,10 @@ type directBuilder struct{}
 
 func (d *directBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (
 	resolver.Resolver, error) {
+	if err := checkNoRouting(target); err != nil {
+		return nil, err
+	}
+
 	endpoints := strings.FieldsFunc(targets.GetEndpoints(target), func(r rune) bool {
 		return r == EndpointSepChar
 	})
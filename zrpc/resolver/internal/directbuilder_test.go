@@ -58,6 +58,16 @@ func TestDirectBuilderBuild(t *testing.T) {
 	}
 }
 
+func TestDirectBuilderRouting(t *testing.T) {
+	var b directBuilder
+	uri, err := url.Parse(fmt.Sprintf("%s:///localhost:123?zone=a", DirectScheme))
+	assert.NoError(t, err)
+	_, err = b.Build(resolver.Target{
+		URL: *uri,
+	}, new(mockedClientConn), resolver.BuildOptions{})
+	assert.Error(t, err)
+}
+
 func TestDirectBuilderScheme(t *testing.T) {
 	var b directBuilder
 	assert.Equal(t, DirectScheme, b.Scheme())
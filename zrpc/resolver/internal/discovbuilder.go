@@ -5,6 +5,7 @@ import (
 
 	"github.com/jialequ/linux-sdk/core/discov"
 	"github.com/jialequ/linux-sdk/core/logx"
+	"github.com/jialequ/linux-sdk/zrpc/internal/instance"
 	"github.com/jialequ/linux-sdk/zrpc/resolver/internal/targets"
 	"google.golang.org/grpc/resolver"
 )
@@ -16,21 +17,26 @@ func (b *discovBuilder) Build(target resolver.Target, cc resolver.ClientConn, _
 	hosts := strings.FieldsFunc(targets.GetAuthority(target), func(r rune) bool {
 		return r == EndpointSepChar
 	})
+	routing, err := instance.ParseRouting(target.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+
 	sub, err := discov.NewSubscriber(hosts, targets.GetEndpoints(target))
 	if err != nil {
 		return nil, err
 	}
 
 	update := func() {
-		var addrs []resolver.Address
-		for _, val := range subset(sub.Values(), subsetSize) {
-			addrs = append(addrs, resolver.Address{
-				Addr: val,
+		instances := sub.Instances()
+		endpoints := make([]endpoint, 0, len(instances))
+		for _, inst := range instances {
+			endpoints = append(endpoints, endpoint{
+				addr:     inst.Addr,
+				metadata: inst.Metadata,
 			})
 		}
-		if err := cc.UpdateState(resolver.State{
-			Addresses: addrs,
-		}); err != nil {
+		if err := updateEndpoints(cc, endpoints, routing); err != nil {
 			logx.Error(err)
 		}
 	}
@@ -0,0 +1,204 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	threading "github.com/jialequ/linux-sdk/core/dist"
+	"github.com/jialequ/linux-sdk/core/logx"
+	"github.com/jialequ/linux-sdk/zrpc/internal/instance"
+	"github.com/jialequ/linux-sdk/zrpc/resolver/internal/targets"
+	"google.golang.org/grpc/resolver"
+)
+
+const (
+	defaultSrvRefreshInterval = 30 * time.Second
+	srvLookupTimeout          = 5 * time.Second
+	priorityKey               = "priority"
+)
+
+var errEmptySrvName = errors.New("no SRV name given")
+
+type (
+	// dnsSrvBuilder builds the resolvers with the DNS SRV records,
+	// like dns+srv:///_grpc._tcp.user.example.com, or with a specified DNS server,
+	// like dns+srv://8.8.8.8:53/_grpc._tcp.user.example.com.
+	// The records are refreshed periodically, customized like ?interval=10s.
+	dnsSrvBuilder struct{}
+
+	srvLookupFunc func(ctx context.Context, name string) ([]*net.SRV, error)
+
+	dnsSrvResolver struct {
+		cc       resolver.ClientConn
+		name     string
+		lookup   srvLookupFunc
+		interval time.Duration
+		routing  instance.RoutingConf
+		// endpoints are the last updated endpoints, only accessed in resolve.
+		endpoints []endpoint
+		rn        chan struct{}
+		ctx       context.Context
+		cancel    context.CancelFunc
+	}
+)
+
+func (b *dnsSrvBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (
+	resolver.Resolver, error) {
+	name := targets.GetEndpoints(target)
+	if len(name) == 0 {
+		return nil, errEmptySrvName
+	}
+
+	interval, err := parseInterval(target, defaultSrvRefreshInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	routing, err := instance.ParseRouting(target.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+
+	r := newDnsSrvResolver(cc, name, newSrvLookup(targets.GetAuthority(target)), interval)
+	r.routing = routing
+	// resolve synchronously to fail fast on the invalid names.
+	if err = r.resolve(); err != nil {
+		r.Close()
+		return nil, err
+	}
+
+	threading.GoSafe(r.watch)
+	return r, nil
+}
+
+func (b *dnsSrvBuilder) Scheme() string {
+	return DnsSrvScheme
+}
+
+func newDnsSrvResolver(cc resolver.ClientConn, name string, lookup srvLookupFunc,
+	interval time.Duration) *dnsSrvResolver {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &dnsSrvResolver{
+		cc:       cc,
+		name:     name,
+		lookup:   lookup,
+		interval: interval,
+		rn:       make(chan struct{}, 1),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+func (r *dnsSrvResolver) Close() {
+	r.cancel()
+}
+
+func (r *dnsSrvResolver) ResolveNow(_ resolver.ResolveNowOptions) {
+	select {
+	case r.rn <- struct{}{}:
+	default:
+	}
+}
+
+func (r *dnsSrvResolver) resolve() error {
+	ctx, cancel := context.WithTimeout(r.ctx, srvLookupTimeout)
+	defer cancel()
+
+	records, err := r.lookup(ctx, r.name)
+	if err != nil {
+		return err
+	}
+
+	// skip the unchanged records to avoid reshuffling the subset, which churns the connections.
+	endpoints := srvEndpoints(records)
+	if r.endpoints != nil && reflect.DeepEqual(endpoints, r.endpoints) {
+		return nil
+	}
+
+	if err = updateEndpoints(r.cc, endpoints, r.routing); err != nil {
+		return err
+	}
+
+	r.endpoints = endpoints
+	return nil
+}
+
+func (r *dnsSrvResolver) watch() {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-r.rn:
+		}
+
+		// keep the current addresses on errors, the DNS servers might be temporarily unavailable.
+		if err := r.resolve(); err != nil {
+			logx.Errorf("failed to resolve SRV records of %s, error: %v", r.name, err)
+			r.cc.ReportError(err)
+		}
+	}
+}
+
+func newSrvLookup(server string) srvLookupFunc {
+	res := net.DefaultResolver
+	if len(server) > 0 {
+		res = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, network, server)
+			},
+		}
+	}
+
+	return func(ctx context.Context, name string) ([]*net.SRV, error) {
+		_, records, err := res.LookupSRV(ctx, "", "", name)
+		return records, err
+	}
+}
+
+// srvEndpoints returns the endpoints with the lowest priority, as the SRV records defined,
+// the others are backups. The endpoints are sorted by address to be compared.
+func srvEndpoints(records []*net.SRV) []endpoint {
+	if len(records) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Priority < records[j].Priority
+	})
+
+	var endpoints []endpoint
+	for _, record := range records {
+		if record.Priority != records[0].Priority {
+			break
+		}
+
+		md := instance.Metadata{
+			priorityKey: strconv.Itoa(int(record.Priority)),
+		}
+		if record.Weight > 0 {
+			md[instance.WeightKey] = strconv.Itoa(int(record.Weight))
+		}
+
+		endpoints = append(endpoints, endpoint{
+			addr:     net.JoinHostPort(strings.TrimSuffix(record.Target, "."), strconv.Itoa(int(record.Port))),
+			metadata: md,
+		})
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		return endpoints[i].addr < endpoints[j].addr
+	})
+
+	return endpoints
+}
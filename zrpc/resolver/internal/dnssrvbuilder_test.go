@@ -0,0 +1,130 @@
+package internal
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jialequ/linux-sdk/zrpc/internal/instance"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/resolver"
+)
+
+func TestDnsSrvBuilderScheme(t *testing.T) {
+	assert.Equal(t, DnsSrvScheme, new(dnsSrvBuilder).Scheme())
+}
+
+func TestDnsSrvBuilderBuildError(t *testing.T) {
+	var cc lockedClientConn
+	_, err := new(dnsSrvBuilder).Build(resolver.Target{URL: url.URL{Scheme: DnsSrvScheme}},
+		&cc, resolver.BuildOptions{})
+	assert.ErrorIs(t, err, errEmptySrvName)
+
+	u, err := url.Parse("dns+srv:///_grpc._tcp.example.com?interval=bad")
+	assert.NoError(t, err)
+	_, err = new(dnsSrvBuilder).Build(resolver.Target{URL: *u}, &cc, resolver.BuildOptions{})
+	assert.Error(t, err)
+
+	// no DNS server listening on the port.
+	u, err = url.Parse("dns+srv://127.0.0.1:1/_grpc._tcp.example.com")
+	assert.NoError(t, err)
+	_, err = new(dnsSrvBuilder).Build(resolver.Target{URL: *u}, &cc, resolver.BuildOptions{})
+	assert.Error(t, err)
+}
+
+func TestDnsSrvResolver(t *testing.T) {
+	var calls int32
+	lookup := func(ctx context.Context, name string) ([]*net.SRV, error) {
+		assert.Equal(t, "_grpc._tcp.example.com", name)
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			return []*net.SRV{
+				{Target: "a.example.com.", Port: 8080, Priority: 10, Weight: 3},
+				{Target: "b.example.com.", Port: 8080, Priority: 10},
+				{Target: "backup.example.com.", Port: 8080, Priority: 20, Weight: 1},
+			}, nil
+		case 2:
+			return nil, assert.AnError
+		default:
+			return []*net.SRV{
+				{Target: "c.example.com.", Port: 9090, Priority: 0},
+			}, nil
+		}
+	}
+
+	var cc lockedClientConn
+	r := newDnsSrvResolver(&cc, "_grpc._tcp.example.com", lookup, time.Hour)
+	defer r.Close()
+	assert.NoError(t, r.resolve())
+
+	addrs := cc.addresses()
+	assert.Equal(t, []string{"a.example.com:8080", "b.example.com:8080"}, addrStrings(addrs))
+	for _, addr := range addrs {
+		if addr.Addr == "a.example.com:8080" {
+			assert.Equal(t, 3, instance.Weight(addr))
+		} else {
+			assert.Equal(t, 1, instance.Weight(addr))
+		}
+		assert.Equal(t, "10", instance.FromAddress(addr)[priorityKey])
+	}
+
+	go r.watch()
+	// the failed lookup keeps the current addresses.
+	r.ResolveNow(resolver.ResolveNowOptions{})
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&calls) >= 2
+	}, time.Second*5, time.Millisecond*10)
+	assert.Equal(t, []string{"a.example.com:8080", "b.example.com:8080"}, addrStrings(cc.addresses()))
+
+	assert.Eventually(t, func() bool {
+		r.ResolveNow(resolver.ResolveNowOptions{})
+		addrs := addrStrings(cc.addresses())
+		return len(addrs) == 1 && addrs[0] == "c.example.com:9090"
+	}, time.Second*5, time.Millisecond*10)
+}
+
+func TestDnsSrvResolverUnchanged(t *testing.T) {
+	records := func() []*net.SRV {
+		return []*net.SRV{
+			{Target: "b.example.com.", Port: 8080},
+			{Target: "a.example.com.", Port: 8080},
+		}
+	}
+	var calls int32
+	lookup := func(ctx context.Context, name string) ([]*net.SRV, error) {
+		if atomic.AddInt32(&calls, 1)%2 == 0 {
+			// the DNS servers might return the records in different orders.
+			srvs := records()
+			srvs[0], srvs[1] = srvs[1], srvs[0]
+			return srvs, nil
+		}
+
+		return records(), nil
+	}
+
+	cc := &countedClientConn{}
+	r := newDnsSrvResolver(cc, "_grpc._tcp.example.com", lookup, time.Hour)
+	defer r.Close()
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, r.resolve())
+	}
+	assert.Equal(t, 1, cc.updates)
+	assert.Equal(t, []string{"a.example.com:8080", "b.example.com:8080"}, addrStrings(cc.state.Addresses))
+}
+
+func TestSrvEndpoints(t *testing.T) {
+	assert.Nil(t, srvEndpoints(nil))
+}
+
+type countedClientConn struct {
+	mockedClientConn
+	updates int
+}
+
+func (c *countedClientConn) UpdateState(state resolver.State) error {
+	c.updates++
+	return c.mockedClientConn.UpdateState(state)
+}
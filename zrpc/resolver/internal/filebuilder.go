@@ -0,0 +1,137 @@
+package internal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/jialequ/linux-sdk/core/conf"
+	"github.com/jialequ/linux-sdk/core/filex"
+	"github.com/jialequ/linux-sdk/core/logx"
+	"github.com/jialequ/linux-sdk/zrpc/internal/instance"
+	"google.golang.org/grpc/resolver"
+)
+
+var errEmptyEndpointsFile = errors.New("no endpoints file given")
+
+type (
+	// fileBuilder builds the resolvers from the endpoints files, like file:///etc/zrpc/user.yaml,
+	// the file is watched, and the addresses are updated on changes.
+	// The watch interval can be customized like file:///etc/zrpc/user.yaml?interval=10s.
+	fileBuilder struct{}
+
+	// fileEndpoints is the content of the endpoints file in yaml or json, like:
+	//	Endpoints:
+	//	  - Addr: 10.0.0.1:8080
+	//	    Weight: 2
+	//	    Metadata:
+	//	      zone: zone-a
+	fileEndpoints struct {
+		Endpoints []fileEndpoint
+	}
+
+	fileEndpoint struct {
+		Addr     string
+		Weight   int               `json:",optional"`
+		Metadata map[string]string `json:",optional"`
+	}
+
+	fileResolver struct {
+		nopResolver
+		watcher *filex.Watcher
+	}
+)
+
+func (b *fileBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (
+	resolver.Resolver, error) {
+	// file:///abs/path.yaml or file://relative/path.yaml
+	file := target.URL.Host + target.URL.Path
+	if len(file) == 0 {
+		return nil, errEmptyEndpointsFile
+	}
+
+	interval, err := parseInterval(target, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	routing, err := instance.ParseRouting(target.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints, err := loadEndpointsFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if err = updateEndpoints(cc, endpoints, routing); err != nil {
+		return nil, err
+	}
+
+	watcher, err := filex.NewWatcher(file, func() {
+		endpoints, err := loadEndpointsFile(file)
+		if err != nil {
+			// keep the current addresses if the file is invalid, like in the middle of writing.
+			logx.Errorf("failed to load endpoints file %s, error: %v", file, err)
+			return
+		}
+
+		if err = updateEndpoints(cc, endpoints, routing); err != nil {
+			logx.Error(err)
+		}
+	}, filex.WithWatchInterval(interval))
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileResolver{
+		nopResolver: nopResolver{cc: cc},
+		watcher:     watcher,
+	}, nil
+}
+
+func (b *fileBuilder) Scheme() string {
+	return FileScheme
+}
+
+func (r *fileResolver) Close() {
+	r.watcher.Stop()
+}
+
+func loadEndpointsFile(file string) ([]endpoint, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var eps fileEndpoints
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".json":
+		err = conf.LoadFromJsonBytes(content, &eps)
+	default:
+		err = conf.LoadFromYamlBytes(content, &eps)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := make([]endpoint, 0, len(eps.Endpoints))
+	for _, ep := range eps.Endpoints {
+		md := make(instance.Metadata, len(ep.Metadata)+1)
+		for k, v := range ep.Metadata {
+			md[k] = v
+		}
+		if ep.Weight > 0 {
+			md[instance.WeightKey] = strconv.Itoa(ep.Weight)
+		}
+
+		endpoints = append(endpoints, endpoint{
+			addr:     ep.Addr,
+			metadata: md,
+		})
+	}
+
+	return endpoints, nil
+}
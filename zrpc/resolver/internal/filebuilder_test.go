@@ -0,0 +1,133 @@
+package internal
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jialequ/linux-sdk/zrpc/internal/instance"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/resolver"
+)
+
+func TestFileBuilderScheme(t *testing.T) {
+	assert.Equal(t, FileScheme, new(fileBuilder).Scheme())
+}
+
+func TestFileBuilderBuild(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "endpoints.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte(`Endpoints:
+  - Addr: localhost:1
+    Weight: 2
+    Metadata:
+      zone: a
+  - Addr: localhost:2
+`), 0o600))
+
+	var cc lockedClientConn
+	r, err := new(fileBuilder).Build(fileTarget(t, file, "10ms"), &cc, resolver.BuildOptions{})
+	assert.NoError(t, err)
+	defer r.Close()
+
+	addrs := cc.addresses()
+	assert.Equal(t, []string{"localhost:1", "localhost:2"}, addrStrings(addrs))
+	for _, addr := range addrs {
+		if addr.Addr == "localhost:1" {
+			assert.Equal(t, 2, instance.Weight(addr))
+			assert.Equal(t, "a", instance.FromAddress(addr)["zone"])
+		} else {
+			assert.Equal(t, 1, instance.Weight(addr))
+		}
+	}
+
+	// invalid content keeps the current addresses.
+	assert.NoError(t, os.WriteFile(file, []byte("Endpoints: ["), 0o600))
+	time.Sleep(time.Millisecond * 50)
+	assert.Equal(t, []string{"localhost:1", "localhost:2"}, addrStrings(cc.addresses()))
+
+	assert.NoError(t, os.WriteFile(file, []byte(`Endpoints:
+  - Addr: localhost:3
+`), 0o600))
+	assert.Eventually(t, func() bool {
+		addrs := addrStrings(cc.addresses())
+		return len(addrs) == 1 && addrs[0] == "localhost:3"
+	}, time.Second*5, time.Millisecond*10)
+}
+
+func TestFileBuilderBuildJson(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "endpoints.json")
+	assert.NoError(t, os.WriteFile(file, []byte(`{"Endpoints":[{"Addr":"localhost:1"}]}`), 0o600))
+
+	var cc lockedClientConn
+	r, err := new(fileBuilder).Build(fileTarget(t, file, ""), &cc, resolver.BuildOptions{})
+	assert.NoError(t, err)
+	r.Close()
+	assert.Equal(t, []string{"localhost:1"}, addrStrings(cc.addresses()))
+}
+
+func TestFileBuilderBuildError(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "endpoints.json")
+	assert.NoError(t, os.WriteFile(file, []byte(`{"Endpoints":[{"Addr":"localhost:1"}]}`), 0o600))
+
+	var cc lockedClientConn
+	_, err := new(fileBuilder).Build(resolver.Target{URL: url.URL{Scheme: FileScheme}},
+		&cc, resolver.BuildOptions{})
+	assert.ErrorIs(t, err, errEmptyEndpointsFile)
+	_, err = new(fileBuilder).Build(fileTarget(t, file, "bad"), &cc, resolver.BuildOptions{})
+	assert.Error(t, err)
+	_, err = new(fileBuilder).Build(fileTarget(t, file, "-1s"), &cc, resolver.BuildOptions{})
+	assert.Error(t, err)
+	_, err = new(fileBuilder).Build(fileTarget(t, file+".not-exist", ""), &cc, resolver.BuildOptions{})
+	assert.Error(t, err)
+
+	assert.NoError(t, os.WriteFile(file, []byte(`{"Endpoints":1}`), 0o600))
+	_, err = new(fileBuilder).Build(fileTarget(t, file, ""), &cc, resolver.BuildOptions{})
+	assert.Error(t, err)
+
+	assert.NoError(t, os.WriteFile(file, []byte(`{"Endpoints":[{"Addr":"localhost:1"}]}`), 0o600))
+	cc.err = assert.AnError
+	_, err = new(fileBuilder).Build(fileTarget(t, file, ""), &cc, resolver.BuildOptions{})
+	assert.ErrorIs(t, err, assert.AnError)
+}
+
+func fileTarget(t *testing.T, file, interval string) resolver.Target {
+	target := fmt.Sprintf("%s://%s", FileScheme, file)
+	if len(interval) > 0 {
+		target += "?" + intervalKey + "=" + interval
+	}
+
+	u, err := url.Parse(target)
+	assert.NoError(t, err)
+	return resolver.Target{URL: *u}
+}
+
+type lockedClientConn struct {
+	mockedClientConn
+	lock sync.Mutex
+}
+
+func (c *lockedClientConn) UpdateState(state resolver.State) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.mockedClientConn.UpdateState(state)
+}
+
+func (c *lockedClientConn) addresses() []resolver.Address {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.state.Addresses
+}
+
+func addrStrings(addrs []resolver.Address) []string {
+	vals := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		vals = append(vals, addr.Addr)
+	}
+	sort.Strings(vals)
+	return vals
+}
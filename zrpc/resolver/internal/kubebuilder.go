@@ -25,6 +25,10 @@ type kubeBuilder struct{}
 
 func (b *kubeBuilder) Build(target resolver.Target, cc resolver.ClientConn,
 	_ resolver.BuildOptions) (resolver.Resolver, error) {
+	if err := checkNoRouting(target); err != nil {
+		return nil, err
+	}
+
 	svc, err := kube.ParseTarget(target)
 	if err != nil {
 		return nil, err
@@ -2,7 +2,9 @@ package internal
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/jialequ/linux-sdk/zrpc/internal/instance"
 	"google.golang.org/grpc/resolver"
 )
 
@@ -15,10 +17,19 @@ const (
 	EtcdScheme = "etcd"
 	// KubernetesScheme stands for k8s scheme.
 	KubernetesScheme = "k8s"
+	// FileScheme stands for file scheme.
+	FileScheme = "file"
+	// DnsSrvScheme stands for dns+srv scheme.
+	DnsSrvScheme = "dns+srv"
 	// EndpointSepChar is the separator cha in endpoints.
 	EndpointSepChar = ','
 
 	subsetSize = 32
+	// minRemotes is the min count of the remote instances kept in the subset,
+	// to let the calls spill over when the local zone is unhealthy.
+	minRemotes = 2
+	// intervalKey is the query key of the targets to customize the refresh interval.
+	intervalKey = "interval"
 )
 
 var (
@@ -29,6 +40,8 @@ var (
 	discovResolverBuilder discovBuilder
 	etcdResolverBuilder   etcdBuilder
 	k8sResolverBuilder    kubeBuilder
+	fileResolverBuilder   fileBuilder
+	dnsSrvResolverBuilder dnsSrvBuilder
 )
 
 // RegisterResolver registers the direct and discov schemes to the resolver.
@@ -37,6 +50,102 @@ func RegisterResolver() {
 	resolver.Register(&discovResolverBuilder)
 	resolver.Register(&etcdResolverBuilder)
 	resolver.Register(&k8sResolverBuilder)
+	resolver.Register(&fileResolverBuilder)
+	resolver.Register(&dnsSrvResolverBuilder)
+}
+
+// checkNoRouting returns an error if the target carries the routing config,
+// which is not supported by the resolvers without the instance metadata.
+func checkNoRouting(target resolver.Target) error {
+	if instance.HasRouting(target.URL.Query()) {
+		return fmt.Errorf("routing is not supported by the %s scheme", target.URL.Scheme)
+	}
+
+	return nil
+}
+
+// endpoint is an address with metadata, like weight.
+type endpoint struct {
+	addr     string
+	metadata instance.Metadata
+}
+
+// parseInterval parses the interval from the query of target, returns def if not given.
+func parseInterval(target resolver.Target, def time.Duration) (time.Duration, error) {
+	val := target.URL.Query().Get(intervalKey)
+	if len(val) == 0 {
+		return def, nil
+	}
+
+	interval, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, err
+	}
+	if interval <= 0 {
+		return 0, fmt.Errorf("invalid interval: %s", val)
+	}
+
+	return interval, nil
+}
+
+// updateEndpoints updates the subset of the endpoints to cc, with the metadata attached.
+// The endpoints are restricted by the version of routing, and the ones in the local zone
+// are preferred in the subset and marked to be preferred by the balancers.
+func updateEndpoints(cc resolver.ClientConn, endpoints []endpoint, routing instance.RoutingConf) error {
+	vals := make([]string, 0, len(endpoints))
+	mds := make(map[string]instance.Metadata, len(endpoints))
+	for _, ep := range endpoints {
+		if !routing.Match(ep.metadata) {
+			continue
+		}
+
+		if _, ok := mds[ep.addr]; !ok {
+			vals = append(vals, ep.addr)
+		}
+		mds[ep.addr] = ep.metadata
+	}
+
+	var locals, remotes []string
+	for _, val := range vals {
+		if routing.Local(mds[val]) {
+			locals = append(locals, val)
+		} else {
+			remotes = append(remotes, val)
+		}
+	}
+	locals = subset(locals, subsetSize-min(len(remotes), minRemotes))
+	remotes = subset(remotes, subsetSize-len(locals))
+
+	// the colored instances are picked in separate groups, count the locals by color.
+	colors := make(map[string]int)
+	for _, val := range locals {
+		colors[mds[val][instance.ColorKey]]++
+	}
+
+	addrs := make([]resolver.Address, 0, len(locals)+len(remotes))
+	for _, val := range locals {
+		total := colors[mds[val][instance.ColorKey]]
+		addr := instance.WithLocality(buildAddress(val, mds[val]), total, routing.MinHealthyRatio)
+		addrs = append(addrs, addr)
+	}
+	for _, val := range remotes {
+		addrs = append(addrs, buildAddress(val, mds[val]))
+	}
+
+	return cc.UpdateState(resolver.State{
+		Addresses: addrs,
+	})
+}
+
+func buildAddress(val string, md instance.Metadata) resolver.Address {
+	addr := resolver.Address{
+		Addr: val,
+	}
+	if len(md) > 0 {
+		addr = instance.WithMetadata(addr, md)
+	}
+
+	return addr
 }
 
 type nopResolver struct {
@@ -1,9 +1,14 @@
 package internal
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
+	"github.com/jialequ/linux-sdk/zrpc/internal/instance"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
 	"google.golang.org/grpc/resolver"
 	"google.golang.org/grpc/serviceconfig"
 )
@@ -46,3 +51,100 @@ func (m *mockedClientConn) NewServiceConfig(_ string) {
 func (m *mockedClientConn) ParseServiceConfig(_ string) *serviceconfig.ParseResult {
 	return nil
 }
+
+func TestUpdateEndpointsWithRouting(t *testing.T) {
+	endpoints := []endpoint{
+		{
+			addr:     "a:1",
+			metadata: instance.Metadata{instance.ZoneKey: "a", instance.VersionKey: "v1"},
+		},
+		{
+			addr:     "a:2",
+			metadata: instance.Metadata{instance.ZoneKey: "a", instance.VersionKey: "v2"},
+		},
+		{
+			addr:     "b:1",
+			metadata: instance.Metadata{instance.ZoneKey: "b", instance.VersionKey: "v1"},
+		},
+		{
+			addr:     "b:2",
+			metadata: instance.Metadata{instance.ZoneKey: "b", instance.TagsKey: "canary,v2"},
+		},
+	}
+
+	t.Run("no routing", func(t *testing.T) {
+		var cc mockedClientConn
+		assert.NoError(t, updateEndpoints(&cc, endpoints, instance.RoutingConf{}))
+		assert.Len(t, cc.state.Addresses, 4)
+		assert.Len(t, instance.PreferLocal(readySCs(cc.state.Addresses)), 4)
+	})
+
+	t.Run("version", func(t *testing.T) {
+		var cc mockedClientConn
+		assert.NoError(t, updateEndpoints(&cc, endpoints, instance.RoutingConf{
+			Version: "v2",
+		}))
+		assert.ElementsMatch(t, []string{"a:2", "b:2"}, addrStrings(cc.state.Addresses))
+	})
+
+	t.Run("zone", func(t *testing.T) {
+		var cc mockedClientConn
+		assert.NoError(t, updateEndpoints(&cc, endpoints, instance.RoutingConf{
+			Zone:            "a",
+			MinHealthyRatio: 0.5,
+		}))
+		assert.Len(t, cc.state.Addresses, 4)
+		// the local ones are placed first to be kept in the subset.
+		assert.ElementsMatch(t, []string{"a:1", "a:2"}, addrStrings(cc.state.Addresses[:2]))
+
+		local := instance.PreferLocal(readySCs(cc.state.Addresses))
+		assert.Len(t, local, 2)
+		for _, info := range local {
+			assert.Equal(t, "a", instance.FromAddress(info.Address)[instance.ZoneKey])
+		}
+	})
+
+	t.Run("zone with min remotes", func(t *testing.T) {
+		var eps []endpoint
+		for i := 0; i < subsetSize+10; i++ {
+			eps = append(eps, endpoint{
+				addr:     fmt.Sprintf("a:%d", i),
+				metadata: instance.Metadata{instance.ZoneKey: "a"},
+			})
+		}
+		for i := 0; i < 5; i++ {
+			eps = append(eps, endpoint{
+				addr:     fmt.Sprintf("b:%d", i),
+				metadata: instance.Metadata{instance.ZoneKey: "b"},
+			})
+		}
+
+		var cc mockedClientConn
+		assert.NoError(t, updateEndpoints(&cc, eps, instance.RoutingConf{
+			Zone:            "a",
+			MinHealthyRatio: 0.5,
+		}))
+		assert.Len(t, cc.state.Addresses, subsetSize)
+		var remotes int
+		for _, addr := range cc.state.Addresses {
+			if strings.HasPrefix(addr.Addr, "b:") {
+				remotes++
+			}
+		}
+		assert.Equal(t, minRemotes, remotes)
+	})
+}
+
+type mockedSubConn struct {
+	balancer.SubConn
+	addr string
+}
+
+func readySCs(addrs []resolver.Address) map[balancer.SubConn]base.SubConnInfo {
+	scs := make(map[balancer.SubConn]base.SubConnInfo, len(addrs))
+	for _, addr := range addrs {
+		scs[&mockedSubConn{addr: addr.Addr}] = base.SubConnInfo{Address: addr}
+	}
+
+	return scs
+}
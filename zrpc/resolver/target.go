@@ -1,12 +1,18 @@
 package resolver
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
+	"github.com/jialequ/linux-sdk/zrpc/internal/instance"
 	"github.com/jialequ/linux-sdk/zrpc/resolver/internal"
 )
 
+// ErrRoutingNotSupported is an error that indicates the scheme of the target
+// doesn't support routing by the instance metadata.
+var ErrRoutingNotSupported = errors.New("routing is not supported by the target scheme")
+
 // BuildDirectTarget returns a string that represents the given endpoints with direct schema.
 func BuildDirectTarget(endpoints []string) string {
 	return fmt.Sprintf("%s:///%s", internal.DirectScheme,
@@ -18,3 +24,36 @@ func BuildDiscovTarget(endpoints []string, key string) string {
 	return fmt.Sprintf("%s://%s/%s", internal.EtcdScheme,
 		strings.Join(endpoints, internal.EndpointSep), key)
 }
+
+// BuildFileTarget returns a string that represents the given endpoints file with file schema.
+func BuildFileTarget(file string) string {
+	return fmt.Sprintf("%s://%s", internal.FileScheme, file)
+}
+
+// BuildDnsSrvTarget returns a string that represents the given SRV name with dns+srv schema.
+func BuildDnsSrvTarget(name string) string {
+	return fmt.Sprintf("%s:///%s", internal.DnsSrvScheme, name)
+}
+
+// BuildRoutingTarget returns the target with the routing config c attached as the query.
+// Only the discov, etcd, file and dns+srv schemas support routing, ErrRoutingNotSupported
+// is returned for the other schemas.
+func BuildRoutingTarget(target string, c instance.RoutingConf) (string, error) {
+	query := c.Query()
+	if len(query) == 0 {
+		return target, nil
+	}
+
+	scheme, _, _ := strings.Cut(target, "://")
+	switch scheme {
+	case internal.DiscovScheme, internal.EtcdScheme, internal.FileScheme, internal.DnsSrvScheme:
+	default:
+		return "", ErrRoutingNotSupported
+	}
+
+	if strings.Contains(target, "?") {
+		return target + "&" + query.Encode(), nil
+	}
+
+	return target + "?" + query.Encode(), nil
+}
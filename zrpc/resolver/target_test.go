@@ -3,6 +3,7 @@ package resolver
 import (
 	"testing"
 
+	"github.com/jialequ/linux-sdk/zrpc/internal/instance"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -15,3 +16,38 @@ func TestBuildDiscovTarget(t *testing.T) {
 	target := BuildDiscovTarget([]string{"localhost:123", "localhost:456"}, "foo")
 	assert.Equal(t, "etcd://localhost:123,localhost:456/foo", target)
 }
+
+func TestBuildFileTarget(t *testing.T) {
+	assert.Equal(t, "file:///etc/zrpc/user.yaml", BuildFileTarget("/etc/zrpc/user.yaml"))
+	assert.Equal(t, "file://etc/user.yaml", BuildFileTarget("etc/user.yaml"))
+}
+
+func TestBuildDnsSrvTarget(t *testing.T) {
+	assert.Equal(t, "dns+srv:///_grpc._tcp.example.com", BuildDnsSrvTarget("_grpc._tcp.example.com"))
+}
+
+func TestBuildRoutingTarget(t *testing.T) {
+	target, err := BuildRoutingTarget("etcd://localhost:2379/foo", instance.RoutingConf{})
+	assert.NoError(t, err)
+	assert.Equal(t, "etcd://localhost:2379/foo", target)
+
+	target, err = BuildRoutingTarget("etcd://localhost:2379/foo", instance.RoutingConf{Version: "v2"})
+	assert.NoError(t, err)
+	assert.Equal(t, "etcd://localhost:2379/foo?version=v2", target)
+
+	target, err = BuildRoutingTarget("file:///etc/user.yaml?interval=1s", instance.RoutingConf{
+		Zone:            "a",
+		MinHealthyRatio: 0.5,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "file:///etc/user.yaml?interval=1s&minHealthyRatio=0.5&zone=a", target)
+
+	target, err = BuildRoutingTarget("direct:///localhost:123", instance.RoutingConf{})
+	assert.NoError(t, err)
+	assert.Equal(t, "direct:///localhost:123", target)
+
+	for _, val := range []string{"direct:///localhost:123", "k8s://ns/svc:8080", "dns:///example.com"} {
+		_, err = BuildRoutingTarget(val, instance.RoutingConf{Zone: "a"})
+		assert.ErrorIs(t, err, ErrRoutingNotSupported)
+	}
+}
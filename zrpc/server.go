@@ -1,33 +1,63 @@
 package zrpc
 
 import (
+	"crypto/tls"
 	"time"
 
 	"github.com/jialequ/linux-sdk/core/load"
 	"github.com/jialequ/linux-sdk/core/logx"
+	"github.com/jialequ/linux-sdk/core/proc"
 	"github.com/jialequ/linux-sdk/core/stat"
 	"github.com/jialequ/linux-sdk/core/stores/redis"
+	"github.com/jialequ/linux-sdk/internal/fault"
 	"github.com/jialequ/linux-sdk/zrpc/internal"
 	"github.com/jialequ/linux-sdk/zrpc/internal/auth"
+	"github.com/jialequ/linux-sdk/zrpc/internal/breakers"
+	"github.com/jialequ/linux-sdk/zrpc/internal/message"
+	"github.com/jialequ/linux-sdk/zrpc/internal/ratelimit"
 	"github.com/jialequ/linux-sdk/zrpc/internal/serverinterceptors"
+	"github.com/jialequ/linux-sdk/zrpc/internal/tlsx"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
-// A RpcServer is a rpc server.
-type RpcServer struct {
-	server   internal.Server
-	register internal.RegisterFn
-}
+var (
+	// ClaimsFromContext returns the claims of the verified bearer token in ctx.
+	ClaimsFromContext = auth.ClaimsFromContext
+	// PeerIdentityFromContext returns the identity of the peer from its TLS certificate in ctx.
+	PeerIdentityFromContext = tlsx.PeerIdentityFromContext
+	// WithListener is an alias of internal.WithListener.
+	WithListener = internal.WithListener
+)
+
+type (
+	// A HealthCheck checks a dependency of a service, returns an error if it's unavailable.
+	HealthCheck = internal.HealthCheck
+	// Claims is the claims of a verified bearer token.
+	Claims = auth.Claims
+	// PeerIdentity is the identity of the peer from its verified TLS certificate.
+	PeerIdentity = tlsx.PeerIdentity
+	// ServerOption is an alias of internal.ServerOption.
+	ServerOption = internal.ServerOption
+
+	// A RpcServer is a rpc server.
+	RpcServer struct {
+		server   internal.Server
+		register internal.RegisterFn
+		// closers release the resources bound to the server after it stops.
+		closers []func()
+	}
+)
 
 // MustNewServer returns a RpcSever, exits on any error.
-func MustNewServer(c RpcServerConf, register internal.RegisterFn) *RpcServer {
-	server, err := NewServer(c, register)
+func MustNewServer(c RpcServerConf, register internal.RegisterFn, opts ...ServerOption) *RpcServer {
+	server, err := NewServer(c, register, opts...)
 	logx.Must(err)
 	return server
 }
 
 // NewServer returns a RpcServer.
-func NewServer(c RpcServerConf, register internal.RegisterFn) (*RpcServer, error) {
+func NewServer(c RpcServerConf, register internal.RegisterFn, opts ...ServerOption) (*RpcServer, error) {
 	var err error
 	if err = c.Validate(); err != nil {
 		return nil, err
@@ -38,7 +68,36 @@ func NewServer(c RpcServerConf, register internal.RegisterFn) (*RpcServer, error
 	serverOptions := []internal.ServerOption{
 		internal.WithMetrics(metrics),
 		internal.WithRpcHealth(c.Health),
+		internal.WithHealthCheckInterval(c.HealthCheckInterval),
+		internal.WithReflection(c.Reflection),
+		internal.WithMetadata(c.Metadata.Metadata()),
+	}
+	var tlsConfig *tls.Config
+	if c.TLS {
+		if tlsConfig, err = tlsx.NewServerConfig(c.TLSConf); err != nil {
+			return nil, err
+		}
+	}
+	if c.Web {
+		serverOptions = append(serverOptions, internal.WithWeb(c.WebConf, tlsConfig))
+	}
+	if c.Middlewares.Breaker && len(c.Middlewares.BreakerConf.Rules) > 0 {
+		b, err := breakers.New(c.Middlewares.BreakerConf)
+		if err != nil {
+			return nil, err
+		}
+
+		serverOptions = append(serverOptions, internal.WithBreakers(b))
+	}
+	if !c.Message.IsZero() {
+		m, err := message.NewMatcher(c.Message)
+		if err != nil {
+			return nil, err
+		}
+
+		serverOptions = append(serverOptions, internal.WithServerMessage(m))
 	}
+	serverOptions = append(serverOptions, opts...)
 
 	if c.HasEtcd() {
 		server, err = internal.NewRpcPubServer(c.Etcd, c.ListenOn, c.Middlewares, serverOptions...)
@@ -53,6 +112,9 @@ func NewServer(c RpcServerConf, register internal.RegisterFn) (*RpcServer, error
 	if err = setupInterceptors(server, c, metrics); err != nil {
 		return nil, err
 	}
+	if tlsConfig != nil {
+		server.AddOptions(grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
 
 	rpcServer := &RpcServer{
 		server:   server,
@@ -70,6 +132,12 @@ func (rs *RpcServer) AddOptions(options ...grpc.ServerOption) {
 	rs.server.AddOptions(options...)
 }
 
+// AddHealthCheck adds a check of the dependency of service, like pkg.Service,
+// the service is NOT_SERVING in the grpc health checks while the check fails.
+func (rs *RpcServer) AddHealthCheck(service string, check HealthCheck) {
+	rs.server.AddHealthCheck(service, check)
+}
+
 // AddStreamInterceptors adds given stream interceptors.
 func (rs *RpcServer) AddStreamInterceptors(interceptors ...grpc.StreamServerInterceptor) {
 	rs.server.AddStreamInterceptors(interceptors...)
@@ -80,21 +148,45 @@ func (rs *RpcServer) AddUnaryInterceptors(interceptors ...grpc.UnaryServerInterc
 	rs.server.AddUnaryInterceptors(interceptors...)
 }
 
+// SetServingStatus sets the serving status of service, like pkg.Service, in the grpc health checks.
+// The registered services are serving by default.
+func (rs *RpcServer) SetServingStatus(service string, serving bool) {
+	rs.server.SetServingStatus(service, serving)
+}
+
 // Start starts the RpcServer.
 // Graceful shutdown is enabled by default.
 // Use proc.SetTimeToForceQuit to customize the graceful shutdown period.
 func (rs *RpcServer) Start() {
+	defer rs.close()
+
 	if err := rs.server.Start(rs.register); err != nil {
 		logx.Error(err)
 		panic(err)
 	}
 }
 
-// Stop stops the RpcServer.
+// Stop stops the RpcServer gracefully like Shutdown, then closes the logs.
+// Notice: Stop only closed the logs in the earlier versions, now it stops the server too,
+// use logx.Close instead to only close the logs.
 func (rs *RpcServer) Stop() {
+	rs.Shutdown()
 	logx.Close()
 }
 
+// Shutdown stops the RpcServer gracefully, along with its health checks and web server,
+// the logs are kept open, which are shared in the process, like by the other servers in tests.
+func (rs *RpcServer) Shutdown() {
+	rs.server.Stop()
+	rs.close()
+}
+
+func (rs *RpcServer) close() {
+	for _, fn := range rs.closers {
+		fn()
+	}
+}
+
 // DontLogContentForMethod disable logging content for given method.
 // Deprecated: use ServerMiddlewaresConf.IgnoreContentMethods instead.
 func DontLogContentForMethod(method string) {
@@ -127,6 +219,7 @@ func setupAuthInterceptors(svr internal.Server, c RpcServerConf) error {
 func setupInterceptors(svr internal.Server, c RpcServerConf, metrics *stat.Metrics) error {
 	if c.CpuThreshold > 0 {
 		shedder := load.NewAdaptiveShedder(load.WithCpuThreshold(c.CpuThreshold))
+		svr.AddStreamInterceptors(serverinterceptors.StreamSheddingInterceptor(shedder, metrics))
 		svr.AddUnaryInterceptors(serverinterceptors.UnarySheddingInterceptor(shedder, metrics))
 	}
 
@@ -134,6 +227,9 @@ func setupInterceptors(svr internal.Server, c RpcServerConf, metrics *stat.Metri
 		svr.AddUnaryInterceptors(serverinterceptors.UnaryTimeoutInterceptor(
 			time.Duration(c.Timeout)*time.Millisecond, c.MethodTimeouts...))
 	}
+	if len(c.MethodTimeouts) > 0 {
+		svr.AddStreamInterceptors(serverinterceptors.StreamTimeoutInterceptor(c.MethodTimeouts...))
+	}
 
 	if c.Auth {
 		if err := setupAuthInterceptors(svr, c); err != nil {
@@ -141,5 +237,43 @@ func setupInterceptors(svr internal.Server, c RpcServerConf, metrics *stat.Metri
 		}
 	}
 
+	if c.JwtAuth {
+		authenticator, err := auth.NewJwtAuthenticator(c.JwtAuthConf)
+		if err != nil {
+			return err
+		}
+
+		svr.AddStreamInterceptors(serverinterceptors.StreamJwtAuthInterceptor(authenticator))
+		svr.AddUnaryInterceptors(serverinterceptors.UnaryJwtAuthInterceptor(authenticator))
+	}
+
+	// rate limit is placed after auth to limit the authenticated apps.
+	if c.Middlewares.RateLimit {
+		if !c.Auth && c.Middlewares.RateLimitConf.KeysByApp() {
+			return ratelimit.ErrKeyByAppWithoutAuth
+		}
+
+		limiter, err := ratelimit.NewLimiter(c.Middlewares.RateLimitConf)
+		if err != nil {
+			return err
+		}
+
+		svr.AddStreamInterceptors(serverinterceptors.StreamRateLimitInterceptor(limiter))
+		svr.AddUnaryInterceptors(serverinterceptors.UnaryRateLimitInterceptor(limiter))
+	}
+
+	// fault injection is placed after auth to only inject into the authenticated requests,
+	// and after shedding and timeout to exercise them.
+	if c.Middlewares.Fault {
+		injector, err := fault.NewInjector(c.Middlewares.FaultConf)
+		if err != nil {
+			return err
+		}
+
+		proc.AddShutdownListener(injector.Stop)
+		svr.AddStreamInterceptors(serverinterceptors.StreamFaultInterceptor(injector))
+		svr.AddUnaryInterceptors(serverinterceptors.UnaryFaultInterceptor(injector))
+	}
+
 	return nil
 }
@@ -2,6 +2,7 @@ package zrpc
 
 import (
 	"fmt"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -12,7 +13,9 @@ import (
 	"github.com/jialequ/linux-sdk/core/stat"
 	"github.com/jialequ/linux-sdk/core/stores/redis"
 	"github.com/jialequ/linux-sdk/zrpc/internal"
+	"github.com/jialequ/linux-sdk/zrpc/internal/ratelimit"
 	"github.com/jialequ/linux-sdk/zrpc/internal/serverinterceptors"
+	"github.com/jialequ/linux-sdk/zrpc/internal/tlsx"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc"
 )
@@ -51,13 +54,114 @@ func TestServersetupInterceptors(t *testing.T) {
 	err = setupInterceptors(server, conf, new(stat.Metrics))
 	assert.Nil(t, err)
 	assert.Equal(t, 3, len(server.unaryInterceptors))
-	assert.Equal(t, 1, len(server.streamInterceptors))
+	assert.Equal(t, 3, len(server.streamInterceptors))
 
 	rds.SetError("mock error")
 	err = setupInterceptors(server, conf, new(stat.Metrics))
 	assert.Error(t, err)
 }
 
+func TestServersetupInterceptorsWithRateLimit(t *testing.T) {
+	server := new(mockedServer)
+	conf := RpcServerConf{
+		Middlewares: ServerMiddlewaresConf{
+			RateLimit: true,
+			RateLimitConf: RateLimitConf{
+				Rules: []RateLimitRuleConf{
+					{
+						KeyBy:       "metadata",
+						MetadataKey: "user",
+						Rate:        10,
+					},
+				},
+			},
+		},
+	}
+	assert.NoError(t, setupInterceptors(server, conf, new(stat.Metrics)))
+	assert.Equal(t, 1, len(server.unaryInterceptors))
+	assert.Equal(t, 1, len(server.streamInterceptors))
+
+	conf.Middlewares.RateLimitConf.Rules[0].Rate = 0
+	assert.Error(t, setupInterceptors(new(mockedServer), conf, new(stat.Metrics)))
+
+	// the app credentials are not verified without Auth.
+	conf.Middlewares.RateLimitConf.Rules[0] = RateLimitRuleConf{
+		KeyBy: "app",
+		Rate:  10,
+	}
+	assert.ErrorIs(t, setupInterceptors(new(mockedServer), conf, new(stat.Metrics)),
+		ratelimit.ErrKeyByAppWithoutAuth)
+}
+
+func TestServersetupInterceptorsWithJwtAuth(t *testing.T) {
+	server := new(mockedServer)
+	conf := RpcServerConf{
+		JwtAuth: true,
+		JwtAuthConf: JwtAuthConf{
+			Secret: "secret",
+		},
+	}
+	assert.NoError(t, setupInterceptors(server, conf, new(stat.Metrics)))
+	assert.Equal(t, 1, len(server.unaryInterceptors))
+	assert.Equal(t, 1, len(server.streamInterceptors))
+
+	conf.JwtAuthConf.Secret = ""
+	assert.Error(t, setupInterceptors(new(mockedServer), conf, new(stat.Metrics)))
+}
+
+func TestNewServerWithTLS(t *testing.T) {
+	_, err := NewServer(RpcServerConf{
+		ServiceConf: service.ServiceConf{
+			Log: logx.LogConf{
+				ServiceName: "foo",
+				Mode:        "console",
+			},
+		},
+		ListenOn: "localhost:0",
+		TLS:      true,
+	}, func(server *grpc.Server) {})
+	assert.ErrorIs(t, err, tlsx.ErrMissingKeyPair)
+}
+
+func TestNewServerWithBadBreakerConf(t *testing.T) {
+	_, err := NewServer(RpcServerConf{
+		ServiceConf: service.ServiceConf{
+			Log: logx.LogConf{
+				ServiceName: "foo",
+				Mode:        "console",
+			},
+		},
+		ListenOn: "localhost:0",
+		Middlewares: ServerMiddlewaresConf{
+			Breaker: true,
+			BreakerConf: BreakerConf{
+				Rules: []BreakerRuleConf{
+					{
+						Fallback: "{",
+					},
+				},
+			},
+		},
+	}, func(server *grpc.Server) {})
+	assert.Error(t, err)
+}
+
+func TestNewServerWithBadMessageConf(t *testing.T) {
+	_, err := NewServer(RpcServerConf{
+		ServiceConf: service.ServiceConf{
+			Log: logx.LogConf{
+				ServiceName: "foo",
+				Mode:        "console",
+			},
+		},
+		ListenOn: "localhost:0",
+		Message: MessageConf{
+			MaxRecvSize: -1,
+		},
+	}, func(server *grpc.Server) {})
+	assert.Error(t, err)
+}
+
 func TestServer(t *testing.T) {
 	DontLogContentForMethod("foo")
 	SetServerSlowThreshold(time.Second)
@@ -98,6 +202,34 @@ func TestServer(t *testing.T) {
 	svr.Stop()
 }
 
+func TestServerShutdown(t *testing.T) {
+	var registered atomic.Bool
+	svr := MustNewServer(RpcServerConf{
+		ServiceConf: service.ServiceConf{
+			Log: logx.LogConf{
+				ServiceName: "foo",
+				Mode:        "console",
+			},
+		},
+		ListenOn: "localhost:0",
+	}, func(server *grpc.Server) {
+		registered.Store(true)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		svr.Start()
+	}()
+	assert.Eventually(t, registered.Load, time.Second, time.Millisecond)
+	svr.Shutdown()
+	select {
+	case <-done:
+	case <-time.After(time.Second * 5):
+		t.Fatal("server not stopped on shutdown")
+	}
+}
+
 func TestServerError(t *testing.T) {
 	_, err := NewServer(RpcServerConf{
 		ServiceConf: service.ServiceConf{
@@ -186,6 +318,9 @@ type mockedServer struct {
 	streamInterceptors []grpc.StreamServerInterceptor
 }
 
+func (m *mockedServer) AddHealthCheck(_ string, _ internal.HealthCheck) {
+}
+
 func (m *mockedServer) AddOptions(_ ...grpc.ServerOption) {
 
 	//func (m *mockedServer) AddOptions(_ ...grpc.ServerOption)
@@ -204,8 +339,14 @@ func (m *mockedServer) SetName(_ string) {
 	//func (m *mockedServer) SetName(_ string)
 }
 
+func (m *mockedServer) SetServingStatus(_ string, _ bool) {
+}
+
 func (m *mockedServer) Start(_ internal.RegisterFn) error {
 	return nil
 }
 
+func (m *mockedServer) Stop() {
+}
+
 const literal_3746 = "localhost:8080"
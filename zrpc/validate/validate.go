@@ -0,0 +1,453 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const validationFailed = "validation failed"
+
+var (
+	fieldRules sync.Map // protoreflect.FullName -> []fieldRule
+	ruledTypes sync.Map // protoreflect.FullName -> bool
+	patterns   sync.Map // string -> *regexp.Regexp
+)
+
+type (
+	// A Validator is a message that validates itself, like the ones with the validators
+	// generated by goctl or protoc-gen-validate.
+	Validator interface {
+		Validate() error
+	}
+
+	// A FieldViolation describes a field that breaks its rules.
+	FieldViolation struct {
+		Field       string
+		Description string
+	}
+
+	// An Error is the error of the fields that break their rules.
+	// It's converted into InvalidArgument with BadRequest details when returned by grpc.
+	Error struct {
+		Violations []FieldViolation
+	}
+
+	// Violations collects the field violations of a message.
+	Violations struct {
+		list []FieldViolation
+	}
+
+	// A Bound is a numeric bound that returns the description of v if v is out of the bound.
+	Bound func(v float64) (string, bool)
+
+	fieldRule struct {
+		fd    protoreflect.FieldDescriptor
+		rules *FieldRules
+	}
+)
+
+// Validate validates msg with its Validate method if it's a Validator,
+// otherwise with the rules annotated on the fields in its descriptor.
+// The nested messages are validated recursively.
+func Validate(msg proto.Message) error {
+	if msg == nil || !msg.ProtoReflect().IsValid() {
+		return nil
+	}
+
+	if v, ok := msg.(Validator); ok {
+		return v.Validate()
+	}
+	// most of the messages don't have rules, skip walking them.
+	if !hasRules(msg.ProtoReflect().Descriptor()) {
+		return nil
+	}
+
+	var v Violations
+	v.check("", msg.ProtoReflect())
+	return v.Err()
+}
+
+// Error returns the string representation of e.
+func (e *Error) Error() string {
+	var builder strings.Builder
+	builder.WriteString(validationFailed)
+	for i, violation := range e.Violations {
+		if i == 0 {
+			builder.WriteString(": ")
+		} else {
+			builder.WriteString("; ")
+		}
+		builder.WriteString(violation.Field)
+		builder.WriteByte(' ')
+		builder.WriteString(violation.Description)
+	}
+
+	return builder.String()
+}
+
+// GRPCStatus returns the InvalidArgument status with the BadRequest details of e.
+func (e *Error) GRPCStatus() *status.Status {
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       violation.Field,
+			Description: violation.Description,
+		})
+	}
+
+	st := status.New(codes.InvalidArgument, e.Error())
+	detailed, err := st.WithDetails(&errdetails.BadRequest{
+		FieldViolations: violations,
+	})
+	if err != nil {
+		return st
+	}
+
+	return detailed
+}
+
+// Add adds a violation of field with description.
+func (v *Violations) Add(field, description string) {
+	v.list = append(v.list, FieldViolation{
+		Field:       field,
+		Description: description,
+	})
+}
+
+// Err returns the Error of the violations, nil if there are no violations.
+func (v *Violations) Err() error {
+	if len(v.list) == 0 {
+		return nil
+	}
+
+	return &Error{Violations: v.list}
+}
+
+// Field validates the field with the given name of msg with the rules in its descriptor.
+func (v *Violations) Field(msg proto.Message, name string) {
+	m := msg.ProtoReflect()
+	fd := m.Descriptor().Fields().ByName(protoreflect.Name(name))
+	if fd == nil {
+		return
+	}
+
+	v.checkField("", m, fieldRule{
+		fd:    fd,
+		rules: rulesOfField(fd),
+	})
+}
+
+// Items checks the number of items n of the list or map field in [min, max], 0 means no limit.
+func (v *Violations) Items(field string, n int, min, max uint64) {
+	if min > 0 && uint64(n) < min {
+		v.Add(field, fmt.Sprintf("must have at least %d items", min))
+	}
+	if max > 0 && uint64(n) > max {
+		v.Add(field, fmt.Sprintf("must have at most %d items", max))
+	}
+}
+
+// Len checks the number of characters of the string field in [min, max], 0 means no limit.
+func (v *Violations) Len(field, s string, min, max uint64) {
+	n := uint64(utf8.RuneCountInString(s))
+	if min > 0 && n < min {
+		v.Add(field, fmt.Sprintf("must be at least %d characters", min))
+	}
+	if max > 0 && n > max {
+		v.Add(field, fmt.Sprintf("must be at most %d characters", max))
+	}
+}
+
+// BytesLen checks the number of bytes of the bytes field in [min, max], 0 means no limit.
+func (v *Violations) BytesLen(field string, b []byte, min, max uint64) {
+	n := uint64(len(b))
+	if min > 0 && n < min {
+		v.Add(field, fmt.Sprintf("must be at least %d bytes", min))
+	}
+	if max > 0 && n > max {
+		v.Add(field, fmt.Sprintf("must be at most %d bytes", max))
+	}
+}
+
+// Match checks the string field against the RE2 pattern.
+func (v *Violations) Match(field, s, pattern string) {
+	re, err := compilePattern(pattern)
+	if err != nil {
+		v.Add(field, fmt.Sprintf("has an invalid pattern %q", pattern))
+		return
+	}
+
+	if !re.MatchString(s) {
+		v.Add(field, fmt.Sprintf("must match pattern %q", pattern))
+	}
+}
+
+// Nested validates the nested message of field, the violations are prefixed with field.
+func (v *Violations) Nested(field string, msg proto.Message) {
+	err := Validate(msg)
+	if err == nil {
+		return
+	}
+
+	var e *Error
+	if errors.As(err, &e) {
+		for _, violation := range e.Violations {
+			v.Add(field+"."+violation.Field, violation.Description)
+		}
+		return
+	}
+
+	v.Add(field, err.Error())
+}
+
+// Range checks the numeric field within the bounds.
+func (v *Violations) Range(field string, val float64, bounds ...Bound) {
+	for _, bound := range bounds {
+		if desc, ok := bound(val); !ok {
+			v.Add(field, desc)
+		}
+	}
+}
+
+// Required checks the field is set, and returns whether it's set.
+func (v *Violations) Required(field string, set bool) bool {
+	if !set {
+		v.Add(field, "is required")
+	}
+
+	return set
+}
+
+func (v *Violations) check(prefix string, m protoreflect.Message) {
+	for _, rule := range rulesOf(m.Descriptor()) {
+		v.checkField(prefix, m, rule)
+	}
+}
+
+func (v *Violations) checkField(prefix string, m protoreflect.Message, rule fieldRule) {
+	fd := rule.fd
+	name := prefix + string(fd.Name())
+	set := m.Has(fd)
+	if rule.rules != nil && rule.rules.GetRequired() && !v.Required(name, set) {
+		return
+	}
+	// the unset oneof members, optional fields and messages are not checked.
+	if fd.HasPresence() && !set {
+		return
+	}
+
+	val := m.Get(fd)
+	switch {
+	case fd.IsList():
+		list := val.List()
+		if rule.rules != nil {
+			v.Items(name, list.Len(), rule.rules.GetMinItems(), rule.rules.GetMaxItems())
+		}
+		for i := 0; i < list.Len(); i++ {
+			v.checkValue(fmt.Sprintf("%s[%d]", name, i), fd, list.Get(i), rule.rules)
+		}
+	case fd.IsMap():
+		mp := val.Map()
+		if rule.rules != nil {
+			v.Items(name, mp.Len(), rule.rules.GetMinItems(), rule.rules.GetMaxItems())
+		}
+		if fd.MapValue().Message() != nil {
+			mp.Range(func(key protoreflect.MapKey, item protoreflect.Value) bool {
+				v.Nested(fmt.Sprintf("%s[%v]", name, key.Interface()), item.Message().Interface())
+				return true
+			})
+		}
+	default:
+		v.checkValue(name, fd, val, rule.rules)
+	}
+}
+
+func (v *Violations) checkValue(name string, fd protoreflect.FieldDescriptor, val protoreflect.Value,
+	rules *FieldRules) {
+	if fd.Message() != nil {
+		v.Nested(name, val.Message().Interface())
+		return
+	}
+
+	if rules == nil {
+		return
+	}
+
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		v.Len(name, val.String(), rules.GetMinLen(), rules.GetMaxLen())
+		if rules.Pattern != nil {
+			v.Match(name, val.String(), rules.GetPattern())
+		}
+	case protoreflect.BytesKind:
+		v.BytesLen(name, val.Bytes(), rules.GetMinLen(), rules.GetMaxLen())
+	case protoreflect.BoolKind:
+		// only required applies to bools.
+	default:
+		v.Range(name, numberOf(fd.Kind(), val), boundsOf(rules)...)
+	}
+}
+
+// Gt returns a Bound that requires the values greater than x.
+func Gt(x float64) Bound {
+	return func(v float64) (string, bool) {
+		return "must be greater than " + formatNumber(x), v > x
+	}
+}
+
+// Gte returns a Bound that requires the values greater than or equal to x.
+func Gte(x float64) Bound {
+	return func(v float64) (string, bool) {
+		return "must be greater than or equal to " + formatNumber(x), v >= x
+	}
+}
+
+// Lt returns a Bound that requires the values less than x.
+func Lt(x float64) Bound {
+	return func(v float64) (string, bool) {
+		return "must be less than " + formatNumber(x), v < x
+	}
+}
+
+// Lte returns a Bound that requires the values less than or equal to x.
+func Lte(x float64) Bound {
+	return func(v float64) (string, bool) {
+		return "must be less than or equal to " + formatNumber(x), v <= x
+	}
+}
+
+func boundsOf(rules *FieldRules) []Bound {
+	var bounds []Bound
+	if rules.Gt != nil {
+		bounds = append(bounds, Gt(rules.GetGt()))
+	}
+	if rules.Gte != nil {
+		bounds = append(bounds, Gte(rules.GetGte()))
+	}
+	if rules.Lt != nil {
+		bounds = append(bounds, Lt(rules.GetLt()))
+	}
+	if rules.Lte != nil {
+		bounds = append(bounds, Lte(rules.GetLte()))
+	}
+
+	return bounds
+}
+
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if val, ok := patterns.Load(pattern); ok {
+		return val.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	patterns.Store(pattern, re)
+	return re, nil
+}
+
+func formatNumber(x float64) string {
+	return strconv.FormatFloat(x, 'g', -1, 64)
+}
+
+func numberOf(kind protoreflect.Kind, val protoreflect.Value) float64 {
+	switch kind {
+	case protoreflect.EnumKind:
+		return float64(val.Enum())
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return val.Float()
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind,
+		protoreflect.Fixed64Kind:
+		return float64(val.Uint())
+	default:
+		return float64(val.Int())
+	}
+}
+
+// rulesOf returns the fields of md to check, which have rules or are messages.
+func rulesOf(md protoreflect.MessageDescriptor) []fieldRule {
+	if val, ok := fieldRules.Load(md.FullName()); ok {
+		return val.([]fieldRule)
+	}
+
+	var rules []fieldRule
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		rule := fieldRule{
+			fd:    fd,
+			rules: rulesOfField(fd),
+		}
+		if rule.rules != nil || fd.Message() != nil && hasRules(fd.Message()) {
+			rules = append(rules, rule)
+		}
+	}
+
+	fieldRules.Store(md.FullName(), rules)
+	return rules
+}
+
+// hasRules checks if md or its nested messages have any rules to check, cached by the type.
+func hasRules(md protoreflect.MessageDescriptor) bool {
+	if val, ok := ruledTypes.Load(md.FullName()); ok {
+		return val.(bool)
+	}
+
+	has := findRules(md, make(map[protoreflect.FullName]bool))
+	ruledTypes.Store(md.FullName(), has)
+	return has
+}
+
+func findRules(md protoreflect.MessageDescriptor, visited map[protoreflect.FullName]bool) bool {
+	// the recursive messages are checked once.
+	if visited[md.FullName()] {
+		return false
+	}
+	visited[md.FullName()] = true
+
+	// the messages with Validate methods are checked by the methods, like protoc-gen-validate.
+	if mt, err := protoregistry.GlobalTypes.FindMessageByName(md.FullName()); err == nil {
+		if _, ok := mt.Zero().Interface().(Validator); ok {
+			return true
+		}
+	}
+
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if rulesOfField(fd) != nil {
+			return true
+		}
+		// the map entries are messages, their values are checked as the fields.
+		if fd.Message() != nil && findRules(fd.Message(), visited) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func rulesOfField(fd protoreflect.FieldDescriptor) *FieldRules {
+	opts, ok := fd.Options().(*descriptorpb.FieldOptions)
+	if !ok || opts == nil || !proto.HasExtension(opts, E_Rules) {
+		return nil
+	}
+
+	rules, _ := proto.GetExtension(opts, E_Rules).(*FieldRules)
+	return rules
+}
@@ -0,0 +1,277 @@
+package validate
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestValidate(t *testing.T) {
+	req := newTestRequest(t)
+	inner := newTestMessage(t, "Inner")
+	set(req, "name", protoreflect.ValueOfString("kevin"))
+	set(req, "age", protoreflect.ValueOfInt32(200))
+	set(req, "data", protoreflect.ValueOfBytes([]byte("abc")))
+	tags := req.NewField(field(req, "tags")).List()
+	tags.Append(protoreflect.ValueOfString("a"))
+	tags.Append(protoreflect.ValueOfString("abcd"))
+	tags.Append(protoreflect.ValueOfString("b"))
+	set(req, "tags", protoreflect.ValueOfList(tags))
+	set(inner, "code", protoreflect.ValueOfString("A1"))
+	set(req, "inner", protoreflect.ValueOfMessage(inner))
+	set(req, "level", protoreflect.ValueOfUint32(1))
+	set(req, "status", protoreflect.ValueOfEnum(2))
+	named := req.NewField(field(req, "named")).Map()
+	named.Set(protoreflect.ValueOfString("x").MapKey(), protoreflect.ValueOfMessage(inner))
+	set(req, "named", protoreflect.ValueOfMap(named))
+
+	err := Validate(req)
+	var e *Error
+	assert.True(t, errors.As(err, &e))
+	assert.ElementsMatch(t, []FieldViolation{
+		{Field: "name", Description: "must be at most 4 characters"},
+		{Field: "age", Description: "must be less than 150"},
+		{Field: "data", Description: "must be at most 2 bytes"},
+		{Field: "tags", Description: "must have at most 2 items"},
+		{Field: "tags[1]", Description: "must be at most 3 characters"},
+		{Field: "inner.code", Description: `must match pattern "^[a-z]+$"`},
+		{Field: "level", Description: "must be greater than 1"},
+		{Field: "status", Description: "must be less than or equal to 1"},
+		{Field: "named[x].code", Description: `must match pattern "^[a-z]+$"`},
+	}, e.Violations)
+}
+
+func TestValidateRequired(t *testing.T) {
+	req := newTestRequest(t)
+	err := Validate(req)
+	var e *Error
+	assert.True(t, errors.As(err, &e))
+	assert.ElementsMatch(t, []FieldViolation{
+		{Field: "name", Description: "is required"},
+		{Field: "inner", Description: "is required"},
+	}, e.Violations)
+}
+
+func TestValidateValid(t *testing.T) {
+	req := newTestRequest(t)
+	inner := newTestMessage(t, "Inner")
+	set(inner, "code", protoreflect.ValueOfString("abc"))
+	set(req, "name", protoreflect.ValueOfString("ke"))
+	set(req, "inner", protoreflect.ValueOfMessage(inner))
+	assert.NoError(t, Validate(req))
+	assert.NoError(t, Validate(nil))
+	assert.NoError(t, Validate((*FieldRules)(nil)))
+}
+
+func TestValidateValidator(t *testing.T) {
+	assert.Equal(t, errMock, Validate(mockValidator{FieldRules: new(FieldRules)}))
+
+	var v Violations
+	v.Nested("foo", mockValidator{FieldRules: new(FieldRules)})
+	assert.Equal(t, &Error{Violations: []FieldViolation{
+		{Field: "foo", Description: errMock.Error()},
+	}}, v.Err())
+}
+
+func TestHasRules(t *testing.T) {
+	assert.True(t, hasRules(newTestRequest(t).Descriptor()))
+	assert.True(t, hasRules(newTestMessage(t, "Inner").Descriptor()))
+	assert.False(t, hasRules((*FieldRules)(nil).ProtoReflect().Descriptor()))
+	// the recursive messages without rules.
+	assert.False(t, hasRules((*descriptorpb.FileDescriptorProto)(nil).ProtoReflect().Descriptor()))
+	assert.NoError(t, Validate(&descriptorpb.FileDescriptorProto{Name: proto.String("foo")}))
+}
+
+func TestViolations(t *testing.T) {
+	var v Violations
+	assert.NoError(t, v.Err())
+
+	v.Required("a", true)
+	v.Len("b", "中文", 1, 2)
+	v.BytesLen("c", []byte("ab"), 1, 2)
+	v.Items("d", 2, 1, 2)
+	v.Range("e", 1, Gt(0), Gte(1), Lt(2), Lte(1))
+	v.Match("f", "abc", "^a")
+	assert.NoError(t, v.Err())
+
+	v.Required("a", false)
+	v.Len("b", "中", 2, 0)
+	v.BytesLen("c", []byte("a"), 2, 0)
+	v.Items("d", 1, 2, 0)
+	v.Range("e", 0, Gt(0), Gte(1))
+	v.Match("f", "abc", "(")
+	assert.Equal(t, "validation failed: a is required; b must be at least 2 characters; "+
+		"c must be at least 2 bytes; d must have at least 2 items; e must be greater than 0; "+
+		`e must be greater than or equal to 1; f has an invalid pattern "("`, v.Err().Error())
+}
+
+func TestViolationsField(t *testing.T) {
+	req := newTestRequest(t)
+	set(req, "age", protoreflect.ValueOfInt32(-1))
+
+	var v Violations
+	v.Field(req, "age")
+	v.Field(req, "unknown")
+	assert.Equal(t, &Error{Violations: []FieldViolation{
+		{Field: "age", Description: "must be greater than or equal to 0"},
+	}}, v.Err())
+}
+
+func TestErrorGRPCStatus(t *testing.T) {
+	err := &Error{Violations: []FieldViolation{
+		{Field: "name", Description: "is required"},
+	}}
+	st, ok := status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	assert.Equal(t, "validation failed: name is required", st.Message())
+	assert.Len(t, st.Details(), 1)
+	badRequest, ok := st.Details()[0].(*errdetails.BadRequest)
+	assert.True(t, ok)
+	assert.Equal(t, "name", badRequest.FieldViolations[0].Field)
+	assert.Equal(t, "is required", badRequest.FieldViolations[0].Description)
+}
+
+var errMock = errors.New("mock")
+
+type mockValidator struct {
+	*FieldRules
+}
+
+func (m mockValidator) Validate() error {
+	return errMock
+}
+
+var testFile protoreflect.FileDescriptor
+
+func newTestRequest(t *testing.T) *dynamicpb.Message {
+	return newTestMessage(t, "Request")
+}
+
+func newTestMessage(t *testing.T, name protoreflect.Name) *dynamicpb.Message {
+	if testFile == nil {
+		testFile = buildTestFile(t)
+	}
+
+	return dynamicpb.NewMessage(testFile.Messages().ByName(name))
+}
+
+func buildTestFile(t *testing.T) protoreflect.FileDescriptor {
+	withRules := func(rules *FieldRules) *descriptorpb.FieldOptions {
+		opts := new(descriptorpb.FieldOptions)
+		proto.SetExtension(opts, E_Rules, rules)
+		return opts
+	}
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+	repeated := descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	fieldOf := func(name string, number int32, label *descriptorpb.FieldDescriptorProto_Label,
+		typ descriptorpb.FieldDescriptorProto_Type, typeName string,
+		opts *descriptorpb.FieldOptions) *descriptorpb.FieldDescriptorProto {
+		fd := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Label:    label,
+			Type:     typ.Enum(),
+			Options:  opts,
+		}
+		if len(typeName) > 0 {
+			fd.TypeName = proto.String(typeName)
+		}
+		return fd
+	}
+
+	level := fieldOf("level", 8, optional, descriptorpb.FieldDescriptorProto_TYPE_UINT32, "",
+		withRules(&FieldRules{Gt: proto.Float64(1)}))
+	level.Proto3Optional = proto.Bool(true)
+	level.OneofIndex = proto.Int32(0)
+	file := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("validate_test.proto"),
+		Package: proto.String("validate.test"),
+		Syntax:  proto.String("proto3"),
+		EnumType: []*descriptorpb.EnumDescriptorProto{
+			{
+				Name: proto.String("Status"),
+				Value: []*descriptorpb.EnumValueDescriptorProto{
+					{Name: proto.String("UNKNOWN"), Number: proto.Int32(0)},
+					{Name: proto.String("OK"), Number: proto.Int32(1)},
+					{Name: proto.String("FAIL"), Number: proto.Int32(2)},
+				},
+			},
+		},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Inner"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					fieldOf("code", 1, optional, descriptorpb.FieldDescriptorProto_TYPE_STRING, "",
+						withRules(&FieldRules{Pattern: proto.String("^[a-z]+$")})),
+				},
+			},
+			{
+				Name: proto.String("Request"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					fieldOf("name", 1, optional, descriptorpb.FieldDescriptorProto_TYPE_STRING, "",
+						withRules(&FieldRules{
+							Required: proto.Bool(true),
+							MinLen:   proto.Uint64(2),
+							MaxLen:   proto.Uint64(4),
+						})),
+					fieldOf("age", 2, optional, descriptorpb.FieldDescriptorProto_TYPE_INT32, "",
+						withRules(&FieldRules{Gte: proto.Float64(0), Lt: proto.Float64(150)})),
+					fieldOf("data", 3, optional, descriptorpb.FieldDescriptorProto_TYPE_BYTES, "",
+						withRules(&FieldRules{MaxLen: proto.Uint64(2)})),
+					fieldOf("tags", 4, repeated, descriptorpb.FieldDescriptorProto_TYPE_STRING, "",
+						withRules(&FieldRules{MaxItems: proto.Uint64(2), MaxLen: proto.Uint64(3)})),
+					fieldOf("inner", 5, optional, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE,
+						".validate.test.Inner", withRules(&FieldRules{Required: proto.Bool(true)})),
+					fieldOf("inners", 6, repeated, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE,
+						".validate.test.Inner", nil),
+					fieldOf("named", 7, repeated, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE,
+						".validate.test.Request.NamedEntry", nil),
+					level,
+					fieldOf("status", 9, optional, descriptorpb.FieldDescriptorProto_TYPE_ENUM,
+						".validate.test.Status", withRules(&FieldRules{Lte: proto.Float64(1)})),
+				},
+				NestedType: []*descriptorpb.DescriptorProto{
+					{
+						Name: proto.String("NamedEntry"),
+						Field: []*descriptorpb.FieldDescriptorProto{
+							fieldOf("key", 1, optional, descriptorpb.FieldDescriptorProto_TYPE_STRING, "", nil),
+							fieldOf("value", 2, optional, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE,
+								".validate.test.Inner", nil),
+						},
+						Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+					},
+				},
+				OneofDecl: []*descriptorpb.OneofDescriptorProto{
+					{Name: proto.String("_level")},
+				},
+			},
+		},
+	}
+
+	fd, err := protodesc.NewFile(file, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return fd
+}
+
+func set(m *dynamicpb.Message, name protoreflect.Name, val protoreflect.Value) {
+	m.Set(field(m, name), val)
+}
+
+func field(m *dynamicpb.Message, name protoreflect.Name) protoreflect.FieldDescriptor {
+	return m.Descriptor().Fields().ByName(name)
+}
@@ -0,0 +1,111 @@
+package zrpctest
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// A Hook is called before the handler of every matched call,
+// a non-nil error is returned to the caller instead of calling the handler.
+type Hook func(ctx context.Context, method string) error
+
+// ErrorHook returns a Hook that fails the calls on given method with err.
+// Empty method matches all the methods.
+func ErrorHook(method string, err error) Hook {
+	return func(ctx context.Context, fullMethod string) error {
+		if !matchMethod(method, fullMethod) {
+			return nil
+		}
+
+		return err
+	}
+}
+
+// LatencyHook returns a Hook that delays the calls on given method with d.
+// Empty method matches all the methods.
+func LatencyHook(method string, d time.Duration) Hook {
+	return func(ctx context.Context, fullMethod string) error {
+		if !matchMethod(method, fullMethod) {
+			return nil
+		}
+
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			return nil
+		}
+	}
+}
+
+// Recorder records the methods of the calls that reach the handlers.
+type Recorder struct {
+	lock    sync.Mutex
+	methods []string
+}
+
+// Hook returns a Hook that records the calls into r.
+func (r *Recorder) Hook() Hook {
+	return func(ctx context.Context, method string) error {
+		r.lock.Lock()
+		r.methods = append(r.methods, method)
+		r.lock.Unlock()
+		return nil
+	}
+}
+
+// Methods returns the recorded methods in order.
+func (r *Recorder) Methods() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return append([]string(nil), r.methods...)
+}
+
+func (s *Server) runHooks(ctx context.Context, method string) error {
+	s.lock.RLock()
+	hooks := s.hooks
+	s.lock.RUnlock()
+
+	for _, hook := range hooks {
+		if err := hook(ctx, method); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) streamHookInterceptor(svr any, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+	if err := s.runHooks(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+
+	return handler(svr, ss)
+}
+
+func (s *Server) unaryHookInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (any, error) {
+	if err := s.runHooks(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// matchMethod matches by full method like /pkg.Service/Method, or by method name only.
+func matchMethod(method, fullMethod string) bool {
+	if len(method) == 0 || method == fullMethod {
+		return true
+	}
+
+	return strings.HasSuffix(fullMethod, "/"+method)
+}
@@ -0,0 +1,180 @@
+package zrpctest
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/jialequ/linux-sdk/core/conf"
+	"github.com/jialequ/linux-sdk/core/service"
+	"github.com/jialequ/linux-sdk/core/stores/redis"
+	"github.com/jialequ/linux-sdk/zrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const (
+	authKey     = "zrpctest:apps"
+	bufSize     = 1024 * 1024
+	defaultName = "zrpctest"
+	endpoint    = "bufnet"
+)
+
+type (
+	// ServerOption customizes a Server.
+	ServerOption func(s *Server)
+
+	// A Server is a zrpc server that serves in process over bufconn for testing,
+	// all the calls go through the full interceptor chains of zrpc.
+	Server struct {
+		t        *testing.T
+		listener *bufconn.Listener
+		tokens   map[string]string
+		hooks    []Hook
+		lock     sync.RWMutex
+	}
+)
+
+// MustNewClientConf returns a zrpc.RpcClientConf with all the default values filled.
+func MustNewClientConf(t *testing.T) zrpc.RpcClientConf {
+	var c zrpc.RpcClientConf
+	if err := conf.FillDefault(&c); err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+// MustNewServerConf returns a zrpc.RpcServerConf with all the default values filled.
+func MustNewServerConf(t *testing.T) zrpc.RpcServerConf {
+	var c zrpc.RpcServerConf
+	if err := conf.FillDefault(&c); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Name = defaultName
+	c.ListenOn = endpoint
+	c.Mode = service.TestMode
+	// the shedding is not predictable in tests.
+	c.CpuThreshold = 0
+	return c
+}
+
+// NewServer returns a started Server with given config and register function.
+// The Server is stopped automatically when the test finishes.
+func NewServer(t *testing.T, c zrpc.RpcServerConf, register func(*grpc.Server),
+	opts ...ServerOption) *Server {
+	s := &Server{
+		t:        t,
+		listener: bufconn.Listen(bufSize),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if len(s.tokens) > 0 {
+		c.Auth = true
+		c.Redis = s.setupTokens()
+	}
+
+	started := make(chan struct{})
+	server, err := zrpc.NewServer(c, func(server *grpc.Server) {
+		register(server)
+		close(started)
+	}, zrpc.WithListener(s.listener))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// hooks are added last, to be right before the handlers.
+	server.AddUnaryInterceptors(s.unaryHookInterceptor)
+	server.AddStreamInterceptors(s.streamHookInterceptor)
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		server.Start()
+	}()
+	<-started
+	t.Cleanup(func() {
+		// not Stop, which closes the global logs under the other tests.
+		server.Shutdown()
+		<-stopped
+	})
+
+	return s
+}
+
+// Dialer returns the dialer that connects to the Server in process.
+func (s *Server) Dialer() func(context.Context, string) (net.Conn, error) {
+	return func(ctx context.Context, _ string) (net.Conn, error) {
+		return s.listener.DialContext(ctx)
+	}
+}
+
+// MustNewClient returns a zrpc.Client connected to the Server,
+// the client interceptors are built from the given config as in production.
+func (s *Server) MustNewClient(c zrpc.RpcClientConf, opts ...zrpc.ClientOption) zrpc.Client {
+	c.Endpoints = []string{endpoint}
+	c.Target = ""
+	opts = append([]zrpc.ClientOption{
+		zrpc.WithDialOption(grpc.WithContextDialer(s.Dialer())),
+	}, opts...)
+
+	cli, err := zrpc.NewClient(c, opts...)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	s.t.Cleanup(func() {
+		_ = cli.Conn().Close()
+	})
+
+	return cli
+}
+
+// Use adds the given hooks into the Server.
+func (s *Server) Use(hooks ...Hook) *Server {
+	s.lock.Lock()
+	s.hooks = append(s.hooks, hooks...)
+	s.lock.Unlock()
+	return s
+}
+
+func (s *Server) setupTokens() redis.RedisKeyConf {
+	mr := miniredis.RunT(s.t)
+	for app, token := range s.tokens {
+		mr.HSet(authKey, app, token)
+	}
+
+	return redis.RedisKeyConf{
+		RedisConf: redis.RedisConf{
+			Host:     mr.Addr(),
+			Type:     redis.NodeType,
+			NonBlock: true,
+		},
+		Key: authKey,
+	}
+}
+
+// WithHooks returns a ServerOption that adds the given hooks into a Server.
+func WithHooks(hooks ...Hook) ServerOption {
+	return func(s *Server) {
+		s.hooks = append(s.hooks, hooks...)
+	}
+}
+
+// WithTokens returns a ServerOption that turns on the authentication on a Server,
+// tokens are keyed by apps, clients need to set App and Token to be authenticated.
+func WithTokens(tokens map[string]string) ServerOption {
+	return func(s *Server) {
+		if s.tokens == nil {
+			s.tokens = make(map[string]string)
+		}
+		for app, token := range tokens {
+			s.tokens[app] = token
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package zrpctest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jialequ/linux-sdk/internal/mock"
+	"github.com/jialequ/linux-sdk/zrpc"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const depositMethod = "/mock.DepositService/Deposit"
+
+func register(server *grpc.Server) {
+	mock.RegisterDepositServiceServer(server, new(mock.DepositServer))
+}
+
+func TestServer(t *testing.T) {
+	var recorder Recorder
+	svr := NewServer(t, MustNewServerConf(t), register, WithHooks(recorder.Hook()))
+	cli := mock.NewDepositServiceClient(svr.MustNewClient(MustNewClientConf(t)).Conn())
+
+	resp, err := cli.Deposit(context.Background(), &mock.DepositRequest{Amount: 1})
+	assert.NoError(t, err)
+	assert.True(t, resp.Ok)
+
+	_, err = cli.Deposit(context.Background(), &mock.DepositRequest{Amount: -1})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, []string{depositMethod, depositMethod}, recorder.Methods())
+}
+
+func TestServerWithTokens(t *testing.T) {
+	svr := NewServer(t, MustNewServerConf(t), register, WithTokens(map[string]string{
+		"foo": "bar",
+	}))
+
+	tests := []struct {
+		name  string
+		app   string
+		token string
+		code  codes.Code
+	}{
+		{
+			name:  "valid token",
+			app:   "foo",
+			token: "bar",
+			code:  codes.OK,
+		},
+		{
+			name:  "invalid token",
+			app:   "foo",
+			token: "baz",
+			code:  codes.Unauthenticated,
+		},
+		{
+			name: "no token",
+			code: codes.Unauthenticated,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			c := MustNewClientConf(t)
+			c.App = test.app
+			c.Token = test.token
+			cli := mock.NewDepositServiceClient(svr.MustNewClient(c).Conn())
+			_, err := cli.Deposit(context.Background(), &mock.DepositRequest{Amount: 1})
+			assert.Equal(t, test.code, status.Code(err))
+		})
+	}
+}
+
+func TestServerWithMethodTimeouts(t *testing.T) {
+	c := MustNewServerConf(t)
+	c.MethodTimeouts = []zrpc.MethodTimeoutConf{
+		{
+			FullMethod: depositMethod,
+			Timeout:    time.Millisecond * 10,
+		},
+	}
+	svr := NewServer(t, c, register)
+	cli := mock.NewDepositServiceClient(svr.MustNewClient(MustNewClientConf(t)).Conn())
+
+	_, err := cli.Deposit(context.Background(), &mock.DepositRequest{Amount: 100})
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+}
+
+func TestServerWithHooks(t *testing.T) {
+	svr := NewServer(t, MustNewServerConf(t), register)
+	cli := mock.NewDepositServiceClient(svr.MustNewClient(MustNewClientConf(t)).Conn())
+
+	svr.Use(ErrorHook("Deposit", status.Error(codes.Unavailable, "unavailable")))
+	_, err := cli.Deposit(context.Background(), &mock.DepositRequest{Amount: 1})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestLatencyHook(t *testing.T) {
+	svr := NewServer(t, MustNewServerConf(t), register,
+		WithHooks(LatencyHook(depositMethod, time.Second)))
+	c := MustNewClientConf(t)
+	c.Timeout = 50
+	cli := mock.NewDepositServiceClient(svr.MustNewClient(c).Conn())
+
+	_, err := cli.Deposit(context.Background(), &mock.DepositRequest{Amount: 1})
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+}
+
+func TestErrorHook(t *testing.T) {
+	errDummy := errors.New("dummy")
+	hook := ErrorHook("Deposit", errDummy)
+	assert.ErrorIs(t, hook(context.Background(), depositMethod), errDummy)
+	assert.NoError(t, hook(context.Background(), "/mock.DepositService/Withdraw"))
+	assert.ErrorIs(t, ErrorHook("", errDummy)(context.Background(), "/any"), errDummy)
+}
+
+func TestMatchMethod(t *testing.T) {
+	assert.True(t, matchMethod("", depositMethod))
+	assert.True(t, matchMethod(depositMethod, depositMethod))
+	assert.True(t, matchMethod("Deposit", depositMethod))
+	assert.False(t, matchMethod("Depo", depositMethod))
+}